@@ -1,10 +0,0 @@
-package rpcobjects
-
-type Args struct {
-	N, M int
-}
-
-func (t *Args) Multiply(args *Args, reply *int) error {
-	*reply = args.N * args.M
-	return nil
-}
\ No newline at end of file
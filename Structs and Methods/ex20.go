@@ -1,37 +0,0 @@
-package main
-import (
-"fmt"
-)
-
-type Log struct {
-    msg string
-}
-
-type Customer struct {
-    Name string
-    log *Log
-}
-
-func main() {
-    c := new(Customer)
-    c.Name = "Barack Obama"
-    c.log = new(Log)
-    c.log.msg = "1 - Yes we can!"
-    // shorter:
-    c = &Customer{"Barack Obama", &Log{"1 - Yes we can!"}}
-    fmt.Println(c.log)
-    c.Log().Add("2 - After me, the world will be a better place!")
-    fmt.Println(c.Log())
-}
-
-func (l *Log) Add(s string) {
-    l.msg += "\n" + s
-}
-
-func (l *Log) String() string {
-    return l.msg
-}
-
-func (c *Customer) Log() *Log {
-    return c.log
-}
\ No newline at end of file
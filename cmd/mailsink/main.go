@@ -0,0 +1,49 @@
+// Command mailsink is an SMTP server for trying out programs that send
+// mail: it accepts every mail (with STARTTLS and AUTH PLAIN, as a real
+// server would) and prints it instead of delivering it.
+//
+//	mailsink [-addr localhost:2525] [-user name -password pw]
+//
+// Its certificate is self-signed, so clients must skip verifying it or
+// trust the PEM file written with -cert.
+package main
+
+import (
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/hannansatopay/training-golang/pkg/mailer"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:2525", "address to listen on")
+	user := flag.String("user", "", "require this username (default: accept anyone)")
+	password := flag.String("password", "", "password of -user")
+	certFile := flag.String("cert", "", "write the sink's certificate to this PEM file")
+	flag.Parse()
+
+	sink, err := mailer.Listen(*addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *user != "" {
+		sink.Users = map[string]string{*user: *password}
+	}
+	if *certFile != "" {
+		block := &pem.Block{Type: "CERTIFICATE", Bytes: sink.Certificate().Raw}
+		if err := os.WriteFile(*certFile, pem.EncodeToMemory(block), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	n := 0
+	sink.Received = func(m mailer.Mail) {
+		n++
+		fmt.Printf("=== mail %d from %s to %s (tls %v, user %q)\n%s\n", n, m.From, strings.Join(m.To, ", "), m.TLS, m.User, m.Data)
+	}
+	log.Printf("mail sink on %s", sink.Addr())
+	log.Fatal(sink.Serve())
+}
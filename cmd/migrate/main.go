@@ -0,0 +1,97 @@
+// Command migrate applies the SQL migrations in a directory to a SQLite
+// database:
+//
+//	migrate [-db guests.db] [-dir migrations] up [version]
+//	migrate [-db guests.db] [-dir migrations] down [steps]
+//	migrate [-db guests.db] [-dir migrations] status
+//
+// The SQLite driver is not part of the standard library; build with
+// -tags sqlite to link modernc.org/sqlite (a pure Go driver).
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/hannansatopay/training-golang/pkg/migrate"
+)
+
+// driverName is set by the file that links a driver.
+var driverName string
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: migrate [flags] up [version] | down [steps] | status\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	dsn := flag.String("db", "guests.db", "SQLite database file")
+	dir := flag.String("dir", "migrations", "directory with NNNN_name.up.sql/.down.sql files")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+	}
+	if driverName == "" {
+		log.Fatal("migrate: no SQLite driver linked in, rebuild with: go build -tags sqlite")
+	}
+
+	migrations, err := migrate.Load(os.DirFS(*dir), ".")
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := sql.Open(driverName, *dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	m := &migrate.Migrator{DB: db, Migrations: migrations, Log: log.Printf}
+	ctx := context.Background()
+
+	// optional numeric argument after the subcommand
+	arg := func(def int) int {
+		if flag.NArg() < 2 {
+			return def
+		}
+		n, err := strconv.Atoi(flag.Arg(1))
+		if err != nil {
+			usage()
+		}
+		return n
+	}
+
+	switch flag.Arg(0) {
+	case "up":
+		n, err := m.Up(ctx, arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d migration(s) applied\n", n)
+	case "down":
+		n, err := m.Down(ctx, arg(1))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d migration(s) reverted\n", n)
+	case "status":
+		list, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range list {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d %-24s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		usage()
+	}
+}
@@ -0,0 +1,2 @@
+DROP INDEX guests_email;
+ALTER TABLE guests DROP COLUMN email;
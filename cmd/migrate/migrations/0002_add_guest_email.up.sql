@@ -0,0 +1,2 @@
+ALTER TABLE guests ADD COLUMN email TEXT NOT NULL DEFAULT '';
+CREATE INDEX guests_email ON guests (email);
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Most exercises keep their benchmarks next to main, as BenchmarkXxx(b
+// *testing.B) functions run with testing.Benchmark, so that go run shows
+// them. trainer bench runs all of them with go test anyway: an overlay
+// (go help build, -overlay) presents the package's files to go test as
+// _test.go files, so the benchmarks run under the real testing framework
+// and print output benchstat understands. Benchmarks in _test.go files
+// need no overlay.
+//
+// Every run is saved in -dir and compared with the run saved before it.
+
+// benchPackage is a directory with benchmark functions.
+type benchPackage struct {
+	dir     string // absolute
+	overlay bool   // benchmarks live in non-test files
+}
+
+func bench(root string, args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	pattern := fs.String("bench", ".", "run only benchmarks matching this regexp")
+	benchtime := fs.String("benchtime", "1s", "run each benchmark for this long (or Nx times)")
+	count := fs.Int("count", 1, "run each benchmark this many times")
+	dir := fs.String("dir", filepath.Join(root, ".bench"), "directory of saved runs")
+	fs.Parse(args)
+
+	pkgs, err := findBenchmarks(root)
+	if err != nil {
+		fatal(err)
+	}
+	if fs.NArg() > 0 { // only packages below the given directories
+		var keep []benchPackage
+		for _, p := range pkgs {
+			for _, arg := range fs.Args() {
+				abs, _ := filepath.Abs(strings.TrimSuffix(arg, "/..."))
+				if p.dir == abs || strings.HasPrefix(p.dir, abs+string(filepath.Separator)) {
+					keep = append(keep, p)
+					break
+				}
+			}
+		}
+		pkgs = keep
+	}
+	if len(pkgs) == 0 {
+		fmt.Println("no benchmarks found")
+		return 0
+	}
+
+	tmp, err := os.MkdirTemp("", "trainer")
+	if err != nil {
+		fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	overlay, err := writeOverlay(pkgs, tmp)
+	if err != nil {
+		fatal(err)
+	}
+
+	goArgs := []string{"test", "-overlay", overlay, "-run", "^$", "-bench", *pattern,
+		"-benchmem", "-benchtime", *benchtime, "-count", strconv.Itoa(*count)}
+	for _, p := range pkgs {
+		rel, _ := filepath.Rel(root, p.dir)
+		goArgs = append(goArgs, "./"+filepath.ToSlash(rel))
+	}
+	var out bytes.Buffer
+	cmd := exec.Command("go", goArgs...)
+	cmd.Dir = root
+	cmd.Stdout, cmd.Stderr = io.MultiWriter(os.Stdout, &out), os.Stderr
+	runErr := cmd.Run()
+
+	cur := parseBench(out.Bytes())
+	if len(cur) == 0 {
+		fatal(fmt.Errorf("no benchmark results: %v", runErr))
+	}
+	prevFile, prev := lastRun(*dir)
+	fmt.Println()
+	if prev == nil {
+		fmt.Println("no saved run to compare with")
+	} else {
+		fmt.Printf("compared with %s\n", filepath.Base(prevFile))
+		compare(os.Stdout, prev, cur)
+	}
+
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		fatal(err)
+	}
+	name := filepath.Join(*dir, time.Now().Format("20060102-150405")+".txt")
+	if err := os.WriteFile(name, out.Bytes(), 0644); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("saved as %s\n", name)
+	if runErr != nil {
+		return 1
+	}
+	return 0
+}
+
+// findBenchmarks returns the package directories below root that declare
+// Benchmark functions.
+func findBenchmarks(root string) ([]benchPackage, error) {
+	found := map[string]*benchPackage{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (strings.HasPrefix(name, ".") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil || !hasBenchmark(f) {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		p := found[dir]
+		if p == nil {
+			p = &benchPackage{dir: dir}
+			found[dir] = p
+		}
+		if !strings.HasSuffix(path, "_test.go") {
+			p.overlay = true
+		}
+		return nil
+	})
+	var pkgs []benchPackage
+	for _, p := range found {
+		pkgs = append(pkgs, *p)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].dir < pkgs[j].dir })
+	return pkgs, err
+}
+
+// hasBenchmark reports whether f declares func BenchmarkXxx(b *testing.B).
+func hasBenchmark(f *ast.File) bool {
+	for _, d := range f.Decls {
+		fn, ok := d.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Benchmark") {
+			continue
+		}
+		if params := fn.Type.Params.List; len(params) == 1 {
+			if star, ok := params[0].Type.(*ast.StarExpr); ok {
+				if sel, ok := star.X.(*ast.SelectorExpr); ok && sel.Sel.Name == "B" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// writeOverlay writes the overlay file that turns every non-test file of
+// the packages into a test file, and returns its path.
+func writeOverlay(pkgs []benchPackage, tmp string) (string, error) {
+	replace := map[string]string{}
+	for _, p := range pkgs {
+		if !p.overlay {
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(p.dir, "*.go"))
+		if err != nil {
+			return "", err
+		}
+		for _, f := range files {
+			if strings.HasSuffix(f, "_test.go") {
+				continue
+			}
+			replace[f] = ""                                            // hide the file ...
+			replace[strings.TrimSuffix(f, ".go")+"_bench_test.go"] = f // ... and show it as a test file
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{"Replace": replace})
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(tmp, "overlay.json")
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// benchResults maps "pkg BenchmarkName-8" to unit to the average value.
+type benchResults map[string]map[string]float64
+
+// parseBench reads go test -bench output: "pkg:" lines and result lines
+// like "BenchmarkAdd-8  1000000  179.3 ns/op  96 B/op  2 allocs/op".
+func parseBench(data []byte) benchResults {
+	results := benchResults{}
+	counts := map[string]map[string]int{}
+	pkg := ""
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "pkg: ") {
+			pkg = strings.TrimPrefix(line, "pkg: ")
+			if i := strings.Index(pkg, "/session"); i >= 0 {
+				pkg = pkg[i+1:]
+			} else if i := strings.Index(pkg, "/pkg/"); i >= 0 {
+				pkg = pkg[i+1:]
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		key := pkg + " " + fields[0]
+		if results[key] == nil {
+			results[key], counts[key] = map[string]float64{}, map[string]int{}
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				break
+			}
+			unit := fields[i+1]
+			n := counts[key][unit]
+			results[key][unit] = (results[key][unit]*float64(n) + v) / float64(n+1)
+			counts[key][unit] = n + 1
+		}
+	}
+	return results
+}
+
+// lastRun returns the most recent saved run in dir, if any.
+func lastRun(dir string) (string, benchResults) {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if len(files) == 0 {
+		return "", nil
+	}
+	sort.Strings(files) // names are timestamps
+	last := files[len(files)-1]
+	data, err := os.ReadFile(last)
+	if err != nil {
+		return "", nil
+	}
+	return last, parseBench(data)
+}
+
+// compare prints old and new time and allocations of every benchmark.
+func compare(w io.Writer, prev, cur benchResults) {
+	keys := make([]string, 0, len(cur))
+	for k := range cur {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(w, "%-50s %12s %12s %8s %10s\n", "benchmark", "old ns/op", "new ns/op", "delta", "allocs/op")
+	for _, k := range keys {
+		now := cur[k]
+		old, ok := prev[k]
+		allocs := fmt.Sprintf("%.0f", now["allocs/op"])
+		if !ok {
+			fmt.Fprintf(w, "%-50s %12s %12.1f %8s %10s\n", k, "-", now["ns/op"], "new", allocs)
+			continue
+		}
+		if old["allocs/op"] != now["allocs/op"] {
+			allocs = fmt.Sprintf("%.0f -> %.0f", old["allocs/op"], now["allocs/op"])
+		}
+		fmt.Fprintf(w, "%-50s %12.1f %12.1f %8s %10s\n", k, old["ns/op"], now["ns/op"], delta(old["ns/op"], now["ns/op"]), allocs)
+	}
+}
+
+func delta(old, now float64) string {
+	if old == 0 {
+		return "?"
+	}
+	return fmt.Sprintf("%+.1f%%", (now-old)/old*100)
+}
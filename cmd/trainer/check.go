@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+)
+
+// trainer check is the gate before grading: the learner's files of an
+// exercise must be formatted as gofmt formats them and pass go vet.
+// Formatting is checked with go/format, the package behind gofmt, and
+// reported as a unified diff of what would change; -w rewrites the files
+// instead. go vet runs as the toolchain's own command in the exercise's
+// folder. trainer grade runs the same check first.
+
+func check(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	write := fs.Bool("w", false, "rewrite badly formatted files instead of showing a diff")
+	list := fs.Bool("l", false, "only list badly formatted files, without a diff")
+	fs.Parse(args)
+
+	selected := all
+	if fs.NArg() > 0 {
+		selected = nil
+		for _, sel := range fs.Args() {
+			e, err := find(all, sel)
+			if err != nil {
+				fatal(err)
+			}
+			selected = append(selected, e)
+		}
+	}
+	failed := 0
+	for _, e := range selected {
+		problems := checkExercise(e, *write, *list, os.Stdout)
+		if problems > 0 {
+			fail("%s: %d problems", e.ID(), problems)
+			failed++
+		} else if fs.NArg() > 0 {
+			pass("%s", e.ID())
+		}
+	}
+	fmt.Printf("%d checked, %d with problems\n", len(selected), failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// checkExercise formats and vets the learner's files of e, writing what
+// is wrong to w, and returns the number of problems. With fix, formatting
+// is corrected in place and not counted; with brief, a badly formatted
+// file is named without its diff.
+func checkExercise(e Exercise, fix, brief bool, w io.Writer) int {
+	problems := 0
+	for _, path := range learnerFiles(e.Dir) {
+		rel := filepath.Join(fmt.Sprintf("session%d", e.Session), e.Name, filepath.Base(path))
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(w, "%s: %v\n", rel, err)
+			problems++
+			continue
+		}
+		formatted, err := format.Source(src)
+		if err != nil {
+			fmt.Fprintf(w, "%s: %v\n", rel, err)
+			problems++
+			continue
+		}
+		switch {
+		case bytes.Equal(src, formatted):
+		case fix:
+			if err := safewrite.WriteFile(path, formatted, 0644); err != nil {
+				fmt.Fprintf(w, "%s: %v\n", rel, err)
+				problems++
+				continue
+			}
+			fmt.Fprintf(w, "gofmt: rewrote %s\n", rel)
+		case brief:
+			fmt.Fprintf(w, "gofmt: %s is not formatted\n", rel)
+			problems++
+		default:
+			fmt.Fprintf(w, "gofmt: %s is not formatted (trainer check -w %s fixes it):\n", rel, e.ID())
+			io.WriteString(w, unifiedDiff(rel, src, formatted))
+			problems++
+		}
+	}
+
+	vet := exec.Command("go", "vet", ".")
+	vet.Dir = e.Dir
+	out, err := vet.CombinedOutput()
+	if err != nil {
+		hidden, _, _ := gradeFiles(e.Dir) // vet checks the tests too, but must not show them
+		var lines []string
+		for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+			if !strings.HasPrefix(line, "#") && !mentions(line, hidden) {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(w, "go vet:\n")
+		}
+		for _, line := range lines {
+			fmt.Fprintf(w, "    %s\n", line)
+			problems++
+		}
+	}
+	return problems
+}
+
+// diffLine is one line of a diff: ' ' in both, '-' only in the old text,
+// '+' only in the new one. a and b count the lines of each text before it.
+type diffLine struct {
+	kind byte
+	text string
+	a, b int
+}
+
+// unifiedDiff returns the differences of two texts in the unified format
+// of diff -u, with three lines of context, or "" if they are equal.
+func unifiedDiff(name string, old, new []byte) string {
+	a := strings.SplitAfter(string(old), "\n")
+	b := strings.SplitAfter(string(new), "\n")
+	if a[len(a)-1] == "" {
+		a = a[:len(a)-1]
+	}
+	if b[len(b)-1] == "" {
+		b = b[:len(b)-1]
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]; exercise files are small enough for the quadratic table.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i], i, j})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	const context = 3
+	var out strings.Builder
+	for k := 0; k < len(lines); {
+		if lines[k].kind == ' ' {
+			k++
+			continue
+		}
+		// a hunk runs from context lines before this change to context
+		// lines after the last change that is no more than 2*context
+		// unchanged lines away from the one before it
+		start, last := max(0, k-context), k
+		for n := k + 1; n < len(lines); n++ {
+			if lines[n].kind != ' ' {
+				if n-last > 2*context {
+					break
+				}
+				last = n
+			}
+		}
+		end := min(len(lines), last+context+1)
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s (formatted)\n", name, name)
+		}
+		var oldLen, newLen int
+		for _, l := range lines[start:end] {
+			if l.kind != '+' {
+				oldLen++
+			}
+			if l.kind != '-' {
+				newLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(lines[start].a, oldLen), hunkRange(lines[start].b, newLen))
+		for _, l := range lines[start:end] {
+			out.WriteByte(l.kind)
+			out.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		k = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk; an empty range
+// names the line before it, as diff does.
+func hunkRange(before, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if n == 1 {
+		return fmt.Sprint(before + 1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, n)
+}
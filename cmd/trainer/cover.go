@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// trainer cover measures which code the tests reach: the tests of the
+// shared packages, the hidden tests of trainer grade and the fuzz
+// targets' seed corpora. One go test run over the whole module writes a
+// single profile in which packages without tests appear with all their
+// statements uncovered; the profile is then summed up per exercise and
+// per session, for instructors looking for exercises that still lack
+// tests.
+
+// coverage counts the statements of one exercise or package.
+type coverage struct {
+	Name     string // exercise ID or package path below the module
+	Doc      string
+	Covered  int
+	Total    int
+	HasTests bool
+}
+
+func (c coverage) Percent() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return float64(c.Covered) * 100 / float64(c.Total)
+}
+
+// coverGroup is a session, or the shared packages.
+type coverGroup struct {
+	Title string
+	Items []coverage
+	Sum   coverage
+}
+
+func cover(root string, all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("cover", flag.ExitOnError)
+	htmlOut := fs.String("html", "", "also write the report as HTML to this file")
+	profile := fs.String("coverprofile", "", "keep the merged profile in this file")
+	verbose := fs.Bool("v", false, "list every exercise, not only those without tests")
+	fs.Parse(args)
+
+	tmp, err := os.MkdirTemp("", "trainer")
+	if err != nil {
+		fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	if *profile == "" {
+		*profile = filepath.Join(tmp, "cover.out")
+	}
+
+	testDirs, err := findTestDirs(root)
+	if err != nil {
+		fatal(err)
+	}
+	cmd := exec.Command("go", "test", "-coverprofile", *profile, "-run", "^(Test|Fuzz)", "./...")
+	cmd.Dir = root
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	runErr := cmd.Run()
+
+	blocks, err := readProfile(*profile)
+	if err != nil {
+		os.Stdout.Write(out.Bytes())
+		fatal(fmt.Errorf("no coverage profile: %v", err))
+	}
+	if runErr != nil {
+		fmt.Println("some tests failed, coverage counts the tests that ran:")
+		for _, line := range strings.Split(out.String(), "\n") {
+			if strings.HasPrefix(line, "FAIL") || strings.HasPrefix(line, "---") {
+				fmt.Println("   ", line)
+			}
+		}
+	}
+
+	groups := summarize(root, all, blocks, testDirs)
+	printCoverage(os.Stdout, groups, *verbose)
+	if *htmlOut != "" {
+		var buf bytes.Buffer
+		if err := coverPage.Execute(&buf, groups); err != nil {
+			fatal(err)
+		}
+		if err := os.WriteFile(*htmlOut, buf.Bytes(), 0644); err != nil {
+			fatal(err)
+		}
+		fmt.Printf("report written to %s\n", *htmlOut)
+	}
+	if runErr != nil {
+		return 1
+	}
+	return 0
+}
+
+// findTestDirs returns the directories below root whose test files
+// declare Test or Fuzz functions.
+func findTestDirs(root string) (map[string]bool, error) {
+	dirs := map[string]bool{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (strings.HasPrefix(name, ".") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, "_test.go") || dirs[filepath.Dir(path)] {
+			return nil
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return nil
+		}
+		for _, d := range f.Decls {
+			if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil &&
+				(strings.HasPrefix(fn.Name.Name, "Test") || strings.HasPrefix(fn.Name.Name, "Fuzz")) {
+				dirs[filepath.Dir(path)] = true
+				break
+			}
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+// profileBlock is one line of a cover profile.
+type profileBlock struct {
+	file  string // import path of the package + "/" + file name
+	stmts int
+	hit   bool
+}
+
+// readProfile reads a profile written by go test -coverprofile. A block
+// listed more than once (one package tested twice) counts as covered if
+// any of its lines says so.
+func readProfile(path string) ([]profileBlock, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	index := map[string]int{}
+	var blocks []profileBlock
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// name.go:line.col,line.col statements count
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		stmts, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		colon := strings.LastIndex(fields[0], ":")
+		if err1 != nil || err2 != nil || colon < 0 {
+			return nil, fmt.Errorf("%s: bad line %q", path, line)
+		}
+		if i, ok := index[fields[0]]; ok {
+			blocks[i].hit = blocks[i].hit || count > 0
+			continue
+		}
+		index[fields[0]] = len(blocks)
+		blocks = append(blocks, profileBlock{file: fields[0][:colon], stmts: stmts, hit: count > 0})
+	}
+	return blocks, sc.Err()
+}
+
+// summarize adds up the blocks per exercise, per session and for the
+// packages under pkg.
+func summarize(root string, all []Exercise, blocks []profileBlock, testDirs map[string]bool) []coverGroup {
+	module := modulePath(root)
+	byPkg := map[string]*coverage{} // by directory relative to root
+	for _, b := range blocks {
+		rel := strings.TrimPrefix(strings.TrimPrefix(filepath.ToSlash(filepath.Dir(b.file)), module), "/")
+		c := byPkg[rel]
+		if c == nil {
+			c = &coverage{}
+			byPkg[rel] = c
+		}
+		c.Total += b.stmts
+		if b.hit {
+			c.Covered += b.stmts
+		}
+	}
+
+	var groups []coverGroup
+	for _, e := range all {
+		if len(groups) == 0 || groups[len(groups)-1].Title != sessionTitle(e.Session) {
+			groups = append(groups, coverGroup{Title: sessionTitle(e.Session)})
+		}
+		g := &groups[len(groups)-1]
+		c := coverage{Name: e.ID(), Doc: e.Doc}
+		rel, _ := filepath.Rel(root, e.Dir)
+		rel = filepath.ToSlash(rel)
+		for dir, pc := range byPkg { // the exercise and its own packages
+			if dir == rel || strings.HasPrefix(dir, rel+"/") {
+				c.Covered += pc.Covered
+				c.Total += pc.Total
+				delete(byPkg, dir)
+			}
+		}
+		for dir := range testDirs {
+			if dir == e.Dir || strings.HasPrefix(dir, e.Dir+string(filepath.Separator)) {
+				c.HasTests = true
+			}
+		}
+		g.Items = append(g.Items, c)
+	}
+
+	shared := coverGroup{Title: "Shared packages"}
+	var dirs []string
+	for dir := range byPkg {
+		if strings.HasPrefix(dir, "pkg/") {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		c := *byPkg[dir]
+		c.Name = dir
+		c.HasTests = testDirs[filepath.Join(root, filepath.FromSlash(dir))]
+		shared.Items = append(shared.Items, c)
+	}
+	if len(shared.Items) > 0 {
+		groups = append(groups, shared)
+	}
+
+	for i := range groups {
+		g := &groups[i]
+		g.Sum.Name = g.Title
+		for _, c := range g.Items {
+			g.Sum.Covered += c.Covered
+			g.Sum.Total += c.Total
+			if c.HasTests {
+				g.Sum.HasTests = true
+			}
+		}
+	}
+	return groups
+}
+
+func sessionTitle(n int) string {
+	return fmt.Sprintf("Session %d: %s", n, sessions[n-1])
+}
+
+// modulePath reads the module line of root/go.mod.
+func modulePath(root string) string {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+func printCoverage(w io.Writer, groups []coverGroup, verbose bool) {
+	for _, g := range groups {
+		tested := 0
+		for _, c := range g.Items {
+			if c.HasTests {
+				tested++
+			}
+		}
+		fmt.Fprintf(w, "\n%-50s %s %5.1f%%  %d/%d with tests\n", g.Title, bar(g.Sum.Covered, g.Sum.Total), g.Sum.Percent(), tested, len(g.Items))
+		var missing []string
+		for _, c := range g.Items {
+			switch {
+			case verbose || c.HasTests:
+				fmt.Fprintf(w, "    %-22s %5.1f%%  %d/%d statements\n", c.Name, c.Percent(), c.Covered, c.Total)
+			default:
+				missing = append(missing, c.Name)
+			}
+		}
+		if len(missing) > 0 && !verbose {
+			fmt.Fprintf(w, "    no tests: %s\n", strings.Join(missing, " "))
+		}
+	}
+}
+
+var coverPage = template.Must(template.New("cover").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Coverage - training-golang</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+td, th { padding: 2px 8px; text-align: left; }
+td.num { text-align: right; font-family: monospace; }
+tr.none td { color: #b00; }
+.bar { background: #eee; width: 10em; } .bar div { background: #2e7d32; height: 1em; }
+</style></head><body>
+<h1>Coverage</h1>
+{{range .}}<h2>{{.Title}}</h2>
+<table>
+<tr><th></th><th></th><th>statements</th><th>covered</th><th></th></tr>
+{{range .Items}}<tr{{if not .HasTests}} class="none"{{end}}>
+<td>{{.Name}}</td><td>{{.Doc}}{{if not .HasTests}} (no tests){{end}}</td>
+<td class="num">{{.Covered}}/{{.Total}}</td><td class="num">{{printf "%.1f%%" .Percent}}</td>
+<td><div class="bar"><div style="width: {{printf "%.0f" .Percent}}%"></div></div></td></tr>
+{{end}}<tr><th>total</th><th></th><td class="num">{{.Sum.Covered}}/{{.Sum.Total}}</td><td class="num">{{printf "%.1f%%" .Sum.Percent}}</td><td></td></tr>
+</table>
+{{end}}</body></html>
+`))
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sessions are the titles of session1, session2, ...
+var sessions = []string{
+	"Structs and Methods",
+	"Interfaces and Reflection",
+	"Goroutines and Channels",
+	"Networking, Templating and Web-Applications",
+	"Common Go Pitfalls and Patterns",
+	"Performance Advices",
+	"Encoding and I-O",
+	"TCP Servers and Protocols",
+	"gRPC Services",
+	"Command-Line Tools",
+}
+
+// Exercise is one runnable program of a session.
+type Exercise struct {
+	Session int    // 1-based session number
+	Name    string // "ex5", or "ex16/server" when an exercise has several programs
+	Dir     string // package directory, the program runs there
+	Doc     string // one-line description
+}
+
+// ID is the name used on the command line, e.g. "3/ex5".
+func (e Exercise) ID() string {
+	return fmt.Sprintf("%d/%s", e.Session, e.Name)
+}
+
+var exName = regexp.MustCompile(`^ex(\d+)$`)
+
+// discover returns the exercises below root in course order: every
+// sessionN/exM directory with a main package, or its subdirectories
+// with one (ex1/client and ex1/server).
+func discover(root string) ([]Exercise, error) {
+	var all []Exercise
+	for i := range sessions {
+		dir := filepath.Join(root, fmt.Sprintf("session%d", i+1))
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var list []Exercise
+		for _, e := range entries {
+			if !e.IsDir() || !exName.MatchString(e.Name()) {
+				continue
+			}
+			exDir := filepath.Join(dir, e.Name())
+			if main := mainFile(exDir); main != "" {
+				list = append(list, Exercise{Session: i + 1, Name: e.Name(), Dir: exDir, Doc: describe(main)})
+				continue
+			}
+			subs, err := os.ReadDir(exDir)
+			if err != nil {
+				return nil, err
+			}
+			for _, sub := range subs {
+				subDir := filepath.Join(exDir, sub.Name())
+				if main := mainFile(subDir); sub.IsDir() && main != "" {
+					list = append(list, Exercise{Session: i + 1, Name: e.Name() + "/" + sub.Name(), Dir: subDir, Doc: describe(main)})
+				}
+			}
+		}
+		sort.SliceStable(list, func(a, b int) bool { return number(list[a].Name) < number(list[b].Name) })
+		all = append(all, list...)
+	}
+	return all, nil
+}
+
+// number returns N for "exN" and "exN/anything".
+func number(name string) int {
+	name = strings.SplitN(name, "/", 2)[0]
+	n, _ := strconv.Atoi(strings.TrimPrefix(name, "ex"))
+	return n
+}
+
+// mainFile returns the file of dir that declares func main, if any.
+func mainFile(dir string) string {
+	names, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, path := range names {
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err == nil && f.Name.Name == "main" && f.Scope.Lookup("main") != nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// describe returns the first line of the first comment in the file, or
+// else the names the file declares.
+func describe(path string) string {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+	if err != nil {
+		return "(does not parse)"
+	}
+	for _, c := range f.Comments {
+		for _, line := range strings.Split(c.Text(), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				return line
+			}
+		}
+	}
+	var names []string
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Name.Name != "main" {
+				names = append(names, d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				if t, ok := s.(*ast.TypeSpec); ok {
+					names = append(names, t.Name.Name)
+				}
+			}
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	if len(names) > 5 {
+		names = append(names[:5], "...")
+	}
+	return "declares " + strings.Join(names, ", ")
+}
+
+// find picks an exercise by list number ("12"), by ID ("3/ex5" or
+// "session3/ex5", also as the path "./session3/ex5/" that the shell
+// completes) or by exercise name alone when that is unambiguous.
+func find(all []Exercise, sel string) (Exercise, error) {
+	if n, err := strconv.Atoi(sel); err == nil {
+		if n < 1 || n > len(all) {
+			return Exercise{}, fmt.Errorf("there is no exercise %d (1-%d)", n, len(all))
+		}
+		return all[n-1], nil
+	}
+	sel = strings.TrimSuffix(filepath.ToSlash(filepath.Clean(sel)), ".go")
+	sel = strings.TrimPrefix(sel, "session")
+	var matches []Exercise
+	for _, e := range all {
+		if e.ID() == sel || e.Name == sel {
+			matches = append(matches, e)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Exercise{}, fmt.Errorf("no exercise %q, see trainer list", sel)
+	case 1:
+		return matches[0], nil
+	}
+	ids := make([]string, len(matches))
+	for i, e := range matches {
+		ids[i] = e.ID()
+	}
+	return Exercise{}, fmt.Errorf("%q is ambiguous: %s", sel, strings.Join(ids, ", "))
+}
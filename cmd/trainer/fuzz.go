@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Fuzz targets (FuzzXxx(f *testing.F)) live in the _test.go files of the
+// code they fuzz, and go test runs their seed corpus with the other
+// tests. trainer fuzz finds them all and fuzzes them one after the
+// other, each for its time budget: -fuzztime, or the duration on a
+//
+//	//trainer:fuzztime 30s
+//
+// line in the target's doc comment. Inputs that fail are saved by go
+// test in testdata/fuzz/FuzzXxx of the package and are run again by
+// trainer fuzz -seeds from then on, along with the targets' f.Add seeds.
+// fuzzTarget is one Fuzz function.
+type fuzzTarget struct {
+	dir    string        // package directory
+	name   string        // "FuzzOpen"
+	budget time.Duration // 0: the -fuzztime default
+}
+
+func fuzz(root string, args []string) int {
+	fs := flag.NewFlagSet("fuzz", flag.ExitOnError)
+	fuzztime := fs.Duration("fuzztime", 10*time.Second, "fuzz each target this long, unless it sets its own budget")
+	run := fs.String("run", "", "only fuzz targets matching this regexp")
+	seeds := fs.Bool("seeds", false, "only run the seed corpus of every target, no fuzzing")
+	fs.Parse(args)
+	match, err := regexp.Compile(*run)
+	if err != nil {
+		fatal(err)
+	}
+
+	targets, err := findFuzzTargets(root)
+	if err != nil {
+		fatal(err)
+	}
+	var keep []fuzzTarget
+	for _, t := range targets {
+		if !match.MatchString(t.name) || !below(t.dir, fs.Args()) {
+			continue
+		}
+		keep = append(keep, t)
+	}
+	if len(keep) == 0 {
+		fmt.Println("no fuzz targets found")
+		return 0
+	}
+
+	failed := 0
+	for _, t := range keep {
+		rel, _ := filepath.Rel(root, t.dir)
+		budget := t.budget
+		if budget == 0 {
+			budget = *fuzztime
+		}
+		goArgs := []string{"test", "-run", "^" + t.name + "$"}
+		if *seeds {
+			fmt.Printf("=== %s %s seed corpus\n", rel, t.name)
+		} else {
+			fmt.Printf("=== %s %s for %v\n", rel, t.name, budget)
+			goArgs = append(goArgs, "-fuzz", "^"+t.name+"$", "-fuzztime", budget.String())
+		}
+		cmd := exec.Command("go", append(goArgs, ".")...)
+		cmd.Dir = t.dir
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			fail("%s %s", rel, t.name)
+			failed++
+		} else {
+			pass("%s %s", rel, t.name)
+		}
+	}
+	fmt.Printf("%d targets, %d failed\n", len(keep), failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// below reports whether dir is one of dirs or below one, or dirs is empty.
+func below(dir string, dirs []string) bool {
+	if len(dirs) == 0 {
+		return true
+	}
+	for _, d := range dirs {
+		abs, _ := filepath.Abs(strings.TrimSuffix(d, "/..."))
+		if dir == abs || strings.HasPrefix(dir, abs+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// findFuzzTargets returns the Fuzz functions in the test files below
+// root.
+func findFuzzTargets(root string) ([]fuzzTarget, error) {
+	var targets []fuzzTarget
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (strings.HasPrefix(name, ".") || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+		if err != nil {
+			return nil
+		}
+		for _, d := range f.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Fuzz") {
+				continue
+			}
+			t := fuzzTarget{dir: filepath.Dir(path), name: fn.Name.Name}
+			if t.budget, err = fuzzBudget(fn.Doc); err != nil {
+				return fmt.Errorf("%s: %s: %v", path, t.name, err)
+			}
+			targets = append(targets, t)
+		}
+		return nil
+	})
+	return targets, err
+}
+
+// fuzzBudget reads the //trainer:fuzztime line of a doc comment.
+func fuzzBudget(doc *ast.CommentGroup) (time.Duration, error) {
+	if doc == nil {
+		return 0, nil
+	}
+	for _, c := range doc.List {
+		if rest, ok := strings.CutPrefix(c.Text, "//trainer:fuzztime "); ok {
+			return time.ParseDuration(strings.TrimSpace(rest))
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/sandbox"
+)
+
+// Golden files pin down what an exercise prints, so that a refactoring
+// (like moving everything into a module) cannot change it unnoticed.
+// Each exercise may have a testdata directory with:
+//
+//	output.golden   the expected standard output
+//	args            command-line arguments, one per line (optional)
+//	stdin           standard input (optional, otherwise empty)
+//
+// trainer golden -update (re)writes output.golden. An exercise is only
+// given a golden file if it finishes within -timeout and prints the same
+// twice in a row: servers and programs that print times, random numbers
+// or goroutines racing each other are left out.
+//
+// Checking (without -update) is also how exercises get completed in the
+// learner's progress, see Progress.
+const goldenName = "output.golden"
+
+// maxOutput is how much a program may print in a check or on the
+// dashboard before it is stopped.
+const maxOutput = 10 << 20
+
+// goldenResult is the outcome of running one exercise.
+type goldenResult struct {
+	out      []byte
+	timedOut bool
+	err      error // build or exit error
+}
+
+func golden(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("golden", flag.ExitOnError)
+	update := fs.Bool("update", false, "write the golden files instead of comparing")
+	timeout := fs.Duration("timeout", 15*time.Second, "give up on a program after this long")
+	verbose := fs.Bool("v", false, "also report exercises without a golden file")
+	fs.Parse(args)
+
+	selected := all
+	if fs.NArg() > 0 {
+		selected = nil
+		for _, sel := range fs.Args() {
+			e, err := find(all, sel)
+			if err != nil {
+				fatal(err)
+			}
+			selected = append(selected, e)
+		}
+	}
+	tmp, err := os.MkdirTemp("", "trainer")
+	if err != nil {
+		fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	progress, err := loadProgress()
+	if err != nil {
+		fatal(err)
+	}
+	var passed, failed, skipped int
+	for _, e := range selected {
+		path := filepath.Join(e.Dir, "testdata", goldenName)
+		want, err := os.ReadFile(path)
+		hasGolden := err == nil
+		exe, err := buildExercise(e, tmp)
+		if err != nil {
+			fmt.Printf("FAIL %-14s does not build\n", e.ID())
+			progress.checked(e, false)
+			failed++
+			continue
+		}
+		if !*update && !hasGolden {
+			if *verbose {
+				fmt.Printf("---- %-14s no golden file\n", e.ID())
+			}
+			progress.checked(e, true) // building is all there is to check
+			skipped++
+			continue
+		}
+
+		r := runGolden(e, exe, *timeout)
+		if *update {
+			reason := ""
+			if !r.timedOut {
+				if again := runGolden(e, exe, *timeout); !bytes.Equal(r.out, again.out) {
+					reason = "output changes from run to run"
+				}
+			} else {
+				reason = "does not finish within " + timeout.String()
+			}
+			if reason != "" {
+				fmt.Printf("---- %-14s %s, no golden file\n", e.ID(), reason)
+				skipped++
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+				err = os.WriteFile(path, r.out, 0644)
+			}
+			if err != nil {
+				fatal(err)
+			}
+			fmt.Printf("ok   %-14s %d bytes written\n", e.ID(), len(r.out))
+			passed++
+			continue
+		}
+
+		switch {
+		case r.timedOut:
+			fmt.Printf("FAIL %-14s did not finish within %v\n", e.ID(), *timeout)
+			failed++
+		case !bytes.Equal(r.out, want):
+			fmt.Printf("FAIL %-14s output differs from %s\n", e.ID(), goldenName)
+			fmt.Print(firstDiff(want, r.out))
+			failed++
+		default:
+			fmt.Printf("ok   %s\n", e.ID())
+			passed++
+		}
+		progress.checked(e, !r.timedOut && bytes.Equal(r.out, want))
+	}
+	if !*update {
+		if err := progress.save(); err != nil {
+			fmt.Fprintln(os.Stderr, "trainer: progress not saved:", err)
+		}
+	}
+
+	fmt.Printf("%d ok, %d failed, %d without golden file\n", passed, failed, skipped)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runGolden runs the binary of e with the inputs from its testdata
+// directory and returns what it wrote to stdout. A non-zero exit status
+// is fine: some exercises end in a deliberate panic. The environment is
+// scrubbed, so the output does not depend on who runs the check.
+func runGolden(e Exercise, exe string, timeout time.Duration) goldenResult {
+	var args []string
+	if data, err := os.ReadFile(filepath.Join(e.Dir, "testdata", "args")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if line != "" {
+				args = append(args, line)
+			}
+		}
+	}
+	var out bytes.Buffer
+	cmd := &sandbox.Cmd{Path: exe, Args: args, Dir: e.Dir, Stdout: &out, Timeout: timeout, MaxOutput: maxOutput}
+	if stdin, err := os.Open(filepath.Join(e.Dir, "testdata", "stdin")); err == nil {
+		defer stdin.Close()
+		cmd.Stdin = stdin
+	}
+	r, err := cmd.Run(context.Background())
+	return goldenResult{out: out.Bytes(), timedOut: r.TimedOut, err: err}
+}
+
+// firstDiff describes the first line where got differs from want.
+func firstDiff(want, got []byte) string {
+	w := strings.Split(string(want), "\n")
+	g := strings.Split(string(got), "\n")
+	for i := 0; i < len(w) || i < len(g); i++ {
+		var wl, gl string
+		if i < len(w) {
+			wl = w[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if wl != gl || i >= len(w) || i >= len(g) {
+			return fmt.Sprintf("     line %d\n     want: %q\n     got:  %q\n", i+1, wl, gl)
+		}
+	}
+	return ""
+}
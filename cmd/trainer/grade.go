@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// An exercise can ship hidden tests that check the learner's solution
+// requirement by requirement. They are the test functions of the _test.go
+// files next to the exercise's main.go, which go test runs like any
+// other; trainer student leaves those files out of the learner's copy.
+// Each TestXxx is one requirement, described by the first line of its
+// doc comment. A _test.go file without TestXxx functions (benchmarks
+// only) is not hidden.
+//
+// Only the outcome of each requirement is printed: the tests' output,
+// and compiler errors pointing into them, would give the answer away.
+// Passing every requirement completes the exercise, see Progress.
+
+// requirement is one hidden test of an exercise.
+type requirement struct {
+	test string // "TestArea"
+	doc  string // "Area multiplies length and width"
+}
+
+// gradeFiles returns the hidden test files of dir and their requirements
+// in source order.
+func gradeFiles(dir string) ([]string, []requirement, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []string
+	var reqs []requirement
+	for _, path := range paths {
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		n := len(reqs)
+		for _, d := range f.Decls {
+			fn, ok := d.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Test") {
+				continue
+			}
+			doc := strings.TrimSpace(strings.SplitN(fn.Doc.Text(), "\n", 2)[0])
+			if doc == "" {
+				doc = fn.Name.Name
+			}
+			reqs = append(reqs, requirement{test: fn.Name.Name, doc: doc})
+		}
+		if len(reqs) > n {
+			files = append(files, path)
+		}
+	}
+	return files, reqs, nil
+}
+
+// learnerFiles returns the Go files of dir as the learner sees them,
+// hidden tests left out.
+func learnerFiles(dir string) []string {
+	hidden := map[string]bool{}
+	if files, _, err := gradeFiles(dir); err == nil {
+		for _, f := range files {
+			hidden[f] = true
+		}
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	var files []string
+	for _, path := range paths {
+		if !hidden[path] {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+func grade(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("grade", flag.ExitOnError)
+	timeout := fs.Duration("timeout", time.Minute, "give up on the hidden tests after this long")
+	gate := fs.Bool("check", true, "require gofmt and go vet to pass first, as trainer check does")
+	fs.Parse(args)
+
+	selected := all
+	if fs.NArg() > 0 {
+		selected = nil
+		for _, sel := range fs.Args() {
+			e, err := find(all, sel)
+			if err != nil {
+				fatal(err)
+			}
+			selected = append(selected, e)
+		}
+	}
+	progress, err := loadProgress()
+	if err != nil {
+		fatal(err)
+	}
+	var graded, passed, failed int
+	for _, e := range selected {
+		files, reqs, err := gradeFiles(e.Dir)
+		if err != nil {
+			fatal(err)
+		}
+		if len(reqs) == 0 {
+			if fs.NArg() > 0 {
+				fmt.Printf("---- %-14s no hidden tests\n", e.ID())
+			}
+			continue
+		}
+		graded++
+		fmt.Printf("=== %s  %s\n", e.ID(), e.Doc)
+		if *gate && checkExercise(e, false, false, os.Stdout) > 0 {
+			fmt.Printf("FAIL %-14s not graded, trainer check %s first\n", e.ID(), e.ID())
+			progress.checked(e, false)
+			failed += len(reqs)
+			continue
+		}
+		results, buildErr := runGrade(e, files, *timeout)
+		if buildErr != "" {
+			fmt.Printf("FAIL %-14s does not build\n%s", e.ID(), buildErr)
+			progress.checked(e, false)
+			failed += len(reqs)
+			continue
+		}
+		ok := 0
+		for _, r := range reqs {
+			switch results[r.test] {
+			case "pass":
+				pass("%s", r.doc)
+				ok++
+			case "":
+				fail("%s (did not finish within %v)", r.doc, *timeout)
+			default:
+				fail("%s", r.doc)
+			}
+		}
+		fmt.Printf("--- %s  %d/%d requirements met\n", e.ID(), ok, len(reqs))
+		passed += ok
+		failed += len(reqs) - ok
+		progress.checked(e, ok == len(reqs))
+	}
+	if graded == 0 {
+		fmt.Println("no exercise with hidden tests")
+		return 0
+	}
+	if err := progress.save(); err != nil {
+		fmt.Fprintln(os.Stderr, "trainer: progress not saved:", err)
+	}
+	fmt.Printf("%d requirements met, %d not met\n", passed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runGrade runs the hidden tests of e and returns the action ("pass",
+// "fail" or "skip") of every top-level test that finished. If the
+// package does not build, the compiler errors in the learner's files
+// are returned instead.
+func runGrade(e Exercise, files []string, timeout time.Duration) (map[string]string, string) {
+	cmd := exec.Command("go", "test", "-json", "-count", "1", "-timeout", timeout.String(), "-run", "^Test", ".")
+	cmd.Dir = e.Dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	cmd.Run() // failing tests are reported in the events
+
+	results := map[string]string{}
+	var buildOut bytes.Buffer
+	buildFailed := false
+	sc := bufio.NewScanner(&stdout)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var ev struct {
+			Action string
+			Test   string
+			Output string
+		}
+		if json.Unmarshal(sc.Bytes(), &ev) != nil {
+			continue
+		}
+		switch ev.Action {
+		case "build-output":
+			buildOut.WriteString(ev.Output)
+		case "build-fail":
+			buildFailed = true
+		case "pass", "fail", "skip":
+			if ev.Test != "" && !strings.Contains(ev.Test, "/") {
+				results[ev.Test] = ev.Action
+			}
+		}
+	}
+	buildOut.Write(stderr.Bytes()) // older go commands print build errors here
+	if len(results) > 0 && !buildFailed {
+		return results, ""
+	}
+	msg := learnerErrors(buildOut.String(), files)
+	if msg == "" && !buildFailed {
+		return results, "" // built, but stopped before any test finished
+	}
+	if msg == "" {
+		msg = "     (errors in the hidden tests only: the exercise does not declare what they need)\n"
+	}
+	return nil, msg
+}
+
+// learnerErrors keeps the compiler errors of out that are not about the
+// hidden test files, indented for the report.
+func learnerErrors(out string, hidden []string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, ".go:") && !mentions(line, hidden) {
+			fmt.Fprintf(&b, "     %s\n", strings.TrimSpace(line))
+		}
+	}
+	return b.String()
+}
+
+// mentions reports whether line names one of files, by base name, as
+// compiler and vet messages do.
+func mentions(line string, files []string) bool {
+	for _, f := range files {
+		if strings.Contains(line, filepath.Base(f)+":") {
+			return true
+		}
+	}
+	return false
+}
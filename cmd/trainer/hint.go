@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Exercise sources can carry help for learners in their comments:
+//
+//	// HINT: a receiver that is a pointer can change the struct
+//
+// trainer hint shows the hints of an exercise one at a time, in source
+// order, each call one more; how many were shown is kept in the progress.
+//
+//	// SOLUTION-START return 0
+//	return 2 * (r.length + r.width)
+//	// SOLUTION-END
+//
+// marks the part of an exercise the learner is meant to write. trainer
+// student copies the repository with every such block replaced by a TODO
+// comment and the code after SOLUTION-START, if any, so that the
+// student version still builds.
+const (
+	hintMarker  = "// HINT:"
+	startMarker = "// SOLUTION-START"
+	endMarker   = "// SOLUTION-END"
+)
+
+// hints returns the hints in the files of e.
+func hints(e Exercise) ([]string, error) {
+	var list []string
+	for _, path := range learnerFiles(e.Dir) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if i := strings.Index(line, hintMarker); i >= 0 {
+				list = append(list, strings.TrimSpace(line[i+len(hintMarker):]))
+			}
+		}
+	}
+	return list, nil
+}
+
+func hint(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("hint", flag.ExitOnError)
+	showAll := fs.Bool("all", false, "show all hints at once")
+	reset := fs.Bool("reset", false, "forget which hints were shown")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	e, err := find(all, fs.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	list, err := hints(e)
+	if err != nil {
+		fatal(err)
+	}
+	if len(list) == 0 {
+		fmt.Printf("%s has no hints\n", e.ID())
+		return 0
+	}
+
+	p, err := loadProgress()
+	if err != nil {
+		fatal(err)
+	}
+	r := p.record(e)
+	switch {
+	case *reset:
+		r.Hints = 0
+		fmt.Printf("%s: hints hidden again\n", e.ID())
+	case *showAll:
+		r.Hints = len(list)
+	case r.Hints < len(list):
+		r.Hints++
+	}
+	if err := p.save(); err != nil {
+		fmt.Fprintln(os.Stderr, "trainer: progress not saved:", err)
+	}
+	for i := 0; i < r.Hints; i++ {
+		fmt.Printf("hint %d/%d: %s\n", i+1, len(list), list[i])
+	}
+	if r.Hints < len(list) && !*reset {
+		fmt.Printf("(%d more, run trainer hint %s again)\n", len(list)-r.Hints, e.ID())
+	}
+	return 0
+}
+
+// student copies the repository at root into a new directory with the
+// solution blocks taken out. Hidden directories (.git, .bench) and the
+// hidden tests of the exercises are left behind.
+func student(root string, all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("student", flag.ExitOnError)
+	out := fs.String("o", "", "directory to create")
+	fs.Parse(args)
+	if *out == "" {
+		fmt.Fprintln(os.Stderr, "usage: trainer student -o dir")
+		return 2
+	}
+	dest, err := filepath.Abs(*out)
+	if err != nil {
+		fatal(err)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		fatal(fmt.Errorf("%s already exists", dest))
+	}
+
+	hidden := map[string]bool{}
+	for _, e := range all {
+		files, _, err := gradeFiles(e.Dir)
+		if err != nil {
+			fatal(err)
+		}
+		for _, f := range files {
+			hidden[f] = true
+		}
+	}
+	files, stripped, err := copyStudent(root, dest, hidden)
+	if err != nil {
+		os.RemoveAll(dest)
+		fatal(err)
+	}
+	fmt.Printf("wrote %d files to %s, %d solution blocks taken out\n", files, dest, stripped)
+	return 0
+}
+
+// copyStudent copies root to dest, stripping the solutions of Go files
+// and leaving out the hidden ones, and returns the number of files and
+// of solution blocks.
+func copyStudent(root, dest string, hidden map[string]bool) (int, int, error) {
+	files, stripped := 0, 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		target := filepath.Join(dest, rel)
+		if d.IsDir() {
+			if path == dest || (path != root && strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() || hidden[path] {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, ".go") {
+			var n int
+			if data, n, err = stripSolutions(data); err != nil {
+				return fmt.Errorf("%s:%v", rel, err)
+			}
+			stripped += n
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+	return files, stripped, err
+}
+
+// stripSolutions replaces the solution blocks of a Go source and returns
+// how many there were. Errors name the line of an unbalanced marker.
+func stripSolutions(src []byte) ([]byte, int, error) {
+	var out bytes.Buffer
+	blocks, start := 0, 0
+	lines := strings.SplitAfter(string(src), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, startMarker):
+			if start != 0 {
+				return nil, 0, fmt.Errorf("%d: %s inside the block started on line %d", i+1, startMarker, start)
+			}
+			start = i + 1
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			out.WriteString(indent + "// TODO: your solution\n")
+			if stub := strings.TrimSpace(strings.TrimPrefix(trimmed, startMarker)); stub != "" {
+				out.WriteString(indent + stub + "\n")
+			}
+		case strings.HasPrefix(trimmed, endMarker):
+			if start == 0 {
+				return nil, 0, fmt.Errorf("%d: %s without %s", i+1, endMarker, startMarker)
+			}
+			start = 0
+			blocks++
+		case start == 0:
+			out.WriteString(line)
+		}
+	}
+	if start != 0 {
+		return nil, 0, fmt.Errorf("%d: %s without %s", start, startMarker, endMarker)
+	}
+	return out.Bytes(), blocks, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// trainer learn walks a learner through the course in order: it shows
+// the first exercise not completed yet and waits for a command.
+//
+//	enter, r   build and run the exercise
+//	c          check it: with its hidden tests if it has them, else
+//	           against its golden file, else that it builds
+//	h          show the next hint
+//	s          skip it for now
+//	q          quit
+//
+// A passing check completes the exercise in the progress, as trainer
+// golden and trainer grade do, and moves on to the next one. Programs run
+// without input and are stopped after -timeout, as in trainer tui.
+
+func learn(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("learn", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 15*time.Second, "stop a program after this long")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		usage()
+	}
+	if len(all) == 0 {
+		fatal(fmt.Errorf("no exercises found"))
+	}
+	tmp, err := os.MkdirTemp("", "trainer")
+	if err != nil {
+		fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	t := &tui{all: all, tmp: tmp, timeout: *timeout}
+
+	cur := 0
+	if fs.NArg() == 1 {
+		e, err := find(all, fs.Arg(0))
+		if err != nil {
+			fatal(err)
+		}
+		for i := range all {
+			if all[i].ID() == e.ID() {
+				cur = i
+			}
+		}
+	}
+	p, err := loadProgress()
+	if err != nil {
+		fatal(err)
+	}
+	cur = nextOpen(all, p, cur)
+
+	in := bufio.NewScanner(os.Stdin)
+	for cur < len(all) {
+		e := all[cur]
+		done := 0
+		for _, x := range all {
+			if p.state(x) == completed {
+				done++
+			}
+		}
+		fmt.Printf("\n=== Session %d: %s  (%d/%d completed)\n", e.Session, sessions[e.Session-1], done, len(all))
+		fmt.Printf("%s  %s\n", e.ID(), e.Doc)
+		fmt.Printf("edit %s, then: enter run, c check, h hint, s skip, q quit\n> ", filepath.Join(fmt.Sprintf("session%d", e.Session), e.Name))
+		if !in.Scan() {
+			break
+		}
+		out := ""
+		switch strings.TrimSpace(in.Text()) {
+		case "", "r":
+			out = t.runOutput(e)
+		case "c":
+			out = learnCheck(t, e)
+		case "h":
+			hint(all, []string{e.ID()})
+		case "s":
+			cur = nextOpen(all, p, cur+1)
+		case "q":
+			return 0
+		default:
+			fmt.Println("enter, r, c, h, s or q")
+		}
+		if out != "" && !strings.HasSuffix(out, "\n") {
+			out += "\n" // the program's last line
+		}
+		fmt.Print(out)
+		if p, err = loadProgress(); err != nil {
+			fatal(err)
+		}
+		if p.state(e) == completed {
+			fmt.Printf("--- %s completed\n", e.ID())
+			cur = nextOpen(all, p, cur)
+		}
+	}
+	if cur == len(all) {
+		fmt.Println("\nNo exercises left from here on: trainer status shows the whole course.")
+	}
+	return 0
+}
+
+// nextOpen returns the index of the first exercise from i on that is not
+// completed, or len(all).
+func nextOpen(all []Exercise, p *Progress, i int) int {
+	for i < len(all) && p.state(all[i]) == completed {
+		i++
+	}
+	return i
+}
+
+// learnCheck checks e the strictest way it can be checked and returns
+// the report.
+func learnCheck(t *tui, e Exercise) string {
+	if _, reqs, err := gradeFiles(e.Dir); err == nil && len(reqs) > 0 {
+		return t.gradeOutput(e)
+	}
+	if _, err := os.Stat(filepath.Join(e.Dir, "testdata", goldenName)); err == nil {
+		return t.goldenOutput(e)
+	}
+	exe, errs := t.build(e)
+	updateProgress(func(p *Progress) { p.checked(e, exe != "") })
+	if exe == "" {
+		return errs
+	}
+	return fmt.Sprintf("PASS %s builds (it has no golden file or hidden tests)\n", e.ID())
+}
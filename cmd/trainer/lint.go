@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hannansatopay/training-golang/pkg/checks"
+	"golang.org/x/tools/go/analysis"
+)
+
+// lint runs the teaching checks of pkg/checks over the repository (or the
+// given packages). Findings are not failures of the exercises: several
+// show a mistake on purpose, and the point is to recognize it. Each check
+// has a flag to turn it off, e.g. -handlerprint=false.
+func lint(root string, args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	enabled := map[*analysis.Analyzer]*bool{}
+	for _, a := range checks.Analyzers {
+		enabled[a] = fs.Bool(a.Name, true, firstLine(a.Doc))
+	}
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: trainer lint [-check=false ...] [packages]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var run []*analysis.Analyzer
+	for _, a := range checks.Analyzers {
+		if *enabled[a] {
+			run = append(run, a)
+		}
+	}
+	pkgs := fs.Args()
+	if len(pkgs) == 0 {
+		pkgs = []string{"./..."}
+	}
+	findings, err := checks.Run(root, pkgs, run)
+	if err != nil {
+		fatal(err)
+	}
+	for _, f := range findings {
+		if rel, err := filepath.Rel(root, f.Pos.Filename); err == nil {
+			f.Pos.Filename = rel
+		}
+		fmt.Println(f)
+	}
+	fmt.Printf("%d findings\n", len(findings))
+	if len(findings) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
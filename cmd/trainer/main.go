@@ -0,0 +1,270 @@
+// Command trainer lists and runs the exercises of every session without
+// having to cd into each folder:
+//
+//	trainer list [session]            numbered list with descriptions
+//	trainer run [flags] <exercise> [args...]
+//	trainer golden [-update] [exercise...]   compare output with golden files
+//	trainer status [session]          what is attempted and completed
+//	trainer watch [-golden] <exercise> [args...]   rerun on every save
+//	trainer new -session N -name name  start the next exercise of a session
+//	trainer bench [flags] [dir...]    run all benchmarks, compare with the last run
+//	trainer grade [exercise...]       check solutions against hidden tests
+//	trainer tui                       browse, run and check exercises in one screen
+//	trainer serve [-addr a]           the same in a browser
+//	trainer hint <exercise>           show the next hint of an exercise
+//	trainer student -o dir            copy the repository without solutions
+//	trainer fuzz [flags] [dir...]     run every fuzz target for its time budget
+//	trainer cover [-html file]        test coverage per exercise and session
+//	trainer lint [packages]           look for the mistakes the web exercises teach
+//	trainer next [-topic t] [exercise]   what to do next, following prerequisites
+//	trainer workbook [-o file] [session...]   all exercises in one Markdown or HTML file
+//	trainer check [-w] [exercise...]  gofmt and go vet, as before grading
+//	trainer record <exercise> [args...]   run and save input and output with timing
+//	trainer replay [-speed x] <exercise|file>   play a recorded run back
+//	trainer learn [exercise]          go through the course one exercise at a time
+//
+// An exercise is chosen by its number in the list (trainer run 12), by
+// session and name (trainer run 3/ex5) or by name alone when only one
+// session has it (trainer run ex27). Like go run, the program is built
+// into a temporary directory and then started from its own folder, so
+// -timeout stops the program itself and not just the go command. The
+// output is shown and can also be saved with -o.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/sandbox"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: trainer [-root dir] list [session]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] run [-timeout d] [-o file] [-q] <exercise> [args...]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] golden [-update] [-timeout d] [exercise...]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] status [session]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] watch [-golden] [-timeout d] [-debounce d] <exercise> [args...]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] new -session N -name name\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] bench [-bench re] [-benchtime d] [-count n] [-dir d] [dir...]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] grade [-timeout d] [-check=false] [exercise...]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] tui [-timeout d]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] serve [-addr a] [-timeout d]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] hint [-all] [-reset] <exercise>\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] student -o dir\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] fuzz [-fuzztime d] [-run re] [-seeds] [dir...]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] cover [-v] [-html file] [-coverprofile file]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] lint [-check=false ...] [packages]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] next [-n N] [-topic t] [-all] [exercise]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] workbook [-o file.md|file.html] [-solutions] [session...]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] check [-w] [-l] [exercise...]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] record [-o file] [-timeout d] <exercise> [args...]\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] replay [-speed x] [-maxwait d] <exercise|file.json>\n")
+	fmt.Fprintf(os.Stderr, "       trainer [-root dir] learn [-timeout d] [exercise]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	root := flag.String("root", "", "repository root (default: found from the working directory)")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+	}
+	if *root == "" {
+		var err error
+		if *root, err = findRoot(); err != nil {
+			fatal(err)
+		}
+	}
+	all, err := discover(*root)
+	if err != nil {
+		fatal(err)
+	}
+
+	switch flag.Arg(0) {
+	case "list":
+		list(all, sessionArg())
+	case "status":
+		status(all, sessionArg())
+	case "run":
+		os.Exit(run(all, flag.Args()[1:]))
+	case "golden":
+		os.Exit(golden(all, flag.Args()[1:]))
+	case "watch":
+		os.Exit(watchExercise(all, flag.Args()[1:]))
+	case "new":
+		os.Exit(newExercise(*root, all, flag.Args()[1:]))
+	case "bench":
+		os.Exit(bench(*root, flag.Args()[1:]))
+	case "grade":
+		os.Exit(grade(all, flag.Args()[1:]))
+	case "tui":
+		os.Exit(tuiMode(all, flag.Args()[1:]))
+	case "serve":
+		os.Exit(serve(all, flag.Args()[1:]))
+	case "hint":
+		os.Exit(hint(all, flag.Args()[1:]))
+	case "student":
+		os.Exit(student(*root, all, flag.Args()[1:]))
+	case "fuzz":
+		os.Exit(fuzz(*root, flag.Args()[1:]))
+	case "cover":
+		os.Exit(cover(*root, all, flag.Args()[1:]))
+	case "lint":
+		os.Exit(lint(*root, flag.Args()[1:]))
+	case "next":
+		os.Exit(next(all, flag.Args()[1:]))
+	case "workbook":
+		os.Exit(workbook(all, flag.Args()[1:]))
+	case "check":
+		os.Exit(check(all, flag.Args()[1:]))
+	case "record":
+		os.Exit(record(all, flag.Args()[1:]))
+	case "replay":
+		os.Exit(replay(all, flag.Args()[1:]))
+	case "learn":
+		os.Exit(learn(all, flag.Args()[1:]))
+	default:
+		usage()
+	}
+}
+
+// sessionArg returns the optional session number after the command.
+func sessionArg() int {
+	if flag.NArg() < 2 {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(flag.Arg(1), "session"))
+	if err != nil {
+		usage()
+	}
+	return n
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "trainer:", err)
+	os.Exit(1)
+}
+
+// findRoot walks up from the working directory to the one holding cmd/trainer.
+func findRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, "cmd", "trainer")); err == nil && fi.IsDir() {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("not inside the training repository, use -root")
+		}
+		dir = parent
+	}
+}
+
+func list(all []Exercise, session int) {
+	p, err := loadProgress()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "trainer:", err)
+		p = &Progress{}
+	}
+	last := 0
+	for i, e := range all {
+		if session != 0 && e.Session != session {
+			continue
+		}
+		if e.Session != last {
+			fmt.Printf("\nSession %d: %s\n", e.Session, sessions[e.Session-1])
+			last = e.Session
+		}
+		fmt.Printf("%4d %s %-14s %s\n", i+1, mark(p.state(e)), e.ID(), e.Doc)
+	}
+}
+
+func run(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 0, "stop the program after this long (0: no limit)")
+	out := fs.String("o", "", "also write the program's output to this file")
+	quiet := fs.Bool("q", false, "do not echo the output, only save it (with -o)")
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		usage()
+	}
+	e, err := find(all, fs.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+
+	tmp, err := os.MkdirTemp("", "trainer")
+	if err != nil {
+		fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	exe, err := buildExercise(e, tmp)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "--- %s  does not build\n", e.ID())
+		return 1
+	}
+
+	var captured bytes.Buffer
+	var w io.Writer = &captured
+	if !*quiet {
+		w = io.MultiWriter(os.Stdout, &captured)
+	}
+	cmd := &sandbox.Cmd{
+		Path: exe, Args: fs.Args()[1:], Dir: e.Dir,
+		Stdin: os.Stdin, Stdout: w, Stderr: w,
+		Timeout: *timeout, KeepEnv: true, SameGroup: true,
+	}
+
+	updateProgress(func(p *Progress) { p.ran(e) })
+	fmt.Fprintf(os.Stderr, "=== %s  %s\n", e.ID(), e.Doc)
+	r, err := cmd.Run(context.Background())
+	took := r.Duration.Round(time.Millisecond)
+
+	code := 0
+	status := "ok"
+	if r.TimedOut {
+		code, status = 1, "stopped after "+timeout.String()
+	} else if err != nil {
+		code, status = 1, err.Error()
+		if r.ExitCode > 0 {
+			code = r.ExitCode
+		}
+	}
+	fmt.Fprintf(os.Stderr, "--- %s  %s in %v, %d bytes of output\n", e.ID(), status, took, captured.Len())
+	if *out != "" {
+		if err := os.WriteFile(*out, captured.Bytes(), 0644); err != nil {
+			fatal(err)
+		}
+	}
+	return code
+}
+
+// buildExercise compiles e into dir and returns the path of the binary.
+// Compiler errors are written to stderr.
+func buildExercise(e Exercise, dir string) (string, error) {
+	return buildExerciseTo(e, dir, os.Stderr)
+}
+
+// buildExerciseTo is buildExercise writing compiler errors to w.
+func buildExerciseTo(e Exercise, dir string, w io.Writer) (string, error) {
+	exe := filepath.Join(dir, strings.ReplaceAll(e.ID(), "/", "_"))
+	build := exec.Command("go", "build", "-o", exe, ".")
+	build.Dir = e.Dir
+	build.Stdout, build.Stderr = w, w
+	return exe, build.Run()
+}
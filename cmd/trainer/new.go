@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// skeleton is the main.go of a new exercise. Its first comment is the
+// description trainer list shows.
+const skeleton = `// %[1]s: TODO one line about what this exercise shows
+package main
+import (
+	"fmt"
+)
+
+func main() {
+	fmt.Println(%[1]q)
+}
+`
+
+// newExercise creates the next exercise of a session, with a main.go
+// skeleton and a golden file for its output, so it is listed, run and
+// checked like every other one right away.
+func newExercise(root string, all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("new", flag.ExitOnError)
+	session := fs.Int("session", 0, "session number")
+	name := fs.String("name", "", "what the exercise is about, e.g. channels_intro")
+	fs.Parse(args)
+	if *session < 1 || *session > len(sessions) || *name == "" {
+		fmt.Fprintf(os.Stderr, "usage: trainer new -session 1-%d -name name\n", len(sessions))
+		return 2
+	}
+
+	next := 1
+	for _, e := range all {
+		if n := number(e.Name); e.Session == *session && n >= next {
+			next = n + 1
+		}
+	}
+	e := Exercise{
+		Session: *session,
+		Name:    fmt.Sprintf("ex%d", next),
+		Dir:     filepath.Join(root, fmt.Sprintf("session%d", *session), fmt.Sprintf("ex%d", next)),
+	}
+	if _, err := os.Stat(e.Dir); err == nil {
+		fatal(fmt.Errorf("%s already exists", e.Dir))
+	}
+	title := strings.NewReplacer("_", " ", "-", " ").Replace(*name)
+	if err := os.MkdirAll(filepath.Join(e.Dir, "testdata"), 0755); err != nil {
+		fatal(err)
+	}
+	files := map[string]string{
+		"main.go":                             fmt.Sprintf(skeleton, title),
+		filepath.Join("testdata", goldenName): title + "\n",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(e.Dir, file), []byte(content), 0644); err != nil {
+			fatal(err)
+		}
+	}
+	rel, _ := filepath.Rel(root, e.Dir)
+	fmt.Printf("created %s (%s)\n", e.ID(), rel)
+	fmt.Printf("  write the exercise in %s\n", filepath.Join(rel, "main.go"))
+	fmt.Printf("  then record its output: trainer golden -update %s\n", e.ID())
+	return 0
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// An exercise may place itself in the course with a manifest next to its
+// source, exercise.json:
+//
+//	{"requires": ["1/ex14"], "topics": ["interfaces"]}
+//
+// requires names the exercises to do first, by ID; topics are free-form
+// tags for trainer next -topic. Without a manifest an exercise has no
+// prerequisites beyond its place in the course. trainer next orders all
+// exercises so that each comes after its prerequisites (and otherwise in
+// course order) and recommends the first ones not completed yet.
+const manifestFile = "exercise.json"
+
+type manifest struct {
+	Requires []string `json:"requires"`
+	Topics   []string `json:"topics"`
+}
+
+// course is the exercises in guided order with their manifests.
+type course struct {
+	order    []Exercise
+	requires map[string][]Exercise // by exercise ID
+	topics   map[string][]string
+}
+
+// readManifest reads the manifest of e; a missing one is an empty one.
+func readManifest(e Exercise) (manifest, error) {
+	var m manifest
+	path := filepath.Join(e.Dir, manifestFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return m, fmt.Errorf("%s: %v", path, err)
+	}
+	return m, nil
+}
+
+// loadCourse reads every manifest and sorts the exercises topologically.
+// Of the exercises whose prerequisites are all placed, the one first in
+// course order goes next, so the order only departs from trainer list
+// where a manifest asks for it.
+func loadCourse(all []Exercise) (*course, error) {
+	c := &course{requires: map[string][]Exercise{}, topics: map[string][]string{}}
+	for _, e := range all {
+		m, err := readManifest(e)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range m.Requires {
+			req, err := find(all, id)
+			if err != nil {
+				return nil, fmt.Errorf("%s: requires %s: %v", e.ID(), id, err)
+			}
+			c.requires[e.ID()] = append(c.requires[e.ID()], req)
+		}
+		c.topics[e.ID()] = m.Topics
+	}
+
+	placed := map[string]bool{}
+	for len(c.order) < len(all) {
+		progress := false
+		for _, e := range all {
+			if placed[e.ID()] || !c.ready(e, placed) {
+				continue
+			}
+			placed[e.ID()] = true
+			c.order = append(c.order, e)
+			progress = true
+			break
+		}
+		if !progress {
+			var cycle []string
+			for _, e := range all {
+				if !placed[e.ID()] {
+					cycle = append(cycle, e.ID())
+				}
+			}
+			return nil, fmt.Errorf("prerequisites form a cycle among %s", strings.Join(cycle, ", "))
+		}
+	}
+	return c, nil
+}
+
+// ready reports whether every prerequisite of e is in done.
+func (c *course) ready(e Exercise, done map[string]bool) bool {
+	for _, req := range c.requires[e.ID()] {
+		if !done[req.ID()] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasTopic reports whether e is tagged with topic, or topic is empty.
+func (c *course) hasTopic(e Exercise, topic string) bool {
+	if topic == "" {
+		return true
+	}
+	for _, t := range c.topics[e.ID()] {
+		if strings.EqualFold(t, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// path returns target and the prerequisites it depends on, directly or
+// not, in guided order.
+func (c *course) path(target Exercise) []Exercise {
+	need := map[string]bool{target.ID(): true}
+	for i := len(c.order) - 1; i >= 0; i-- { // dependents come after their prerequisites
+		if e := c.order[i]; need[e.ID()] {
+			for _, req := range c.requires[e.ID()] {
+				need[req.ID()] = true
+			}
+		}
+	}
+	var path []Exercise
+	for _, e := range c.order {
+		if need[e.ID()] {
+			path = append(path, e)
+		}
+	}
+	return path
+}
+
+func next(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("next", flag.ExitOnError)
+	n := fs.Int("n", 3, "how many exercises to recommend")
+	topic := fs.String("topic", "", "only recommend exercises on this topic")
+	showAll := fs.Bool("all", false, "print the whole guided order")
+	fs.Parse(args)
+
+	c, err := loadCourse(all)
+	if err != nil {
+		fatal(err)
+	}
+	p, err := loadProgress()
+	if err != nil {
+		fatal(err)
+	}
+	done := map[string]bool{}
+	for _, e := range all {
+		done[e.ID()] = p.state(e) == completed
+	}
+
+	switch {
+	case fs.NArg() > 0:
+		target, err := find(all, fs.Arg(0))
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("The way to %s:\n", target.ID())
+		for _, e := range c.path(target) {
+			c.printEntry(e, p, done)
+		}
+	case *showAll:
+		for _, e := range c.order {
+			if c.hasTopic(e, *topic) {
+				c.printEntry(e, p, done)
+			}
+		}
+	default:
+		var recommend []Exercise
+		for _, e := range c.order {
+			if len(recommend) == *n {
+				break
+			}
+			if !done[e.ID()] && c.ready(e, done) && c.hasTopic(e, *topic) {
+				recommend = append(recommend, e)
+			}
+		}
+		if len(recommend) == 0 && *topic != "" {
+			fmt.Printf("No exercise on %q is ready to do.\n", *topic)
+			return 0
+		}
+		if len(recommend) == 0 {
+			fmt.Println("Nothing left to do, every exercise is completed.")
+			return 0
+		}
+		fmt.Println("Next up:")
+		for _, e := range recommend {
+			c.printEntry(e, p, done)
+		}
+	}
+	return 0
+}
+
+// printEntry prints one line of trainer next: the exercise with its
+// state and topics, and the prerequisites still to complete.
+func (c *course) printEntry(e Exercise, p *Progress, done map[string]bool) {
+	line := fmt.Sprintf("%s %-14s %s", mark(p.state(e)), e.ID(), e.Doc)
+	if t := c.topics[e.ID()]; len(t) > 0 {
+		line += " [" + strings.Join(t, ", ") + "]"
+	}
+	var missing []string
+	for _, req := range c.requires[e.ID()] {
+		if !done[req.ID()] {
+			missing = append(missing, req.ID())
+		}
+	}
+	if len(missing) > 0 {
+		line += " (needs " + strings.Join(missing, ", ") + ")"
+	}
+	fmt.Println(line)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The playground of trainer serve runs exercises in the browser: the
+// page has the source in text areas, and Run compiles the edited files
+// to WebAssembly on the server (GOOS=js GOARCH=wasm go build, with the
+// edits passed as an overlay so the exercise on disk is not touched)
+// and starts the module with the Go runtime's wasm_exec.js shim. What
+// the program prints goes to the page instead of the browser console.
+//
+// Only exercises that need nothing the browser lacks can be played:
+// their files may import the packages in playImports, mysort and the
+// exercise's own packages, but no files, network or processes.
+var playImports = map[string]bool{
+	"bytes": true, "container/heap": true, "container/list": true, "container/ring": true,
+	"errors": true, "fmt": true, "math": true, "math/big": true, "math/cmplx": true,
+	"math/rand": true, "reflect": true, "sort": true, "strconv": true, "strings": true,
+	"sync": true, "time": true, "unicode": true, "unicode/utf8": true, "unsafe": true,
+	"github.com/hannansatopay/training-golang/pkg/mysort": true,
+}
+
+// playable reports whether e can run in the browser.
+func playable(e Exercise) bool {
+	files := learnerFiles(e.Dir)
+	if len(files) == 0 {
+		return false
+	}
+	own := fmt.Sprintf("/session%d/%s/", e.Session, strings.SplitN(e.Name, "/", 2)[0])
+	for _, path := range files {
+		f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+		if err != nil {
+			return false
+		}
+		for _, imp := range f.Imports {
+			p, _ := strconv.Unquote(imp.Path.Value)
+			if !playImports[p] && !strings.Contains(p+"/", own) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type playPage struct {
+	N     int
+	ID    string
+	Doc   string
+	Files []playFile
+}
+
+type playFile struct {
+	Name   string
+	Source string
+}
+
+func (s *server) playHandler(w http.ResponseWriter, req *http.Request) {
+	n, e, ok := s.exercise(req)
+	if !ok || !playable(e) {
+		http.NotFound(w, req)
+		return
+	}
+	page := playPage{N: n, ID: e.ID(), Doc: e.Doc}
+	for _, path := range learnerFiles(e.Dir) {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		page.Files = append(page.Files, playFile{Name: filepath.Base(path), Source: string(src)})
+	}
+	render(w, "play", page)
+}
+
+// buildWasmHandler compiles the posted files (form fields named after
+// the files of the exercise) and answers with the WebAssembly module,
+// or with the compiler errors as text.
+func (s *server) buildWasmHandler(w http.ResponseWriter, req *http.Request) {
+	_, e, ok := s.exercise(req)
+	if !ok || !playable(e) {
+		http.NotFound(w, req)
+		return
+	}
+	req.Body = http.MaxBytesReader(w, req.Body, 1<<20)
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tmp, err := os.MkdirTemp("", "trainer")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	src := filepath.Join(tmp, "src") // edited files keep their names for the compiler errors
+	if err := os.Mkdir(src, 0755); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	replace := map[string]string{}
+	for _, path := range learnerFiles(e.Dir) {
+		text, ok := req.MultipartForm.Value[filepath.Base(path)]
+		if !ok {
+			continue
+		}
+		edited := filepath.Join(src, filepath.Base(path))
+		if err := os.WriteFile(edited, []byte(text[0]), 0644); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		replace[path] = edited
+	}
+	data, _ := json.Marshal(map[string]interface{}{"Replace": replace})
+	overlay := filepath.Join(tmp, "overlay.json")
+	if err := os.WriteFile(overlay, data, 0644); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	wasm := filepath.Join(tmp, "main.wasm")
+	var errs bytes.Buffer
+	cmd := exec.CommandContext(req.Context(), "go", "build", "-overlay", overlay, "-o", wasm, ".")
+	cmd.Dir = e.Dir
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	cmd.Stdout, cmd.Stderr = &errs, &errs
+	if err := cmd.Run(); err != nil {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write(bytes.ReplaceAll(errs.Bytes(), []byte(src+string(filepath.Separator)), nil))
+		return
+	}
+	serveLog.Info("play", "exercise", e.ID(), "remote", req.RemoteAddr)
+	updateProgress(func(p *Progress) { p.ran(e) })
+	w.Header().Set("Content-Type", "application/wasm")
+	http.ServeFile(w, req, wasm)
+}
+
+// wasmExecHandler serves the JavaScript support file of the Go toolchain
+// that compiled the modules.
+func wasmExecHandler(w http.ResponseWriter, req *http.Request) {
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	goroot := strings.TrimSpace(string(out))
+	for _, dir := range []string{"lib/wasm", "misc/wasm"} { // moved in Go 1.24
+		path := filepath.Join(goroot, dir, "wasm_exec.js")
+		if _, err := os.Stat(path); err == nil {
+			w.Header().Set("Content-Type", "text/javascript")
+			http.ServeFile(w, req, path)
+			return
+		}
+	}
+	http.NotFound(w, req)
+}
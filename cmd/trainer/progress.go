@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+)
+
+// Progress remembers which exercises a learner has worked on. It is kept
+// in ~/.training-golang/progress.json, or in the file named by the
+// TRAINING_PROGRESS environment variable.
+//
+// Running an exercise marks it attempted; it becomes completed when its
+// check passes: trainer golden finds the output unchanged, or, for an
+// exercise without a golden file, the program builds. A failing check
+// moves a completed exercise back to attempted.
+type Progress struct {
+	Exercises map[string]*Record `json:"exercises"` // by exercise ID, "3/ex5"
+
+	path string
+}
+
+// Record is the progress of one exercise.
+type Record struct {
+	State     string     `json:"state"` // "attempted" or "completed"
+	Runs      int        `json:"runs"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	Completed *time.Time `json:"completed,omitempty"`
+	Hints     int        `json:"hints,omitempty"` // how many trainer hint has shown
+}
+
+const (
+	attempted = "attempted"
+	completed = "completed"
+)
+
+func progressPath() (string, error) {
+	if p := os.Getenv("TRAINING_PROGRESS"); p != "" {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".training-golang", "progress.json"), nil
+}
+
+// loadProgress reads the progress file; a missing file is no progress yet.
+func loadProgress() (*Progress, error) {
+	path, err := progressPath()
+	if err != nil {
+		return nil, err
+	}
+	p := &Progress{Exercises: map[string]*Record{}, path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if p.Exercises == nil {
+		p.Exercises = map[string]*Record{}
+	}
+	return p, nil
+}
+
+func (p *Progress) save() error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return err
+	}
+	return safewrite.WriteFile(p.path, append(data, '\n'), 0644)
+}
+
+func (p *Progress) record(e Exercise) *Record {
+	r := p.Exercises[e.ID()]
+	if r == nil {
+		r = &Record{State: attempted}
+		p.Exercises[e.ID()] = r
+	}
+	return r
+}
+
+// ran notes that e was run.
+func (p *Progress) ran(e Exercise) {
+	r := p.record(e)
+	r.Runs++
+	now := time.Now()
+	r.LastRun = &now
+}
+
+// checked notes the outcome of e's check.
+func (p *Progress) checked(e Exercise, passed bool) {
+	r := p.record(e)
+	switch {
+	case passed && r.State != completed:
+		now := time.Now()
+		r.State, r.Completed = completed, &now
+	case !passed:
+		r.State, r.Completed = attempted, nil
+	}
+}
+
+// state returns "completed", "attempted" or "" for an exercise.
+func (p *Progress) state(e Exercise) string {
+	if r := p.Exercises[e.ID()]; r != nil {
+		return r.State
+	}
+	return ""
+}
+
+// mark is the one-character view of a state used in lists.
+func mark(state string) string {
+	switch state {
+	case completed:
+		return "[x]"
+	case attempted:
+		return "[~]"
+	}
+	return "[ ]"
+}
+
+// updateProgress loads the progress, applies fn and saves it again.
+// Progress is a convenience: failing to store it only prints a warning.
+func updateProgress(fn func(*Progress)) {
+	p, err := loadProgress()
+	if err == nil {
+		fn(p)
+		err = p.save()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "trainer: progress not saved:", err)
+	}
+}
+
+// status prints per-session completion, or the exercises of one session.
+func status(all []Exercise, session int) {
+	p, err := loadProgress()
+	if err != nil {
+		fatal(err)
+	}
+	if session != 0 {
+		for _, e := range all {
+			if e.Session == session {
+				fmt.Printf("%s %-14s %s\n", mark(p.state(e)), e.ID(), e.Doc)
+			}
+		}
+		return
+	}
+	var total, totalDone int
+	for i, title := range sessions {
+		var n, done, tried int
+		for _, e := range all {
+			if e.Session != i+1 {
+				continue
+			}
+			n++
+			switch p.state(e) {
+			case completed:
+				done++
+			case attempted:
+				tried++
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		total += n
+		totalDone += done
+		fmt.Printf("Session %d %-45s %s %3d%%  %d/%d completed, %d attempted\n",
+			i+1, title, bar(done, n), percent(done, n), done, n, tried)
+	}
+	fmt.Printf("Total %d/%d completed (%d%%), progress in %s\n", totalDone, total, percent(totalDone, total), p.path)
+}
+
+func percent(done, n int) int {
+	if n == 0 {
+		return 0
+	}
+	return done * 100 / n
+}
+
+// bar draws done out of n as a 20 character bar.
+func bar(done, n int) string {
+	const width = 20
+	filled := 0
+	if n > 0 {
+		filled = done * width / n
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+	"github.com/hannansatopay/training-golang/pkg/sandbox"
+)
+
+// trainer record runs an exercise like trainer run and keeps everything
+// that went in and out of it, with the time it happened, in a transcript:
+//
+//	{"exercise": "3/ex14", "events": [{"t": 0.52, "stream": "out", "data": "..."}, ...]}
+//
+// By default the transcript is saved as testdata/transcript.json of the
+// exercise, where trainer replay finds it, so that an interactive run
+// (a chat client, a prompt) can be shipped with the exercise and shown
+// without typing it again. trainer replay plays a transcript back at the
+// speed it was recorded, or faster.
+const transcriptName = "transcript.json"
+
+type transcript struct {
+	Exercise string    `json:"exercise"`
+	Args     []string  `json:"args,omitempty"`
+	Recorded time.Time `json:"recorded"`
+	Events   []event   `json:"events"`
+	ExitCode int       `json:"exit_code"`
+}
+
+// event is one chunk of input or output.
+type event struct {
+	Time   float64 `json:"t"`      // seconds since the start
+	Stream string  `json:"stream"` // "in", "out" or "err"
+	Data   string  `json:"data"`
+}
+
+// recorder collects the events of a run.
+type recorder struct {
+	mu     sync.Mutex
+	start  time.Time
+	events []event
+}
+
+func (r *recorder) add(stream string, p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := time.Since(r.start).Round(time.Millisecond).Seconds()
+	if n := len(r.events); n > 0 && r.events[n-1].Stream == stream && t-r.events[n-1].Time <= 0.01 {
+		r.events[n-1].Data += string(p) // one write split by a buffer
+		return
+	}
+	r.events = append(r.events, event{Time: t, Stream: stream, Data: string(p)})
+}
+
+// recordWriter passes output on to w and records it.
+type recordWriter struct {
+	w      io.Writer
+	rec    *recorder
+	stream string
+}
+
+func (rw recordWriter) Write(p []byte) (int, error) {
+	rw.rec.add(rw.stream, p)
+	return rw.w.Write(p)
+}
+
+func record(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	out := fs.String("o", "", "write the transcript to this file (default: testdata/"+transcriptName+" of the exercise)")
+	timeout := fs.Duration("timeout", 0, "stop the program after this long (0: no limit)")
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		usage()
+	}
+	e, err := find(all, fs.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	if *out == "" {
+		*out = filepath.Join(e.Dir, "testdata", transcriptName)
+	}
+
+	tmp, err := os.MkdirTemp("", "trainer")
+	if err != nil {
+		fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	exe, err := buildExercise(e, tmp)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "--- %s  does not build\n", e.ID())
+		return 1
+	}
+
+	// The program reads from a pipe that is fed from our stdin, so that
+	// the input can be recorded. The copy is left blocked on the terminal
+	// when the program ends; the trainer exits right after.
+	rec := &recorder{}
+	stdin, feed, err := os.Pipe()
+	if err != nil {
+		fatal(err)
+	}
+	cmd := &sandbox.Cmd{
+		Path: exe, Args: fs.Args()[1:], Dir: e.Dir,
+		Stdin:   stdin,
+		Stdout:  recordWriter{os.Stdout, rec, "out"},
+		Stderr:  recordWriter{os.Stderr, rec, "err"},
+		Timeout: *timeout, KeepEnv: true, SameGroup: true,
+	}
+	// Ctrl-C is for the program: the trainer stays to save the transcript.
+	signal.Notify(make(chan os.Signal, 1), os.Interrupt)
+
+	updateProgress(func(p *Progress) { p.ran(e) })
+	fmt.Fprintf(os.Stderr, "=== %s  %s (recording)\n", e.ID(), e.Doc)
+	rec.start = time.Now()
+	go func() {
+		io.Copy(recordWriter{feed, rec, "in"}, os.Stdin)
+		feed.Close()
+	}()
+	r, err := cmd.Run(context.Background())
+	stdin.Close()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) && !errors.Is(err, sandbox.ErrStopped) {
+		fatal(err)
+	}
+
+	rec.mu.Lock()
+	t := transcript{Exercise: e.ID(), Args: fs.Args()[1:], Recorded: rec.start.UTC().Truncate(time.Second), Events: rec.events, ExitCode: r.ExitCode}
+	rec.mu.Unlock()
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(*out), 0755); err != nil {
+		fatal(err)
+	}
+	if err := safewrite.WriteFile(*out, append(data, '\n'), 0644); err != nil {
+		fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "--- %s  exit status %d after %v, %d events saved in %s\n",
+		e.ID(), r.ExitCode, r.Duration.Round(time.Millisecond), len(t.Events), *out)
+	return 0
+}
+
+func replay(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := fs.Float64("speed", 1, "play this many times faster than recorded")
+	maxWait := fs.Duration("maxwait", 2*time.Second, "never pause longer than this between events (0: no limit)")
+	fs.Parse(args)
+	if fs.NArg() != 1 || *speed <= 0 {
+		usage()
+	}
+	path := fs.Arg(0)
+	if !strings.HasSuffix(path, ".json") {
+		e, err := find(all, path)
+		if err != nil {
+			fatal(err)
+		}
+		path = filepath.Join(e.Dir, "testdata", transcriptName)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fatal(err)
+	}
+	var t transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		fatal(fmt.Errorf("%s: %v", path, err))
+	}
+
+	fmt.Fprintf(os.Stderr, "=== %s  replay of %s\n", t.Exercise, t.Recorded.Local().Format("2006-01-02 15:04"))
+	last := 0.0
+	for _, ev := range t.Events {
+		wait := time.Duration((ev.Time - last) / *speed * float64(time.Second))
+		if *maxWait > 0 && wait > *maxWait {
+			wait = *maxWait
+		}
+		time.Sleep(wait)
+		last = ev.Time
+		switch ev.Stream {
+		case "err":
+			io.WriteString(os.Stderr, ev.Data)
+		default: // what was typed is echoed, as the terminal did
+			io.WriteString(os.Stdout, ev.Data)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "--- %s  exit status %d\n", t.Exercise, t.ExitCode)
+	return 0
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/sandbox"
+)
+
+// trainer serve shows the exercises in a browser, built like the web
+// applications of session 4: net/http handlers, html/template pages and
+// a logger. An exercise page has its source with syntax highlighting and
+// a Run button. The program then runs on the server, in its own folder
+// and without input, and its output is streamed back as it is printed
+// with server-sent events (GET /run/N, read by an EventSource). A run is
+// stopped after -timeout, after too much output or when the page is
+// closed, and it does not see the environment of the server. Exercises
+// that can run in the browser also have a playground, see play.go.
+//
+// Anyone who can reach the server can run the exercises, so it listens
+// on localhost only unless -addr says otherwise.
+
+var serveLog = logger.New("trainer")
+
+type server struct {
+	all     []Exercise
+	timeout time.Duration
+}
+
+func serve(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	timeout := fs.Duration("timeout", 15*time.Second, "stop a program after this long")
+	fs.Parse(args)
+
+	s := &server{all: all, timeout: *timeout}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", logPanics(s.indexHandler))
+	mux.HandleFunc("GET /ex/{n}", logPanics(s.exerciseHandler))
+	mux.HandleFunc("GET /run/{n}", logPanics(s.runHandler))
+	mux.HandleFunc("GET /play/{n}", logPanics(s.playHandler))
+	mux.HandleFunc("POST /play/{n}/build", logPanics(s.buildWasmHandler))
+	mux.HandleFunc("GET /wasm_exec.js", logPanics(wasmExecHandler))
+	serveLog.Info("serving the exercises", "url", "http://"+*addr+"/")
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		fatal(err)
+	}
+	return 0
+}
+
+// logPanics keeps a panicking handler from killing the server.
+func logPanics(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			if x := recover(); x != nil {
+				serveLog.Error("caught panic", "path", req.URL.Path, "panic", x)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+			}
+		}()
+		h(w, req)
+	}
+}
+
+// exercise returns the exercise numbered by the {n} of the path.
+func (s *server) exercise(req *http.Request) (int, Exercise, bool) {
+	n, err := strconv.Atoi(req.PathValue("n"))
+	if err != nil || n < 1 || n > len(s.all) {
+		return 0, Exercise{}, false
+	}
+	return n, s.all[n-1], true
+}
+
+type indexSession struct {
+	Number    int
+	Title     string
+	Exercises []indexExercise
+}
+
+type indexExercise struct {
+	N     int
+	Mark  string
+	ID    string
+	Doc   string
+	State string
+	Play  bool
+}
+
+func (s *server) indexHandler(w http.ResponseWriter, req *http.Request) {
+	p, err := loadProgress()
+	if err != nil {
+		p = &Progress{}
+	}
+	var list []indexSession
+	for i, e := range s.all {
+		if len(list) == 0 || list[len(list)-1].Number != e.Session {
+			list = append(list, indexSession{Number: e.Session, Title: sessions[e.Session-1]})
+		}
+		cur := &list[len(list)-1]
+		state := p.state(e)
+		cur.Exercises = append(cur.Exercises, indexExercise{N: i + 1, Mark: mark(state), ID: e.ID(), Doc: e.Doc, State: state, Play: playable(e)})
+	}
+	render(w, "index", list)
+}
+
+type exercisePage struct {
+	N     int
+	ID    string
+	Doc   string
+	Play  bool
+	Files []sourceFile
+}
+
+type sourceFile struct {
+	Name string
+	Code template.HTML
+}
+
+func (s *server) exerciseHandler(w http.ResponseWriter, req *http.Request) {
+	n, e, ok := s.exercise(req)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	page := exercisePage{N: n, ID: e.ID(), Doc: e.Doc, Play: playable(e)}
+	for _, path := range learnerFiles(e.Dir) {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		page.Files = append(page.Files, sourceFile{Name: filepath.Base(path), Code: highlight(src)})
+	}
+	render(w, "exercise", page)
+}
+
+// runHandler builds and runs an exercise, streaming its output as "out"
+// events with a JSON string each, and ends with a "done" event.
+func (s *server) runHandler(w http.ResponseWriter, req *http.Request) {
+	_, e, ok := s.exercise(req)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	out := &sseWriter{w: w, flush: flusher.Flush}
+
+	tmp, err := os.MkdirTemp("", "trainer")
+	if err != nil {
+		out.event("done", err.Error())
+		return
+	}
+	defer os.RemoveAll(tmp)
+	var errs bytes.Buffer
+	exe, err := buildExerciseTo(e, tmp, &errs)
+	if err != nil {
+		out.Write(errs.Bytes())
+		out.event("done", "does not build")
+		return
+	}
+
+	serveLog.Info("run", "exercise", e.ID(), "remote", req.RemoteAddr)
+	updateProgress(func(p *Progress) { p.ran(e) })
+	cmd := &sandbox.Cmd{Path: exe, Dir: e.Dir, Stdout: out, Stderr: out, Timeout: s.timeout, MaxOutput: maxOutput}
+	r, err := cmd.Run(req.Context()) // also ends when the page goes away
+	took := r.Duration.Round(time.Millisecond)
+	switch {
+	case r.TimedOut:
+		out.event("done", "stopped after "+s.timeout.String())
+	case r.Truncated:
+		out.event("done", fmt.Sprintf("stopped after %d bytes of output", maxOutput))
+	case err != nil:
+		out.event("done", fmt.Sprintf("%v after %v", err, took))
+	default:
+		out.event("done", "exited after "+took.String())
+	}
+}
+
+// sseWriter sends everything written to it as server-sent events.
+type sseWriter struct {
+	w     http.ResponseWriter
+	flush func()
+}
+
+func (s *sseWriter) Write(p []byte) (int, error) {
+	if err := s.event("out", string(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *sseWriter) event(name, data string) error {
+	js, _ := json.Marshal(data)
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", name, js); err != nil {
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+// highlight returns Go source as HTML with its tokens in spans of class
+// kw (keywords), str, num and com (comments). Text between tokens is
+// copied as it is, so the layout stays the same.
+func highlight(src []byte) template.HTML {
+	var b strings.Builder
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var sc scanner.Scanner
+	sc.Init(file, src, nil, scanner.ScanComments)
+	done := 0
+	for {
+		pos, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue // inserted by the scanner, not in the source
+		}
+		off := file.Offset(pos)
+		if off < done {
+			continue
+		}
+		n := len(lit)
+		if lit == "" {
+			n = len(tok.String())
+		}
+		if off+n > len(src) {
+			n = len(src) - off
+		}
+		class := ""
+		switch {
+		case tok.IsKeyword():
+			class = "kw"
+		case tok == token.STRING || tok == token.CHAR:
+			class = "str"
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = "num"
+		case tok == token.COMMENT:
+			class = "com"
+		}
+		b.WriteString(template.HTMLEscapeString(string(src[done:off])))
+		text := template.HTMLEscapeString(string(src[off : off+n]))
+		if class != "" {
+			text = `<span class="` + class + `">` + text + `</span>`
+		}
+		b.WriteString(text)
+		done = off + n
+	}
+	b.WriteString(template.HTMLEscapeString(string(src[done:])))
+	return template.HTML(b.String())
+}
+
+var pages = template.Must(template.New("").Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.}} - training-golang</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; }
+a { color: #0b5394; text-decoration: none; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+.kw { color: #a626a4; font-weight: bold; } .str { color: #50a14f; }
+.num { color: #986801; } .com { color: #888; font-style: italic; }
+.completed { color: #2e7d32; } .attempted { color: #b26a00; }
+#output { background: #222; color: #eee; min-height: 3em; }
+</style></head><body>{{end}}
+
+{{define "index"}}{{template "head" "Exercises"}}
+<h1>Exercises</h1>
+{{range .}}<h2>Session {{.Number}}: {{.Title}}</h2>
+<table>{{range .Exercises}}
+<tr><td class="{{.State}}"><code>{{.Mark}}</code></td><td><a href="/ex/{{.N}}">{{.ID}}</a></td><td>{{.Doc}}</td><td>{{if .Play}}<a href="/play/{{.N}}">play</a>{{end}}</td></tr>{{end}}
+</table>{{end}}
+</body></html>{{end}}
+
+{{define "exercise"}}{{template "head" .ID}}
+<p><a href="/">all exercises</a></p>
+<h1>{{.ID}}</h1>
+<p>{{.Doc}}</p>
+<p><button id="run">Run</button> <span id="status"></span>
+{{if .Play}}<a href="/play/{{.N}}">edit and run in the browser</a>{{end}}</p>
+<pre id="output"></pre>
+{{range .Files}}<h3>{{.Name}}</h3>
+<pre>{{.Code}}</pre>{{end}}
+<script>
+const button = document.getElementById("run");
+const output = document.getElementById("output");
+const status = document.getElementById("status");
+button.onclick = () => {
+	button.disabled = true;
+	output.textContent = "";
+	status.textContent = "running...";
+	const events = new EventSource("/run/{{.N}}");
+	events.addEventListener("out", e => { output.textContent += JSON.parse(e.data); });
+	events.addEventListener("done", e => {
+		status.textContent = JSON.parse(e.data);
+		events.close();
+		button.disabled = false;
+	});
+	events.onerror = () => { status.textContent = "connection lost"; events.close(); button.disabled = false; };
+};
+</script>
+</body></html>{{end}}
+
+{{define "play"}}{{template "head" .ID}}
+<p><a href="/">all exercises</a> | <a href="/ex/{{.N}}">run on the server</a></p>
+<h1>{{.ID}} playground</h1>
+<p>{{.Doc}}</p>
+<form id="files">{{range .Files}}<h3>{{.Name}}</h3>
+<textarea name="{{.Name}}" rows="20" cols="100" spellcheck="false">{{.Source}}</textarea>{{end}}
+</form>
+<p><button id="run">Run</button> <button id="reset">Reset</button> <span id="status"></span></p>
+<pre id="output"></pre>
+<script src="/wasm_exec.js"></script>
+<script>
+const form = document.getElementById("files");
+const button = document.getElementById("run");
+const output = document.getElementById("output");
+const status = document.getElementById("status");
+const decoder = new TextDecoder("utf-8");
+globalThis.fs.writeSync = (fd, buf) => { output.textContent += decoder.decode(buf); return buf.length; };
+document.getElementById("reset").onclick = () => form.reset();
+button.onclick = async () => {
+	button.disabled = true;
+	output.textContent = "";
+	status.textContent = "compiling...";
+	try {
+		const resp = await fetch("/play/{{.N}}/build", {method: "POST", body: new FormData(form)});
+		if (!resp.ok) {
+			output.textContent = await resp.text();
+			status.textContent = "does not build";
+			return;
+		}
+		const go = new Go();
+		const {instance} = await WebAssembly.instantiate(await resp.arrayBuffer(), go.importObject);
+		status.textContent = "running...";
+		const start = performance.now();
+		await go.run(instance);
+		status.textContent = "exited after " + Math.round(performance.now() - start) + "ms";
+	} catch (err) {
+		status.textContent = String(err);
+	} finally {
+		button.disabled = false;
+	}
+};
+</script>
+</body></html>{{end}}
+`))
+
+func render(w http.ResponseWriter, name string, data interface{}) {
+	var buf bytes.Buffer
+	if err := pages.ExecuteTemplate(&buf, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
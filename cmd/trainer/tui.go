@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/sandbox"
+)
+
+// trainer tui drives the whole training from one screen: the exercises
+// on the left, the source of the selected one or the output of the last
+// action on the right.
+//
+//	up/down, k/j      select an exercise
+//	pgup/pgdn, u/d    scroll the right pane
+//	tab               switch between source and output
+//	enter, r          build and run the exercise
+//	c                 check its output against the golden file
+//	t                 grade it with its hidden tests
+//	q                 quit
+//
+// The terminal is put into raw mode with stty, so this needs a Unix-like
+// system. Programs run without input and are stopped after -timeout;
+// servers and interactive exercises are better started with trainer run.
+
+const (
+	viewSource = "source"
+	viewOutput = "output"
+)
+
+type tui struct {
+	all      []Exercise
+	progress *Progress
+	tmp      string
+	timeout  time.Duration
+
+	cur    int    // selected exercise
+	top    int    // first exercise shown in the list
+	view   string // viewSource or viewOutput
+	scroll int    // first line shown in the right pane
+	output []string
+	status string
+
+	width, height int
+}
+
+func tuiMode(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 15*time.Second, "stop a program after this long")
+	fs.Parse(args)
+	if len(all) == 0 {
+		fatal(fmt.Errorf("no exercises found"))
+	}
+
+	saved, err := stty("-g")
+	if err != nil {
+		fatal(fmt.Errorf("trainer tui needs a terminal: %v", err))
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		fatal(err)
+	}
+	fmt.Print("\x1b[?1049h\x1b[?25l") // alternate screen, hide the cursor
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		stty(strings.TrimSpace(saved))
+	}()
+
+	tmp, err := os.MkdirTemp("", "trainer")
+	if err != nil {
+		fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	t := &tui{all: all, tmp: tmp, timeout: *timeout, view: viewSource}
+	t.reloadProgress()
+	t.status = "enter run  c check  t grade  tab source/output  q quit"
+
+	key := make([]byte, 8)
+	for {
+		t.draw()
+		n, err := os.Stdin.Read(key)
+		if err != nil {
+			return 1
+		}
+		if !t.handle(string(key[:n])) {
+			return 0
+		}
+	}
+}
+
+// stty runs stty on the terminal and returns what it prints.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func (t *tui) reloadProgress() {
+	p, err := loadProgress()
+	if err != nil {
+		p = &Progress{}
+	}
+	t.progress = p
+}
+
+// handle acts on one key press and reports whether to go on.
+func (t *tui) handle(key string) bool {
+	switch key {
+	case "q", "\x03", "\x04": // q, Ctrl-C, Ctrl-D
+		return false
+	case "k", "\x1b[A", "\x1bOA":
+		t.selectExercise(t.cur - 1)
+	case "j", "\x1b[B", "\x1bOB":
+		t.selectExercise(t.cur + 1)
+	case "u", "\x1b[5~":
+		t.scroll -= t.paneHeight() / 2
+	case "d", " ", "\x1b[6~":
+		t.scroll += t.paneHeight() / 2
+	case "\t":
+		if t.view == viewSource {
+			t.view = viewOutput
+		} else {
+			t.view = viewSource
+		}
+		t.scroll = 0
+	case "\r", "\n", "r":
+		t.act("running", t.runOutput)
+	case "c":
+		t.act("checking", t.goldenOutput)
+	case "t":
+		t.act("grading", t.gradeOutput)
+	}
+	if t.scroll < 0 {
+		t.scroll = 0
+	}
+	return true
+}
+
+func (t *tui) selectExercise(i int) {
+	if i < 0 || i >= len(t.all) {
+		return
+	}
+	t.cur, t.scroll = i, 0
+	t.view = viewSource
+}
+
+// act shows that something is going on, does it and shows its output.
+func (t *tui) act(what string, fn func(Exercise) string) {
+	e := t.all[t.cur]
+	t.status = what + " " + e.ID() + " ..."
+	t.draw()
+	start := time.Now()
+	out := fn(e)
+	t.output = strings.Split(strings.TrimRight(out, "\n"), "\n")
+	t.view, t.scroll = viewOutput, 0
+	t.status = fmt.Sprintf("%s %s took %v", what, e.ID(), time.Since(start).Round(time.Millisecond))
+	t.reloadProgress()
+}
+
+// build compiles e and returns the binary, or the compiler errors.
+func (t *tui) build(e Exercise) (string, string) {
+	var errs bytes.Buffer
+	exe, err := buildExerciseTo(e, t.tmp, &errs)
+	if err != nil {
+		return "", fmt.Sprintf("FAIL %s does not build\n%s", e.ID(), errs.String())
+	}
+	return exe, ""
+}
+
+func (t *tui) runOutput(e Exercise) string {
+	exe, errs := t.build(e)
+	if exe == "" {
+		return errs
+	}
+	updateProgress(func(p *Progress) { p.ran(e) })
+	var out bytes.Buffer
+	cmd := &sandbox.Cmd{Path: exe, Dir: e.Dir, Stdout: &out, Stderr: &out, Timeout: t.timeout, MaxOutput: maxOutput}
+	r, err := cmd.Run(context.Background())
+	switch {
+	case r.TimedOut:
+		fmt.Fprintf(&out, "\n--- stopped after %v\n", t.timeout)
+	case r.Truncated:
+		fmt.Fprintf(&out, "\n--- stopped after %d bytes of output\n", maxOutput)
+	case err != nil:
+		fmt.Fprintf(&out, "\n--- %v\n", err)
+	}
+	return out.String()
+}
+
+func (t *tui) goldenOutput(e Exercise) string {
+	want, err := os.ReadFile(filepath.Join(e.Dir, "testdata", goldenName))
+	if err != nil {
+		return fmt.Sprintf("%s has no golden file, create it with trainer golden -update %s\n", e.ID(), e.ID())
+	}
+	exe, errs := t.build(e)
+	if exe == "" {
+		updateProgress(func(p *Progress) { p.checked(e, false) })
+		return errs
+	}
+	r := runGolden(e, exe, t.timeout)
+	ok := !r.timedOut && bytes.Equal(r.out, want)
+	updateProgress(func(p *Progress) { p.checked(e, ok) })
+	switch {
+	case r.timedOut:
+		return fmt.Sprintf("FAIL %s did not finish within %v\n", e.ID(), t.timeout)
+	case !ok:
+		return fmt.Sprintf("FAIL %s output differs from %s\n%s", e.ID(), goldenName, firstDiff(want, r.out))
+	}
+	return fmt.Sprintf("PASS %s\n\n%s", e.ID(), r.out)
+}
+
+func (t *tui) gradeOutput(e Exercise) string {
+	files, reqs, err := gradeFiles(e.Dir)
+	if err != nil {
+		return err.Error()
+	}
+	if len(reqs) == 0 {
+		return e.ID() + " has no hidden tests\n"
+	}
+	results, buildErr := runGrade(e, files, t.timeout)
+	if buildErr != "" {
+		updateProgress(func(p *Progress) { p.checked(e, false) })
+		return fmt.Sprintf("FAIL %s does not build\n%s", e.ID(), buildErr)
+	}
+	var b strings.Builder
+	ok := 0
+	for _, r := range reqs {
+		if results[r.test] == "pass" {
+			fmt.Fprintf(&b, "PASS %s\n", r.doc)
+			ok++
+		} else {
+			fmt.Fprintf(&b, "FAIL %s\n", r.doc)
+		}
+	}
+	fmt.Fprintf(&b, "\n%d/%d requirements met\n", ok, len(reqs))
+	updateProgress(func(p *Progress) { p.checked(e, ok == len(reqs)) })
+	return b.String()
+}
+
+func (t *tui) source(e Exercise) []string {
+	var lines []string
+	for _, path := range learnerFiles(e.Dir) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		lines = append(lines, "── "+filepath.Base(path))
+		lines = append(lines, strings.Split(strings.TrimRight(string(data), "\n"), "\n")...)
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+func (t *tui) paneHeight() int {
+	return t.height - 2 // title and status line
+}
+
+// size asks the terminal for its size, 80x24 if it does not say.
+func (t *tui) size() {
+	t.width, t.height = 80, 24
+	out, err := stty("size")
+	if err != nil {
+		return
+	}
+	f := strings.Fields(out)
+	if len(f) != 2 {
+		return
+	}
+	if h, err := strconv.Atoi(f[0]); err == nil && h > 5 {
+		t.height = h
+	}
+	if w, err := strconv.Atoi(f[1]); err == nil && w > 40 {
+		t.width = w
+	}
+}
+
+func (t *tui) draw() {
+	t.size()
+	e := t.all[t.cur]
+	listWidth := t.width / 3
+	if listWidth > 40 {
+		listWidth = 40
+	}
+	rows := t.paneHeight()
+	if t.cur < t.top {
+		t.top = t.cur
+	}
+	if t.cur >= t.top+rows {
+		t.top = t.cur - rows + 1
+	}
+	right := t.output
+	if t.view == viewSource {
+		right = t.source(e)
+	}
+	if last := len(right) - rows; t.scroll > last {
+		t.scroll = last
+	}
+	if t.scroll < 0 {
+		t.scroll = 0
+	}
+
+	var b bytes.Buffer
+	b.WriteString("\x1b[H")
+	title := fmt.Sprintf(" Session %d: %s  │  %s  %s  [%s]", e.Session, sessions[e.Session-1], e.ID(), e.Doc, t.view)
+	b.WriteString("\x1b[1m" + fit(title, t.width) + "\x1b[0m\r\n")
+	for i := 0; i < rows; i++ {
+		left := ""
+		if n := t.top + i; n < len(t.all) {
+			x := t.all[n]
+			left = fmt.Sprintf("%s %-10s %s", mark(t.progress.state(x)), x.ID(), x.Doc)
+			left = fit(left, listWidth)
+			if n == t.cur {
+				left = "\x1b[7m" + left + "\x1b[0m"
+			}
+		} else {
+			left = fit("", listWidth)
+		}
+		line := ""
+		if n := t.scroll + i; n < len(right) {
+			line = right[n]
+		}
+		b.WriteString(left + "│" + colorResult(fit(line, t.width-listWidth-1)) + "\r\n")
+	}
+	b.WriteString("\x1b[7m" + fit(" "+t.status, t.width) + "\x1b[0m")
+	io.Copy(os.Stdout, &b)
+}
+
+// fit expands tabs and cuts or pads s to exactly width columns.
+func fit(s string, width int) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	r := []rune(s)
+	for i, c := range r {
+		if c < ' ' {
+			r[i] = ' '
+		}
+	}
+	if len(r) > width {
+		return string(r[:width])
+	}
+	return string(r) + strings.Repeat(" ", width-len(r))
+}
+
+// colorResult colors PASS and FAIL lines like the other commands do.
+func colorResult(line string) string {
+	switch {
+	case strings.HasPrefix(line, "PASS "):
+		return green + line + reset
+	case strings.HasPrefix(line, "FAIL "):
+		return red + line + reset
+	}
+	return line
+}
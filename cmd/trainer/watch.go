@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/sandbox"
+	"github.com/hannansatopay/training-golang/pkg/watch"
+)
+
+// colors for pass/fail lines, left out when stdout is not a terminal or
+// NO_COLOR is set (https://no-color.org).
+var green, red, reset = "\x1b[32m", "\x1b[31m", "\x1b[0m"
+
+func init() {
+	fi, err := os.Stdout.Stat()
+	if os.Getenv("NO_COLOR") != "" || err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		green, red, reset = "", "", ""
+	}
+}
+
+func pass(format string, args ...interface{}) {
+	fmt.Printf(green+"PASS "+format+reset+"\n", args...)
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Printf(red+"FAIL "+format+reset+"\n", args...)
+}
+
+// watchExercise rebuilds and reruns an exercise every time one of its
+// files is saved, until interrupted. A program still running from the
+// previous save (a web server, say) is stopped first. With -golden the
+// output is compared with the golden file instead of shown.
+func watchExercise(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	check := fs.Bool("golden", false, "check the output against testdata/output.golden")
+	timeout := fs.Duration("timeout", 15*time.Second, "with -golden, give up on the program after this long")
+	debounce := fs.Duration("debounce", 300*time.Millisecond, "wait until files are quiet for this long")
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		usage()
+	}
+	e, err := find(all, fs.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+	tmp, err := os.MkdirTemp("", "trainer")
+	if err != nil {
+		fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	w := &watch.Watcher{Root: e.Dir, Debounce: *debounce}
+	changes := w.Changes(ctx)
+
+	fmt.Printf("watching %s, press Ctrl-C to stop\n", e.Dir)
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if *check {
+				watchGolden(e, tmp, *timeout)
+			} else {
+				watchRun(runCtx, e, tmp, fs.Args()[1:])
+			}
+		}()
+
+		paths, ok := <-changes
+		cancel()
+		<-done
+		if !ok {
+			fmt.Println()
+			return 0
+		}
+		fmt.Printf("\n--- %s changed\n", strings.Join(paths, ", "))
+	}
+}
+
+// watchRun builds and runs e until it ends or ctx is cancelled.
+func watchRun(ctx context.Context, e Exercise, tmp string, args []string) {
+	exe, err := buildExercise(e, tmp)
+	if err != nil {
+		fail("%s does not build", e.ID())
+		return
+	}
+	updateProgress(func(p *Progress) { p.ran(e) })
+	cmd := &sandbox.Cmd{Path: exe, Args: args, Dir: e.Dir, Stdout: os.Stdout, Stderr: os.Stderr, KeepEnv: true}
+	r, err := cmd.Run(ctx) // a rebuild stops what the program started too
+	took := r.Duration.Round(time.Millisecond)
+	switch {
+	case ctx.Err() != nil:
+		fmt.Printf("--- %s stopped for the rebuild\n", e.ID())
+	case err != nil:
+		fail("%s %v after %v", e.ID(), err, took)
+	default:
+		pass("%s exited after %v", e.ID(), took)
+	}
+}
+
+// watchGolden checks e against its golden file and records the outcome.
+func watchGolden(e Exercise, tmp string, timeout time.Duration) {
+	want, err := os.ReadFile(filepath.Join(e.Dir, "testdata", goldenName))
+	if err != nil {
+		fail("%s has no golden file, create it with trainer golden -update %s", e.ID(), e.ID())
+		return
+	}
+	exe, err := buildExercise(e, tmp)
+	if err != nil {
+		fail("%s does not build", e.ID())
+		updateProgress(func(p *Progress) { p.checked(e, false) })
+		return
+	}
+	r := runGolden(e, exe, timeout)
+	ok := !r.timedOut && bytes.Equal(r.out, want)
+	switch {
+	case r.timedOut:
+		fail("%s did not finish within %v", e.ID(), timeout)
+	case !ok:
+		fail("%s output differs from %s", e.ID(), goldenName)
+		fmt.Print(firstDiff(want, r.out))
+	default:
+		pass("%s", e.ID())
+	}
+	updateProgress(func(p *Progress) { p.checked(e, ok) })
+}
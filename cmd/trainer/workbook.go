@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+)
+
+// trainer workbook writes every exercise into one document to read or
+// print: its description, place in the course (exercise.json), source,
+// the input it is run with and the output it should print (both from
+// testdata, as trainer golden uses them), the requirements its hidden
+// tests check and its hints. Like trainer student, the sources have
+// their solution blocks taken out unless -solutions asks for the
+// instructor's edition; hint comments are moved out of the source into
+// a section of their own. The extension of -o picks Markdown or HTML.
+
+type workbookSession struct {
+	Title     string
+	Anchor    string
+	Exercises []workbookExercise
+}
+
+type workbookExercise struct {
+	ID           string
+	Anchor       string
+	Doc          string
+	Requires     []string
+	Topics       []string
+	Files        []workbookFile
+	Args         []string
+	Stdin        string
+	Output       string
+	HasOutput    bool
+	Requirements []string // what the hidden tests check
+	Hints        []string
+}
+
+type workbookFile struct {
+	Name   string
+	Source string
+}
+
+// Highlighted is the source as HTML with syntax highlighting.
+func (f workbookFile) Highlighted() template.HTML {
+	return highlight([]byte(f.Source))
+}
+
+func workbook(all []Exercise, args []string) int {
+	fs := flag.NewFlagSet("workbook", flag.ExitOnError)
+	out := fs.String("o", "workbook.md", "file to write, .md or .html")
+	solutions := fs.Bool("solutions", false, "keep the solution blocks (instructor's edition)")
+	fs.Parse(args)
+
+	keep := map[int]bool{}
+	for _, arg := range fs.Args() {
+		n, err := strconv.Atoi(strings.TrimPrefix(arg, "session"))
+		if err != nil {
+			fatal(fmt.Errorf("not a session: %s", arg))
+		}
+		keep[n] = true
+	}
+	var selected []Exercise
+	for _, e := range all {
+		if len(keep) == 0 || keep[e.Session] {
+			selected = append(selected, e)
+		}
+	}
+	book, err := extractWorkbook(all, selected, *solutions)
+	if err != nil {
+		fatal(err)
+	}
+
+	var buf bytes.Buffer
+	switch filepath.Ext(*out) {
+	case ".md":
+		err = workbookMarkdown.Execute(&buf, book)
+	case ".html", ".htm":
+		err = workbookHTML.Execute(&buf, book)
+	default:
+		fatal(fmt.Errorf("%s: the workbook is written as .md or .html", *out))
+	}
+	if err != nil {
+		fatal(err)
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("wrote %d exercises to %s\n", len(selected), *out)
+	return 0
+}
+
+// extractWorkbook collects what the workbook shows of the selected
+// exercises, grouped by session.
+func extractWorkbook(all, selected []Exercise, solutions bool) ([]workbookSession, error) {
+	c, err := loadCourse(all)
+	if err != nil {
+		return nil, err
+	}
+	var book []workbookSession
+	for _, e := range selected {
+		if len(book) == 0 || book[len(book)-1].Title != sessionTitle(e.Session) {
+			book = append(book, workbookSession{Title: sessionTitle(e.Session), Anchor: fmt.Sprintf("session%d", e.Session)})
+		}
+		s := &book[len(book)-1]
+		x := workbookExercise{ID: e.ID(), Anchor: anchor(e.ID()), Doc: e.Doc, Topics: c.topics[e.ID()]}
+		for _, req := range c.requires[e.ID()] {
+			x.Requires = append(x.Requires, req.ID())
+		}
+		if x.Hints, err = hints(e); err != nil {
+			return nil, err
+		}
+		for _, path := range learnerFiles(e.Dir) {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			if !solutions {
+				if src, _, err = stripSolutions(src); err != nil {
+					return nil, fmt.Errorf("%s:%v", path, err)
+				}
+			}
+			x.Files = append(x.Files, workbookFile{Name: filepath.Base(path), Source: withoutHints(string(src))})
+		}
+
+		testdata := filepath.Join(e.Dir, "testdata")
+		if data, err := os.ReadFile(filepath.Join(testdata, "args")); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if line != "" {
+					x.Args = append(x.Args, line)
+				}
+			}
+		}
+		if data, err := os.ReadFile(filepath.Join(testdata, "stdin")); err == nil {
+			x.Stdin = string(data)
+		}
+		if data, err := os.ReadFile(filepath.Join(testdata, goldenName)); err == nil {
+			x.Output, x.HasOutput = string(data), true
+		}
+		_, reqs, err := gradeFiles(e.Dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range reqs {
+			x.Requirements = append(x.Requirements, r.doc)
+		}
+		s.Exercises = append(s.Exercises, x)
+	}
+	return book, nil
+}
+
+// withoutHints drops the lines that only hold a hint comment. The
+// result ends in a newline, for the Markdown fences.
+func withoutHints(src string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(src, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), hintMarker) {
+			b.WriteString(line)
+		}
+	}
+	if s := b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// anchor is the ID of an exercise usable in a URL fragment.
+func anchor(id string) string {
+	return strings.ReplaceAll(id, "/", "-")
+}
+
+var workbookFuncs = map[string]interface{}{"join": strings.Join, "anchor": anchor}
+
+var workbookMarkdown = texttemplate.Must(texttemplate.New("md").Funcs(workbookFuncs).Parse(`# training-golang workbook
+{{range .}}
+- [{{.Title}}](#{{.Anchor}})
+{{- end}}
+{{range .}}
+<a id="{{.Anchor}}"></a>
+## {{.Title}}
+{{range .Exercises}}
+<a id="{{.Anchor}}"></a>
+### {{.ID}}{{if .Doc}}: {{.Doc}}{{end}}
+{{if .Requires}}
+Do first: {{range $i, $r := .Requires}}{{if $i}}, {{end}}[{{$r}}](#{{anchor $r}}){{end}}{{if .Topics}} · Topics: {{join .Topics ", "}}{{end}}
+{{else if .Topics}}
+Topics: {{join .Topics ", "}}
+{{end}}
+{{- range .Files}}
+` + "`{{.Name}}`" + `
+
+` + "```go" + `
+{{.Source}}` + "```" + `
+{{end}}
+{{- if .Args}}
+Run with: ` + "`{{join .Args \" \"}}`" + `
+{{end}}
+{{- if .Stdin}}
+Input:
+
+` + "```text" + `
+{{.Stdin}}` + "```" + `
+{{end}}
+{{- if .HasOutput}}
+Expected output:
+
+` + "```text" + `
+{{.Output}}` + "```" + `
+{{end}}
+{{- if .Requirements}}
+Your solution should meet these requirements (trainer grade {{.ID}}):
+{{range .Requirements}}
+- {{.}}
+{{- end}}
+{{end}}
+{{- if .Hints}}
+<details><summary>Hints</summary>
+{{range .Hints}}
+- {{.}}
+{{- end}}
+
+</details>
+{{end}}
+{{- end}}
+{{- end}}`))
+
+var workbookHTML = template.Must(template.New("html").Funcs(workbookFuncs).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Workbook - training-golang</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 50em; line-height: 1.4; }
+pre { background: #f6f8fa; padding: 0.8em; overflow-x: auto; }
+.kw { color: #a626a4; } .str { color: #50a14f; } .num { color: #986801; } .com { color: #8e908c; }
+.meta { color: #555; font-size: 90%; }
+h3 { border-top: 1px solid #ddd; padding-top: 1em; }
+@media print { details { display: block; } h2 { page-break-before: always; } }
+</style></head><body>
+<h1>training-golang workbook</h1>
+<ul>{{range .}}<li><a href="#{{.Anchor}}">{{.Title}}</a></li>{{end}}</ul>
+{{range .}}<h2 id="{{.Anchor}}">{{.Title}}</h2>
+{{range .Exercises}}<h3 id="{{.Anchor}}">{{.ID}}{{if .Doc}}: {{.Doc}}{{end}}</h3>
+{{if or .Requires .Topics}}<p class="meta">{{if .Requires}}Do first: {{range $i, $r := .Requires}}{{if $i}}, {{end}}<a href="#{{anchor $r}}">{{$r}}</a>{{end}}{{end}}
+{{if .Topics}} Topics: {{join .Topics ", "}}{{end}}</p>{{end}}
+{{range .Files}}<p><code>{{.Name}}</code></p>
+<pre>{{.Highlighted}}</pre>
+{{end}}{{if .Args}}<p>Run with: <code>{{join .Args " "}}</code></p>
+{{end}}{{if .Stdin}}<p>Input:</p>
+<pre>{{.Stdin}}</pre>
+{{end}}{{if .HasOutput}}<p>Expected output:</p>
+<pre>{{.Output}}</pre>
+{{end}}{{if .Requirements}}<p>Your solution should meet these requirements (<code>trainer grade {{.ID}}</code>):</p>
+<ul>{{range .Requirements}}<li>{{.}}</li>{{end}}</ul>
+{{end}}{{if .Hints}}<details><summary>Hints</summary>
+<ol>{{range .Hints}}<li>{{.}}</li>{{end}}</ol>
+</details>
+{{end}}{{end}}{{end}}</body></html>
+`))
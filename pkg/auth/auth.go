@@ -0,0 +1,145 @@
+// Package auth keeps passwords as bcrypt hashes and checks logins against
+// them.
+//
+// bcrypt is slow on purpose, and its cost says how slow: every step up
+// doubles the work of hashing, for the server and for anyone trying
+// passwords against a stolen hash. When the cost is raised, existing
+// hashes keep their old cost; Store.Login hashes the password again with
+// the new cost the next time its user logs in, the only moment the
+// password is known.
+//
+// Comparing a password with a hash takes the same time wherever the two
+// differ (bcrypt compares in constant time), and a login for a user that
+// does not exist takes as long as one with a wrong password, so the time
+// of an answer gives away neither.
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrMismatch is returned for a wrong password and for an unknown user
+// alike.
+var ErrMismatch = errors.New("auth: wrong user name or password")
+
+// Hasher hashes passwords with bcrypt at a fixed cost.
+type Hasher struct {
+	Cost int // between bcrypt.MinCost and bcrypt.MaxCost; zero means bcrypt.DefaultCost
+}
+
+func (h Hasher) cost() int {
+	if h.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return h.Cost
+}
+
+// Hash returns the bcrypt hash of password, salted and with h's cost.
+// Passwords longer than 72 bytes are rejected, as bcrypt ignores the rest.
+func (h Hasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost())
+	if err != nil {
+		return "", fmt.Errorf("auth: %v", err)
+	}
+	return string(hash), nil
+}
+
+// Verify checks password against hash and returns ErrMismatch if it is
+// wrong. If it is right but hash has another cost than h, rehash is a new
+// hash of the password with h's cost, to be stored in place of hash.
+func (h Hasher) Verify(hash, password string) (rehash string, err error) {
+	err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return "", ErrMismatch
+	}
+	if err != nil {
+		return "", fmt.Errorf("auth: %v", err)
+	}
+	if cost, err := bcrypt.Cost([]byte(hash)); err == nil && cost == h.cost() {
+		return "", nil
+	}
+	return h.Hash(password)
+}
+
+// Store maps user names to password hashes. It is safe for concurrent
+// use.
+type Store struct {
+	Hasher Hasher
+
+	// OnRehash, if set, is called after a login replaced the hash of user,
+	// made with cost from, by one made with cost to.
+	OnRehash func(user string, from, to int)
+
+	mu     sync.Mutex
+	hashes map[string]string
+	dummy  string // hash compared against for unknown users
+}
+
+// NewStore returns an empty store hashing with h.
+func NewStore(h Hasher) *Store {
+	dummy, _ := h.Hash("not a password") // a bad cost shows in Set and Login
+	return &Store{Hasher: h, hashes: map[string]string{}, dummy: dummy}
+}
+
+// Set stores the hash of password for user, replacing any earlier one.
+func (s *Store) Set(user, password string) error {
+	hash, err := s.Hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	s.SetHash(user, hash)
+	return nil
+}
+
+// SetHash stores a hash made earlier, for example one read from a file.
+func (s *Store) SetHash(user, hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hashes[user] = hash
+}
+
+// Hashes returns a copy of the stored hashes, to be saved.
+func (s *Store) Hashes() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hashes := make(map[string]string, len(s.hashes))
+	for user, hash := range s.hashes {
+		hashes[user] = hash
+	}
+	return hashes
+}
+
+// Login checks the password of user and returns ErrMismatch if the user
+// does not exist or the password is wrong. A right password stored with
+// another cost than the Hasher's is hashed again.
+func (s *Store) Login(user, password string) error {
+	s.mu.Lock()
+	hash, ok := s.hashes[user]
+	if !ok {
+		hash = s.dummy
+	}
+	s.mu.Unlock()
+
+	// the comparison takes long: it must not hold the lock
+	rehash, err := s.Hasher.Verify(hash, password)
+	if !ok && err == nil {
+		err = ErrMismatch // only the dummy's password gets here
+	}
+	if err != nil || rehash == "" {
+		return err
+	}
+	s.mu.Lock()
+	if s.hashes[user] == hash { // not changed meanwhile
+		s.hashes[user] = rehash
+	}
+	s.mu.Unlock()
+	if s.OnRehash != nil {
+		from, _ := bcrypt.Cost([]byte(hash))
+		s.OnRehash(user, from, s.Hasher.cost())
+	}
+	return nil
+}
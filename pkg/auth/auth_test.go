@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// the lowest cost keeps the tests fast
+var fast = Hasher{Cost: bcrypt.MinCost}
+
+func cost(t *testing.T, hash string) int {
+	t.Helper()
+	c, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		t.Fatalf("bcrypt.Cost(%q): %v", hash, err)
+	}
+	return c
+}
+
+// TestHashVerify accepts the right password only, and salts every hash.
+func TestHashVerify(t *testing.T) {
+	hash, err := fast.Hash("gopher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := cost(t, hash); c != bcrypt.MinCost {
+		t.Errorf("cost %d, want %d", c, bcrypt.MinCost)
+	}
+	if rehash, err := fast.Verify(hash, "gopher"); rehash != "" || err != nil {
+		t.Errorf("Verify(right password) = %q, %v, want no rehash and nil", rehash, err)
+	}
+	for _, wrong := range []string{"Gopher", "gopher ", ""} {
+		if _, err := fast.Verify(hash, wrong); err != ErrMismatch {
+			t.Errorf("Verify(%q) = %v, want ErrMismatch", wrong, err)
+		}
+	}
+	if again, _ := fast.Hash("gopher"); again == hash {
+		t.Error("two hashes of the same password are equal: no salt")
+	}
+	if (Hasher{}).cost() != bcrypt.DefaultCost {
+		t.Errorf("the zero Hasher has cost %d, want bcrypt.DefaultCost", Hasher{}.cost())
+	}
+}
+
+// TestHashErrors rejects passwords bcrypt would cut, bad costs and hashes
+// that are no bcrypt hashes, none of them as a mismatch.
+func TestHashErrors(t *testing.T) {
+	if _, err := fast.Hash(strings.Repeat("a", 73)); err == nil {
+		t.Error("Hash of a 73-byte password: no error")
+	}
+	if _, err := (Hasher{Cost: bcrypt.MaxCost + 1}).Hash("gopher"); err == nil {
+		t.Error("Hash with a cost above bcrypt.MaxCost: no error")
+	}
+	if _, err := fast.Verify("plain text", "plain text"); err == nil || errors.Is(err, ErrMismatch) {
+		t.Errorf("Verify of a malformed hash = %v, want another error than ErrMismatch", err)
+	}
+}
+
+// TestVerifyRehash hashes a right password again when its hash has
+// another cost than the Hasher.
+func TestVerifyRehash(t *testing.T) {
+	old, err := fast.Hash("gopher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slower := Hasher{Cost: bcrypt.MinCost + 1}
+	rehash, err := slower.Verify(old, "gopher")
+	if err != nil || rehash == "" {
+		t.Fatalf("Verify = %q, %v, want a new hash", rehash, err)
+	}
+	if c := cost(t, rehash); c != slower.Cost {
+		t.Errorf("the new hash has cost %d, want %d", c, slower.Cost)
+	}
+	if again, err := slower.Verify(rehash, "gopher"); again != "" || err != nil {
+		t.Errorf("Verify of the new hash = %q, %v, want no rehash and nil", again, err)
+	}
+	if rehash, err := slower.Verify(old, "wrong"); rehash != "" || err != ErrMismatch {
+		t.Errorf("Verify(wrong password) = %q, %v, want ErrMismatch and no rehash", rehash, err)
+	}
+}
+
+// TestStoreLogin gives the same error for a wrong password and an unknown
+// user, whatever password that one tries.
+func TestStoreLogin(t *testing.T) {
+	s := NewStore(fast)
+	if err := s.Set("mary", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		user, password string
+		want           error
+	}{
+		{"mary", "secret", nil},
+		{"mary", "wrong", ErrMismatch},
+		{"chris", "secret", ErrMismatch},
+		{"chris", "not a password", ErrMismatch}, // the password of the dummy hash
+		{"", "", ErrMismatch},
+	} {
+		if err := s.Login(tc.user, tc.password); err != tc.want {
+			t.Errorf("Login(%q, %q) = %v, want %v", tc.user, tc.password, err, tc.want)
+		}
+	}
+	if err := s.Set("mary", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Login("mary", "secret"); err != ErrMismatch {
+		t.Errorf("Login with the replaced password = %v, want ErrMismatch", err)
+	}
+}
+
+// TestStoreRehash replaces a hash of another cost at the next login, and
+// reports it through OnRehash.
+func TestStoreRehash(t *testing.T) {
+	old, err := fast.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore(Hasher{Cost: bcrypt.MinCost + 1})
+	var calls []string
+	s.OnRehash = func(user string, from, to int) {
+		calls = append(calls, user)
+		if from != bcrypt.MinCost || to != bcrypt.MinCost+1 {
+			t.Errorf("OnRehash(%q, %d, %d), want costs %d and %d", user, from, to, bcrypt.MinCost, bcrypt.MinCost+1)
+		}
+	}
+	s.SetHash("mary", old)
+	if err := s.Login("mary", "wrong"); err != ErrMismatch {
+		t.Errorf("Login(wrong password) = %v, want ErrMismatch", err)
+	}
+	if h := s.Hashes()["mary"]; h != old {
+		t.Error("a failed login replaced the hash")
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.Login("mary", "secret"); err != nil {
+			t.Fatalf("login %d: %v", i+1, err)
+		}
+	}
+	if len(calls) != 1 {
+		t.Errorf("OnRehash called for %q, want once for mary", calls)
+	}
+	if c := cost(t, s.Hashes()["mary"]); c != bcrypt.MinCost+1 {
+		t.Errorf("the stored hash has cost %d after the login, want %d", c, bcrypt.MinCost+1)
+	}
+}
+
+// TestHashes returns a copy: changing it leaves the store alone.
+func TestHashes(t *testing.T) {
+	s := NewStore(fast)
+	s.SetHash("mary", "$2a$04$a")
+	hashes := s.Hashes()
+	hashes["mary"] = "changed"
+	hashes["chris"] = "added"
+	if got := s.Hashes(); len(got) != 1 || got["mary"] != "$2a$04$a" {
+		t.Errorf("Hashes = %q after changing the copy", got)
+	}
+}
+
+// TestConcurrent logs in while passwords change, for the race detector.
+func TestConcurrent(t *testing.T) {
+	s := NewStore(fast)
+	if err := s.Set("mary", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				s.Set("mary", "secret")
+				return
+			}
+			if err := s.Login("mary", "secret"); err != nil {
+				t.Errorf("Login: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,105 @@
+// Package bloomfilter implements a Bloom filter: a compact set that can
+// say "definitely not present" or "probably present". It never gives a
+// false negative; the false-positive rate is chosen when the filter is
+// created. A Filter is not safe for concurrent use.
+package bloomfilter
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// Filter is a Bloom filter of m bits using k hash functions.
+type Filter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+	n    uint64 // items added
+}
+
+// New sizes a filter for n items with a false-positive rate of p
+// (0 < p < 1), using the usual formulas
+//
+//	m = -n·ln(p) / ln(2)²     k = m/n · ln(2)
+func New(n uint, p float64) *Filter {
+	if n == 0 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		panic("bloomfilter: false-positive rate must be between 0 and 1")
+	}
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(n) * math.Ln2)
+	return NewWithSize(uint64(m), uint64(math.Max(k, 1)))
+}
+
+// NewWithSize creates a filter with exactly m bits and k hash functions.
+func NewWithSize(m, k uint64) *Filter {
+	if m == 0 || k == 0 {
+		panic("bloomfilter: m and k must be positive")
+	}
+	return &Filter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// hashes returns two 64-bit hashes of data, FNV-1a and FNV-1. The k bit
+// positions are then h1 + i·h2 (Kirsch–Mitzenmacher double hashing),
+// which is as good as k separate hash functions and much cheaper.
+func hashes(data []byte) (uint64, uint64) {
+	a := fnv.New64a()
+	a.Write(data)
+	b := fnv.New64()
+	b.Write(data)
+	h2 := b.Sum64()
+	h2 |= 1 // odd, so the positions don't repeat when m is even
+	return a.Sum64(), h2
+}
+
+func (f *Filter) positions(data []byte, visit func(bit uint64) bool) {
+	h1, h2 := hashes(data)
+	for i := uint64(0); i < f.k; i++ {
+		if !visit((h1 + i*h2) % f.m) {
+			return
+		}
+	}
+}
+
+// Add inserts data into the set.
+func (f *Filter) Add(data []byte) {
+	f.positions(data, func(bit uint64) bool {
+		f.bits[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+	f.n++
+}
+
+// AddString inserts s into the set.
+func (f *Filter) AddString(s string) { f.Add([]byte(s)) }
+
+// Test reports whether data may be in the set. false means it is
+// certainly not.
+func (f *Filter) Test(data []byte) bool {
+	found := true
+	f.positions(data, func(bit uint64) bool {
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			found = false
+		}
+		return found
+	})
+	return found
+}
+
+// TestString reports whether s may be in the set.
+func (f *Filter) TestString(s string) bool { return f.Test([]byte(s)) }
+
+// Bits and Hashes return m and k.
+func (f *Filter) Bits() uint64   { return f.m }
+func (f *Filter) Hashes() uint64 { return f.k }
+
+// Len returns how many items were added.
+func (f *Filter) Len() uint64 { return f.n }
+
+// EstimatedFPRate is the expected false-positive rate for the items added
+// so far: (1 - e^(-k·n/m))^k.
+func (f *Filter) EstimatedFPRate() float64 {
+	return math.Pow(1-math.Exp(-float64(f.k*f.n)/float64(f.m)), float64(f.k))
+}
@@ -0,0 +1,58 @@
+package bloomfilter
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestFPRate fills filters to the n they were sized for, then asks for
+// keys that were never added: the share of them found must stay close to
+// the configured rate p.
+func TestFPRate(t *testing.T) {
+	for _, tc := range []struct {
+		n uint
+		p float64
+	}{
+		{1000, 0.1},
+		{10000, 0.01},
+		{10000, 0.001},
+		{50000, 0.02},
+	} {
+		t.Run(fmt.Sprintf("n=%d,p=%g", tc.n, tc.p), func(t *testing.T) {
+			f := New(tc.n, tc.p)
+			for i := uint(0); i < tc.n; i++ {
+				f.AddString(fmt.Sprintf("https://example.com/%d", i))
+			}
+			for i := uint(0); i < tc.n; i++ {
+				if !f.TestString(fmt.Sprintf("https://example.com/%d", i)) {
+					t.Fatalf("false negative for item %d", i)
+				}
+			}
+			const tries = 200000
+			found := 0
+			for i := 0; i < tries; i++ {
+				if f.TestString(fmt.Sprintf("https://other.example/%d", i)) {
+					found++
+				}
+			}
+			rate := float64(found) / tries
+			t.Logf("m=%d k=%d: measured %.5f, estimated %.5f", f.Bits(), f.Hashes(), rate, f.EstimatedFPRate())
+			if rate > 1.5*tc.p {
+				t.Errorf("false-positive rate %.5f, want at most 1.5 × %g", rate, tc.p)
+			}
+		})
+	}
+}
+
+// TestEmpty finds nothing in a filter that has nothing.
+func TestEmpty(t *testing.T) {
+	f := New(100, 0.01)
+	for i := 0; i < 1000; i++ {
+		if f.TestString(fmt.Sprint(i)) {
+			t.Fatalf("an empty filter has %d", i)
+		}
+	}
+	if f.Len() != 0 || f.EstimatedFPRate() != 0 {
+		t.Errorf("Len() = %d, EstimatedFPRate() = %g, want 0 and 0", f.Len(), f.EstimatedFPRate())
+	}
+}
@@ -0,0 +1,205 @@
+// Package checks holds go/analysis passes for mistakes the web exercises
+// of session 4 make on purpose, or made before they were fixed:
+//
+//	templateexec   the error of a template's Execute is dropped
+//	listenserve    the error of ListenAndServe is dropped
+//	handlerprint   a handler prints to stdout with fmt instead of logging
+//
+// Run type-checks packages and applies the passes to them; trainer lint
+// reports the findings with their positions like go vet messages.
+package checks
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Analyzers are all passes of the package.
+var Analyzers = []*analysis.Analyzer{TemplateExec, ListenServe, HandlerPrint}
+
+var TemplateExec = &analysis.Analyzer{
+	Name: "templateexec",
+	Doc: `report template executions whose error is ignored
+
+A template that fails halfway has already written part of the page; the
+error is the only sign of it and should at least be logged.`,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runTemplateExec,
+}
+
+var ListenServe = &analysis.Analyzer{
+	Name: "listenserve",
+	Doc: `report ListenAndServe calls whose error is ignored
+
+ListenAndServe only returns on failure (the port is taken, say), so a
+dropped error makes the program exit silently.`,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runListenServe,
+}
+
+var HandlerPrint = &analysis.Analyzer{
+	Name: "handlerprint",
+	Doc: `report fmt.Print calls in HTTP handlers
+
+Printing to stdout from a handler is debugging left behind: it is not
+part of the response and not in the server's log either.`,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      runHandlerPrint,
+}
+
+// ignoredCalls calls report for every call whose results are thrown away:
+// a call statement, or a call in go or defer.
+func ignoredCalls(pass *analysis.Pass, report func(*ast.CallExpr, *types.Func)) {
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	filter := []ast.Node{(*ast.ExprStmt)(nil), (*ast.GoStmt)(nil), (*ast.DeferStmt)(nil)}
+	ins.Preorder(filter, func(n ast.Node) {
+		var call *ast.CallExpr
+		switch n := n.(type) {
+		case *ast.ExprStmt:
+			call, _ = n.X.(*ast.CallExpr)
+		case *ast.GoStmt:
+			call = n.Call
+		case *ast.DeferStmt:
+			call = n.Call
+		}
+		if call == nil {
+			return
+		}
+		if fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func); ok {
+			report(call, fn)
+		}
+	})
+}
+
+// isMethod reports whether fn is the method name of a type called typ in
+// one of the packages.
+func isMethod(fn *types.Func, name, typ string, pkgs ...string) bool {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil || fn.Name() != name {
+		return false
+	}
+	t := sig.Recv().Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Name() != typ || named.Obj().Pkg() == nil {
+		return false
+	}
+	for _, p := range pkgs {
+		if named.Obj().Pkg().Path() == p {
+			return true
+		}
+	}
+	return false
+}
+
+// isFunc reports whether fn is the package-level function pkg.name.
+func isFunc(fn *types.Func, pkg string, names ...string) bool {
+	if fn.Pkg() == nil || fn.Pkg().Path() != pkg {
+		return false
+	}
+	if sig, ok := fn.Type().(*types.Signature); !ok || sig.Recv() != nil {
+		return false
+	}
+	for _, n := range names {
+		if fn.Name() == n {
+			return true
+		}
+	}
+	return false
+}
+
+func runTemplateExec(pass *analysis.Pass) (interface{}, error) {
+	ignoredCalls(pass, func(call *ast.CallExpr, fn *types.Func) {
+		for _, name := range []string{"Execute", "ExecuteTemplate"} {
+			if isMethod(fn, name, "Template", "html/template", "text/template") {
+				pass.Reportf(call.Pos(), "error of %s.%s is ignored", fn.Pkg().Name(), name)
+			}
+		}
+	})
+	return nil, nil
+}
+
+func runListenServe(pass *analysis.Pass) (interface{}, error) {
+	ignoredCalls(pass, func(call *ast.CallExpr, fn *types.Func) {
+		switch {
+		case isFunc(fn, "net/http", "ListenAndServe", "ListenAndServeTLS"),
+			isMethod(fn, "ListenAndServe", "Server", "net/http"),
+			isMethod(fn, "ListenAndServeTLS", "Server", "net/http"):
+			pass.Reportf(call.Pos(), "error of %s is ignored, the server may never have started", fn.Name())
+		}
+	})
+	return nil, nil
+}
+
+func runHandlerPrint(pass *analysis.Pass) (interface{}, error) {
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	filter := []ast.Node{(*ast.FuncDecl)(nil), (*ast.FuncLit)(nil)}
+	ins.Preorder(filter, func(n ast.Node) {
+		var typ *ast.FuncType
+		var body *ast.BlockStmt
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			typ, body = n.Type, n.Body
+		case *ast.FuncLit:
+			typ, body = n.Type, n.Body
+		}
+		if body == nil || !isHandler(pass, typ) {
+			return
+		}
+		ast.Inspect(body, func(n ast.Node) bool {
+			if _, ok := n.(*ast.FuncLit); ok {
+				return false // checked on its own if it is a handler
+			}
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func); ok &&
+				isFunc(fn, "fmt", "Print", "Printf", "Println") {
+				pass.Reportf(call.Pos(), "fmt.%s in an HTTP handler writes to stdout, not to the response or the log", fn.Name())
+			}
+			return true
+		})
+	})
+	return nil, nil
+}
+
+// isHandler reports whether a function takes (http.ResponseWriter,
+// *http.Request).
+func isHandler(pass *analysis.Pass, typ *ast.FuncType) bool {
+	var params []types.Type
+	for _, f := range typ.Params.List {
+		t := pass.TypesInfo.TypeOf(f.Type)
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			params = append(params, t)
+		}
+	}
+	if len(params) != 2 || params[0] == nil || params[1] == nil {
+		return false
+	}
+	return isNamed(params[0], "net/http", "ResponseWriter") && isNamed(params[1], "net/http", "*Request")
+}
+
+// isNamed reports whether t is pkg.name, or a pointer to it for "*name".
+func isNamed(t types.Type, pkg, name string) bool {
+	if len(name) > 0 && name[0] == '*' {
+		p, ok := t.(*types.Pointer)
+		if !ok {
+			return false
+		}
+		t, name = p.Elem(), name[1:]
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == pkg && named.Obj().Name() == name
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"flag"
+	"os"
+)
+
+// AddrEnv is the environment variable that sets the address of the
+// training's servers, as the Addr setting of a Loader with EnvPrefix
+// "TRAINING_" does.
+const AddrEnv = "TRAINING_ADDR"
+
+// Addr defines the -addr flag on flag.CommandLine for a server that needs
+// no other settings, and returns its value for after flag.Parse. A
+// non-empty TRAINING_ADDR replaces def, and -addr wins over both: the
+// order Load applies them in.
+func Addr(def string) *string {
+	if v, ok := os.LookupEnv(AddrEnv); ok && v != "" {
+		def = v
+	}
+	return flag.String("addr", def, "address to listen on (or $"+AddrEnv+")")
+}
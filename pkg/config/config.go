@@ -0,0 +1,326 @@
+// Package config fills a settings struct from several sources.
+// Later sources win over earlier ones:
+//
+//	defaults (the values already in the struct)
+//	< a JSON or YAML file
+//	< environment variables
+//	< command-line flags
+//
+// Files ending in .yaml or .yml are read as YAML, anything else as JSON.
+//
+// Every exported field is a setting. Its key is taken from the `config`
+// tag, or the lower-cased field name when there is none; `config:"-"`
+// leaves the field out. Nested structs give dotted keys ("server.addr").
+// The key names the setting in every source: in the file (server: addr:
+// in YAML, {"server": {"addr": ...}} in JSON), the flag (-server.addr)
+// and the environment variable (PREFIX_SERVER_ADDR). `yaml` and `json`
+// tags are ignored. Fields that flags cannot hold, such as lists of
+// structs, are set by the file only. Other tags: `usage:"..."` is the
+// flag help text and `required:"true"` rejects an empty value.
+//
+// A server that only needs an address can skip the struct: Addr defines
+// its -addr flag with TRAINING_ADDR in between.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+)
+
+// Validator can be implemented by a settings struct to check the final
+// values after every source has been applied.
+type Validator interface {
+	Validate() error
+}
+
+// Loader describes where settings come from. The zero value reads
+// os.Args and the process environment, with no file and no env prefix.
+type Loader struct {
+	EnvPrefix string        // prepended to every variable name, e.g. "TRAINING_"
+	File      string        // JSON or YAML file to read; empty means none
+	FileFlag  string        // if set, a flag with this name can override File
+	FlagSet   *flag.FlagSet // defaults to flag.CommandLine
+	Args      []string      // defaults to os.Args[1:]
+
+	// LookupEnv defaults to os.LookupEnv; swap it to read from a map.
+	LookupEnv func(string) (string, bool)
+}
+
+// Load fills dst (a pointer to a struct) using the default Loader.
+func Load(dst interface{}) error {
+	return new(Loader).Load(dst)
+}
+
+// setting is one leaf field of the settings struct.
+type setting struct {
+	key      string
+	usage    string
+	required bool
+	value    reflect.Value
+}
+
+// Load fills dst (a pointer to a struct) from all sources and validates it.
+func (l *Loader) Load(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("config: Load needs a pointer to a struct")
+	}
+	settings := collect(rv.Elem(), "")
+
+	// Flags are registered and parsed first so that -config can point at
+	// the file, but their values are only applied at the very end.
+	fs := l.FlagSet
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	flags := make(map[string]*flagValue)
+	for _, s := range settings {
+		fv := &flagValue{isBool: s.value.Kind() == reflect.Bool, def: format(s.value)}
+		fs.Var(fv, s.key, s.usage)
+		flags[s.key] = fv
+	}
+	file := l.File
+	if l.FileFlag != "" {
+		fs.StringVar(&file, l.FileFlag, l.File, "path to a JSON or YAML config file")
+	}
+	args := l.Args
+	if args == nil {
+		args = os.Args[1:]
+	}
+	if !fs.Parsed() {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+	}
+
+	if file != "" {
+		if err := readFile(file, dst); err != nil {
+			return err
+		}
+	}
+
+	lookup := l.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	for _, s := range settings {
+		name := l.EnvPrefix + envName(s.key)
+		if v, ok := lookup(name); ok {
+			if err := set(s.value, v); err != nil {
+				return fmt.Errorf("config: env %s: %v", name, err)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if fv := flags[s.key]; fv.set {
+			if err := set(s.value, fv.raw); err != nil {
+				return fmt.Errorf("config: flag -%s: %v", s.key, err)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if s.required && s.value.IsZero() {
+			return fmt.Errorf("config: required setting %q is empty", s.key)
+		}
+	}
+	if v, ok := dst.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("config: %v", err)
+		}
+	}
+	return nil
+}
+
+// readFile decodes a JSON or YAML file on top of the values already in dst.
+func readFile(path string, dst interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		err = UnmarshalYAML(data, dst)
+	} else {
+		err = unmarshalJSON(data, dst)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %v", path, err)
+	}
+	return nil
+}
+
+// Save writes src as indented JSON, in the format Load reads: under the
+// keys of the settings, in alphabetical order, with durations as text.
+// The file is replaced atomically so a crash never leaves a half-written
+// config.
+func Save(path string, src interface{}) error {
+	data, err := json.MarshalIndent(encode(reflect.ValueOf(src)), "", "  ")
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	return safewrite.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// collect walks the struct and returns its leaf fields, recursing into
+// nested structs (but not into time.Duration and friends). Fields set
+// cannot parse are left to the file.
+func collect(v reflect.Value, prefix string) []setting {
+	var out []setting
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+		key := fieldKey(f)
+		if key == "-" {
+			continue
+		}
+		key = prefix + key
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			out = append(out, collect(fv, key+".")...)
+			continue
+		}
+		if !settable(fv.Type()) {
+			continue
+		}
+		out = append(out, setting{
+			key:      key,
+			usage:    f.Tag.Get("usage"),
+			required: f.Tag.Get("required") == "true",
+			value:    fv,
+		})
+	}
+	return out
+}
+
+// fieldKey returns the key of a field: its `config` tag, or else the
+// lower-cased field name.
+func fieldKey(f reflect.StructField) string {
+	if name := strings.Split(f.Tag.Get("config"), ",")[0]; name != "" {
+		return name
+	}
+	return strings.ToLower(f.Name)
+}
+
+// envName turns "server.read-timeout" into "SERVER_READ_TIMEOUT".
+func envName(key string) string {
+	r := strings.NewReplacer(".", "_", "-", "_")
+	return strings.ToUpper(r.Replace(key))
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses s and stores it in v according to v's type.
+func set(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var parts []string
+		for _, p := range strings.Split(s, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+		v.Set(reflect.ValueOf(parts).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// settable reports whether set can parse a value of type t.
+func settable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// format renders v the way set would accept it, for flag defaults.
+func format(v reflect.Value) string {
+	if v.Kind() == reflect.Slice {
+		return strings.Join(v.Convert(reflect.TypeOf([]string(nil))).Interface().([]string), ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// flagValue remembers the raw text given on the command line so it can
+// be applied after the file and environment.
+type flagValue struct {
+	raw    string
+	def    string
+	set    bool
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+	if f.set {
+		return f.raw
+	}
+	return f.def
+}
+
+func (f *flagValue) Set(s string) error {
+	f.raw, f.set = s, true
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
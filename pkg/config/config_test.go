@@ -0,0 +1,277 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type dbSettings struct {
+	URL  string `config:"url" required:"true"`
+	Pool int    `config:"pool" usage:"connections to keep open"`
+}
+
+type settings struct {
+	Addr    string        `config:"addr" json:"address" yaml:"address" usage:"address to listen on"`
+	Grace   time.Duration `config:"grace"`
+	Debug   bool          // untagged: the key is "debug"
+	Tags    []string      `config:"tags"`
+	DB      dbSettings    `config:"db"`
+	Admins  []yamlAdmin   `config:"admins"` // file only: a flag cannot hold it
+	Secret  string        `config:"-"`
+	private int
+}
+
+func defaults() settings {
+	return settings{Addr: "localhost:3000", Grace: 5 * time.Second, DB: dbSettings{URL: "mem://", Pool: 1}}
+}
+
+// env returns a LookupEnv that reads from vars
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+// write puts data in a file called name in a new temporary directory
+func write(t *testing.T, name, data string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// load loads defaults() with file, the variables of vars and args, not
+// os.Args when there are none
+func load(file string, vars map[string]string, args ...string) (settings, error) {
+	s := defaults()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	l := Loader{EnvPrefix: "TEST_", File: file, FlagSet: fs, Args: append([]string{}, args...), LookupEnv: env(vars)}
+	err := l.Load(&s)
+	return s, err
+}
+
+// TestKeys gives every setting one key, from the config tag or the field
+// name, for the flags and the environment alike.
+func TestKeys(t *testing.T) {
+	s := defaults()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var asked []string
+	lookup := func(name string) (string, bool) {
+		asked = append(asked, name)
+		return "", false
+	}
+	l := Loader{EnvPrefix: "TEST_", FlagSet: fs, Args: []string{}, LookupEnv: lookup}
+	if err := l.Load(&s); err != nil {
+		t.Fatal(err)
+	}
+	var flags []string
+	fs.VisitAll(func(f *flag.Flag) { flags = append(flags, f.Name) })
+	sort.Strings(asked)
+	if want := []string{"addr", "db.pool", "db.url", "debug", "grace", "tags"}; !reflect.DeepEqual(flags, want) {
+		t.Errorf("flags %q, want %q", flags, want)
+	}
+	if want := []string{"TEST_ADDR", "TEST_DB_POOL", "TEST_DB_URL", "TEST_DEBUG", "TEST_GRACE", "TEST_TAGS"}; !reflect.DeepEqual(asked, want) {
+		t.Errorf("looked up %q, want %q", asked, want)
+	}
+	if f := fs.Lookup("db.pool"); f.Usage != "connections to keep open" || f.DefValue != "1" {
+		t.Errorf("-db.pool has usage %q and default %q", f.Usage, f.DefValue)
+	}
+}
+
+// TestFileKeys reads the same keys from JSON and YAML files, and ignores
+// json and yaml tags.
+func TestFileKeys(t *testing.T) {
+	want := defaults()
+	want.Addr, want.Grace, want.Debug, want.Tags = ":80", 10*time.Second, true, []string{"a", "b"}
+	want.DB.Pool = 5
+	want.Admins = []yamlAdmin{{"Mary", 30}}
+	for name, doc := range map[string]string{
+		"app.json": `{"addr": ":80", "grace": "10s", "debug": true, "tags": ["a", "b"],
+			"db": {"pool": 5}, "admins": [{"name": "Mary", "age": 30}]}`,
+		"app.yaml": "addr: :80\ngrace: 10s\ndebug: true\ntags: [a, b]\ndb:\n  pool: 5\nadmins:\n  - name: Mary\n    age: 30\n",
+	} {
+		got, err := load(write(t, name, doc), nil)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v\nwant %+v", name, got, want)
+		}
+	}
+	for name, doc := range map[string]string{
+		"tag.json":    `{"address": ":80"}`,
+		"tag.yaml":    "address: :80\n",
+		"secret.json": `{"secret": "x"}`,
+		"name.json":   `{"Addr": ":80"}`,
+	} {
+		if _, err := load(write(t, name, doc), nil); err == nil {
+			t.Errorf("%s: %s loaded, want an unknown field", name, doc)
+		}
+	}
+}
+
+// TestPrecedence applies the sources in order: defaults, file,
+// environment, flags.
+func TestPrecedence(t *testing.T) {
+	file := write(t, "app.json", `{"addr": "file", "grace": "10s", "db": {"pool": 5}}`)
+	vars := map[string]string{"TEST_ADDR": "env", "TEST_DB_POOL": "20"}
+	for _, tc := range []struct {
+		name  string
+		file  string
+		vars  map[string]string
+		args  []string
+		addr  string
+		grace time.Duration
+		pool  int
+	}{
+		{"defaults", "", nil, nil, "localhost:3000", 5 * time.Second, 1},
+		{"file", file, nil, nil, "file", 10 * time.Second, 5},
+		{"env", file, vars, nil, "env", 10 * time.Second, 20},
+		{"flags", file, vars, []string{"-addr", "flag", "-grace", "1m"}, "flag", time.Minute, 20},
+		{"a flag at its default", file, vars, []string{"-addr", "localhost:3000"}, "localhost:3000", 10 * time.Second, 20},
+	} {
+		s, err := load(tc.file, tc.vars, tc.args...)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if s.Addr != tc.addr || s.Grace != tc.grace || s.DB.Pool != tc.pool {
+			t.Errorf("%s: addr=%s grace=%v pool=%d, want %s %v %d", tc.name, s.Addr, s.Grace, s.DB.Pool, tc.addr, tc.grace, tc.pool)
+		}
+	}
+}
+
+// TestJSONErrors checks that every error names the path of the value.
+func TestJSONErrors(t *testing.T) {
+	for _, tc := range []struct {
+		doc, want string
+	}{
+		{`{"db": {"pool": "many"}}`, `json: db.pool: cannot use "many" as int`},
+		{`{"db": {"pool": 1.5}}`, `json: db.pool: cannot use 1.5 as int`},
+		{`{"grace": "soon"}`, `json: grace: cannot use "soon" as time.Duration`},
+		{`{"db": {"host": "x"}}`, "json: db.host: unknown field"},
+		{`{"admins": [{"name": "a"}, {"age": "old"}]}`, `json: admins[1].age: cannot use "old" as int`},
+		{`{"db": [1]}`, "json: db: expected config.dbSettings, found an array"},
+		{`{"tags": "a"}`, `json: tags: expected []string, found "a"`},
+		{`[]`, "json: expected config.settings, found an array"},
+		{`{"addr": `, "unexpected end of JSON input"},
+	} {
+		s := defaults()
+		err := unmarshalJSON([]byte(tc.doc), &s)
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("unmarshalJSON(%s) = %v, want %s", tc.doc, err, tc.want)
+		}
+	}
+}
+
+// TestJSONKeepsDefaults leaves what the document does not set, or sets
+// to null.
+func TestJSONKeepsDefaults(t *testing.T) {
+	s := defaults()
+	if err := unmarshalJSON([]byte(`{"addr": null, "grace": 2000000000, "db": {}}`), &s); err != nil {
+		t.Fatal(err)
+	}
+	want := defaults()
+	want.Grace = 2 * time.Second // a number is nanoseconds, as for encoding/json
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+}
+
+// TestSave writes the keys Load reads, so a saved file loads back the
+// same settings.
+func TestSave(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.json")
+	s := defaults()
+	s.Grace, s.Tags, s.Secret = 90*time.Second, []string{"web"}, "not saved"
+	s.Admins = []yamlAdmin{{"Chris", 40}}
+	if err := Save(file, &s); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "addr": "localhost:3000",
+  "admins": [
+    {
+      "age": 40,
+      "name": "Chris"
+    }
+  ],
+  "db": {
+    "pool": 1,
+    "url": "mem://"
+  },
+  "debug": false,
+  "grace": "1m30s",
+  "tags": [
+    "web"
+  ]
+}
+`
+	if string(data) != want {
+		t.Errorf("Save wrote\n%s\nwant\n%s", data, want)
+	}
+	got, err := load(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Secret = ""
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("loaded %+v, want %+v", got, s)
+	}
+}
+
+type checked struct {
+	Min, Max int
+}
+
+func (c *checked) Validate() error {
+	if c.Min > c.Max {
+		return errors.New("min is above max")
+	}
+	return nil
+}
+
+// TestRequiredAndValidate rejects an empty required setting, and what
+// Validate rejects.
+func TestRequiredAndValidate(t *testing.T) {
+	if _, err := load("", map[string]string{"TEST_DB_URL": ""}); err == nil || err.Error() != `config: required setting "db.url" is empty` {
+		t.Errorf("an empty db.url gave %v", err)
+	}
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-min", "1", "-max", "2"}, ""},
+		{[]string{"-min", "3", "-max", "2"}, "config: min is above max"},
+		{[]string{"-min", "x"}, `config: flag -min: strconv.ParseInt: parsing "x": invalid syntax`},
+	} {
+		var c checked
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		got := ""
+		if err := (&Loader{FlagSet: fs, Args: tc.args, LookupEnv: noEnv}).Load(&c); err != nil {
+			got = err.Error()
+		}
+		if got != tc.want {
+			t.Errorf("%q: error %q, want %q", tc.args, got, tc.want)
+		}
+	}
+	if err := Load(checked{}); err == nil {
+		t.Error("Load of a struct, not a pointer: no error")
+	}
+}
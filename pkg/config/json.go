@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// This file reads and writes JSON config files under the keys Load gives
+// the settings, the same keys as YAML, flags and the environment, rather
+// than the `json` tags encoding/json would go by. A JSON string may hold
+// any setting in the form a flag takes, so durations can be "10s".
+
+// JSONError reports a problem with the value at Path in a JSON document,
+// e.g. "database.pool.size".
+type JSONError struct {
+	Path string
+	Msg  string
+}
+
+func (e *JSONError) Error() string {
+	if e.Path == "" {
+		return "json: " + e.Msg
+	}
+	return fmt.Sprintf("json: %s: %s", e.Path, e.Msg)
+}
+
+// unmarshalJSON decodes a JSON document into dst, which must be a
+// pointer. Values already in dst are kept unless the document sets them.
+func unmarshalJSON(data []byte, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("json: decoding needs a non-nil pointer")
+	}
+	var raw json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return decodeJSON(raw, rv.Elem(), "")
+}
+
+// decodeJSON stores data in v; path names the position for error messages.
+func decodeJSON(data json.RawMessage, v reflect.Value, path string) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil // keep the default
+	}
+	fail := func(format string, args ...interface{}) error {
+		return &JSONError{Path: path, Msg: fmt.Sprintf(format, args...)}
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeJSON(data, v.Elem(), path)
+	case reflect.Struct:
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fail("expected %s, found %s", v.Type(), found(data))
+		}
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys) // the first error is the same every time
+		for _, key := range keys {
+			f, ok := fieldByKey(v, key)
+			if !ok {
+				return &JSONError{Path: join(path, key), Msg: "unknown field"}
+			}
+			if err := decodeJSON(m[key], f, join(path, key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return fail("expected %s, found %s", v.Type(), found(data))
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeJSON(item, s.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		var m map[string]json.RawMessage
+		if v.Type().Key().Kind() != reflect.String {
+			return fail("cannot decode into %s", v.Type())
+		}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return fail("expected %s, found %s", v.Type(), found(data))
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for key, raw := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeJSON(raw, elem, join(path, key)); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+	default:
+		if data[0] == '"' && v.Kind() != reflect.String && v.Kind() != reflect.Interface {
+			var s string
+			if err := json.Unmarshal(data, &s); err != nil || set(v, s) != nil {
+				return fail("cannot use %s as %s", data, v.Type())
+			}
+			return nil
+		}
+		if err := json.Unmarshal(data, v.Addr().Interface()); err != nil {
+			return fail("cannot use %s as %s", data, v.Type())
+		}
+	}
+	return nil
+}
+
+// found describes a JSON value for an error message.
+func found(data json.RawMessage) string {
+	switch data[0] {
+	case '{':
+		return "an object"
+	case '[':
+		return "an array"
+	}
+	return string(data)
+}
+
+// encode turns v into what encoding/json writes as the document
+// unmarshalJSON reads back: structs become objects under the keys of
+// their fields, durations text like "1m30s".
+func encode(v reflect.Value) interface{} {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return encode(v.Elem())
+	case reflect.Struct:
+		m := map[string]interface{}{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if key := fieldKey(f); f.PkgPath == "" && key != "-" {
+				m[key] = encode(v.Field(i))
+			}
+		}
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = encode(v.Index(i))
+		}
+		return s
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		m := map[string]interface{}{}
+		for iter := v.MapRange(); iter.Next(); {
+			m[iter.Key().String()] = encode(iter.Value())
+		}
+		return m
+	}
+	return v.Interface()
+}
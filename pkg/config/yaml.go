@@ -0,0 +1,495 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// This file reads the part of YAML that config files need: nested block
+// mappings, block and flow ([a, b]) sequences, plain and quoted scalars,
+// literal (|) and folded (>) blocks and comments. Anchors, tags and
+// multi-document streams are not supported.
+
+// YAMLError reports a problem at a position in a YAML document. Path is
+// the dotted path of the offending key, e.g. "database.pool.size".
+type YAMLError struct {
+	Line int
+	Path string
+	Msg  string
+}
+
+func (e *YAMLError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("yaml: line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("yaml: line %d: %s: %s", e.Line, e.Path, e.Msg)
+}
+
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	mapNode
+	seqNode
+)
+
+type node struct {
+	kind   nodeKind
+	line   int
+	value  string // scalars
+	quoted bool   // quoted scalars are never null, bool or numbers
+	keys   []string
+	lines  []int // line of every key
+	values []*node
+	items  []*node
+}
+
+type yamlLine struct {
+	num    int // 1-based line number
+	indent int
+	text   string // without indentation and comments
+}
+
+// UnmarshalYAML decodes a YAML document into dst, which must be a pointer.
+// Fields are matched by their key, as Load names them: the `config` tag
+// or the lower-cased field name. Values already in dst are kept unless the document sets
+// them, so dst can hold the defaults.
+func UnmarshalYAML(data []byte, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("yaml: UnmarshalYAML needs a non-nil pointer")
+	}
+	doc := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	lines, err := splitLines(doc)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	p := &parser{doc: doc, lines: lines}
+	root, err := p.block(lines[0].indent)
+	if err != nil {
+		return err
+	}
+	if p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		return &YAMLError{Line: l.num, Msg: "unexpected indentation"}
+	}
+	return decode(root, rv.Elem(), "")
+}
+
+// splitLines returns the lines of doc that hold something, without their
+// comments. The lines of | and > blocks come out too, stripped like the
+// rest: textBlock reads them again from doc.
+func splitLines(doc []string) ([]yamlLine, error) {
+	var out []yamlLine
+	for i, s := range doc {
+		trimmed := strings.TrimLeft(s, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, &YAMLError{Line: i + 1, Msg: "tabs are not allowed for indentation"}
+		}
+		body := strings.TrimRight(stripComment(trimmed), " \t")
+		if body == "" || body == "---" {
+			continue
+		}
+		out = append(out, yamlLine{num: i + 1, indent: len(s) - len(trimmed), text: body})
+	}
+	return out, nil
+}
+
+// stripComment removes a '#' comment that is not inside quotes.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '"' && c == '\\':
+			i++ // an escaped character, \" among them
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+type parser struct {
+	doc   []string // the lines of the document as they are
+	lines []yamlLine
+	pos   int
+}
+
+// block parses the mapping or sequence whose lines start at indent.
+func (p *parser) block(indent int) (*node, error) {
+	l := p.lines[p.pos]
+	if isSeqItem(l.text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func isSeqItem(s string) bool { return s == "-" || strings.HasPrefix(s, "- ") }
+
+func (p *parser) mapping(indent int) (*node, error) {
+	n := &node{kind: mapNode, line: p.lines[p.pos].num}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, &YAMLError{Line: l.num, Msg: "unexpected indentation"}
+		}
+		if isSeqItem(l.text) {
+			return nil, &YAMLError{Line: l.num, Msg: "sequence item inside a mapping"}
+		}
+		key, rest, ok := splitKey(l.text)
+		if !ok {
+			return nil, &YAMLError{Line: l.num, Msg: fmt.Sprintf("expected \"key: value\", got %q", l.text)}
+		}
+		for _, k := range n.keys {
+			if k == key {
+				return nil, &YAMLError{Line: l.num, Path: key, Msg: "duplicate key"}
+			}
+		}
+		p.pos++
+		value, err := p.value(rest, l, indent)
+		if err != nil {
+			return nil, err
+		}
+		n.keys = append(n.keys, key)
+		n.lines = append(n.lines, l.num)
+		n.values = append(n.values, value)
+	}
+	return n, nil
+}
+
+func (p *parser) sequence(indent int) (*node, error) {
+	n := &node{kind: seqNode, line: p.lines[p.pos].num}
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.indent < indent || (l.indent == indent && !isSeqItem(l.text)) {
+			break
+		}
+		if l.indent > indent {
+			return nil, &YAMLError{Line: l.num, Msg: "unexpected indentation"}
+		}
+		rest := strings.TrimSpace(strings.TrimPrefix(l.text, "-"))
+		if _, _, isMap := splitKey(rest); isMap && rest != "" && !strings.HasPrefix(rest, "[") && !isQuoted(rest) {
+			// "- key: value" starts a mapping indented past the dash
+			after := l.text[1:]
+			itemIndent := indent + 1 + len(after) - len(strings.TrimLeft(after, " "))
+			p.lines[p.pos] = yamlLine{num: l.num, indent: itemIndent, text: rest}
+			item, err := p.mapping(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+			continue
+		}
+		p.pos++
+		item, err := p.value(rest, l, indent)
+		if err != nil {
+			return nil, err
+		}
+		n.items = append(n.items, item)
+	}
+	return n, nil
+}
+
+// value parses what follows "key:" or "-": an inline scalar or flow
+// sequence, a literal/folded block, or a nested block on the next lines.
+func (p *parser) value(rest string, l yamlLine, indent int) (*node, error) {
+	switch {
+	case rest == "|" || rest == ">":
+		return p.textBlock(rest == "|", l, indent), nil
+	case rest != "":
+		return inline(rest, l.num)
+	}
+	if p.pos < len(p.lines) {
+		next := p.lines[p.pos]
+		if next.indent > indent || (next.indent == indent && isSeqItem(next.text)) {
+			return p.block(next.indent)
+		}
+	}
+	return &node{kind: scalarNode, line: l.num}, nil // empty value is null
+}
+
+// textBlock collects the more-indented lines after "|" or ">" as they are
+// in the document, '#' and blank lines included, less the indentation of
+// the first one. A literal block keeps its line breaks; a folded one
+// joins its lines with spaces, and a blank line in it is a line break.
+// Either ends in a single line break.
+func (p *parser) textBlock(literal bool, l yamlLine, indent int) *node {
+	var parts []string
+	blockIndent := -1
+	next := l.num // the line after the header, 0-based
+	for ; next < len(p.doc); next++ {
+		s := strings.TrimRight(p.doc[next], " ")
+		if s == "" {
+			parts = append(parts, "")
+			continue
+		}
+		n := len(s) - len(strings.TrimLeft(s, " "))
+		if n <= indent || (blockIndent >= 0 && n < blockIndent) {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = n
+		}
+		parts = append(parts, s[blockIndent:])
+	}
+	for len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1] // trailing blank lines belong to no one
+	}
+	for p.pos < len(p.lines) && p.lines[p.pos].num <= next {
+		p.pos++
+	}
+	if len(parts) == 0 {
+		return &node{kind: scalarNode, line: l.num, quoted: true}
+	}
+	if literal {
+		return &node{kind: scalarNode, line: l.num, value: strings.Join(parts, "\n") + "\n", quoted: true}
+	}
+	var b strings.Builder
+	for i, part := range parts {
+		switch {
+		case part == "":
+			b.WriteByte('\n')
+		case i > 0 && parts[i-1] != "":
+			b.WriteByte(' ')
+		}
+		b.WriteString(part)
+	}
+	return &node{kind: scalarNode, line: l.num, value: b.String() + "\n", quoted: true}
+}
+
+// splitKey splits "key: value" (or "key:") into its parts.
+func splitKey(s string) (key, rest string, ok bool) {
+	if isQuoted(s) {
+		q := s[0]
+		end := strings.IndexByte(s[1:], q)
+		if end < 0 || !strings.HasPrefix(s[end+2:], ":") {
+			return "", "", false
+		}
+		return s[1 : end+1], strings.TrimSpace(s[end+3:]), true
+	}
+	i := strings.Index(s, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(s, ":") {
+			return "", "", false
+		}
+		i = len(s) - 1
+	}
+	key = strings.TrimSpace(s[:i])
+	if key == "" {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(s[i+1:]), true
+}
+
+func isQuoted(s string) bool { return len(s) > 0 && (s[0] == '"' || s[0] == '\'') }
+
+// inline parses a scalar or a flow sequence such as [a, "b c", 3].
+func inline(s string, line int) (*node, error) {
+	if strings.HasPrefix(s, "[") {
+		if !strings.HasSuffix(s, "]") {
+			return nil, &YAMLError{Line: line, Msg: "unterminated flow sequence"}
+		}
+		n := &node{kind: seqNode, line: line}
+		body := strings.TrimSpace(s[1 : len(s)-1])
+		if body == "" {
+			return n, nil
+		}
+		for _, part := range splitFlow(body) {
+			item, err := scalar(strings.TrimSpace(part), line)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		}
+		return n, nil
+	}
+	if s == "{}" {
+		return &node{kind: mapNode, line: line}, nil
+	}
+	return scalar(s, line)
+}
+
+// splitFlow splits the items of a flow sequence at the commas that are
+// not inside quotes.
+func splitFlow(s string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func scalar(s string, line int) (*node, error) {
+	if !isQuoted(s) {
+		return &node{kind: scalarNode, line: line, value: s}, nil
+	}
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return nil, &YAMLError{Line: line, Msg: fmt.Sprintf("unterminated quoted string %s", s)}
+	}
+	if s[0] == '\'' {
+		return &node{kind: scalarNode, line: line, value: strings.ReplaceAll(s[1:len(s)-1], "''", "'"), quoted: true}, nil
+	}
+	v, err := strconv.Unquote(s)
+	if err != nil {
+		return nil, &YAMLError{Line: line, Msg: fmt.Sprintf("bad escape in %s", s)}
+	}
+	return &node{kind: scalarNode, line: line, value: v, quoted: true}, nil
+}
+
+func (n *node) isNull() bool {
+	return n.kind == scalarNode && !n.quoted && (n.value == "" || n.value == "~" || n.value == "null")
+}
+
+// decode stores n in v; path names the position for error messages.
+func decode(n *node, v reflect.Value, path string) error {
+	if n.isNull() {
+		return nil // keep the default
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decode(n, v.Elem(), path)
+	}
+	fail := func(format string, args ...interface{}) error {
+		return &YAMLError{Line: n.line, Path: path, Msg: fmt.Sprintf(format, args...)}
+	}
+	switch n.kind {
+	case mapNode:
+		switch v.Kind() {
+		case reflect.Struct:
+			for i, key := range n.keys {
+				f, ok := fieldByKey(v, key)
+				if !ok {
+					return &YAMLError{Line: n.lines[i], Path: join(path, key), Msg: "unknown field"}
+				}
+				if err := decode(n.values[i], f, join(path, key)); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return fail("cannot decode into %s", v.Type())
+			}
+			if v.IsNil() {
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			for i, key := range n.keys {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err := decode(n.values[i], elem, join(path, key)); err != nil {
+					return err
+				}
+				v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+			}
+		case reflect.Interface:
+			m := map[string]interface{}{}
+			if err := decode(n, reflect.ValueOf(&m).Elem(), path); err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(m))
+		default:
+			return fail("expected %s, found a mapping", v.Type())
+		}
+	case seqNode:
+		switch v.Kind() {
+		case reflect.Slice:
+			s := reflect.MakeSlice(v.Type(), len(n.items), len(n.items))
+			for i, item := range n.items {
+				if err := decode(item, s.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			v.Set(s)
+		case reflect.Interface:
+			var s []interface{}
+			if err := decode(n, reflect.ValueOf(&s).Elem(), path); err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(s))
+		default:
+			return fail("expected %s, found a sequence", v.Type())
+		}
+	default:
+		if v.Kind() == reflect.Interface {
+			v.Set(reflect.ValueOf(guess(n)))
+			return nil
+		}
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Map || v.Kind() == reflect.Struct {
+			return fail("expected %s, found %q", v.Type(), n.value)
+		}
+		if err := set(v, n.value); err != nil {
+			return fail("cannot use %q as %s", n.value, v.Type())
+		}
+	}
+	return nil
+}
+
+// guess picks a Go type for a scalar decoded into interface{}.
+func guess(n *node) interface{} {
+	if n.quoted {
+		return n.value
+	}
+	switch n.value { // not strconv.ParseBool, which takes 1 and t for true
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if i, err := strconv.ParseInt(n.value, 10, 64); err == nil {
+		return int(i)
+	}
+	if f, err := strconv.ParseFloat(n.value, 64); err == nil {
+		return f
+	}
+	return n.value
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// fieldByKey finds the exported field that key refers to.
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if name := fieldKey(f); name != "-" && name == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
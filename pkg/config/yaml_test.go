@@ -0,0 +1,154 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestUnmarshalYAML decodes documents into interface{}, which shows what
+// the parser made of them.
+func TestUnmarshalYAML(t *testing.T) {
+	for _, tc := range []struct {
+		name, doc string
+		want      interface{}
+	}{
+		{"scalars", "a: 1\nb: true\nc: 1.5\nd: text\ne: \"2\"\nf: ~\n",
+			map[string]interface{}{"a": 1, "b": true, "c": 1.5, "d": "text", "e": "2", "f": nil}},
+		{"nested", "server:\n  addr: :80\n  tls:\n    cert: a.pem\nname: x\n",
+			map[string]interface{}{"server": map[string]interface{}{"addr": ":80", "tls": map[string]interface{}{"cert": "a.pem"}}, "name": "x"}},
+		{"block sequence", "tags:\n  - a\n  - b\n",
+			map[string]interface{}{"tags": []interface{}{"a", "b"}}},
+		{"sequence at the key's indent", "tags:\n- a\n- b\n",
+			map[string]interface{}{"tags": []interface{}{"a", "b"}}},
+		{"sequence of mappings", "admins:\n  - name: Mary\n    age: 30\n  - name: Chris\n",
+			map[string]interface{}{"admins": []interface{}{
+				map[string]interface{}{"name": "Mary", "age": 30},
+				map[string]interface{}{"name": "Chris"},
+			}}},
+		{"flow sequence", "tags: [a, \"b c\", 3]\nnone: []\n",
+			map[string]interface{}{"tags": []interface{}{"a", "b c", 3}, "none": []interface{}{}}},
+		{"commas in quotes", "tags: [a, 'b, c', \"d, \\\"e\\\"\", 'it''s']\n",
+			map[string]interface{}{"tags": []interface{}{"a", "b, c", "d, \"e\"", "it's"}}},
+		{"comments", "# top\na: 1 # one\nb: \"x # not a comment\"\nc: a#b\n\n---\n",
+			map[string]interface{}{"a": 1, "b": "x # not a comment", "c": "a#b"}},
+		{"quoted keys", "\"a b\": 1\n'c': 2\n",
+			map[string]interface{}{"a b": 1, "c": 2}},
+		{"escapes", "a: \"tab\\there\"\nb: 'no\\tescape'\n",
+			map[string]interface{}{"a": "tab\there", "b": `no\tescape`}},
+		{"literal block", "script: |\n  echo hi # x\n\n    indented\n  second\nnext: 1\n",
+			map[string]interface{}{"script": "echo hi # x\n\n  indented\nsecond\n", "next": 1}},
+		{"literal block at the end", "script: |\n  one\n  # two\n\n\n",
+			map[string]interface{}{"script": "one\n# two\n"}},
+		{"folded block", "motd: >\n  Welcome to\n  the guest book.\n\n  Be nice.\nnext: 1\n",
+			map[string]interface{}{"motd": "Welcome to the guest book.\nBe nice.\n", "next": 1}},
+		{"empty block", "a: |\nb: 1\n",
+			map[string]interface{}{"a": "", "b": 1}},
+		{"literal block in a sequence", "steps:\n  - |\n    make\n\n    make test\n  - done\n",
+			map[string]interface{}{"steps": []interface{}{"make\n\nmake test\n", "done"}}},
+		{"CRLF", "a: 1\r\nb: 2\r\n",
+			map[string]interface{}{"a": 1, "b": 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got interface{}
+			if err := UnmarshalYAML([]byte(tc.doc), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("UnmarshalYAML(%q)\n got %#v\nwant %#v", tc.doc, got, tc.want)
+			}
+		})
+	}
+}
+
+type yamlServer struct {
+	Addr    string        `config:"addr" required:"true"`
+	Port    int           `config:"port"`
+	Timeout time.Duration `config:"timeout"`
+}
+
+type yamlAdmin struct {
+	Name string `config:"name"`
+	Age  int    `config:"age"`
+}
+
+type yamlApp struct {
+	Name   string      `config:"name"`
+	Server yamlServer  `config:"server"`
+	Admins []yamlAdmin `config:"admins"`
+	Tags   []string    `config:"tags"`
+}
+
+// TestUnmarshalYAMLStruct keeps the values the document does not set.
+func TestUnmarshalYAMLStruct(t *testing.T) {
+	app := yamlApp{Name: "default", Server: yamlServer{Port: 80, Timeout: time.Second}}
+	doc := "server:\n  addr: localhost\n  timeout: 5s\nadmins:\n  - name: Mary\n    age: 30\ntags: [web, 'a, b']\n"
+	if err := UnmarshalYAML([]byte(doc), &app); err != nil {
+		t.Fatal(err)
+	}
+	want := yamlApp{
+		Name:   "default",
+		Server: yamlServer{Addr: "localhost", Port: 80, Timeout: 5 * time.Second},
+		Admins: []yamlAdmin{{"Mary", 30}},
+		Tags:   []string{"web", "a, b"},
+	}
+	if !reflect.DeepEqual(app, want) {
+		t.Errorf("got %+v, want %+v", app, want)
+	}
+}
+
+// TestYAMLErrors checks that every error names the line, and the path of
+// the key when there is one.
+func TestYAMLErrors(t *testing.T) {
+	for _, tc := range []struct {
+		doc, want string
+	}{
+		{"server:\n  port: eighty\n", `yaml: line 2: server.port: cannot use "eighty" as int`},
+		{"server:\n  timeout: soon\n", `yaml: line 2: server.timeout: cannot use "soon" as time.Duration`},
+		{"server:\n  host: x\n", "yaml: line 2: server.host: unknown field"},
+		{"admins:\n  - name: a\n  - age: old\n", `yaml: line 3: admins[1].age: cannot use "old" as int`},
+		{"server:\n  - a\n", "yaml: line 2: server: expected config.yamlServer, found a sequence"},
+		{"tags:\n  a: b\n", "yaml: line 2: tags: expected []string, found a mapping"},
+		{"name: a\nname: b\n", "yaml: line 2: name: duplicate key"},
+		{"name: a\n\tport: 1\n", "yaml: line 2: tabs are not allowed for indentation"},
+		{"tags: [a, b\n", "yaml: line 1: unterminated flow sequence"},
+		{"tags: [a, 'b]\n", "yaml: line 1: unterminated quoted string 'b"},
+		{"name: \"a\n", "yaml: line 1: unterminated quoted string \"a"},
+		{"name: a\n  port: 1\n", "yaml: line 2: unexpected indentation"},
+		{"just text\n", `yaml: line 1: expected "key: value", got "just text"`},
+	} {
+		var app yamlApp
+		err := UnmarshalYAML([]byte(tc.doc), &app)
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("UnmarshalYAML(%q) = %v, want %s", tc.doc, err, tc.want)
+		}
+	}
+}
+
+// TestYAMLRequired loads a YAML file with a required setting missing: the
+// error names its dotted path.
+func TestYAMLRequired(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		doc, want string
+	}{
+		{"name: app\n", `config: required setting "server.addr" is empty`},
+		{"server:\n  addr: \"\"\n", `config: required setting "server.addr" is empty`},
+		{"server:\n  port: x\n", `config: ` + filepath.Join(dir, "app.yaml") + `: yaml: line 2: server.port: cannot use "x" as int`},
+	} {
+		file := filepath.Join(dir, "app.yaml")
+		if err := os.WriteFile(file, []byte(tc.doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var app yamlApp
+		l := Loader{File: file, FlagSet: flag.NewFlagSet("test", flag.ContinueOnError), Args: []string{}, LookupEnv: noEnv}
+		if err := l.Load(&app); err == nil || err.Error() != tc.want {
+			t.Errorf("Load with %q = %v, want %s", tc.doc, err, tc.want)
+		}
+	}
+}
+
+func noEnv(string) (string, bool) { return "", false }
@@ -0,0 +1,60 @@
+// Package graceful stops an HTTP server without cutting off the requests
+// it is answering. http.ListenAndServe only ends with the program: a
+// Ctrl+C or a kill drops every open connection mid-response. Here the
+// signal makes the server stop accepting connections and wait, for a
+// grace period, until the requests in flight are answered.
+package graceful
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Signals stop a server: Ctrl+C, and SIGTERM, which kill and container
+// runtimes send first.
+var Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// ListenAndServe runs srv until the program gets one of Signals, then
+// shuts it down as Run does.
+func ListenAndServe(srv *http.Server, grace time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), Signals...)
+	defer stop()
+	return Run(ctx, srv, grace)
+}
+
+// Run runs srv until ctx is done, over TLS when srv.TLSConfig is set: its
+// Certificates or GetCertificate then give the certificate, as in
+// srv.ListenAndServeTLS("", ""). It then stops accepting connections,
+// closes the idle ones and waits up to grace for the requests in flight
+// to be answered; those still running after that are cut off. Run
+// returns nil after a clean shutdown, the error of srv.ListenAndServe if
+// the server could not start (the port is taken, say), or an error if
+// the grace period ran out.
+func Run(ctx context.Context, srv *http.Server, grace time.Duration) error {
+	errc := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			errc <- srv.ListenAndServeTLS("", "")
+		} else {
+			errc <- srv.ListenAndServe()
+		}
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil {
+		srv.Close()
+		return fmt.Errorf("graceful: requests still running after %v: %v", grace, err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package graceful
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// freeAddr returns a local address nobody listens on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// get retries until the server has started
+func get(t *testing.T, c *http.Client, url string) string {
+	t.Helper()
+	for i := 0; ; i++ {
+		resp, err := c.Get(url)
+		if err == nil {
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			return string(body)
+		}
+		if i == 50 {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// TestRunTLS serves over TLS with the certificate of srv.TLSConfig, and
+// returns nil once ctx is done.
+func TestRunTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(nil) // only for its certificate and a client that trusts it
+	defer ts.Close()
+	addr := freeAddr(t)
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil {
+				w.Write([]byte("plain"))
+				return
+			}
+			w.Write([]byte("tls"))
+		}),
+		TLSConfig: ts.TLS.Clone(),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Run(ctx, srv, time.Second) }()
+	if got := get(t, ts.Client(), "https://"+addr+"/"); got != "tls" {
+		t.Errorf("GET over https = %q, want tls", got)
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after ctx was done")
+	}
+}
+
+// TestRunTaken returns the error of a server that cannot start.
+func TestRunTaken(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if err := Run(context.Background(), &http.Server{Addr: l.Addr().String()}, time.Second); err == nil {
+		t.Error("Run on a taken port = nil, want an error")
+	}
+}
@@ -0,0 +1,73 @@
+// Package guests keeps the entries of a guest book in a SQL database, in
+// the guests table that the migrations of cmd/migrate create. The store
+// does not create its schema itself: run the migrations first.
+package guests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Get for an ID that is not in the table.
+var ErrNotFound = errors.New("guests: not found")
+
+// Guest is one row of the guests table.
+type Guest struct {
+	ID      int64
+	Name    string
+	Email   string // "" when the guest gave none
+	Created time.Time
+}
+
+// Store reads and writes guests in DB.
+type Store struct {
+	DB *sql.DB
+}
+
+// Add stores a guest and returns it with its ID and creation time.
+func (s *Store) Add(ctx context.Context, name, email string) (Guest, error) {
+	g := Guest{Name: name, Email: email, Created: time.Now().UTC().Truncate(time.Second)}
+	res, err := s.DB.ExecContext(ctx, `INSERT INTO guests (name, email, created) VALUES (?, ?, ?)`, g.Name, g.Email, g.Created)
+	if err != nil {
+		return Guest{}, err
+	}
+	g.ID, err = res.LastInsertId()
+	return g, err
+}
+
+// Get returns the guest with id.
+func (s *Store) Get(ctx context.Context, id int64) (Guest, error) {
+	var g Guest
+	err := s.DB.QueryRowContext(ctx, `SELECT id, name, email, created FROM guests WHERE id = ?`, id).
+		Scan(&g.ID, &g.Name, &g.Email, &g.Created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Guest{}, ErrNotFound
+	}
+	return g, err
+}
+
+// List returns the guests, newest first; email, if not "", keeps only the
+// guests with that address.
+func (s *Store) List(ctx context.Context, email string) ([]Guest, error) {
+	query, args := `SELECT id, name, email, created FROM guests`, []any{}
+	if email != "" {
+		query += ` WHERE email = ?` // served by the guests_email index
+		args = append(args, email)
+	}
+	rows, err := s.DB.QueryContext(ctx, query+` ORDER BY created DESC, id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []Guest
+	for rows.Next() {
+		var g Guest
+		if err := rows.Scan(&g.ID, &g.Name, &g.Email, &g.Created); err != nil {
+			return nil, err
+		}
+		list = append(list, g)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,123 @@
+package guests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+
+	"github.com/hannansatopay/training-golang/pkg/migrate"
+)
+
+// migrator returns a migrator for db with the migrations of cmd/migrate
+func migrator(t *testing.T, db *sql.DB) *migrate.Migrator {
+	t.Helper()
+	migrations, err := migrate.Load(os.DirFS("../../cmd/migrate/migrations"), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &migrate.Migrator{DB: db, Migrations: migrations, Log: t.Logf}
+}
+
+// newStore returns a store on an empty in-memory database, its schema
+// built from scratch by the migrations
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1) // every connection to :memory: is a database of its own
+	t.Cleanup(func() { db.Close() })
+	if _, err := migrator(t, db).Up(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	return &Store{DB: db}
+}
+
+func TestAddGet(t *testing.T) {
+	s := newStore(t)
+	ctx := context.Background()
+	g, err := s.Add(ctx, "Ada", "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Get(ctx, g.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Ada" || got.Email != "ada@example.com" || !got.Created.Equal(g.Created) {
+		t.Errorf("Get(%d) = %+v, want %+v", g.ID, got, g)
+	}
+	if _, err := s.Get(ctx, g.ID+1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(%d): %v, want ErrNotFound", g.ID+1, err)
+	}
+}
+
+// guests added in the same second come newest first, by ID
+func TestList(t *testing.T) {
+	s := newStore(t)
+	ctx := context.Background()
+	for _, g := range []struct{ name, email string }{
+		{"Ada", "ada@example.com"},
+		{"Grace", ""},
+		{"Ada again", "ada@example.com"},
+	} {
+		if _, err := s.Add(ctx, g.name, g.email); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tc := range []struct {
+		email string
+		want  []string
+	}{
+		{"", []string{"Ada again", "Grace", "Ada"}},
+		{"ada@example.com", []string{"Ada again", "Ada"}},
+		{"nobody@example.com", nil},
+	} {
+		list, err := s.List(ctx, tc.email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, g := range list {
+			names = append(names, g.Name)
+		}
+		if len(names) != len(tc.want) {
+			t.Errorf("List(%q) = %q, want %q", tc.email, names, tc.want)
+			continue
+		}
+		for i := range names {
+			if names[i] != tc.want[i] {
+				t.Errorf("List(%q) = %q, want %q", tc.email, names, tc.want)
+				break
+			}
+		}
+	}
+}
+
+// the migrations go down to an empty schema and up again: the store
+// works on the result
+func TestMigrateDownUp(t *testing.T) {
+	s := newStore(t)
+	ctx := context.Background()
+	m := migrator(t, s.DB)
+	if n, err := m.Down(ctx, len(m.Migrations)); err != nil || n != len(m.Migrations) {
+		t.Fatalf("Down = %d, %v, want %d", n, err, len(m.Migrations))
+	}
+	if _, err := s.Add(ctx, "Ada", ""); err == nil {
+		t.Fatal("Add succeeded without a guests table")
+	}
+	if v, err := m.Version(ctx); err != nil || v != 0 {
+		t.Errorf("Version after Down = %d, %v, want 0", v, err)
+	}
+	if _, err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Add(ctx, "Ada", "ada@example.com"); err != nil {
+		t.Error(err)
+	}
+}
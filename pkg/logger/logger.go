@@ -0,0 +1,282 @@
+// Package logger is a small leveled logger with structured fields.
+//
+// Every package asks for its own named logger:
+//
+//	var log = logger.New("web")
+//	log.Info("request served", "path", "/spy", "status", 200)
+//
+// Output, format and levels are shared by all loggers and can be set in
+// code or through the environment:
+//
+//	TRAINING_LOG=info,web=debug   default level, then per-logger levels
+//	TRAINING_LOG_FORMAT=json      "text" (default) or "json"
+//
+// Slog returns a log/slog logger that writes the same way, for code
+// written against the standard library's structured logging.
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < Debug || l > Error {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel turns "debug", "info", "warn" or "error" into a Level.
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return Info, fmt.Errorf("logger: unknown level %q", s)
+}
+
+// Format selects how entries are written.
+type Format int
+
+const (
+	Text Format = iota
+	JSON
+)
+
+// Fields are extra key/value pairs attached to every entry of a logger.
+type Fields map[string]interface{}
+
+// state shared by all loggers
+var (
+	mu       sync.Mutex
+	out      io.Writer = os.Stderr
+	format             = Text
+	level              = Info
+	levels             = map[string]Level{}
+	now                = time.Now
+	exitFunc           = os.Exit
+)
+
+func init() {
+	if spec := os.Getenv("TRAINING_LOG"); spec != "" {
+		if err := Configure(spec); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	if strings.EqualFold(os.Getenv("TRAINING_LOG_FORMAT"), "json") {
+		SetFormat(JSON)
+	}
+}
+
+// SetOutput redirects all loggers to w and returns a function that
+// restores the previous writer. Handy to capture output in a buffer.
+func SetOutput(w io.Writer) (restore func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	prev := out
+	out = w
+	return func() {
+		mu.Lock()
+		out = prev
+		mu.Unlock()
+	}
+}
+
+// SetFormat switches between text and JSON output.
+func SetFormat(f Format) {
+	mu.Lock()
+	format = f
+	mu.Unlock()
+}
+
+// SetLevel sets the level of loggers without a level of their own.
+func SetLevel(l Level) {
+	mu.Lock()
+	level = l
+	mu.Unlock()
+}
+
+// SetPackageLevel overrides the level of the logger with the given name.
+func SetPackageLevel(name string, l Level) {
+	mu.Lock()
+	levels[name] = l
+	mu.Unlock()
+}
+
+// Configure reads a verbosity spec such as "warn,web=debug,workers=info":
+// a bare level sets the default, name=level sets one logger.
+func Configure(spec string) error {
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, lvl := "", part
+		if i := strings.Index(part, "="); i >= 0 {
+			name, lvl = part[:i], part[i+1:]
+		}
+		l, err := ParseLevel(lvl)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			SetLevel(l)
+		} else {
+			SetPackageLevel(name, l)
+		}
+	}
+	return nil
+}
+
+// Logger writes entries tagged with its name and fields.
+type Logger struct {
+	name   string
+	fields Fields
+}
+
+// New returns a logger for the package (or component) called name.
+func New(name string) *Logger {
+	return &Logger{name: name}
+}
+
+// With returns a copy of l that adds the given key/value pairs to
+// every entry.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	fields := make(Fields, len(l.fields)+len(keyvals)/2)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	addPairs(fields, keyvals)
+	return &Logger{name: l.name, fields: fields}
+}
+
+// Enabled reports whether entries at level lv would be written.
+func (l *Logger) Enabled(lv Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return lv >= l.levelLocked()
+}
+
+func (l *Logger) levelLocked() Level { return levelOfLocked(l.name) }
+
+// levelOfLocked is the level of the logger called name. mu must be held.
+func levelOfLocked(name string) Level {
+	if lv, ok := levels[name]; ok {
+		return lv
+	}
+	return level
+}
+
+func (l *Logger) Debug(msg string, keyvals ...interface{}) { l.log(Debug, msg, keyvals) }
+func (l *Logger) Info(msg string, keyvals ...interface{})  { l.log(Info, msg, keyvals) }
+func (l *Logger) Warn(msg string, keyvals ...interface{})  { l.log(Warn, msg, keyvals) }
+func (l *Logger) Error(msg string, keyvals ...interface{}) { l.log(Error, msg, keyvals) }
+
+// Fatal logs at Error level and exits the program with status 1.
+func (l *Logger) Fatal(msg string, keyvals ...interface{}) {
+	l.log(Error, msg, keyvals)
+	exitFunc(1)
+}
+
+func (l *Logger) log(lv Level, msg string, keyvals []interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	if lv < l.levelLocked() {
+		return
+	}
+	fields := make(Fields, len(l.fields)+len(keyvals)/2)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	addPairs(fields, keyvals)
+	t := now()
+	if format == JSON {
+		writeJSON(t, lv, l.name, msg, fields)
+	} else {
+		writeText(t, lv, l.name, msg, fields)
+	}
+}
+
+// addPairs stores alternating keys and values; a missing value is
+// reported as "!MISSING" rather than dropped.
+func addPairs(fields Fields, keyvals []interface{}) {
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if i+1 < len(keyvals) {
+			v := keyvals[i+1]
+			if err, ok := v.(error); ok {
+				v = err.Error() // errors marshal to {} in JSON otherwise
+			}
+			fields[key] = v
+		} else {
+			fields[key] = "!MISSING"
+		}
+	}
+}
+
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeText produces lines like:
+// 2022-05-16T10:00:00Z INFO  web: request served path=/spy status=200
+func writeText(t time.Time, lv Level, name, msg string, fields Fields) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5s ", t.Format(time.RFC3339), strings.ToUpper(lv.String()))
+	if name != "" {
+		b.WriteString(name + ": ")
+	}
+	b.WriteString(msg)
+	for _, k := range sortedKeys(fields) {
+		v := fmt.Sprint(fields[k])
+		if strings.ContainsAny(v, " \t\n\"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+		fmt.Fprintf(&b, " %s=%s", k, v)
+	}
+	b.WriteByte('\n')
+	io.WriteString(out, b.String())
+}
+
+func writeJSON(t time.Time, lv Level, name, msg string, fields Fields) {
+	entry := make(map[string]interface{}, len(fields)+4)
+	for k, v := range fields {
+		entry[k] = v
+	}
+	entry["time"] = t.Format(time.RFC3339)
+	entry["level"] = lv.String()
+	entry["msg"] = msg
+	if name != "" {
+		entry["pkg"] = name
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"level": "error", "msg": "logger: " + err.Error()})
+	}
+	out.Write(append(data, '\n'))
+}
@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+var moment = time.Date(2022, 5, 16, 10, 0, 0, 0, time.UTC)
+
+// capture sends the output of every logger to the returned buffer, with
+// the clock stopped at moment and the levels and format at their
+// defaults; the test's end restores them all
+func capture(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	restore := SetOutput(&buf)
+	mu.Lock()
+	prevFormat, prevLevel, prevLevels, prevNow := format, level, levels, now
+	format, level, levels, now = Text, Info, map[string]Level{}, func() time.Time { return moment }
+	mu.Unlock()
+	t.Cleanup(func() {
+		restore()
+		mu.Lock()
+		format, level, levels, now = prevFormat, prevLevel, prevLevels, prevNow
+		mu.Unlock()
+	})
+	return &buf
+}
+
+// lines returns the lines written to buf, and empties it
+func lines(buf *bytes.Buffer) []string {
+	s := strings.TrimSuffix(buf.String(), "\n")
+	buf.Reset()
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// TestLevels writes the entries at the level of the logger and above.
+func TestLevels(t *testing.T) {
+	buf := capture(t)
+	log := New("web")
+	log.Debug("d")
+	log.Info("i")
+	log.Warn("w")
+	log.Error("e")
+	if got := lines(buf); len(got) != 3 || !strings.Contains(got[0], "INFO  web: i") {
+		t.Errorf("at the default level Info got %q, want info, warn and error", got)
+	}
+	SetLevel(Error)
+	log.Warn("w")
+	log.Error("e")
+	if got := lines(buf); len(got) != 1 || !strings.Contains(got[0], "ERROR web: e") {
+		t.Errorf("at level Error got %q, want the error only", got)
+	}
+	if log.Enabled(Warn) || !log.Enabled(Error) {
+		t.Errorf("Enabled(Warn), Enabled(Error) = %t, %t at level Error, want false, true", log.Enabled(Warn), log.Enabled(Error))
+	}
+}
+
+// TestConfigure sets the default level and the levels of single loggers
+// from a spec like TRAINING_LOG's.
+func TestConfigure(t *testing.T) {
+	buf := capture(t)
+	if err := Configure("warn, web=debug,db=error"); err != nil {
+		t.Fatal(err)
+	}
+	web, db, other := New("web"), New("db"), New("other")
+	web.Debug("web debug")
+	db.Warn("db warn")
+	db.Error("db error")
+	other.Info("other info")
+	other.Warn("other warn")
+	want := []string{"DEBUG web: web debug", "ERROR db: db error", "WARN  other: other warn"}
+	got := lines(buf)
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if !strings.HasSuffix(got[i], want[i]) {
+			t.Errorf("line %d = %q, want it to end in %q", i, got[i], want[i])
+		}
+	}
+	for _, spec := range []string{"loud", "web=verbose", "web=", "="} {
+		if err := Configure(spec); err == nil {
+			t.Errorf("Configure(%q) = nil, want an error", spec)
+		}
+	}
+}
+
+// TestParseLevel takes the names in any case, and rejects the rest.
+func TestParseLevel(t *testing.T) {
+	for _, l := range []Level{Debug, Info, Warn, Error} {
+		for _, s := range []string{l.String(), strings.ToUpper(l.String())} {
+			if got, err := ParseLevel(s); got != l || err != nil {
+				t.Errorf("ParseLevel(%q) = %v, %v, want %v", s, got, err, l)
+			}
+		}
+	}
+	if _, err := ParseLevel("trace"); err == nil {
+		t.Error(`ParseLevel("trace") = nil error`)
+	}
+	if s := Level(7).String(); s != "level(7)" {
+		t.Errorf("Level(7).String() = %q, want level(7)", s)
+	}
+}
+
+// TestText writes one line per entry: time, level, name, message and the
+// fields sorted by key, quoted where they need it.
+func TestText(t *testing.T) {
+	buf := capture(t)
+	log := New("web").With("req", 7)
+	log.Info("request served", "path", "/spy", "status", 200, "agent", "curl 8.0", "err", errors.New("a=b"), "odd")
+	want := `2022-05-16T10:00:00Z INFO  web: request served agent="curl 8.0" err="a=b" odd=!MISSING path=/spy req=7 status=200`
+	if got := lines(buf); len(got) != 1 || got[0] != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+	New("").Warn("no name")
+	if got := lines(buf); len(got) != 1 || got[0] != "2022-05-16T10:00:00Z WARN  no name" {
+		t.Errorf("a logger without a name wrote %q", got)
+	}
+}
+
+// TestJSON writes one object per entry, the fields next to time, level,
+// msg and pkg.
+func TestJSON(t *testing.T) {
+	buf := capture(t)
+	SetFormat(JSON)
+	New("web").With("req", 7).Error("failed", "err", errors.New("boom"), "n", 1.5)
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("%v in %q", err, buf.String())
+	}
+	want := map[string]interface{}{
+		"time": "2022-05-16T10:00:00Z", "level": "error", "msg": "failed", "pkg": "web",
+		"req": 7.0, "err": "boom", "n": 1.5,
+	}
+	if len(entry) != len(want) {
+		t.Errorf("got %v, want %v", entry, want)
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s = %v, want %v", k, entry[k], v)
+		}
+	}
+}
+
+// TestFatal logs at Error level and exits with status 1, through
+// exitFunc, which the test swaps for one that only remembers the status.
+func TestFatal(t *testing.T) {
+	buf := capture(t)
+	SetLevel(Error)
+	code, prev := -1, exitFunc
+	exitFunc = func(c int) { code = c }
+	defer func() { exitFunc = prev }()
+	New("web").Fatal("cannot listen", "addr", ":80")
+	if code != 1 {
+		t.Errorf("Fatal exited with %d, want 1", code)
+	}
+	if got := lines(buf); len(got) != 1 || !strings.HasSuffix(got[0], "ERROR web: cannot listen addr=:80") {
+		t.Errorf("Fatal wrote %q", got)
+	}
+}
+
+// TestSlog writes slog records like the package's own entries, groups
+// as key prefixes. The time is the record's, not the package clock.
+func TestSlog(t *testing.T) {
+	buf := capture(t)
+	SetPackageLevel("web", Warn)
+	log := Slog("web").With("req", 7).WithGroup("http")
+	log.Info("dropped")
+	log.Warn("slow", "path", "/spy", slog.Group("resp", "status", 200))
+	want := " WARN  web: slow http.path=/spy http.resp.status=200 req=7"
+	if got := lines(buf); len(got) != 1 || !strings.HasSuffix(got[0], want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Slog returns a log/slog logger that writes like New(name): to the shared
+// output, in the shared format, at the level set for name.
+//
+//	log := logger.Slog("web")
+//	log.Info("request served", "path", "/spy", slog.Int("status", 200))
+//
+// Attributes in a group are written with the group as a prefix, "req.path".
+func Slog(name string) *slog.Logger {
+	return slog.New(&Handler{name: name})
+}
+
+// Slog returns a log/slog logger with the name and fields of l.
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(&Handler{name: l.name, fields: l.fields})
+}
+
+// Handler is a slog.Handler on the loggers of this package. Slog levels
+// between two of ours count as the lower one: slog.LevelInfo+2 is Info.
+type Handler struct {
+	name   string
+	fields Fields
+	group  string // prefix of the keys, "req." in a group req
+}
+
+// NewHandler returns a handler for the logger called name, for use with
+// slog.New or slog.SetDefault.
+func NewHandler(name string) *Handler {
+	return &Handler{name: name}
+}
+
+func fromSlog(lv slog.Level) Level {
+	switch {
+	case lv < slog.LevelInfo:
+		return Debug
+	case lv < slog.LevelWarn:
+		return Info
+	case lv < slog.LevelError:
+		return Warn
+	}
+	return Error
+}
+
+func (h *Handler) Enabled(_ context.Context, lv slog.Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return fromSlog(lv) >= levelOfLocked(h.name)
+}
+
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	fields := make(Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.group, a)
+		return true
+	})
+	t := r.Time
+	mu.Lock()
+	defer mu.Unlock()
+	if fromSlog(r.Level) < levelOfLocked(h.name) {
+		return nil
+	}
+	if t.IsZero() {
+		t = now()
+	}
+	if format == JSON {
+		writeJSON(t, fromSlog(r.Level), h.name, r.Message, fields)
+	} else {
+		writeText(t, fromSlog(r.Level), h.name, r.Message, fields)
+	}
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(fields, h.group, a)
+	}
+	return &Handler{name: h.name, fields: fields, group: h.group}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{name: h.name, fields: h.fields, group: h.group + name + "."}
+}
+
+// addAttr stores a under prefix+its key, the attributes of a group each
+// under the group's prefix; empty attributes are dropped, as slog does.
+func addAttr(fields Fields, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			addAttr(fields, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	addPairs(fields, []interface{}{prefix + a.Key, v.Any()})
+}
@@ -0,0 +1,175 @@
+// Package lru is a generic least-recently-used cache. When it is full,
+// adding a key evicts the entry that was used longest ago. Entries can
+// also expire after a fixed time to live. A Cache is safe for concurrent
+// use.
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Stats counts what happened to a cache since it was created.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64 // includes expired entries
+	Evictions uint64 // entries dropped to make room
+	Expired   uint64 // entries dropped because their TTL passed
+}
+
+// HitRate returns the fraction of lookups that were hits.
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // zero: never
+}
+
+// Cache maps keys of type K to values of type V.
+type Cache[K comparable, V any] struct {
+	// OnEvict, if set, is called when an entry is evicted or expires.
+	// It runs with the cache locked, so it must not call the cache.
+	OnEvict func(key K, value V)
+
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List // front is most recently used
+	items    map[K]*list.Element
+	stats    Stats
+	now      func() time.Time
+}
+
+// New returns a cache holding at most capacity entries. If ttl is not
+// zero, entries expire ttl after they were added.
+func New[K comparable, V any](capacity int, ttl time.Duration) *Cache[K, V] {
+	if capacity < 1 {
+		panic("lru: capacity must be at least 1")
+	}
+	return &Cache[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		items:    make(map[K]*list.Element, capacity),
+		now:      time.Now,
+	}
+}
+
+// Get returns the value for key and marks it as recently used.
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		return value, false
+	}
+	e := el.Value.(*entry[K, V])
+	if !e.expires.IsZero() && c.now().After(e.expires) {
+		c.remove(el)
+		c.stats.Expired++
+		c.stats.Misses++
+		return value, false
+	}
+	c.order.MoveToFront(el)
+	c.stats.Hits++
+	return e.value, true
+}
+
+// Peek returns the value for key without changing its position or the stats.
+func (c *Cache[K, V]) Peek(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		if e.expires.IsZero() || !c.now().After(e.expires) {
+			return e.value, true
+		}
+	}
+	return value, false
+}
+
+// Add stores value under key and reports whether another entry had to be
+// evicted to make room.
+func (c *Cache[K, V]) Add(key K, value V) (evicted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var expires time.Time
+	if c.ttl > 0 {
+		expires = c.now().Add(c.ttl)
+	}
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return false
+	}
+	c.items[key] = c.order.PushFront(&entry[K, V]{key, value, expires})
+	if c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+		return true
+	}
+	return false
+}
+
+// Remove deletes key and reports whether it was present.
+func (c *Cache[K, V]) Remove(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+	return ok
+}
+
+// Len returns the number of entries, including expired ones not yet dropped.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Keys returns the keys from most to least recently used.
+func (c *Cache[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]K, 0, c.order.Len())
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		keys = append(keys, el.Value.(*entry[K, V]).key)
+	}
+	return keys
+}
+
+// Purge empties the cache. The stats are kept.
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[K]*list.Element, c.capacity)
+}
+
+// Stats returns a snapshot of the counters.
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// remove drops el and tells OnEvict; c.mu must be held.
+func (c *Cache[K, V]) remove(el *list.Element) {
+	e := c.order.Remove(el).(*entry[K, V])
+	delete(c.items, e.key)
+	if c.OnEvict != nil {
+		c.OnEvict(e.key, e.value)
+	}
+}
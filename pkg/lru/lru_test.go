@@ -0,0 +1,146 @@
+package lru
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func keys(t *testing.T, c *Cache[string, int], want ...string) {
+	t.Helper()
+	if got := c.Keys(); !slices.Equal(got, want) {
+		t.Errorf("Keys() = %q, want %q", got, want)
+	}
+}
+
+// TestEvictionOrder fills the cache past its capacity: the entries go in
+// the order they were added, and OnEvict hears of each.
+func TestEvictionOrder(t *testing.T) {
+	c := New[string, int](3, 0)
+	var evicted []string
+	c.OnEvict = func(key string, _ int) { evicted = append(evicted, key) }
+	for i, k := range []string{"a", "b", "c"} {
+		if c.Add(k, i) {
+			t.Errorf("Add(%q) evicted an entry from a cache that is not full", k)
+		}
+	}
+	if !c.Add("d", 3) || !c.Add("e", 4) {
+		t.Error("Add to a full cache evicted nothing")
+	}
+	keys(t, c, "e", "d", "c")
+	if !slices.Equal(evicted, []string{"a", "b"}) {
+		t.Errorf("evicted %q, want [a b]", evicted)
+	}
+	if s := c.Stats(); s.Evictions != 2 {
+		t.Errorf("Stats().Evictions = %d, want 2", s.Evictions)
+	}
+}
+
+// TestGetPromotes uses the oldest entry: the next one is evicted instead.
+func TestGetPromotes(t *testing.T) {
+	c := New[string, int](3, 0)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("c", 3)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf(`Get("a") = %d, %v, want 1, true`, v, ok)
+	}
+	keys(t, c, "a", "c", "b")
+	c.Add("d", 4)
+	keys(t, c, "d", "a", "c")
+	if _, ok := c.Get("b"); ok {
+		t.Error(`Get("b") found the entry that should have been evicted`)
+	}
+	if v, ok := c.Peek("c"); !ok || v != 3 {
+		t.Errorf(`Peek("c") = %d, %v, want 3, true`, v, ok)
+	}
+	keys(t, c, "d", "a", "c") // Peek does not promote
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 1 {
+		t.Errorf("Stats() = %+v, want 1 hit and 1 miss", s)
+	}
+}
+
+// TestOverwrite adds a key twice: the value is replaced, the entry
+// promoted, and nothing is evicted.
+func TestOverwrite(t *testing.T) {
+	c := New[string, int](2, 0)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	if c.Add("a", 10) {
+		t.Error("overwriting a key evicted an entry")
+	}
+	if v, _ := c.Get("a"); v != 10 {
+		t.Errorf(`Get("a") = %d, want 10`, v)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+	c.Add("c", 3)
+	keys(t, c, "c", "a")
+}
+
+// TestCapacity tries the smallest caches: 0 is refused, 1 keeps the last key.
+func TestCapacity(t *testing.T) {
+	t.Run("0", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("New with capacity 0 did not panic")
+			}
+		}()
+		New[string, int](0, 0)
+	})
+	t.Run("1", func(t *testing.T) {
+		c := New[string, int](1, 0)
+		c.Add("a", 1)
+		if !c.Add("b", 2) {
+			t.Error(`Add("b") evicted nothing`)
+		}
+		keys(t, c, "b")
+		if c.Add("b", 3) {
+			t.Error(`overwriting "b" evicted it`)
+		}
+		if v, ok := c.Get("b"); !ok || v != 3 {
+			t.Errorf(`Get("b") = %d, %v, want 3, true`, v, ok)
+		}
+	})
+}
+
+// TestTTL moves the cache's clock past the time to live of an entry.
+func TestTTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := New[string, int](2, time.Minute)
+	c.now = func() time.Time { return now }
+	c.Add("a", 1)
+	now = now.Add(30 * time.Second)
+	if _, ok := c.Get("a"); !ok {
+		t.Error(`Get("a") missed before the TTL passed`)
+	}
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("a"); ok {
+		t.Error(`Get("a") hit after the TTL passed`)
+	}
+	if s := c.Stats(); s.Expired != 1 || c.Len() != 0 {
+		t.Errorf("Stats().Expired = %d and Len() = %d, want 1 and 0", s.Expired, c.Len())
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	c := New[string, int](1000, 0)
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		c.Add(keys[i], i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Get(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkAddEvict(b *testing.B) {
+	c := New[int, int](1000, 0)
+	for i := 0; i < b.N; i++ {
+		c.Add(i, i) // past the first 1000, every Add evicts
+	}
+}
@@ -0,0 +1,114 @@
+// Package mailer sends e-mail over SMTP with net/smtp: the connection is
+// upgraded with STARTTLS when the server offers it (or must be, with
+// RequireTLS), then the sender authenticates with PLAIN and the message
+// is handed over. Message builds the MIME text of a mail with a plain
+// text and an HTML version, and Sink is an SMTP server for trying it all
+// out locally: it accepts every mail and keeps it instead of sending it on.
+package mailer
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// Config is where and as whom mail is sent.
+type Config struct {
+	Addr     string // host:port of the SMTP server, e.g. "smtp.example.com:587"
+	Username string // no authentication if empty
+	Password string
+
+	// RequireTLS refuses to send over a server that does not offer
+	// STARTTLS. Without it a plain connection is used then, which
+	// net/smtp only allows to authenticate on localhost.
+	RequireTLS bool
+	TLS        *tls.Config // nil: verify the server's certificate for its host name
+
+	Timeout time.Duration // for connecting and the whole exchange, 0 means 30s
+}
+
+// ErrNoTLS is returned when the server does not offer STARTTLS and
+// Config.RequireTLS is set.
+var ErrNoTLS = errors.New("mailer: server does not offer STARTTLS")
+
+// Mailer sends mail with one Config.
+type Mailer struct {
+	cfg Config
+}
+
+// New returns a Mailer for cfg.
+func New(cfg Config) *Mailer {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &Mailer{cfg: cfg}
+}
+
+// Send delivers msg, a complete message with headers, from the envelope
+// sender from to the recipients to. Each mail uses its own connection.
+func (m *Mailer) Send(from string, to []string, msg []byte) error {
+	if len(to) == 0 {
+		return errors.New("mailer: no recipients")
+	}
+	host, _, err := net.SplitHostPort(m.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", m.cfg.Addr, m.cfg.Timeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(m.cfg.Timeout))
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if err := c.Hello("localhost"); err != nil {
+		return err
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		cfg := &tls.Config{}
+		if m.cfg.TLS != nil {
+			cfg = m.cfg.TLS.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = host
+		}
+		if err := c.StartTLS(cfg); err != nil {
+			return fmt.Errorf("mailer: STARTTLS: %w", err)
+		}
+	} else if m.cfg.RequireTLS {
+		return ErrNoTLS
+	}
+	if m.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, host)); err != nil {
+			return fmt.Errorf("mailer: authentication: %w", err)
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("mailer: recipient %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is a mail with a plain text body, an HTML body or both.
+type Message struct {
+	From    mail.Address
+	To      []mail.Address
+	Subject string
+	Date    time.Time // zero: now
+	Text    string
+	HTML    string
+}
+
+// Recipients returns the addresses of To, for Send.
+func (m *Message) Recipients() []string {
+	var list []string
+	for _, a := range m.To {
+		list = append(list, a.Address)
+	}
+	return list
+}
+
+// Bytes returns the message in MIME format. With both bodies it is a
+// multipart/alternative message, text first: mail programs show the last
+// part they can display. Bodies are quoted-printable, header words that
+// are not ASCII are encoded.
+func (m *Message) Bytes() ([]byte, error) {
+	if m.Text == "" && m.HTML == "" {
+		return nil, fmt.Errorf("mailer: message has no body")
+	}
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	var to []string
+	for _, a := range m.To {
+		to = append(to, a.String())
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", m.From.String())
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if m.Text == "" || m.HTML == "" {
+		ctype, body := "text/plain", m.Text
+		if m.HTML != "" {
+			ctype, body = "text/html", m.HTML
+		}
+		fmt.Fprintf(&b, "Content-Type: %s; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n", ctype)
+		if err := writeQuoted(&b, body); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+	for _, part := range []struct{ ctype, body string }{{"text/plain", m.Text}, {"text/html", m.HTML}} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.ctype + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuoted(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeQuoted(w io.Writer, s string) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
@@ -0,0 +1,299 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mail is what a Sink received in one transaction.
+type Mail struct {
+	From string   // envelope sender
+	To   []string // envelope recipients
+	Data []byte   // the message, headers and body
+	User string   // who authenticated, "" for nobody
+	TLS  bool     // whether the session used STARTTLS
+}
+
+// Sink is an SMTP server that accepts every mail and keeps it instead of
+// delivering it, to try out a mailer without sending anything. It offers
+// STARTTLS, with a self-signed certificate made when it starts (see
+// ClientTLS), and AUTH PLAIN.
+type Sink struct {
+	// Users are the accepted usernames and passwords. With Users set, a
+	// client must authenticate before sending; nil accepts anyone.
+	Users map[string]string
+	// Received, if set, is called with every mail as it arrives.
+	Received func(Mail)
+	// Refuse, if set, is asked about every recipient. An error refuses
+	// it: with the code and message of a *textproto.Error, so that a 4xx
+	// code can say "try again later", or with 550 and the error's text.
+	Refuse func(rcpt string) error
+
+	l    net.Listener
+	tls  *tls.Config
+	cert *x509.Certificate
+
+	mu    sync.Mutex
+	mails []Mail
+	conns sync.WaitGroup
+}
+
+// Listen starts listening on addr ("127.0.0.1:0" for any free port);
+// Serve then accepts the connections.
+func Listen(addr string) (*Sink, error) {
+	cert, leaf, err := selfSigned()
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Sink{l: l, tls: &tls.Config{Certificates: []tls.Certificate{cert}}, cert: leaf}, nil
+}
+
+// Addr is the address the sink listens on.
+func (s *Sink) Addr() string { return s.l.Addr().String() }
+
+// Certificate is the self-signed certificate of the sink.
+func (s *Sink) Certificate() *x509.Certificate { return s.cert }
+
+// ClientTLS returns a TLS configuration for Config.TLS that trusts the
+// sink's certificate.
+func (s *Sink) ClientTLS() *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.cert)
+	return &tls.Config{RootCAs: pool}
+}
+
+// Mails returns the mails received so far.
+func (s *Sink) Mails() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mail(nil), s.mails...)
+}
+
+// Serve handles connections until Close.
+func (s *Sink) Serve() error {
+	for {
+		conn, err := s.l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.conns.Add(1)
+		go func() {
+			defer s.conns.Done()
+			defer conn.Close()
+			s.session(conn)
+		}()
+	}
+}
+
+// Close stops listening and waits for open sessions to end.
+func (s *Sink) Close() error {
+	err := s.l.Close()
+	s.conns.Wait()
+	return err
+}
+
+// session speaks SMTP (RFC 5321) on one connection, just enough of it for
+// net/smtp and common mail programs.
+func (s *Sink) session(conn net.Conn) {
+	conn.SetDeadline(time.Now().Add(5 * time.Minute))
+	tc := textproto.NewConn(conn)
+	var (
+		mail   Mail
+		inMail bool
+		user   string
+		secure bool
+	)
+	tc.PrintfLine("220 localhost mailer.Sink ready")
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			tc.PrintfLine("250 localhost")
+		case "EHLO":
+			ext := []string{"localhost", "8BITMIME", "AUTH PLAIN"}
+			if !secure {
+				ext = append(ext, "STARTTLS")
+			}
+			for i, e := range ext {
+				sep := "-"
+				if i == len(ext)-1 {
+					sep = " "
+				}
+				tc.PrintfLine("250%s%s", sep, e)
+			}
+		case "STARTTLS":
+			if secure {
+				tc.PrintfLine("503 5.5.1 TLS already active")
+				continue
+			}
+			tc.PrintfLine("220 2.0.0 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			tc = textproto.NewConn(conn)
+			user, inMail = "", false // the client starts over with EHLO
+		case "AUTH":
+			mech, initial, _ := strings.Cut(arg, " ")
+			if !strings.EqualFold(mech, "PLAIN") {
+				tc.PrintfLine("504 5.5.4 only PLAIN")
+				continue
+			}
+			if initial == "" {
+				tc.PrintfLine("334 ")
+				if initial, err = tc.ReadLine(); err != nil {
+					return
+				}
+			}
+			name, ok := s.checkPlain(initial)
+			if !ok {
+				tc.PrintfLine("535 5.7.8 authentication failed")
+				continue
+			}
+			user = name
+			tc.PrintfLine("235 2.7.0 authenticated")
+		case "MAIL":
+			if s.Users != nil && user == "" {
+				tc.PrintfLine("530 5.7.0 authentication required")
+				continue
+			}
+			mail, inMail = Mail{From: address(arg), User: user, TLS: secure}, true
+			tc.PrintfLine("250 2.1.0 OK")
+		case "RCPT":
+			if !inMail {
+				tc.PrintfLine("503 5.5.1 MAIL first")
+				continue
+			}
+			rcpt := address(arg)
+			if s.Refuse != nil {
+				if err := s.Refuse(rcpt); err != nil {
+					code, msg := 550, err.Error()
+					var te *textproto.Error
+					if errors.As(err, &te) {
+						code, msg = te.Code, te.Msg
+					}
+					tc.PrintfLine("%d %s", code, msg)
+					continue
+				}
+			}
+			mail.To = append(mail.To, rcpt)
+			tc.PrintfLine("250 2.1.5 OK")
+		case "DATA":
+			if !inMail || len(mail.To) == 0 {
+				tc.PrintfLine("503 5.5.1 MAIL and RCPT first")
+				continue
+			}
+			tc.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(tc.DotReader())
+			if err != nil {
+				return
+			}
+			mail.Data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			s.mu.Unlock()
+			if s.Received != nil {
+				s.Received(mail)
+			}
+			inMail = false
+			tc.PrintfLine("250 2.0.0 OK, kept")
+		case "RSET":
+			inMail = false
+			tc.PrintfLine("250 2.0.0 OK")
+		case "NOOP":
+			tc.PrintfLine("250 2.0.0 OK")
+		case "QUIT":
+			tc.PrintfLine("221 2.0.0 bye")
+			return
+		default:
+			tc.PrintfLine("502 5.5.2 command not implemented")
+		}
+	}
+}
+
+// checkPlain checks the base64 "authzid NUL user NUL password" of AUTH PLAIN.
+func (s *Sink) checkPlain(resp string) (string, bool) {
+	raw, err := base64.StdEncoding.DecodeString(resp)
+	if err != nil {
+		return "", false
+	}
+	parts := strings.Split(string(raw), "\x00")
+	if len(parts) != 3 || parts[1] == "" {
+		return "", false
+	}
+	if s.Users != nil {
+		if pw, ok := s.Users[parts[1]]; !ok || pw != parts[2] {
+			return "", false
+		}
+	}
+	return parts[1], true
+}
+
+// address returns the address of "FROM:<a@b>" or "TO:<a@b> SIZE=..".
+func address(arg string) string {
+	_, a, _ := strings.Cut(arg, ":")
+	a = strings.TrimSpace(a)
+	if i := strings.IndexByte(a, '>'); i >= 0 {
+		a = a[:i+1]
+	}
+	return strings.Trim(a, "<>")
+}
+
+// selfSigned makes a certificate for localhost that is valid for a day.
+func selfSigned() (tls.Certificate, *x509.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "mailer.Sink"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, leaf, nil
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Instrument puts /healthz and /metrics in front of h, the handler of a
+// server (nil for http.DefaultServeMux, as in http.Server):
+//
+//	srv := &http.Server{Addr: addr, Handler: metrics.Instrument(metrics.NewRegistry(), mux)}
+//
+// GET /healthz answers 200 "ok" while the server runs, GET /metrics
+// writes reg. Every other request goes to h and is counted by method,
+// route and status, timed in a histogram, and counted while in flight.
+// The route is the pattern of h that matches the request, "none" if none
+// does, or "" when h is not a *http.ServeMux. session4/ex42 does the same
+// by hand, with health checks of its own.
+func Instrument(reg *Registry, h http.Handler) http.Handler {
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+	requests := reg.Counter("http_requests_total", "HTTP requests answered.", "method", "route", "status")
+	duration := reg.Histogram("http_request_duration_seconds", "Time taken to answer HTTP requests.", DefBuckets, "method", "route")
+	inFlight := reg.Gauge("http_requests_in_flight", "HTTP requests being answered.")
+	mux, _ := h.(*http.ServeMux)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/healthz":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte("ok\n"))
+			return
+		case r.Method == http.MethodGet && r.URL.Path == "/metrics":
+			reg.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		route := ""
+		if mux != nil {
+			if _, route = mux.Handler(r); route == "" {
+				route = "none"
+			}
+		}
+		inFlight.Inc()
+		defer inFlight.Dec()
+		rec := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r)
+		requests.Inc(r.Method, route, rec.code())
+		duration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
+
+// statusRecorder remembers the status code of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(p)
+}
+
+// Flush lets handlers that stream, with w.(http.Flusher), flush through
+// the recorder.
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController the ResponseWriter underneath.
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+// code is the status as a label value; a handler that writes nothing answers 200
+func (s *statusRecorder) code() string {
+	if s.status == 0 {
+		return "200"
+	}
+	return strconv.Itoa(s.status)
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serve(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+// TestInstrument counts requests by the pattern that matched, and
+// answers /healthz and /metrics itself.
+func TestInstrument(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	reg := NewRegistry()
+	h := Instrument(reg, mux)
+	for _, path := range []string{"/users/1", "/users/2", "/users/3"} {
+		if w := serve(h, "GET", path); w.Code != http.StatusOK {
+			t.Errorf("GET %s: %d", path, w.Code)
+		}
+	}
+	serve(h, "POST", "/users")
+	serve(h, "GET", "/nowhere")
+
+	if w := serve(h, "GET", "/healthz"); w.Code != http.StatusOK || w.Body.String() != "ok\n" {
+		t.Errorf("GET /healthz: %d %q, want 200 ok", w.Code, w.Body.String())
+	}
+	w := serve(h, "GET", "/metrics")
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="GET /users/{id}",status="200"} 3`,
+		`http_requests_total{method="POST",route="POST /users",status="201"} 1`,
+		`http_requests_total{method="GET",route="none",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="GET /users/{id}"} 3`,
+		"http_requests_in_flight 0",
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("/metrics has no %s in\n%s", want, w.Body.String())
+		}
+	}
+	if strings.Contains(w.Body.String(), "/healthz") {
+		t.Error("/healthz is counted")
+	}
+}
+
+// TestInstrumentHandler labels the requests of a handler that is not a
+// ServeMux with an empty route.
+func TestInstrumentHandler(t *testing.T) {
+	reg := NewRegistry()
+	h := Instrument(reg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush() // streaming still works through the recorder
+	}))
+	serve(h, "GET", "/anything")
+	w := serve(h, "GET", "/metrics")
+	if want := `http_requests_total{method="GET",route="",status="200"} 1`; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("/metrics has no %s in\n%s", want, w.Body.String())
+	}
+}
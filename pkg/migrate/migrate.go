@@ -0,0 +1,267 @@
+// Package migrate applies versioned SQL migrations to a database/sql
+// database. Migrations are files named
+//
+//	0001_create_guests.up.sql
+//	0001_create_guests.down.sql
+//
+// The number is the version, "up" applies the change, "down" reverts it.
+// Applied versions are recorded in a schema_versions table, so running Up
+// twice is harmless. Every migration runs in its own transaction.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is one schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string // may be empty: the migration can't be reverted
+}
+
+// Status describes one migration and whether it has been applied.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var (
+	fileRe = regexp.MustCompile(`^(\d+)_([^.]+)\.(up|down)\.sql$`)
+
+	ErrNoDown = errors.New("migrate: migration has no down script")
+)
+
+// Load reads all migrations in dir of fsys (use os.DirFS for a directory
+// on disk), sorted by version.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		m := fileRe.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migrate: version %d used by %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+	var out []Migration
+	for _, mig := range byVersion {
+		if strings.TrimSpace(mig.Up) == "" {
+			return nil, fmt.Errorf("migrate: version %d (%s) has no up script", mig.Version, mig.Name)
+		}
+		out = append(out, *mig)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Migrator applies migrations to DB.
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+	Table      string // defaults to "schema_versions"
+
+	// Log, if set, is called for every migration that is applied or reverted.
+	Log func(format string, args ...interface{})
+}
+
+func (m *Migrator) table() string {
+	if m.Table == "" {
+		return "schema_versions"
+	}
+	return m.Table
+}
+
+func (m *Migrator) logf(format string, args ...interface{}) {
+	if m.Log != nil {
+		m.Log(format, args...)
+	}
+}
+
+func (m *Migrator) init(ctx context.Context) error {
+	_, err := m.DB.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+m.table()+` (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	return err
+}
+
+// applied returns the applied versions and when they were applied.
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := m.DB.QueryContext(ctx, `SELECT version, applied_at FROM `+m.table())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int]time.Time{}
+	for rows.Next() {
+		var v int
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		out[v] = at
+	}
+	return out, rows.Err()
+}
+
+// Status lists every known migration in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	done, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []Status
+	for _, mig := range m.Migrations {
+		at, ok := done[mig.Version]
+		out = append(out, Status{Migration: mig, Applied: ok, AppliedAt: at})
+	}
+	return out, nil
+}
+
+// Version returns the highest applied version, 0 for an empty database.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	done, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	max := 0
+	for v := range done {
+		if v > max {
+			max = v
+		}
+	}
+	return max, nil
+}
+
+// Up applies all pending migrations up to and including target;
+// target 0 means all of them. It returns how many were applied.
+func (m *Migrator) Up(ctx context.Context, target int) (int, error) {
+	done, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, mig := range m.Migrations {
+		if target > 0 && mig.Version > target {
+			break
+		}
+		if _, ok := done[mig.Version]; ok {
+			continue
+		}
+		err := m.inTx(ctx, mig.Up, `INSERT INTO `+m.table()+` (version, name, applied_at) VALUES (?, ?, ?)`,
+			mig.Version, mig.Name, time.Now().UTC())
+		if err != nil {
+			return n, fmt.Errorf("migrate: up %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		m.logf("applied %04d_%s", mig.Version, mig.Name)
+		n++
+	}
+	return n, nil
+}
+
+// Down reverts the last steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	done, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for i := len(m.Migrations) - 1; i >= 0 && n < steps; i-- {
+		mig := m.Migrations[i]
+		if _, ok := done[mig.Version]; !ok {
+			continue
+		}
+		if strings.TrimSpace(mig.Down) == "" {
+			return n, fmt.Errorf("%w: %04d_%s", ErrNoDown, mig.Version, mig.Name)
+		}
+		err := m.inTx(ctx, mig.Down, `DELETE FROM `+m.table()+` WHERE version = ?`, mig.Version)
+		if err != nil {
+			return n, fmt.Errorf("migrate: down %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		m.logf("reverted %04d_%s", mig.Version, mig.Name)
+		n++
+	}
+	return n, nil
+}
+
+// inTx runs a script and the bookkeeping statement in one transaction, so
+// a failing migration leaves neither a half-applied schema (on databases
+// with transactional DDL) nor a wrong version row.
+func (m *Migrator) inTx(ctx context.Context, script, record string, args ...interface{}) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // no-op after Commit
+	for _, stmt := range splitStatements(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// splitStatements cuts a script at semicolons that end a line. Keeping
+// every statement separate works with drivers that execute only one
+// statement per call.
+func splitStatements(script string) []string {
+	var out []string
+	var cur strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		cur.WriteString(line)
+		cur.WriteByte('\n')
+		if strings.HasSuffix(trimmed, ";") {
+			if s := strings.TrimSpace(cur.String()); s != ";" {
+				out = append(out, s)
+			}
+			cur.Reset()
+		}
+	}
+	if s := strings.TrimSpace(cur.String()); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
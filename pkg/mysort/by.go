@@ -0,0 +1,38 @@
+package mysort
+
+// Lexicographic combines less functions into one that sorts by the first,
+// then, among elements the first finds equal, by the second, and so on,
+// the way a dictionary sorts by the first letter, then the second. i and
+// j are equal for a less function if neither less(i, j) nor less(j, i).
+func Lexicographic(less ...func(i, j int) bool) func(i, j int) bool {
+	return func(i, j int) bool {
+		for _, l := range less {
+			switch {
+			case l(i, j):
+				return true
+			case l(j, i):
+				return false
+			}
+		}
+		return false // equal by every key
+	}
+}
+
+// By returns data sorted by the given keys instead of by its own Less:
+//
+//	mysort.Sort(mysort.By(people,
+//		func(i, j int) bool { return people[i].last < people[j].last },
+//		func(i, j int) bool { return people[i].first < people[j].first },
+//	))
+//
+// The less functions index the same elements data swaps.
+func By(data Interface, less ...func(i, j int) bool) Interface {
+	return byKeys{data, Lexicographic(less...)}
+}
+
+type byKeys struct {
+	Interface // Len and Swap
+	less      func(i, j int) bool
+}
+
+func (b byKeys) Less(i, j int) bool { return b.less(i, j) }
@@ -0,0 +1,30 @@
+package mysort
+
+import "fmt"
+
+// CountingSortInts sorts a, whose elements must all lie between min and
+// max inclusive, without comparing them: it counts how often each value
+// occurs and then writes the values out again in order. That is one pass
+// over a and one over the max-min+1 counts, O(n+k) for k possible values,
+// so it is linear when the range is small next to len(a): dice rolls,
+// ages, grades. The counts take memory for the whole range, used or not.
+// It panics if max < min or an element lies outside the range.
+func CountingSortInts(a []int, min, max int) {
+	if max < min {
+		panic(fmt.Sprintf("mysort: CountingSortInts range [%d, %d] is empty", min, max))
+	}
+	count := make([]int, max-min+1)
+	for _, v := range a {
+		if v < min || v > max {
+			panic(fmt.Sprintf("mysort: CountingSortInts value %d outside [%d, %d]", v, min, max))
+		}
+		count[v-min]++
+	}
+	i := 0
+	for k, c := range count {
+		for ; c > 0; c-- {
+			a[i] = min + k
+			i++
+		}
+	}
+}
@@ -0,0 +1,228 @@
+// Package external sorts text files too large to sort in memory, the way
+// sort(1) does: it reads as many lines as fit into Options.ChunkSize,
+// sorts them, writes them to a temporary file, and goes on until the
+// input is used up. Then a k-way merge reads all temporary files at once
+// and writes the smallest of their next lines, again and again; a heap
+// picks it in O(log(k)) for k files. Memory use is about one chunk, and
+// every line is read and written twice.
+package external
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hannansatopay/training-golang/pkg/mysort"
+)
+
+// Options tune a sort; the zero value works.
+type Options struct {
+	ChunkSize int64  // bytes of lines sorted in memory at once; default 64 MiB
+	TempDir   string // where the chunks go; default os.TempDir()
+
+	// Less orders the lines, without their newline; default byte order.
+	Less func(a, b string) bool
+}
+
+// Stats tells what a sort did.
+type Stats struct {
+	Lines  int64
+	Chunks int // temporary files written, 0 if the input fit into one chunk
+}
+
+const defaultChunkSize = 64 << 20
+
+// Sort writes the lines of src to dst in order. Every line written ends
+// in a newline, the last one too.
+func Sort(dst io.Writer, src io.Reader, opt Options) (Stats, error) {
+	if opt.ChunkSize <= 0 {
+		opt.ChunkSize = defaultChunkSize
+	}
+	if opt.Less == nil {
+		opt.Less = func(a, b string) bool { return a < b }
+	}
+	var st Stats
+	var chunks []string
+	defer func() {
+		for _, name := range chunks {
+			os.Remove(name)
+		}
+	}()
+
+	r := bufio.NewReaderSize(src, 1<<20)
+	var lines []string
+	for {
+		var size int64
+		lines = lines[:0]
+		var err error
+		for size < opt.ChunkSize {
+			var line string
+			line, err = readLine(r)
+			if err != nil {
+				break
+			}
+			lines = append(lines, line)
+			size += int64(len(line)) + 16 // and the string header
+		}
+		if err != nil && err != io.EOF {
+			return st, err
+		}
+		st.Lines += int64(len(lines))
+		mysort.Sort(lessLines{lines, opt.Less})
+		if err == io.EOF && len(chunks) == 0 {
+			return st, writeLines(dst, lines) // all of it fit into memory
+		}
+		if len(lines) > 0 {
+			name, werr := writeChunk(opt.TempDir, lines)
+			if werr != nil {
+				return st, werr
+			}
+			chunks = append(chunks, name)
+			st.Chunks++
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	return st, merge(dst, chunks, opt.Less)
+}
+
+// SortFile sorts the file src into the file dst, which may be the same.
+func SortFile(dst, src string, opt Options) (Stats, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer in.Close()
+	// write next to the destination and rename: dst may be src, and a
+	// failed sort leaves it as it was
+	out, err := os.CreateTemp(filepath.Dir(dst), ".sort-*")
+	if err != nil {
+		return Stats{}, err
+	}
+	defer os.Remove(out.Name())
+	w := bufio.NewWriterSize(out, 1<<20)
+	st, err := Sort(w, in, opt)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return st, err
+	}
+	return st, os.Rename(out.Name(), dst)
+}
+
+// readLine returns the next line without its newline, or io.EOF after
+// the last one. The last line need not end in a newline.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		return line, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return line[:len(line)-1], nil
+}
+
+func writeLines(w io.Writer, lines []string) error {
+	bw := bufio.NewWriterSize(w, 1<<20)
+	for _, line := range lines {
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// writeChunk writes sorted lines to a new temporary file and returns its name.
+func writeChunk(dir string, lines []string) (string, error) {
+	f, err := os.CreateTemp(dir, "chunk-*")
+	if err != nil {
+		return "", err
+	}
+	err = writeLines(f, lines)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// source is a chunk being merged and its next line.
+type source struct {
+	r    *bufio.Reader
+	line string
+}
+
+// sources is a min-heap of the chunks by their next line, for
+// container/heap.
+type sources struct {
+	s    []*source
+	less func(a, b string) bool
+}
+
+func (h *sources) Len() int           { return len(h.s) }
+func (h *sources) Less(i, j int) bool { return h.less(h.s[i].line, h.s[j].line) }
+func (h *sources) Swap(i, j int)      { h.s[i], h.s[j] = h.s[j], h.s[i] }
+func (h *sources) Push(x any)         { h.s = append(h.s, x.(*source)) }
+func (h *sources) Pop() any {
+	x := h.s[len(h.s)-1]
+	h.s = h.s[:len(h.s)-1]
+	return x
+}
+
+// merge writes the lines of the sorted chunks to w in order.
+func merge(w io.Writer, chunks []string, less func(a, b string) bool) error {
+	h := &sources{less: less}
+	for _, name := range chunks {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		// the chunks share the memory of one: small buffers for many files
+		s := &source{r: bufio.NewReaderSize(f, 64<<10)}
+		if s.line, err = readLine(s.r); err != nil {
+			return fmt.Errorf("external: chunk %s: %v", name, err)
+		}
+		h.s = append(h.s, s)
+	}
+	heap.Init(h)
+
+	bw := bufio.NewWriterSize(w, 1<<20)
+	for h.Len() > 0 {
+		s := h.s[0]
+		bw.WriteString(s.line)
+		bw.WriteByte('\n')
+		line, err := readLine(s.r)
+		switch {
+		case err == io.EOF:
+			heap.Pop(h)
+		case err != nil:
+			return err
+		default:
+			s.line = line
+			heap.Fix(h, 0)
+		}
+	}
+	return bw.Flush()
+}
+
+// lessLines sorts a chunk with mysort.
+type lessLines struct {
+	lines []string
+	less  func(a, b string) bool
+}
+
+func (l lessLines) Len() int           { return len(l.lines) }
+func (l lessLines) Less(i, j int) bool { return l.less(l.lines[i], l.lines[j]) }
+func (l lessLines) Swap(i, j int)      { l.lines[i], l.lines[j] = l.lines[j], l.lines[i] }
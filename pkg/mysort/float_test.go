@@ -0,0 +1,86 @@
+package mysort
+
+import (
+	"math"
+	"testing"
+)
+
+var (
+	nan  = math.NaN()
+	inf  = math.Inf(1)
+	ninf = math.Inf(-1)
+)
+
+// same reports whether a and b hold the same floats, NaN equal to NaN
+func same(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] && !(math.IsNaN(a[i]) && math.IsNaN(b[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestSortFloat64s pins the order Float64Slice documents: NaN first,
+// then -Inf, the numbers, +Inf.
+func TestSortFloat64s(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		in, out []float64
+	}{
+		{"infinities", []float64{inf, 1, ninf, -1}, []float64{ninf, -1, 1, inf}},
+		{"NaN first", []float64{3, nan, 1, 2}, []float64{nan, 1, 2, 3}},
+		{"NaN last", []float64{2, 1, nan}, []float64{nan, 1, 2}},
+		{"all of them", []float64{inf, nan, 0, ninf, nan, -2.5, inf, 1e300}, []float64{nan, nan, ninf, -2.5, 0, 1e300, inf, inf}},
+		{"only NaN", []float64{nan, nan}, []float64{nan, nan}},
+		{"only infinities", []float64{inf, ninf, inf, ninf}, []float64{ninf, ninf, inf, inf}},
+		{"empty", []float64{}, []float64{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, sort := range map[string]func([]float64){
+				"Sort":   SortFloat64s,
+				"Stable": func(a []float64) { Stable(Float64Slice(a)) },
+			} {
+				a := append([]float64{}, tc.in...)
+				sort(a)
+				if !same(a, tc.out) {
+					t.Errorf("%s(%v) = %v, want %v", name, tc.in, a, tc.out)
+				}
+				if !Float64sAreSorted(a) {
+					t.Errorf("%s(%v): Float64sAreSorted(%v) = false", name, tc.in, a)
+				}
+			}
+		})
+	}
+	if Float64sAreSorted([]float64{1, nan}) {
+		t.Error("Float64sAreSorted([1 NaN]) = true, want NaN first")
+	}
+}
+
+// TestMedianFloat64 checks the median with NaN, which counts as smaller
+// than every number, and with infinities.
+func TestMedianFloat64(t *testing.T) {
+	for _, tc := range []struct {
+		in   []float64
+		want float64
+	}{
+		{[]float64{}, nan},
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+		{[]float64{nan, 1, 2}, 1},
+		{[]float64{nan, nan, 5}, nan},
+		{[]float64{nan, 1, 2, 3}, 1.5},
+		{[]float64{ninf, 0, inf}, 0},
+		{[]float64{inf, inf, 1}, inf},
+		{[]float64{ninf, inf}, nan}, // -Inf + +Inf
+		{[]float64{ninf, 1, 2, inf}, 1.5},
+	} {
+		in := append([]float64{}, tc.in...)
+		if got := Float64Slice(in).Median(); !same([]float64{got}, []float64{tc.want}) {
+			t.Errorf("Median(%v) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
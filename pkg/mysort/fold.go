@@ -0,0 +1,66 @@
+package mysort
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// FoldStringSlice sorts strings without regard to case, so that "monday"
+// and "Monday" end up next to each other instead of all capitals before
+// all small letters. Strings that differ only in case are ordered as by
+// StringSlice, capitals first. Letters are compared by their code point,
+// so "é" still comes after "z"; CollatedStringSlice can sort by the rules
+// of a language.
+type FoldStringSlice []string
+
+func (p FoldStringSlice) Len() int { return len(p) }
+
+func (p FoldStringSlice) Less(i, j int) bool {
+	if c := foldCompare(p[i], p[j]); c != 0 {
+		return c < 0
+	}
+	return p[i] < p[j]
+}
+
+func (p FoldStringSlice) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+// foldCompare compares a and b rune by rune in lower case, without
+// making lower-case copies of them.
+func foldCompare(a, b string) int {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if la, lb := unicode.ToLower(ra), unicode.ToLower(rb); la != lb {
+			if la < lb {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[na:], b[nb:]
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// CollatedStringSlice sorts Strings by Compare, which returns a negative
+// number, zero or a positive number when a sorts before, with or after b.
+// It is the hook for orders this package does not know, like the rules
+// of a language from golang.org/x/text/collate:
+//
+//	c := collate.New(language.German)
+//	mysort.Sort(mysort.CollatedStringSlice{Strings: names, Compare: c.CompareString})
+type CollatedStringSlice struct {
+	Strings []string
+	Compare func(a, b string) int
+}
+
+func (p CollatedStringSlice) Len() int { return len(p.Strings) }
+
+func (p CollatedStringSlice) Less(i, j int) bool { return p.Compare(p.Strings[i], p.Strings[j]) < 0 }
+
+func (p CollatedStringSlice) Swap(i, j int) { p.Strings[i], p.Strings[j] = p.Strings[j], p.Strings[i] }
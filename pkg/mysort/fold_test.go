@@ -0,0 +1,67 @@
+package mysort
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestFoldCompare ignores case, also of accented letters, but compares
+// code points: é stays after z.
+func TestFoldCompare(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"monday", "Monday", 0},
+		{"MONDAY", "monday", 0},
+		{"apple", "Banana", -1},
+		{"Zebra", "apple", 1},
+		{"École", "école", 0}, // É folds to é
+		{"ÉCOLE", "école", 0},
+		{"élan", "zoo", 1},       // code points: é after z
+		{"straße", "STRASSE", 1}, // no full case folding: ß is not ss
+		{"Ǆemal", "ǆemal", 0},    // a letter with three cases
+		{"ab", "AB c", -1},
+		{"", "a", -1},
+	} {
+		if got := foldCompare(tc.a, tc.b); got != tc.want {
+			t.Errorf("foldCompare(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+// TestFoldSort puts the spellings of a word together, capitals first.
+func TestFoldSort(t *testing.T) {
+	for _, tc := range []struct{ in, want []string }{
+		{
+			[]string{"tuesday", "Monday", "monday", "Tuesday", "MONDAY"},
+			[]string{"MONDAY", "Monday", "monday", "Tuesday", "tuesday"},
+		},
+		{
+			[]string{"zoo", "Émile", "emile", "émile", "Zoo"},
+			[]string{"emile", "Zoo", "zoo", "Émile", "émile"},
+		},
+	} {
+		got := slices.Clone(tc.in)
+		Sort(FoldStringSlice(got))
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("Sort(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestCollated sorts with a compare function that reads accented letters
+// as plain ones, the hook for the rules of a language.
+func TestCollated(t *testing.T) {
+	plain := strings.NewReplacer("é", "e", "É", "E", "è", "e", "ü", "u", "Ü", "U")
+	compare := func(a, b string) int {
+		return foldCompare(plain.Replace(a), plain.Replace(b))
+	}
+	got := []string{"zoo", "Émile", "Ulrich", "ecole", "Über", "fée"}
+	Sort(CollatedStringSlice{Strings: got, Compare: compare})
+	want := []string{"ecole", "Émile", "fée", "Über", "Ulrich", "zoo"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package mysort
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// The fuzz targets check the two promises of every sort: the result is
+// in order, and it holds the same elements as the input. Run them with
+// trainer fuzz pkg/mysort, or after changing a sort, trainer fuzz -seeds
+// for the inputs that once failed.
+
+// ints turns fuzz input into ints: single bytes, read as int8, give many
+// duplicates and negative numbers; after a 0xff byte, eight bytes make
+// one int of any size.
+func ints(data []byte) []int {
+	var out []int
+	for len(data) > 0 {
+		if data[0] == 0xff && len(data) >= 9 {
+			out = append(out, int(binary.LittleEndian.Uint64(data[1:9])))
+			data = data[9:]
+			continue
+		}
+		out = append(out, int(int8(data[0])))
+		data = data[1:]
+	}
+	return out
+}
+
+// checkSorted fails t unless got is sorted and a permutation of in.
+func checkSorted(t *testing.T, name string, in, got []int, sorted bool) {
+	t.Helper()
+	if !sorted {
+		t.Errorf("%s: not sorted: %v (from %v)", name, got, in)
+	}
+	if !IsPermutation(in, got) {
+		t.Errorf("%s: elements lost or added: %v (from %v)", name, got, in)
+	}
+}
+
+// FuzzSortInts runs every sort of ints on the same input.
+func FuzzSortInts(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{5})
+	f.Add([]byte{3, 3, 3, 3, 1, 1, 1})
+	f.Add([]byte{0x80, 0x7f, 0, 0xfe})
+	f.Add(append([]byte{0xff, 0, 0, 0, 0, 0, 0, 0, 0x80}, bytes.Repeat([]byte{9, 2}, 40)...))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		in := ints(data)
+		sorts := map[string]func([]int){
+			"Sort":          func(a []int) { Sort(IntSlice(a)) },
+			"Stable":        func(a []int) { Stable(IntSlice(a)) },
+			"ParallelSort":  func(a []int) { ParallelSort(IntSlice(a)) },
+			"RadixSortInts": RadixSortInts,
+			"SortSlice":     SortSlice[int],
+			"Reverse":       func(a []int) { Sort(Reverse(Reverse(IntSlice(a)))) },
+		}
+		for name, sort := range sorts {
+			a := append([]int(nil), in...)
+			sort(a)
+			checkSorted(t, name, in, a, IntsAreSorted(a))
+		}
+		for _, k := range []int{0, min(1, len(in)), len(in) / 2, len(in)} {
+			a := append([]int(nil), in...)
+			PartialSort(IntSlice(a), k)
+			checkSorted(t, "PartialSort", in, a, IntsAreSorted(a[:k]))
+			for _, v := range a[k:] {
+				if k > 0 && v < a[k-1] {
+					t.Fatalf("PartialSort(%d): %d after the first k, less than %d", k, v, a[k-1])
+				}
+			}
+		}
+	})
+}
+
+// FuzzStable checks that Stable keeps equal elements in their order:
+// every element is sorted by its value and carries its input position.
+func FuzzStable(f *testing.F) {
+	f.Add([]byte{1, 0, 1, 0, 1, 0})
+	f.Add(bytes.Repeat([]byte{3, 1, 2}, 30))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		type elem struct{ key, pos int }
+		elems := make([]elem, len(data))
+		for i, b := range data {
+			elems[i] = elem{int(b % 8), i}
+		}
+		Stable(By(lessSlice[elem]{elems, nil}, func(i, j int) bool { return elems[i].key < elems[j].key }))
+		for i := 1; i < len(elems); i++ {
+			a, b := elems[i-1], elems[i]
+			if a.key > b.key || a.key == b.key && a.pos > b.pos {
+				t.Fatalf("not stable at %d: %v", i, elems)
+			}
+		}
+	})
+}
+
+// FuzzSortStrings sorts the lines of the input with every string order.
+func FuzzSortStrings(f *testing.F) {
+	f.Add("")
+	f.Add("b\na\nb\n\na")
+	f.Add("file10\nfile2\nfile02\nFile1\nfile\n007\n7")
+	f.Add("Émile\némile\nzed\nZed\n\xff\xfe")
+	f.Fuzz(func(t *testing.T, s string) {
+		in := bytes.Split([]byte(s), []byte("\n"))
+		lines := make([]string, len(in))
+		for i, l := range in {
+			lines[i] = string(l)
+		}
+		for name, data := range map[string]Interface{
+			"StringSlice":        StringSlice(append([]string(nil), lines...)),
+			"FoldStringSlice":    FoldStringSlice(append([]string(nil), lines...)),
+			"NaturalStringSlice": NaturalStringSlice(append([]string(nil), lines...)),
+		} {
+			Sort(data)
+			if !IsSorted(data) {
+				t.Errorf("%s: not sorted: %q", name, data)
+			}
+			// a consistent order: never both a < b and b < a
+			for i := 0; i < data.Len() && i < 50; i++ {
+				for j := 0; j < i; j++ {
+					if data.Less(i, j) && data.Less(j, i) {
+						t.Errorf("%s: %d and %d are less than each other: %q", name, i, j, data)
+					}
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package mysort
+
+import "cmp"
+
+// The generic functions below sort slices of any element type without a
+// type like IntSlice for each: the type parameter stands for the element
+// type, and the compiler checks that it can be compared. cmp.Ordered is
+// the constraint of the types that < works on (the standard library's
+// version of golang.org/x/exp/constraints.Ordered). They sort with Sort,
+// through an Interface made from the slice and less.
+
+// SortSlice sorts s in increasing order.
+func SortSlice[T cmp.Ordered](s []T) {
+	SortFunc(s, func(a, b T) bool { return a < b })
+}
+
+// SortFunc sorts s so that less(s[i+1], s[i]) is never true. Like Sort,
+// it makes no promise about the order of equal elements.
+func SortFunc[T any](s []T, less func(a, b T) bool) {
+	Sort(lessSlice[T]{s, less})
+}
+
+// lessSlice is the Interface of a slice and its less function.
+type lessSlice[T any] struct {
+	s    []T
+	less func(a, b T) bool
+}
+
+func (l lessSlice[T]) Len() int           { return len(l.s) }
+func (l lessSlice[T]) Less(i, j int) bool { return l.less(l.s[i], l.s[j]) }
+func (l lessSlice[T]) Swap(i, j int)      { l.s[i], l.s[j] = l.s[j], l.s[i] }
+
+// SliceIsSorted reports whether s is sorted in increasing order.
+func SliceIsSorted[T cmp.Ordered](s []T) bool {
+	for i := len(s) - 1; i > 0; i-- {
+		if s[i] < s[i-1] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package mysort
+
+// Sort is an introsort (Musser, 1997): quicksort, which is fast on
+// average, watched by a depth limit. Quicksort splits the data around a
+// pivot and sorts both sides; a bad pivot splits off only a few elements,
+// and with bad pivots all the way down it takes O(n*n) steps. Once the
+// splits are more than 2*log2(n) deep, the part left is sorted with
+// heapsort, which is never worse than O(n*log(n)). Short parts are sorted
+// by insertion.
+
+// insertionSortMax is the length up to which a part is sorted by insertion.
+const insertionSortMax = 12
+
+// maxDepth returns the depth of quicksort splits after which introSort
+// switches to heapsort: twice the number of times n can be halved.
+func maxDepth(n int) int {
+	depth := 0
+	for i := n; i > 0; i >>= 1 {
+		depth++
+	}
+	return depth * 2
+}
+
+// introSort sorts data[a:b].
+func introSort(data Interface, a, b, depth int) {
+	for b-a > insertionSortMax {
+		if depth == 0 {
+			heapSort(data, a, b)
+			return
+		}
+		depth--
+		p := partition(data, a, b)
+		// recurse into the smaller side and loop on the larger one, so
+		// the stack stays O(log(n)) deep
+		if p-a < b-p {
+			introSort(data, a, p, depth)
+			a = p + 1
+		} else {
+			introSort(data, p+1, b, depth)
+			b = p
+		}
+	}
+	insertionSort(data, a, b)
+}
+
+// partition picks the median of the first, middle and last element of
+// data[a:b] as pivot, moves the smaller elements before it and the
+// others after it, and returns where the pivot ended up. The median of
+// three makes sorted and reversed input split evenly.
+func partition(data Interface, a, b int) int {
+	m := int(uint(a+b) >> 1)
+	// order data[a], data[m], data[b-1]; the median is then at m
+	if data.Less(m, a) {
+		data.Swap(m, a)
+	}
+	if data.Less(b-1, m) {
+		data.Swap(b-1, m)
+		if data.Less(m, a) {
+			data.Swap(m, a)
+		}
+	}
+	data.Swap(a, m) // keep the pivot at a while partitioning
+	i, j := a+1, b-1
+	for {
+		for i <= j && data.Less(i, a) {
+			i++
+		}
+		for i <= j && data.Less(a, j) {
+			j--
+		}
+		if i >= j {
+			break
+		}
+		// equal elements are swapped too: many equal keys still split evenly
+		data.Swap(i, j)
+		i++
+		j--
+	}
+	data.Swap(a, j)
+	return j
+}
+
+// heapSort sorts data[a:b]: it makes the part a max-heap, then swaps the
+// largest element to the end and restores the heap for the rest, n times.
+func heapSort(data Interface, a, b int) {
+	n := b - a
+	for i := (n - 1) / 2; i >= 0; i-- {
+		siftDown(data, i, n, a)
+	}
+	for i := n - 1; i > 0; i-- {
+		data.Swap(a, a+i)
+		siftDown(data, 0, i, a)
+	}
+}
+
+// siftDown moves the element at root of the heap data[first:first+n]
+// down until both its children are smaller.
+func siftDown(data Interface, root, n, first int) {
+	for {
+		child := 2*root + 1
+		if child >= n {
+			return
+		}
+		if child+1 < n && data.Less(first+child, first+child+1) {
+			child++
+		}
+		if !data.Less(first+root, first+child) {
+			return
+		}
+		data.Swap(first+root, first+child)
+		root = child
+	}
+}
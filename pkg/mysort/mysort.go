@@ -0,0 +1,86 @@
+package mysort
+
+type Interface interface {
+	Len() int
+	Less(i, j int) bool
+	Swap(i, j int)
+}
+
+// Sort sorts data in O(n*log(n)) calls to Less and Swap, see introSort.
+// It makes no promise about the order of equal elements; use Stable to
+// keep them in order.
+func Sort(data Interface) {
+	n := data.Len()
+	introSort(data, 0, n, maxDepth(n))
+}
+
+func IsSorted(data Interface) bool {
+	n := data.Len()
+	for i := n - 1; i > 0; i-- {
+		if data.Less(i, i-1) {
+			return false
+		}
+	}
+	return true
+}
+
+type reverse struct {
+	// This embedded Interface permits Reverse to use the methods of
+	// another Interface implementation.
+	Interface
+}
+
+// Less returns the opposite of the embedded implementation's Less method.
+func (r reverse) Less(i, j int) bool {
+	return r.Interface.Less(j, i)
+}
+
+// Reverse returns the reverse order for data.
+func Reverse(data Interface) Interface {
+	return &reverse{data}
+}
+
+// Convenience types for common cases
+type IntSlice []int
+
+func (p IntSlice) Len() int { return len(p) }
+
+func (p IntSlice) Less(i, j int) bool { return p[i] < p[j] }
+
+func (p IntSlice) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+type StringSlice []string
+
+func (p StringSlice) Len() int { return len(p) }
+
+func (p StringSlice) Less(i, j int) bool { return p[i] < p[j] }
+
+func (p StringSlice) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+// Float64Slice sorts NaN (not-a-number) values before all others, -Inf
+// and +Inf where they belong. NaN is neither less nor greater than any
+// number, itself included, so a plain < would leave it where it was and
+// the numbers around it unsorted.
+type Float64Slice []float64
+
+func (p Float64Slice) Len() int { return len(p) }
+
+func (p Float64Slice) Less(i, j int) bool { return p[i] < p[j] || (isNaN(p[i]) && !isNaN(p[j])) }
+
+func (p Float64Slice) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+// isNaN is math.IsNaN without importing math: only NaN differs from itself.
+func isNaN(f float64) bool { return f != f }
+
+// Convenience wrappers for common cases
+func SortInts(a []int) { Sort(IntSlice(a)) }
+
+func SortStrings(a []string) { Sort(StringSlice(a)) }
+
+func SortFloat64s(a []float64) { Sort(Float64Slice(a)) }
+
+func IntsAreSorted(a []int) bool { return IsSorted(IntSlice(a)) }
+
+func StringsAreSorted(a []string) bool { return IsSorted(StringSlice(a)) }
+
+func Float64sAreSorted(a []float64) bool { return IsSorted(Float64Slice(a)) }
@@ -0,0 +1,69 @@
+package mysort
+
+// NaturalStringSlice sorts strings the way people count: numbers inside
+// them are compared by value, so "file2" comes before "file10", where
+// StringSlice puts "file10" first because '1' < '2'. The rest is compared
+// byte by byte, as StringSlice does. Of numbers with the same value,
+// "7" comes before "07" before "007".
+type NaturalStringSlice []string
+
+func (p NaturalStringSlice) Len() int { return len(p) }
+
+func (p NaturalStringSlice) Less(i, j int) bool { return naturalLess(p[i], p[j]) }
+
+func (p NaturalStringSlice) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+// naturalLess walks both strings at once, a run of digits or a single
+// other byte at a time.
+func naturalLess(a, b string) bool {
+	tie := 0 // the first difference in leading zeros: -1 if a has fewer
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			na, nb := digits(a), digits(b)
+			// without leading zeros, a longer number is the larger one,
+			// and of two numbers of the same length the one that is
+			// larger as a string; this works for any number of digits
+			va, vb := trimZeros(a[:na]), trimZeros(b[:nb])
+			if len(va) != len(vb) {
+				return len(va) < len(vb)
+			}
+			if va != vb {
+				return va < vb
+			}
+			if tie == 0 && na != nb {
+				tie = -1
+				if na > nb {
+					tie = 1
+				}
+			}
+			a, b = a[na:], b[nb:]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	if len(a) != len(b) {
+		return len(a) < len(b) // a prefix comes first
+	}
+	return tie < 0
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// digits returns the length of the run of digits at the start of s.
+func digits(s string) int {
+	n := 0
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+	return n
+}
+
+func trimZeros(s string) string {
+	for len(s) > 1 && s[0] == '0' {
+		s = s[1:]
+	}
+	return s
+}
@@ -0,0 +1,59 @@
+package mysort
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestNaturalLess compares numbers by value and the rest byte by byte,
+// in mixed case and with accented letters.
+func TestNaturalLess(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		less bool
+	}{
+		{"file2", "file10", true},
+		{"file10", "file2", false},
+		{"file10", "file10", false},
+		{"7", "07", true},
+		{"07", "007", true},
+		{"007", "7", false},
+		{"a07b", "a7c", true}, // the numbers tie, the letters decide
+		{"x99999999999999999999", "x100000000000000000000", true}, // longer than any int
+		{"File10", "file2", true},                                 // byte by byte: capitals first
+		{"file2", "File10", false},
+		{"Übung2", "Übung10", true}, // the digits after an accented letter
+		{"café10", "café9", false},
+		{"café", "cafe", false}, // é is two bytes, both above e
+		{"abc", "abc1", true},   // a prefix comes first
+		{"", "0", true},
+	} {
+		if got := naturalLess(tc.a, tc.b); got != tc.less {
+			t.Errorf("naturalLess(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.less)
+		}
+	}
+}
+
+// TestNaturalSort sorts file names and headings as people count.
+func TestNaturalSort(t *testing.T) {
+	for _, tc := range []struct{ in, want []string }{
+		{
+			[]string{"img12.png", "img10.png", "IMG2.png", "img2.png", "img1.png"},
+			[]string{"IMG2.png", "img1.png", "img2.png", "img10.png", "img12.png"},
+		},
+		{
+			[]string{"Kapitel 10", "Kapitel 9", "Kapitel 010", "Kapitel 1"},
+			[]string{"Kapitel 1", "Kapitel 9", "Kapitel 10", "Kapitel 010"},
+		},
+		{
+			[]string{"résumé 3", "résumé 20", "resume 20", "Résumé 1"},
+			[]string{"Résumé 1", "resume 20", "résumé 3", "résumé 20"},
+		},
+	} {
+		got := slices.Clone(tc.in)
+		Sort(NaturalStringSlice(got))
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("Sort(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
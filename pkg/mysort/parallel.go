@@ -0,0 +1,42 @@
+package mysort
+
+import (
+	"runtime"
+	"sync"
+)
+
+// parallelThreshold is the length below which ParallelSort sorts a part
+// on its own goroutine instead of splitting it further: starting a
+// goroutine and merging costs more than it saves on small parts.
+const parallelThreshold = 1 << 13
+
+// ParallelSort sorts data like Stable, spreading the work over up to
+// GOMAXPROCS goroutines: the two halves of data are sorted at the same
+// time, each split again the same way, and then merged in place. Inputs
+// shorter than parallelThreshold are sorted by Stable alone.
+//
+// The goroutines only ever call Less and Swap on indexes in separate
+// parts of data, so data must allow that, as a slice does; an Interface
+// that keeps other state, like a counter of swaps, must lock it.
+func ParallelSort(data Interface) {
+	parallelSort(data, 0, data.Len(), runtime.GOMAXPROCS(0))
+}
+
+// parallelSort sorts data[a:b] with procs goroutines, the caller's one
+// included.
+func parallelSort(data Interface, a, b, procs int) {
+	if b-a < parallelThreshold || procs < 2 {
+		stable(data, a, b)
+		return
+	}
+	m := int(uint(a+b) >> 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		parallelSort(data, a, m, procs/2)
+	}()
+	parallelSort(data, m, b, procs-procs/2)
+	wg.Wait()
+	symMerge(data, a, m, b)
+}
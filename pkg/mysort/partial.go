@@ -0,0 +1,28 @@
+package mysort
+
+// PartialSort moves the k smallest elements of data to its front, in
+// order; the order of the rest is unspecified. It keeps the k smallest
+// elements seen so far in a max-heap at the front and lets each later
+// element in only if it is less than the largest of them, which takes
+// O(n*log(k)) steps instead of the O(n*log(n)) of a full Sort: the top 10
+// of 100000 takes about a twentieth of the comparisons. k larger than
+// data.Len() sorts everything.
+func PartialSort(data Interface, k int) {
+	n := data.Len()
+	if k > n {
+		k = n
+	}
+	if k <= 0 {
+		return
+	}
+	for i := (k - 1) / 2; i >= 0; i-- {
+		siftDown(data, i, k, 0)
+	}
+	for i := k; i < n; i++ {
+		if data.Less(i, 0) {
+			data.Swap(0, i)
+			siftDown(data, 0, k, 0)
+		}
+	}
+	heapSort(data, 0, k)
+}
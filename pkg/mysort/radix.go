@@ -0,0 +1,47 @@
+package mysort
+
+// RadixSortInts sorts a in increasing order without comparing elements:
+// it distributes them by their lowest byte, then stably by the next one,
+// and so on up to the highest, eight passes of O(n) each for 64-bit ints.
+// A pass in which all elements share the byte is skipped, so small
+// numbers take fewer passes. It needs a second slice as large as a and
+// beats a comparison sort on long slices; on short ones, the fixed cost
+// of the passes loses to SortInts.
+func RadixSortInts(a []int) {
+	if len(a) < 2 {
+		return
+	}
+	const bits = 8
+	buf := make([]int, len(a))
+	src, dst := a, buf
+	for shift := uint(0); shift < 64; shift += bits {
+		var count [1 << bits]int
+		for _, v := range src {
+			count[radixKey(v, shift)]++
+		}
+		if count[radixKey(src[0], shift)] == len(src) {
+			continue // the same byte everywhere: this pass would change nothing
+		}
+		// count[b] becomes the index of the first element with byte b
+		pos := 0
+		for b, c := range count {
+			count[b] = pos
+			pos += c
+		}
+		for _, v := range src {
+			k := radixKey(v, shift)
+			dst[count[k]] = v
+			count[k]++
+		}
+		src, dst = dst, src
+	}
+	if &src[0] != &a[0] {
+		copy(a, src)
+	}
+}
+
+// radixKey returns byte shift/8 of v, with the sign bit flipped so that
+// negative numbers sort before positive ones.
+func radixKey(v int, shift uint) uint8 {
+	return uint8((uint64(v) ^ 1<<63) >> shift)
+}
@@ -0,0 +1,38 @@
+package mysort
+
+import "math/rand"
+
+// Shuffle puts the elements of data in a random order, every order being
+// equally likely (Fisher-Yates): each position from the last down gets
+// one of the elements not placed yet. The randomness comes from r, so a
+// source with a fixed seed gives the same order every time; nil uses the
+// math/rand functions.
+func Shuffle(data Interface, r *rand.Rand) {
+	intn := rand.Intn
+	if r != nil {
+		intn = r.Intn
+	}
+	for i := data.Len() - 1; i > 0; i-- {
+		data.Swap(i, intn(i+1))
+	}
+}
+
+// IsPermutation reports whether b holds the same elements as a, each as
+// often, in any order. IsSorted alone cannot tell that a sort lost or
+// doubled an element: check both.
+func IsPermutation[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[T]int, len(a))
+	for _, v := range a {
+		count[v]++
+	}
+	for _, v := range b {
+		if count[v] == 0 {
+			return false
+		}
+		count[v]--
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package mysort
+
+// Stable sorts data like Sort, but keeps equal elements in the order they
+// had: sorting by one key and then stably by another sorts by both, the
+// second key first. Like the standard library's sort.Stable, it sorts
+// blocks of insertionBlock elements by insertion and then merges them in
+// place, doubling the block size each round, with O(n*log(n)) calls to
+// Less and O(n*log(n)*log(n)) calls to Swap.
+func Stable(data Interface) {
+	stable(data, 0, data.Len())
+}
+
+// stable sorts data[lo:hi] as Stable does.
+func stable(data Interface, lo, hi int) {
+	a, b := lo, lo+insertionBlock
+	for b <= hi {
+		insertionSort(data, a, b)
+		a, b = b, b+insertionBlock
+	}
+	insertionSort(data, a, hi)
+
+	for size := insertionBlock; size < hi-lo; size *= 2 {
+		for a = lo; a+2*size <= hi; a += 2 * size {
+			symMerge(data, a, a+size, a+2*size)
+		}
+		if m := a + size; m < hi {
+			symMerge(data, a, m, hi)
+		}
+	}
+}
+
+// insertionBlock is the size of the blocks Stable sorts by insertion:
+// below it, insertion sort beats merging.
+const insertionBlock = 20
+
+// insertionSort sorts data[a:b], moving every element left past the
+// larger ones before it but never past an equal one.
+func insertionSort(data Interface, a, b int) {
+	for i := a + 1; i < b; i++ {
+		for j := i; j > a && data.Less(j, j-1); j-- {
+			data.Swap(j, j-1)
+		}
+	}
+}
+
+// symMerge merges the sorted runs data[a:m] and data[m:b] in place, the
+// SymMerge algorithm of Kim and Kutzner: the run boundary is moved so
+// that the halves can be swapped around it with a rotation, and both
+// sides are merged the same way. Of equal elements, those of the first
+// run stay first.
+func symMerge(data Interface, a, m, b int) {
+	// a run of one element is inserted by binary search
+	if m-a == 1 {
+		i, j := m, b
+		for i < j {
+			h := int(uint(i+j) >> 1)
+			if data.Less(h, a) {
+				i = h + 1
+			} else {
+				j = h
+			}
+		}
+		for k := a; k < i-1; k++ {
+			data.Swap(k, k+1)
+		}
+		return
+	}
+	if b-m == 1 {
+		i, j := a, m
+		for i < j {
+			h := int(uint(i+j) >> 1)
+			if !data.Less(m, h) {
+				i = h + 1
+			} else {
+				j = h
+			}
+		}
+		for k := m; k > i; k-- {
+			data.Swap(k, k-1)
+		}
+		return
+	}
+
+	mid := int(uint(a+b) >> 1)
+	n := mid + m
+	var start, r int
+	if m > mid {
+		start, r = n-b, mid
+	} else {
+		start, r = a, m
+	}
+	p := n - 1
+	for start < r {
+		c := int(uint(start+r) >> 1)
+		if !data.Less(p-c, c) {
+			start = c + 1
+		} else {
+			r = c
+		}
+	}
+	end := n - start
+	if start < m && m < end {
+		rotate(data, start, m, end)
+	}
+	if a < start && start < mid {
+		symMerge(data, a, start, mid)
+	}
+	if mid < end && end < b {
+		symMerge(data, mid, end, b)
+	}
+}
+
+// swapRange swaps the n elements from a with the n elements from b.
+func swapRange(data Interface, a, b, n int) {
+	for i := 0; i < n; i++ {
+		data.Swap(a+i, b+i)
+	}
+}
+
+// rotate turns data[a:m] data[m:b] into data[m:b] data[a:m] by swapping
+// blocks of equal length.
+func rotate(data Interface, a, m, b int) {
+	i := m - a
+	j := b - m
+	for i != j {
+		if i > j {
+			swapRange(data, m-i, m, j)
+			i -= j
+		} else {
+			swapRange(data, m-i, m+j-i, i)
+			j -= i
+		}
+	}
+	swapRange(data, m-i, m, i) // i == j
+}
@@ -0,0 +1,77 @@
+package mysort
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// record is sorted by key; pos is where it was in the input
+type record struct {
+	key, pos int
+}
+
+type byKey []record
+
+func (p byKey) Len() int           { return len(p) }
+func (p byKey) Less(i, j int) bool { return p[i].key < p[j].key }
+func (p byKey) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// TestStable sorts records with few distinct keys, so that most of them
+// are equal: every run of equal keys must keep its input order. The sizes
+// go past insertionBlock, where the merging begins.
+func TestStable(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{0, 1, 2, insertionBlock - 1, insertionBlock, insertionBlock + 1, 2*insertionBlock + 3, 100, 1000, 4099} {
+		for _, keys := range []int{1, 2, 10} {
+			t.Run(fmt.Sprintf("n=%d,keys=%d", n, keys), func(t *testing.T) {
+				data := make(byKey, n)
+				for i := range data {
+					data[i] = record{r.Intn(keys), i}
+				}
+				Stable(data)
+				for i := 1; i < n; i++ {
+					a, b := data[i-1], data[i]
+					if a.key > b.key {
+						t.Fatalf("not sorted at %d: key %d before %d", i, a.key, b.key)
+					}
+					if a.key == b.key && a.pos > b.pos {
+						t.Fatalf("key %d: the record from %d comes before the one from %d", a.key, a.pos, b.pos)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestStableTwoKeys sorts by first name, then stably by last name: the
+// result is sorted by last name, and by first name among equal last
+// names.
+func TestStableTwoKeys(t *testing.T) {
+	people := [][2]string{
+		{"Grace", "Hopper"}, {"Alan", "Turing"}, {"Ada", "Lovelace"},
+		{"Ken", "Thompson"}, {"Edsger", "Dijkstra"}, {"Dennis", "Thompson"},
+		{"Barbara", "Liskov"}, {"Alan", "Kay"}, {"Ada", "Hopper"},
+	}
+	first := func(i, j int) bool { return people[i][0] < people[j][0] }
+	last := func(i, j int) bool { return people[i][1] < people[j][1] }
+	Stable(By(pairs(people), first))
+	Stable(By(pairs(people), last))
+	want := [][2]string{
+		{"Edsger", "Dijkstra"}, {"Ada", "Hopper"}, {"Grace", "Hopper"},
+		{"Alan", "Kay"}, {"Barbara", "Liskov"}, {"Ada", "Lovelace"},
+		{"Dennis", "Thompson"}, {"Ken", "Thompson"}, {"Alan", "Turing"},
+	}
+	for i := range want {
+		if people[i] != want[i] {
+			t.Fatalf("got %v, want %v", people, want)
+		}
+	}
+}
+
+// pairs only swaps pairs of strings; By gives it a Less.
+type pairs [][2]string
+
+func (p pairs) Len() int           { return len(p) }
+func (p pairs) Less(i, j int) bool { return false }
+func (p pairs) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
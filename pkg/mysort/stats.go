@@ -0,0 +1,94 @@
+package mysort
+
+import "math"
+
+// MinIndex returns the index of the smallest element of data, the first
+// one if several are equally small, or -1 if data is empty. It takes
+// data.Len()-1 calls to Less and no Swap.
+func MinIndex(data Interface) int {
+	n := data.Len()
+	if n == 0 {
+		return -1
+	}
+	m := 0
+	for i := 1; i < n; i++ {
+		if data.Less(i, m) {
+			m = i
+		}
+	}
+	return m
+}
+
+// MaxIndex returns the index of the largest element of data, the first
+// one if several are equally large, or -1 if data is empty.
+func MaxIndex(data Interface) int {
+	n := data.Len()
+	if n == 0 {
+		return -1
+	}
+	m := 0
+	for i := 1; i < n; i++ {
+		if data.Less(m, i) {
+			m = i
+		}
+	}
+	return m
+}
+
+// quickselect moves the element that a sort would put at index k of
+// data[a:b] there, the smaller ones before it and the others after it.
+// It partitions like introSort but only goes on into the side holding
+// k, which takes O(n) steps on average instead of O(n*log(n)); past the
+// same depth limit, the part left is sorted with heapsort.
+func quickselect(data Interface, a, b, k int) {
+	depth := maxDepth(b - a)
+	for b-a > 1 {
+		if depth == 0 {
+			heapSort(data, a, b)
+			return
+		}
+		depth--
+		p := partition(data, a, b)
+		switch {
+		case k < p:
+			b = p
+		case k > p:
+			a = p + 1
+		default:
+			return
+		}
+	}
+}
+
+// Median returns the middle value of p, or the mean of the two middle
+// values if its length is even, and NaN if p is empty. It finds them with
+// quickselect instead of sorting, so p is left partly reordered.
+func (p IntSlice) Median() float64 {
+	n := len(p)
+	if n == 0 {
+		return math.NaN()
+	}
+	quickselect(p, 0, n, n/2)
+	if n%2 == 1 {
+		return float64(p[n/2])
+	}
+	// the other middle value is the largest of the smaller half
+	lo := p[:n/2][MaxIndex(p[:n/2])]
+	return (float64(lo) + float64(p[n/2])) / 2
+}
+
+// Median is IntSlice.Median for floats. NaN values count as smaller than
+// all others, as in Sort, so they move the median down and make it NaN
+// once they are half of p.
+func (p Float64Slice) Median() float64 {
+	n := len(p)
+	if n == 0 {
+		return math.NaN()
+	}
+	quickselect(p, 0, n, n/2)
+	if n%2 == 1 {
+		return p[n/2]
+	}
+	lo := p[:n/2][MaxIndex(p[:n/2])]
+	return (lo + p[n/2]) / 2
+}
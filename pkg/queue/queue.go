@@ -0,0 +1,402 @@
+// Package queue is a persistent FIFO queue stored in a directory.
+//
+// Messages are appended to segment files (00000000000000000000.seg, ...)
+// as records of
+//
+//	length uint32 | crc32 uint32 | payload
+//
+// A consumer Dequeues a message, handles it and then Acks it. The position
+// after the last acked message is committed to a small file, so after a
+// crash or restart every message that was not acked is delivered again
+// (at-least-once delivery). Fully acked segments are deleted.
+//
+// On Open, a record that was only partly written when the process died is
+// detected by its length or checksum and cut off.
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+)
+
+const (
+	recordHeader = 8
+	commitFile   = "commit.json"
+	segmentExt   = ".seg"
+
+	// DefaultSegmentSize is used when Options.SegmentSize is zero.
+	DefaultSegmentSize = 16 << 20
+	// MaxMessage is the largest payload Enqueue accepts.
+	MaxMessage = 4 << 20
+)
+
+var (
+	ErrClosed    = errors.New("queue: closed")
+	ErrTooLarge  = errors.New("queue: message too large")
+	ErrAckOrder  = errors.New("queue: messages must be acked in order")
+	errBadRecord = errors.New("queue: bad record")
+)
+
+// Position is a place in the queue: a segment and a byte offset in it.
+type Position struct {
+	Segment uint64 `json:"segment"`
+	Offset  int64  `json:"offset"`
+}
+
+func (p Position) less(q Position) bool {
+	return p.Segment < q.Segment || (p.Segment == q.Segment && p.Offset < q.Offset)
+}
+
+// Message is a dequeued message. Pass it to Ack once it has been handled.
+type Message struct {
+	Pos  Position
+	Data []byte
+	next Position
+}
+
+// Options tune a queue.
+type Options struct {
+	SegmentSize int64 // roll over to a new segment after this many bytes
+	// Sync makes every Enqueue fsync, so an acknowledged Enqueue survives
+	// a power cut, at the cost of much slower writes.
+	Sync bool
+}
+
+// Queue is safe for concurrent producers and consumers.
+type Queue struct {
+	dir  string
+	opts Options
+
+	mu       sync.Mutex
+	closed   bool
+	w        *os.File // current write segment
+	write    Position // end of the data
+	read     Position // next message to deliver
+	commit   Position // everything before this is acked
+	inFlight []Position
+	readers  map[uint64]*os.File
+	notify   chan struct{} // closed and replaced on every Enqueue
+}
+
+// Open opens (or creates) the queue in dir and recovers it after a crash.
+func Open(dir string, opts Options) (*Queue, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	q := &Queue{dir: dir, opts: opts, readers: map[uint64]*os.File{}, notify: make(chan struct{})}
+	segs, err := q.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segs) == 0 {
+		segs = []uint64{0}
+	}
+	if err := q.loadCommit(segs[0]); err != nil {
+		return nil, err
+	}
+	last := segs[len(segs)-1]
+	end, err := q.recover(last)
+	if err != nil {
+		return nil, err
+	}
+	q.w, err = os.OpenFile(q.segPath(last), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	q.write = Position{last, end}
+	if q.write.less(q.commit) { // commit points past the data: trust the data
+		q.commit = q.write
+	}
+	q.read = q.commit
+	return q, nil
+}
+
+func (q *Queue) segPath(seg uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seg, segmentExt))
+}
+
+// segments lists the segment numbers in order.
+func (q *Queue) segments() ([]uint64, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return nil, err
+	}
+	var segs []uint64
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err == nil {
+			segs = append(segs, n)
+		}
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i] < segs[j] })
+	return segs, nil
+}
+
+func (q *Queue) loadCommit(first uint64) error {
+	data, err := os.ReadFile(filepath.Join(q.dir, commitFile))
+	if errors.Is(err, os.ErrNotExist) {
+		q.commit = Position{first, 0}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &q.commit); err != nil {
+		return fmt.Errorf("queue: corrupt %s: %v", commitFile, err)
+	}
+	if q.commit.Segment < first {
+		q.commit = Position{first, 0}
+	}
+	return nil
+}
+
+// recover scans the last segment and truncates a torn final record.
+func (q *Queue) recover(seg uint64) (int64, error) {
+	f, err := os.OpenFile(q.segPath(seg), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	var off int64
+	for off < info.Size() {
+		_, n, err := readRecord(f, off)
+		if err != nil {
+			break // torn or corrupt tail: everything from here is dropped
+		}
+		off += n
+	}
+	if off < info.Size() {
+		if err := f.Truncate(off); err != nil {
+			return 0, err
+		}
+	}
+	return off, nil
+}
+
+// readRecord reads the record at off and returns its payload and size.
+func readRecord(r io.ReaderAt, off int64) ([]byte, int64, error) {
+	var h [recordHeader]byte
+	if _, err := r.ReadAt(h[:], off); err != nil {
+		return nil, 0, errBadRecord
+	}
+	n := binary.BigEndian.Uint32(h[:4])
+	if n > MaxMessage {
+		return nil, 0, errBadRecord
+	}
+	data := make([]byte, n)
+	if _, err := r.ReadAt(data, off+recordHeader); err != nil {
+		return nil, 0, errBadRecord
+	}
+	if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(h[4:]) {
+		return nil, 0, errBadRecord
+	}
+	return data, recordHeader + int64(n), nil
+}
+
+// Enqueue appends a message.
+func (q *Queue) Enqueue(data []byte) error {
+	if len(data) > MaxMessage {
+		return ErrTooLarge
+	}
+	buf := make([]byte, recordHeader+len(data))
+	binary.BigEndian.PutUint32(buf, uint32(len(data)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(data))
+	copy(buf[recordHeader:], data)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if q.write.Offset > 0 && q.write.Offset+int64(len(buf)) > q.opts.SegmentSize {
+		if err := q.roll(); err != nil {
+			return err
+		}
+	}
+	if _, err := q.w.Write(buf); err != nil {
+		return err
+	}
+	if q.opts.Sync {
+		if err := q.w.Sync(); err != nil {
+			return err
+		}
+	}
+	q.write.Offset += int64(len(buf))
+	close(q.notify)
+	q.notify = make(chan struct{})
+	return nil
+}
+
+// roll starts a new segment; q.mu must be held.
+func (q *Queue) roll() error {
+	if err := q.w.Sync(); err != nil {
+		return err
+	}
+	q.w.Close()
+	next := q.write.Segment + 1
+	f, err := os.OpenFile(q.segPath(next), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	q.w = f
+	q.write = Position{next, 0}
+	return nil
+}
+
+// Dequeue returns the next message, waiting until one arrives or ctx is
+// done. The message stays in the queue until it is acked.
+func (q *Queue) Dequeue(ctx context.Context) (Message, error) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return Message{}, ErrClosed
+		}
+		if m, ok, err := q.readLocked(); ok || err != nil {
+			q.mu.Unlock()
+			return m, err
+		}
+		wait := q.notify
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		case <-wait:
+		}
+	}
+}
+
+// readLocked reads the message at q.read, if there is one; q.mu must be held.
+func (q *Queue) readLocked() (Message, bool, error) {
+	for q.read.less(q.write) {
+		f, err := q.reader(q.read.Segment)
+		if err != nil {
+			return Message{}, false, err
+		}
+		if q.read.Segment < q.write.Segment {
+			info, err := f.Stat()
+			if err != nil {
+				return Message{}, false, err
+			}
+			if q.read.Offset >= info.Size() { // segment done: move on
+				q.read = Position{q.read.Segment + 1, 0}
+				continue
+			}
+		}
+		data, n, err := readRecord(f, q.read.Offset)
+		if err != nil {
+			return Message{}, false, fmt.Errorf("queue: segment %d offset %d: %w", q.read.Segment, q.read.Offset, err)
+		}
+		m := Message{Pos: q.read, Data: data, next: Position{q.read.Segment, q.read.Offset + n}}
+		q.read = m.next
+		q.inFlight = append(q.inFlight, m.Pos)
+		return m, true, nil
+	}
+	return Message{}, false, nil
+}
+
+func (q *Queue) reader(seg uint64) (*os.File, error) {
+	if f, ok := q.readers[seg]; ok {
+		return f, nil
+	}
+	f, err := os.Open(q.segPath(seg))
+	if err != nil {
+		return nil, err
+	}
+	q.readers[seg] = f
+	return f, nil
+}
+
+// Ack marks m as handled and commits the new read position to disk.
+// Messages must be acked in the order they were dequeued.
+func (q *Queue) Ack(m Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	if len(q.inFlight) == 0 || q.inFlight[0] != m.Pos {
+		return ErrAckOrder
+	}
+	q.inFlight = q.inFlight[1:]
+	q.commit = m.next
+	data, _ := json.Marshal(q.commit)
+	if err := safewrite.WriteFile(filepath.Join(q.dir, commitFile), data, 0644); err != nil {
+		return err
+	}
+	// segments before the committed one will never be read again
+	segs, err := q.segments()
+	if err != nil {
+		return err
+	}
+	for _, s := range segs {
+		if s >= q.commit.Segment {
+			break
+		}
+		if f, ok := q.readers[s]; ok {
+			f.Close()
+			delete(q.readers, s)
+		}
+		os.Remove(q.segPath(s))
+	}
+	return nil
+}
+
+// Len returns the number of bytes not yet acked, a cheap measure of backlog.
+func (q *Queue) Len() (pending int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.commit.Segment == q.write.Segment {
+		return q.write.Offset - q.commit.Offset
+	}
+	for s := q.commit.Segment; s < q.write.Segment; s++ {
+		if info, err := os.Stat(q.segPath(s)); err == nil {
+			pending += info.Size()
+		}
+	}
+	return pending - q.commit.Offset + q.write.Offset
+}
+
+// Close flushes and closes the segment files. Waiting Dequeue calls
+// return ErrClosed.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.notify)
+	for _, f := range q.readers {
+		f.Close()
+	}
+	err := q.w.Sync()
+	if cerr := q.w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
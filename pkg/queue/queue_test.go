@@ -0,0 +1,244 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func open(t *testing.T, dir string, opts Options) *Queue {
+	t.Helper()
+	q, err := Open(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+// TestProducersConsumers runs producers and consumers at the same time,
+// over several segments: every message arrives once, in the order its
+// producer sent it. Every Dequeue has a deadline, so a deadlock fails
+// the test instead of hanging it.
+func TestProducersConsumers(t *testing.T) {
+	const producers, consumers, each = 4, 3, 200
+	q := open(t, t.TempDir(), Options{SegmentSize: 1 << 10})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		mu    sync.Mutex        // Ack wants the order of Dequeue: one consumer at a time
+		got   = map[int][]int{} // producer -> its messages, as received
+		total int
+		wg    sync.WaitGroup
+	)
+	consume := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		m, err := q.Dequeue(ctx)
+		if err == nil {
+			err = q.Ack(m)
+		}
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("still waiting for messages after 10s, with %d of %d received", total, producers*each)
+			return false
+		}
+		if err != nil {
+			t.Error(err)
+			return false
+		}
+		var p, i int
+		fmt.Sscanf(string(m.Data), "%d %d", &p, &i)
+		got[p] = append(got[p], i)
+		total++
+		if total == producers*each {
+			cancel() // all there: the consumers waiting for mu give up in Dequeue
+		}
+		return true
+	}
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				if err := q.Enqueue([]byte(fmt.Sprintf("%d %d", p, i))); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	for c := 0; c < consumers; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for consume() {
+			}
+		}()
+	}
+	wg.Wait()
+	for p := 0; p < producers; p++ {
+		if len(got[p]) != each {
+			t.Errorf("producer %d: %d messages, want %d", p, len(got[p]), each)
+			continue
+		}
+		for i, n := range got[p] {
+			if n != i {
+				t.Errorf("producer %d: message %d arrived in place %d", p, n, i)
+				break
+			}
+		}
+	}
+	if n := q.Len(); n != 0 {
+		t.Errorf("Len() = %d after everything was acked, want 0", n)
+	}
+}
+
+// TestCloseWakesConsumers closes the queue under consumers that wait for
+// a message: they all return ErrClosed, and so does Enqueue.
+func TestCloseWakesConsumers(t *testing.T) {
+	q := open(t, t.TempDir(), Options{})
+	errs := make(chan error, 3)
+	for range 3 {
+		go func() {
+			_, err := q.Dequeue(context.Background())
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond) // let them wait
+	if err := q.Close(); err != nil {
+		t.Error(err)
+	}
+	for range 3 {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrClosed) {
+				t.Errorf("Dequeue after Close: %v, want ErrClosed", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Dequeue still blocked 2s after Close")
+		}
+	}
+	if err := q.Enqueue([]byte("late")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Enqueue after Close: %v, want ErrClosed", err)
+	}
+}
+
+// TestCancel gives up on a Dequeue with its context; the queue goes on
+// working, and the message sent later is not lost.
+func TestCancel(t *testing.T) {
+	q := open(t, t.TempDir(), Options{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := q.Dequeue(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Dequeue on an empty queue: %v, want context.DeadlineExceeded", err)
+	}
+	if err := q.Enqueue([]byte("after")); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	m, err := q.Dequeue(ctx)
+	if err != nil || string(m.Data) != "after" {
+		t.Errorf("Dequeue = %q, %v, want after", m.Data, err)
+	}
+}
+
+// TestCrashRecovery leaves a queue the way a crash in the middle of an
+// Enqueue would: the last record half written, a message dequeued but not
+// acked, and the temporary file of a commit that never finished. After
+// Open the torn record is gone, the unacked message comes again, and new
+// messages follow the old ones.
+func TestCrashRecovery(t *testing.T) {
+	dir := t.TempDir()
+	q := open(t, dir, Options{})
+	for _, s := range []string{"one", "two", "three"} {
+		if err := q.Enqueue([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	m, err := q.Dequeue(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Ack(m); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Dequeue(ctx); err != nil { // "two", never acked
+		t.Fatal(err)
+	}
+	size := q.write.Offset
+
+	// the crash: a record cut off after its header and part of its payload
+	torn := make([]byte, recordHeader+4)
+	binary.BigEndian.PutUint32(torn, 100)
+	f, err := os.OpenFile(q.segPath(0), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write(torn)
+	f.Close()
+	if err := os.WriteFile(filepath.Join(dir, "."+commitFile+".tmp-123"), []byte(`{"segm`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	q = open(t, dir, Options{})
+	info, err := os.Stat(q.segPath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != size {
+		t.Errorf("segment after recovery: %d bytes, want the torn record cut off at %d", info.Size(), size)
+	}
+	if err := q.Enqueue([]byte("four")); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	for _, want := range []string{"two", "three", "four"} {
+		m, err := q.Dequeue(ctx)
+		if err != nil || string(m.Data) != want {
+			t.Fatalf("Dequeue = %q, %v, want %q", m.Data, err, want)
+		}
+		if err := q.Ack(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestCorruptTail flips a byte in the payload of the last record: its
+// checksum fails, so recovery drops it like a torn one.
+func TestCorruptTail(t *testing.T) {
+	dir := t.TempDir()
+	q := open(t, dir, Options{})
+	q.Enqueue([]byte("kept"))
+	q.Enqueue([]byte("corrupt"))
+	q.Close()
+	f, err := os.OpenFile(q.segPath(0), os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteAt([]byte{'X'}, 2*recordHeader+int64(len("kept")))
+	f.Close()
+
+	q = open(t, dir, Options{})
+	m, err := q.Dequeue(context.Background())
+	if err != nil || string(m.Data) != "kept" {
+		t.Fatalf("Dequeue = %q, %v, want kept", m.Data, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if m, err := q.Dequeue(ctx); err == nil {
+		t.Errorf("Dequeue = %q, want the corrupt record dropped", m.Data)
+	}
+}
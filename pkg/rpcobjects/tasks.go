@@ -0,0 +1,75 @@
+package rpcobjects
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Task is one entry of a to-do list, as in the Tasks service of
+// session9/ex1, but as a plain Go struct: net/rpc needs no IDL.
+type Task struct {
+	ID      int64
+	Title   string
+	Done    bool
+	Created time.Time
+}
+
+type AddArgs struct {
+	Title string
+}
+
+type ListArgs struct {
+	IncludeDone bool
+}
+
+type DoneArgs struct {
+	ID int64
+}
+
+// Tasks is a to-do list in memory. Its exported methods have the form
+// net/rpc serves, func (t *T) Name(args *A, reply *R) error, and are
+// called as "Tasks.Add" and so on. The server calls them concurrently.
+type Tasks struct {
+	mu    sync.Mutex
+	tasks []Task
+}
+
+func (t *Tasks) Add(args *AddArgs, reply *Task) error {
+	if strings.TrimSpace(args.Title) == "" {
+		return errors.New("title is empty")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	task := Task{ID: int64(len(t.tasks) + 1), Title: args.Title, Created: time.Now()}
+	t.tasks = append(t.tasks, task)
+	*reply = task
+	return nil
+}
+
+// List replies with all tasks at once, the oldest first: net/rpc has no
+// streams.
+func (t *Tasks) List(args *ListArgs, reply *[]Task) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*reply = nil
+	for _, task := range t.tasks {
+		if !task.Done || args.IncludeDone {
+			*reply = append(*reply, task)
+		}
+	}
+	return nil
+}
+
+func (t *Tasks) Done(args *DoneArgs, reply *Task) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if args.ID < 1 || args.ID > int64(len(t.tasks)) {
+		return fmt.Errorf("no task %d", args.ID)
+	}
+	t.tasks[args.ID-1].Done = true
+	*reply = t.tasks[args.ID-1]
+	return nil
+}
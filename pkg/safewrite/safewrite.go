@@ -0,0 +1,118 @@
+// Package safewrite replaces files atomically: data is written to a
+// temporary file in the same directory, flushed to disk with fsync, and
+// only then renamed over the target. Readers see either the old file or
+// the complete new one, never a half-written mix, even if the program
+// crashes or the machine loses power in the middle.
+package safewrite
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrClosed is returned when a File is used after Commit or Abort.
+var ErrClosed = errors.New("safewrite: file already committed or aborted")
+
+// File is a pending replacement of a file. Write to it, then Commit to
+// make the new content visible, or Abort to throw it away.
+type File struct {
+	tmp  *os.File
+	path string
+	perm os.FileMode
+	done bool
+}
+
+// Create starts replacing the file at path. The temporary file lives in
+// the same directory because rename is only atomic within one file system.
+func Create(path string, perm os.FileMode) (*File, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &File{tmp: tmp, path: path, perm: perm}, nil
+}
+
+// Write appends to the temporary file.
+func (f *File) Write(p []byte) (int, error) {
+	if f.done {
+		return 0, ErrClosed
+	}
+	return f.tmp.Write(p)
+}
+
+// Name returns the path of the temporary file.
+func (f *File) Name() string { return f.tmp.Name() }
+
+// Commit flushes the data to disk and atomically moves it into place.
+func (f *File) Commit() error {
+	if f.done {
+		return ErrClosed
+	}
+	f.done = true
+	err := f.tmp.Sync() // without this a crash can leave a renamed but empty file
+	if cerr := f.tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.tmp.Name(), f.perm)
+	}
+	if err == nil {
+		err = os.Rename(f.tmp.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.tmp.Name())
+		return err
+	}
+	syncDir(filepath.Dir(f.path))
+	return nil
+}
+
+// Abort discards the temporary file; the original file is untouched.
+// It is safe to defer Abort and call Commit on the success path.
+func (f *File) Abort() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	f.tmp.Close()
+	return os.Remove(f.tmp.Name())
+}
+
+// WriteFile is the atomic counterpart of os.WriteFile.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return WriteTo(path, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// WriteTo lets fn stream the new content; if fn fails the original file
+// is kept.
+func WriteTo(path string, perm os.FileMode, fn func(w io.Writer) error) error {
+	f, err := Create(path, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Abort()
+	if err := fn(f); err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+// syncDir makes the rename itself durable. It is best effort: some
+// systems (Windows) cannot open or sync a directory.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
@@ -0,0 +1,125 @@
+package safewrite
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var errDiskFull = errors.New("disk full")
+
+// failingWriter passes on the first n bytes, then fails like a full disk
+type failingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > f.n {
+		n, _ := f.w.Write(p[:f.n])
+		f.n = 0
+		return n, errDiskFull
+	}
+	f.n -= len(p)
+	return f.w.Write(p)
+}
+
+// setup returns the path of a file that holds "original"
+func setup(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// check fails unless path holds want and the directory has no temporary
+// file left
+func check(t *testing.T, path, want string) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil || string(b) != want {
+		t.Errorf("%s holds %q (%v), want %q", filepath.Base(path), b, err, want)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != filepath.Base(path) {
+			t.Errorf("%s left behind", e.Name())
+		}
+	}
+}
+
+// TestWriteFile replaces the file, with the new permissions.
+func TestWriteFile(t *testing.T) {
+	path := setup(t)
+	if err := WriteFile(path, []byte("replaced"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	check(t, path, "replaced")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode %v, want 0600", info.Mode().Perm())
+	}
+}
+
+// TestPartialWrite fails in the middle of the new content: the original
+// file is left as it was.
+func TestPartialWrite(t *testing.T) {
+	path := setup(t)
+	err := WriteTo(path, 0644, func(w io.Writer) error {
+		_, err := io.Copy(&failingWriter{w: w, n: 5}, strings.NewReader("new content, longer than the original"))
+		return err
+	})
+	if !errors.Is(err, errDiskFull) {
+		t.Errorf("WriteTo: %v, want %v", err, errDiskFull)
+	}
+	check(t, path, "original")
+}
+
+// TestAbort writes a whole new content, then throws it away.
+func TestAbort(t *testing.T) {
+	path := setup(t)
+	f, err := Create(path, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("never committed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	check(t, path, "original")
+	if _, err := f.Write([]byte("more")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Write after Abort: %v, want ErrClosed", err)
+	}
+	if err := f.Commit(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Commit after Abort: %v, want ErrClosed", err)
+	}
+}
+
+// TestCommitFails cannot rename into a missing directory: the error is
+// returned and the temporary file removed.
+func TestCommitFails(t *testing.T) {
+	path := setup(t)
+	f, err := Create(path, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.path = filepath.Join(filepath.Dir(path), "missing", "data.txt")
+	f.Write([]byte("lost"))
+	if err := f.Commit(); err == nil {
+		t.Error("Commit into a missing directory succeeded")
+	}
+	check(t, path, "original")
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package sandbox
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setGroup does nothing: only Unix has process groups to put it in.
+func setGroup(cmd *exec.Cmd) {}
+
+// killGroup kills the program; what it started keeps running.
+func killGroup(p *os.Process) error {
+	return p.Kill()
+}
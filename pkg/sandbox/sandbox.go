@@ -0,0 +1,191 @@
+// Package sandbox runs a program as a subprocess with limits: a wall-clock
+// timeout, a cap on how much output it may write, and an environment
+// scrubbed down to a few harmless variables. On Unix the program gets its
+// own process group, and stopping it (timeout, cancelled context, too
+// much output) kills the whole group, so the goroutines, children and
+// servers it started go with it.
+//
+// It is not a security boundary: the program still runs as the same user
+// with the same files and network. It keeps a runaway exercise from
+// hanging or flooding the trainer, and keeps secrets in the environment
+// out of programs run for someone else (trainer serve).
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeptEnv are the variables a scrubbed environment keeps.
+var KeptEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "LC_CTYPE", "TERM", "TZ", "TMPDIR", "SYSTEMROOT"}
+
+// Cmd describes a program to run. The zero limits mean no limit.
+type Cmd struct {
+	Path string
+	Args []string // without the program name
+	Dir  string
+
+	Stdin          io.Reader
+	Stdout, Stderr io.Writer
+
+	Timeout   time.Duration // wall clock
+	MaxOutput int64         // bytes written to Stdout and Stderr together
+
+	// Env is added to the scrubbed environment. With KeepEnv the
+	// program gets the whole environment of the trainer instead.
+	Env     []string
+	KeepEnv bool
+
+	// SameGroup leaves the program in the trainer's process group. A
+	// program that reads from the terminal needs this: outside the
+	// foreground group it would be stopped on its first read, and
+	// Ctrl-C would not reach it. Only the program itself is killed then.
+	SameGroup bool
+}
+
+// Result is how a run ended.
+type Result struct {
+	ExitCode  int           // -1 if the program was killed
+	TimedOut  bool          // stopped after Timeout
+	Truncated bool          // stopped because it wrote more than MaxOutput
+	Duration  time.Duration // wall clock
+}
+
+// ErrStopped is returned by Run when the program was stopped by a limit.
+var ErrStopped = errors.New("sandbox: program stopped")
+
+// Run starts the program and waits for it. The error is nil if the
+// program exited with status 0, an *exec.ExitError for another status,
+// ErrStopped when a limit stopped it and ctx.Err() when ctx ended first.
+func (c *Cmd) Run(ctx context.Context) (Result, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var timer <-chan time.Time
+	if c.Timeout > 0 {
+		t := time.NewTimer(c.Timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	timedOut := make(chan bool, 1)
+	go func() {
+		select {
+		case <-timer:
+			timedOut <- true
+			cancel()
+		case <-runCtx.Done():
+			timedOut <- false
+		}
+	}()
+
+	cmd := exec.CommandContext(runCtx, c.Path, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Stdin = c.Stdin
+	cmd.Env = c.environ()
+	if !c.SameGroup {
+		setGroup(cmd)
+		cmd.Cancel = func() error { return killGroup(cmd.Process) }
+	}
+	cmd.WaitDelay = time.Second // do not wait forever for pipes held by orphans
+
+	lim := &limiter{max: c.MaxOutput, stop: cancel}
+	if c.Stdout != nil {
+		cmd.Stdout = &limitWriter{w: c.Stdout, lim: lim}
+	}
+	if c.Stderr != nil {
+		if c.Stderr == c.Stdout {
+			cmd.Stderr = cmd.Stdout // one writer, so writes stay in order
+		} else {
+			cmd.Stderr = &limitWriter{w: c.Stderr, lim: lim}
+		}
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	r := Result{Duration: time.Since(start), ExitCode: -1}
+	if cmd.ProcessState != nil {
+		r.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	cancel()
+	r.TimedOut = <-timedOut
+	r.Truncated = lim.exceeded()
+	switch {
+	case r.Truncated || r.TimedOut:
+		return r, ErrStopped
+	case ctx.Err() != nil:
+		return r, ctx.Err()
+	}
+	return r, err
+}
+
+// environ returns the environment of the program.
+func (c *Cmd) environ() []string {
+	if c.KeepEnv {
+		return append(os.Environ(), c.Env...)
+	}
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		for _, keep := range KeptEnv {
+			if strings.EqualFold(name, keep) {
+				env = append(env, kv)
+				break
+			}
+		}
+	}
+	return append(env, c.Env...)
+}
+
+// limiter counts the output of a program and stops it at max bytes.
+type limiter struct {
+	mu      sync.Mutex
+	max     int64
+	written int64
+	over    bool
+	stop    func()
+}
+
+// allow returns how much of n more bytes may be written.
+func (l *limiter) allow(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.max <= 0 {
+		return n
+	}
+	left := l.max - l.written
+	if int64(n) > left {
+		l.over = true
+		l.stop()
+		n = int(left)
+	}
+	l.written += int64(n)
+	return n
+}
+
+func (l *limiter) exceeded() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.over
+}
+
+// limitWriter passes on output until the limit is reached and swallows
+// the rest, so that the program is not blocked on a full pipe while it
+// is being killed.
+type limitWriter struct {
+	w   io.Writer
+	lim *limiter
+}
+
+func (lw *limitWriter) Write(p []byte) (int, error) {
+	if n := lw.lim.allow(len(p)); n > 0 {
+		if _, err := lw.w.Write(p[:n]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
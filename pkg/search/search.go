@@ -0,0 +1,177 @@
+// Package search is a small in-memory full-text search engine. Documents
+// are split into lower-case terms and stored in an inverted index: for
+// every term, the documents containing it and how often.
+//
+// Queries are words separated by spaces; all of them must match (AND).
+// The keyword OR separates alternatives, so
+//
+//	golang web OR templates
+//
+// finds documents with both "golang" and "web", or with "templates".
+// Results are ranked by TF-IDF: rare terms count more than common ones.
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Result is a matching document and its relevance score.
+type Result struct {
+	ID    int
+	Score float64
+}
+
+// Index is an inverted index, safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	postings map[string]map[int]int // term -> document -> term frequency
+	lengths  map[int]int            // document -> number of terms
+}
+
+// New returns an empty index.
+func New() *Index {
+	return &Index{postings: map[string]map[int]int{}, lengths: map[int]int{}}
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "of": true, "to": true,
+	"in": true, "is": true, "it": true, "on": true, "for": true,
+}
+
+// Tokenize lower-cases text and splits it into terms at anything that is
+// not a letter or digit. Very common English words are dropped.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := fields[:0]
+	for _, f := range fields {
+		if !stopWords[f] {
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
+
+// Add indexes text as document id. Adding an existing id replaces it.
+func (ix *Index) Add(id int, text string) {
+	terms := Tokenize(text)
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeLocked(id)
+	for _, t := range terms {
+		docs := ix.postings[t]
+		if docs == nil {
+			docs = map[int]int{}
+			ix.postings[t] = docs
+		}
+		docs[id]++
+	}
+	ix.lengths[id] = len(terms)
+}
+
+// Remove deletes document id from the index.
+func (ix *Index) Remove(id int) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeLocked(id)
+}
+
+func (ix *Index) removeLocked(id int) {
+	if _, ok := ix.lengths[id]; !ok {
+		return
+	}
+	for t, docs := range ix.postings {
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(ix.postings, t)
+		}
+	}
+	delete(ix.lengths, id)
+}
+
+// Len returns the number of documents.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.lengths)
+}
+
+// ParseQuery turns "a b OR c" into [[a b] [c]]: a list of alternatives,
+// each a list of terms that must all match.
+func ParseQuery(q string) [][]string {
+	var groups [][]string
+	var cur []string
+	for _, word := range strings.Fields(q) {
+		if word == "OR" {
+			if len(cur) > 0 {
+				groups = append(groups, cur)
+			}
+			cur = nil
+			continue
+		}
+		cur = append(cur, Tokenize(word)...)
+	}
+	if len(cur) > 0 {
+		groups = append(groups, cur)
+	}
+	return groups
+}
+
+// Search runs q and returns the matching documents, best first.
+func (ix *Index) Search(q string) []Result {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	scores := map[int]float64{}
+	for _, group := range ParseQuery(q) {
+		for id, score := range ix.matchAll(group) {
+			if score > scores[id] { // a document is as good as its best alternative
+				scores[id] = score
+			}
+		}
+	}
+	results := make([]Result, 0, len(scores))
+	for id, s := range scores {
+		results = append(results, Result{id, s})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results
+}
+
+// matchAll returns the documents containing every term, with their score.
+func (ix *Index) matchAll(terms []string) map[int]float64 {
+	if len(terms) == 0 {
+		return nil
+	}
+	// start from the rarest term: the intersection can only shrink
+	sorted := append([]string(nil), terms...)
+	sort.Slice(sorted, func(i, j int) bool { return len(ix.postings[sorted[i]]) < len(ix.postings[sorted[j]]) })
+	out := map[int]float64{}
+	for id := range ix.postings[sorted[0]] {
+		out[id] = 0
+	}
+	for _, t := range sorted {
+		docs := ix.postings[t]
+		idf := math.Log(1 + float64(len(ix.lengths))/float64(len(docs)+1))
+		for id := range out {
+			tf, ok := docs[id]
+			if !ok {
+				delete(out, id)
+				continue
+			}
+			// term frequency damped by document length, so long entries
+			// don't win just by repeating words
+			out[id] += float64(tf) / math.Sqrt(float64(ix.lengths[id])) * idf
+		}
+	}
+	return out
+}
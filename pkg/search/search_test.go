@@ -0,0 +1,190 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestTokenize splits at anything but letters and digits, and drops stop
+// words.
+func TestTokenize(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want []string
+	}{
+		{"Hello, World!", []string{"hello", "world"}},
+		{"The Go programming language", []string{"go", "programming", "language"}},
+		{"html/template and text/template", []string{"html", "template", "text", "template"}},
+		{"Go 1.22 is out", []string{"go", "1", "22", "out"}},
+		{"Größe über Maß", []string{"größe", "über", "maß"}},
+		{"the a an of", nil},
+		{"  ...  ", nil},
+		{"", nil},
+	} {
+		got := Tokenize(tc.text)
+		if len(got) == 0 && len(tc.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+// TestParseQuery groups the terms between the OR keywords.
+func TestParseQuery(t *testing.T) {
+	for _, tc := range []struct {
+		q    string
+		want [][]string
+	}{
+		{"golang", [][]string{{"golang"}}},
+		{"golang web OR templates", [][]string{{"golang", "web"}, {"templates"}}},
+		{"Go-Web", [][]string{{"go", "web"}}},
+		{"OR a OR OR b OR", [][]string{{"b"}}}, // "a" is a stop word
+		{"or", [][]string{{"or"}}},             // only OR in capitals is the keyword
+		{"the", nil},
+		{"", nil},
+	} {
+		if got := ParseQuery(tc.q); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseQuery(%q) = %q, want %q", tc.q, got, tc.want)
+		}
+	}
+}
+
+func ids(results []Result) []int {
+	var out []int
+	for _, r := range results {
+		out = append(out, r.ID)
+	}
+	return out
+}
+
+// TestSearchMatch checks which documents match: every word of an
+// alternative, and any of the alternatives.
+func TestSearchMatch(t *testing.T) {
+	ix := New()
+	ix.Add(1, "golang web server")
+	ix.Add(2, "golang templates")
+	ix.Add(3, "web templates")
+	for _, tc := range []struct {
+		q    string
+		want []int
+	}{
+		{"golang", []int{1, 2}},
+		{"golang web", []int{1}},
+		{"GOLANG, Web!", []int{1}},
+		{"golang web OR templates", []int{1, 2, 3}},
+		{"golang python", nil},
+		{"python OR web", []int{1, 3}},
+		{"the", nil},
+	} {
+		got := ids(ix.Search(tc.q))
+		if !sameIDs(got, tc.want) {
+			t.Errorf("Search(%q) = %v, want %v in any order", tc.q, got, tc.want)
+		}
+	}
+}
+
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := map[int]bool{}
+	for _, id := range a {
+		seen[id] = true
+	}
+	for _, id := range b {
+		if !seen[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestSearchRanking checks the order of the results: by TF-IDF, ties by
+// ID.
+func TestSearchRanking(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		docs []string
+		q    string
+		want []int
+	}{
+		{"a rare term counts more", []string{
+			0: "common", 1: "common", 2: "gopher", 3: "common",
+		}, "common OR gopher", []int{2, 0, 1, 3}},
+		{"more occurrences rank higher", []string{
+			0: "go web", 1: "go go web",
+		}, "go", []int{1, 0}},
+		{"a short document beats a long one", []string{
+			0: "go templates and many other words here", 1: "go templates",
+		}, "templates", []int{1, 0}},
+		{"ties go by ID", []string{
+			0: "same text", 1: "same text", 2: "same text",
+		}, "same", []int{0, 1, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ix := New()
+			for id, text := range tc.docs {
+				ix.Add(id, text)
+			}
+			results := ix.Search(tc.q)
+			if got := ids(results); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Search(%q) = %v, want %v", tc.q, results, tc.want)
+			}
+			for _, r := range results {
+				if r.Score <= 0 {
+					t.Errorf("document %d has score %v, want > 0", r.ID, r.Score)
+				}
+			}
+		})
+	}
+}
+
+// TestSearchBestAlternative scores a document that matches several
+// alternatives by the best of them, not by their sum.
+func TestSearchBestAlternative(t *testing.T) {
+	ix := New()
+	ix.Add(0, "web gopher")
+	ix.Add(1, "web server")
+	ix.Add(2, "templates")
+	score := func(q string) float64 {
+		for _, r := range ix.Search(q) {
+			if r.ID == 0 {
+				return r.Score
+			}
+		}
+		t.Fatalf("Search(%q) does not find document 0", q)
+		return 0
+	}
+	web, gopher := score("web"), score("gopher")
+	if both := score("web OR gopher"); both != max(web, gopher) {
+		t.Errorf("web OR gopher scores %v, want the better of %v and %v", both, web, gopher)
+	}
+}
+
+// TestAddRemove replaces a document added again under its ID, and drops
+// removed ones.
+func TestAddRemove(t *testing.T) {
+	ix := New()
+	ix.Add(1, "old text")
+	ix.Add(2, "other text")
+	ix.Add(1, "new words")
+	if n := ix.Len(); n != 2 {
+		t.Errorf("Len = %d, want 2", n)
+	}
+	if got := ids(ix.Search("old")); got != nil {
+		t.Errorf("Search(old) = %v after replacing document 1, want none", got)
+	}
+	if got := ids(ix.Search("new")); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("Search(new) = %v, want [1]", got)
+	}
+	ix.Remove(2)
+	ix.Remove(3) // not there: nothing happens
+	if got := ids(ix.Search("text")); got != nil {
+		t.Errorf("Search(text) = %v after Remove(2), want none", got)
+	}
+	if n := ix.Len(); n != 1 {
+		t.Errorf("Len = %d, want 1", n)
+	}
+}
@@ -0,0 +1,144 @@
+// Package watch reports changed files below a directory.
+//
+// It polls: every Interval the tree is walked and the size and
+// modification time of each file compared with the previous walk. That
+// is slower than the operating system's notifications but needs nothing
+// outside the standard library and works the same everywhere.
+//
+// Editors often save a file in several steps (write a temp file, rename,
+// touch). Changes are therefore collected until nothing has changed for
+// Debounce, and then delivered together:
+//
+//	w := &watch.Watcher{Root: "session3/ex5"}
+//	for paths := range w.Changes(ctx) {
+//		fmt.Println("changed:", paths)
+//	}
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Watcher watches the files below Root.
+type Watcher struct {
+	Root     string
+	Interval time.Duration // time between walks, default 250ms
+	Debounce time.Duration // quiet time before changes are delivered, default 300ms
+
+	// Match selects the files to watch, by path relative to Root. The
+	// default watches everything except files and directories whose name
+	// starts with "." (.git, editor swap files).
+	Match func(rel string) bool
+}
+
+type stamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// Changes starts watching and returns a channel of changed paths
+// (created, modified or removed), sorted and relative to Root. The
+// channel is closed when ctx is done.
+func (w *Watcher) Changes(ctx context.Context) <-chan []string {
+	interval, debounce := w.Interval, w.Debounce
+	if interval <= 0 {
+		interval = 250 * time.Millisecond
+	}
+	if debounce <= 0 {
+		debounce = 300 * time.Millisecond
+	}
+	out := make(chan []string)
+	go func() {
+		defer close(out)
+		prev := w.scan()
+		pending := map[string]bool{}
+		var lastChange time.Time
+		tick := time.NewTicker(interval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-tick.C:
+				cur := w.scan()
+				for _, p := range diff(prev, cur) {
+					pending[p] = true
+					lastChange = now
+				}
+				prev = cur
+				if len(pending) == 0 || now.Sub(lastChange) < debounce {
+					continue
+				}
+				paths := make([]string, 0, len(pending))
+				for p := range pending {
+					paths = append(paths, p)
+				}
+				sort.Strings(paths)
+				pending = map[string]bool{}
+				select {
+				case out <- paths:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// scan records size and modification time of every watched file.
+// Files that vanish or cannot be read during the walk are left out.
+func (w *Watcher) scan() map[string]stamp {
+	files := map[string]stamp{}
+	filepath.WalkDir(w.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(w.Root, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		if !w.match(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files[rel] = stamp{info.Size(), info.ModTime()}
+		}
+		return nil
+	})
+	return files
+}
+
+func (w *Watcher) match(rel string) bool {
+	if w.Match != nil {
+		return w.Match(rel)
+	}
+	return !strings.HasPrefix(filepath.Base(rel), ".")
+}
+
+// diff returns the paths that differ between two scans.
+func diff(prev, cur map[string]stamp) []string {
+	var changed []string
+	for p, s := range cur {
+		if old, ok := prev[p]; !ok || old != s {
+			changed = append(changed, p)
+		}
+	}
+	for p := range prev {
+		if _, ok := cur[p]; !ok {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
@@ -11,22 +11,22 @@ type IntType int // New type
 type IntAlias = int // Alias
 
 func (recv S) print() { // function for type defined on type S
-  fmt.Printf("%t: %[1]v\n", recv)
+  fmt.Printf("%T: %[1]v\n", recv)
 }
 func (recv SType) print() { // function for type defined on the basis of S
-  fmt.Printf("%t: %[1]v\n", recv)
+  fmt.Printf("%T: %[1]v\n", recv)
 }
 
 // func (recv SAlias) print() { // <-- error: S.print redeclared in this block previous declaration at ./struct_method.go:15:6
-// fmt.Printf("%t: %[1]v\n", recv)
+// fmt.Printf("%T: %[1]v\n", recv)
 // }
 
 func (recv IntType) print() { // function for type defined on type on the basis of int
-  fmt.Printf("%t: %[1]v\n", recv)
+  fmt.Printf("%T: %[1]v\n", recv)
 }
 
 // func (recv IntAlias) print() { // <-- error: cannot define new methods on non-local type int
-// fmt.Printf("%t: %[1]v\n", recv)
+// fmt.Printf("%T: %[1]v\n", recv)
 // }
 
 func main(){
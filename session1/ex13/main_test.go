@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+// Scale multiplies both coordinates
+func TestScale(t *testing.T) {
+	for _, s := range []float64{5, 0, -1, 0.5} {
+		p := Point{3, -4}
+		p.Scale(s)
+		if want := (Point{3 * s, -4 * s}); p != want {
+			t.Errorf("Point{3, -4}.Scale(%v) gives %v, want %v", s, p, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import "fmt"
+
+type Rectangle struct { // struct of type Rectangle
+	length, width int
+}
+
+// HINT: a method reaches the fields of its receiver as r.length and r.width
+func (r *Rectangle) Area() int { // method calculating area of rectangle
+	// SOLUTION-START return 0
+	return r.length * r.width
+	// SOLUTION-END
+}
+
+// HINT: a rectangle has two sides of each length
+func (r *Rectangle) Perimeter() int { // method calculating perimeter of rectangle
+	// SOLUTION-START return 0
+	return 2 * (r.length + r.width)
+	// SOLUTION-END
+}
+
+func main() {
+	r1 := Rectangle{4, 3}
+	fmt.Println("Rectangle is: ", r1)
+	fmt.Println("Rectangle area is: ", r1.Area())           // calling method of area
+	fmt.Println("Rectangle perimeter is: ", r1.Perimeter()) // calling method of perimeter
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+// Area multiplies length and width
+func TestArea(t *testing.T) {
+	for _, r := range []Rectangle{{4, 3}, {0, 7}, {5, 5}} {
+		if got, want := r.Area(), r.length*r.width; got != want {
+			t.Errorf("%v.Area() = %d, want %d", r, got, want)
+		}
+	}
+}
+
+// Perimeter adds up all four sides
+func TestPerimeter(t *testing.T) {
+	for _, r := range []Rectangle{{4, 3}, {0, 7}, {5, 5}} {
+		if got, want := r.Perimeter(), 2*r.length+2*r.width; got != want {
+			t.Errorf("%v.Perimeter() = %d, want %d", r, got, want)
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package main
 import (
 "fmt"
-"pers"
+"github.com/hannansatopay/training-golang/session1/ex15/pers"
 )
 
 func main() {
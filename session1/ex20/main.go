@@ -0,0 +1,45 @@
+// a Customer with a Log that is reached through a getter method
+package main
+
+import (
+	"fmt"
+)
+
+type Log struct {
+	msg string
+}
+
+type Customer struct {
+	Name string
+	log  *Log
+}
+
+func main() {
+	c := new(Customer)
+	c.Name = "Barack Obama"
+	c.log = new(Log)
+	c.log.msg = "1 - Yes we can!"
+	// shorter:
+	c = &Customer{"Barack Obama", &Log{"1 - Yes we can!"}}
+	fmt.Println(c.log)
+	c.Log().Add("2 - After me, the world will be a better place!")
+	fmt.Println(c.Log())
+}
+
+// HINT: each message goes on a line of its own, after the ones already in the log
+func (l *Log) Add(s string) {
+	// SOLUTION-START
+	l.msg += "\n" + s
+	// SOLUTION-END
+}
+
+// HINT: with a String method, fmt.Println prints a *Log as the text it returns
+func (l *Log) String() string {
+	// SOLUTION-START return ""
+	return l.msg
+	// SOLUTION-END
+}
+
+func (c *Customer) Log() *Log {
+	return c.log
+}
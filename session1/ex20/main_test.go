@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Add appends a message on a new line
+func TestAdd(t *testing.T) {
+	l := &Log{"first"}
+	l.Add("second")
+	l.Add("third")
+	if want := "first\nsecond\nthird"; l.msg != want {
+		t.Errorf("after two Adds the log holds %q, want %q", l.msg, want)
+	}
+}
+
+// a *Log prints as its messages
+func TestString(t *testing.T) {
+	l := &Log{"one\ntwo"}
+	if got := fmt.Sprint(l); got != "one\ntwo" {
+		t.Errorf("fmt.Sprint(log) = %q, want %q", got, "one\ntwo")
+	}
+}
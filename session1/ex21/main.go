@@ -0,0 +1,37 @@
+// a Customer that embeds its Log and so gets its Add method
+package main
+
+import (
+	"fmt"
+)
+
+type Log struct {
+	msg string
+}
+
+type Customer struct {
+	Name string
+	Log
+}
+
+func main() {
+	c := &Customer{"Barack Obama", Log{"1 - Yes we can!"}}
+	c.Add("2 - After me, the world will be a better place!")
+	fmt.Println(c)
+}
+
+func (l *Log) Add(s string) {
+	l.msg += "\n" + s
+}
+
+func (l *Log) String() string {
+	return l.msg
+}
+
+// HINT: the name, then "Log:" and the log, each on a line of its own
+// HINT: c.Log is a Log value, not a *Log: see what fmt.Sprintln makes of it
+func (c *Customer) String() string {
+	// SOLUTION-START return c.Name
+	return c.Name + "\nLog:\n" + fmt.Sprintln(c.Log)
+	// SOLUTION-END
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// a *Customer prints as its name, then its log as a plain struct
+// (only *Log has a String method, the embedded Log is a value)
+func TestCustomerString(t *testing.T) {
+	c := &Customer{"Ada", Log{"one"}}
+	c.Add("two")
+	want := "Ada\nLog:\n{one\ntwo}\n"
+	if got := fmt.Sprint(c); got != want {
+		t.Errorf("fmt.Sprint(customer) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+)
+
+// nested YAML maps onto nested structs: every YAML mapping becomes a struct
+// (or a map), every sequence a slice. The `config` tags name the keys.
+
+type Server struct {
+	Addr         string        `config:"addr" required:"true"`
+	ReadTimeout  time.Duration `config:"read_timeout"`
+	WriteTimeout time.Duration `config:"write_timeout"`
+}
+
+type Pool struct {
+	MaxOpen int `config:"max_open"`
+	MaxIdle int `config:"max_idle"`
+}
+
+type Database struct {
+	Driver string `config:"driver" required:"true"`
+	DSN    string `config:"dsn" required:"true"`
+	Pool   Pool   `config:"pool"`
+}
+
+type Admin struct {
+	Name  string `config:"name"`
+	Email string `config:"email"`
+}
+
+type App struct {
+	Name     string   `config:"name"`
+	Server   Server   `config:"server"`
+	Database Database `config:"database"`
+	Admins   []Admin  `config:"admins"` // a list of structs: no flag or env variable, only the file sets it
+	Tags     []string `config:"tags"`
+	Motd     string   `config:"motd"`
+}
+
+// defaults are simply the values in the struct before loading
+func defaults() App {
+	return App{
+		Server:   Server{Addr: "localhost:8080", ReadTimeout: time.Second, WriteTimeout: 10 * time.Second},
+		Database: Database{Driver: "memory", Pool: Pool{MaxOpen: 4, MaxIdle: 2}},
+	}
+}
+
+func main() {
+	// 1. the plain decoder
+	data, err := os.ReadFile("app.yaml")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	app := defaults()
+	if err := config.UnmarshalYAML(data, &app); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%+v\n", app.Server)
+	fmt.Printf("%+v\n", app.Database)
+	fmt.Printf("admins: %+v\ntags: %q\nmotd: %q\n", app.Admins, app.Tags, app.Motd)
+
+	// 2. through the config loader, which adds env/flag overrides and
+	// checks required fields; every error names the YAML path
+	for _, file := range []string{"app.yaml", "broken.yaml", "incomplete.yaml"} {
+		app := defaults()
+		loader := config.Loader{File: file, Args: []string{}, FlagSet: newFlagSet()}
+		if err := loader.Load(&app); err != nil {
+			fmt.Printf("%-16s %v\n", file+":", err)
+		} else {
+			fmt.Printf("%-16s ok, listening on %s with %s\n", file+":", app.Server.Addr, app.Database.Driver)
+		}
+	}
+}
+
+// each Load registers its flags, so use a fresh flag set per load
+func newFlagSet() *flag.FlagSet { return flag.NewFlagSet("ex24", flag.ContinueOnError) }
@@ -5,9 +5,9 @@ import (
 )
 
 type TagType struct { // tags
-  field1 bool "An important answer"
-  field2 string `The name of the thing` 
-  field3 int "How much there are"
+  field1 bool   `desc:"An important answer"` // by convention a tag is a list of key:"value" pairs
+  field2 string `desc:"The name of the thing"`
+  field3 int    `desc:"How much there are"`
 }
 
 func main() {
@@ -21,4 +21,5 @@ func refTag(tt TagType, ix int) {
   ttType := reflect.TypeOf(tt)
   ixField := ttType.Field(ix)       // getting field at a position ix
   fmt.Printf("%v\n", ixField.Tag)   // printing tags
+  fmt.Printf("%v\n", ixField.Tag.Get("desc")) // printing the value of one key
 }
\ No newline at end of file
@@ -5,8 +5,8 @@ import (
 )
 
 type T struct {
-  a int "This is a tag"
-  b int `A raw string tag`
+  a int `note:"This is a tag"`       // any string is allowed, but go vet wants key:"value" pairs
+  b int `note:"A raw string tag"`
   c int `key1:"value1" key2:"value2"`
 }
 
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// the flag package beyond flag.Parse: FlagSets, flag values and subcommands
+//
+// A FlagSet per command line, what stops the parsing, flags that collect
+// values, and errors instead of exits. Every run below parses a made-up
+// command line and shows what it got.
+
+// tags is a flag.Value: Set is called once for every -tag on the command line
+type tags []string
+
+func (t *tags) String() string { return strings.Join(*t, ",") }
+
+func (t *tags) Set(s string) error {
+	if s == "" {
+		return errors.New("empty tag")
+	}
+	*t = append(*t, s)
+	return nil
+}
+
+type options struct {
+	verbose bool
+	limit   int
+	tags    tags
+	sort    string
+}
+
+// newFlagSet returns a fresh FlagSet filling o. ContinueOnError makes
+// Parse return errors instead of exiting, and SetOutput(io.Discard) keeps
+// it from printing the usage on every mistake.
+func newFlagSet(o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&o.verbose, "v", false, "show everything")
+	fs.IntVar(&o.limit, "limit", 10, "show at most this many")
+	fs.Var(&o.tags, "tag", "only with this tag (repeatable)")
+	fs.Func("sort", "newest or oldest", func(s string) error {
+		if s != "newest" && s != "oldest" {
+			return fmt.Errorf("want newest or oldest, not %q", s)
+		}
+		o.sort = s
+		return nil
+	})
+	return fs
+}
+
+func parse(args ...string) {
+	o := options{sort: "newest"}
+	fs := newFlagSet(&o)
+	fmt.Printf("%-36s ", strings.Join(args, " "))
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		fmt.Println("-h or -help: print the usage and exit 0, not 2")
+		return
+	} else if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	// Visit goes through the flags that were set, VisitAll through all
+	var set []string
+	fs.Visit(func(f *flag.Flag) { set = append(set, f.Name) })
+	fmt.Printf("v=%t limit=%d tags=%q sort=%s set=%v rest=%q\n", o.verbose, o.limit, o.tags, o.sort, set, fs.Args())
+}
+
+func main() {
+	parse()
+	parse("-v", "-limit", "3")
+	parse("--limit=3", "-v=false") // one or two dashes, = or a space
+	parse("-tag", "go", "-tag", "cli", "-sort", "oldest")
+	parse("-limit", "3", "first", "-v") // parsing stops at the first argument that is not a flag
+	parse("-v", "--", "-limit")         // and after --
+	parse("-limit", "three")
+	parse("-sort", "random")
+	parse("-color")
+	parse("-h")
+
+	// subcommands: the first argument picks the FlagSet for the rest
+	for _, args := range [][]string{{"list", "-limit", "2"}, {"add", "-tag", "go", "buy milk"}, {"rm", "3"}, {"rm"}, {"edit"}} {
+		fmt.Printf("%-36s ", strings.Join(args, " "))
+		switch args[0] {
+		case "list":
+			var o options
+			fs := newFlagSet(&o)
+			fs.Parse(args[1:])
+			fmt.Printf("list, limit %d\n", o.limit)
+		case "add":
+			fs := flag.NewFlagSet("add", flag.ContinueOnError)
+			var t tags
+			fs.Var(&t, "tag", "tag the note")
+			fs.Parse(args[1:])
+			fmt.Printf("add %q with tags %q\n", strings.Join(fs.Args(), " "), t)
+		case "rm":
+			fs := flag.NewFlagSet("rm", flag.ContinueOnError)
+			fs.Parse(args[1:])
+			if fs.NArg() == 0 {
+				fmt.Println("error: rm needs the id of a note")
+				continue
+			}
+			fmt.Printf("rm %v\n", fs.Args())
+		default:
+			fmt.Printf("error: unknown command %q\n", args[0])
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+)
+
+// notes: a command-line tool with subcommands, each with its own flags
+//
+//	notes [-file notes.json] add [-tags a,b] text...
+//	notes [-file notes.json] list [-tag t] [-limit n] [-sort newest|oldest]
+//	notes [-file notes.json] rm [-force] id...
+//
+// The global flags come before the command, which stops their parsing;
+// every command then parses the rest with a FlagSet of its own. Settings
+// are loaded with the config package, so each flag falls back to an
+// environment variable: NOTES_FILE for -file, NOTES_LIST_LIMIT for the
+// -limit of list, and so on. Try NOTES_LIST_SORT=oldest go run . list
+
+// settings before the command
+type global struct {
+	File string `config:"file" usage:"the file keeping the notes"`
+}
+
+type addSettings struct {
+	Tags []string `config:"tags" usage:"comma-separated tags of the note"`
+}
+
+type listSettings struct {
+	Tag   string `config:"tag" usage:"only notes with this tag"`
+	Limit int    `config:"limit" usage:"show at most this many notes (0: all)"`
+	Sort  string `config:"sort" usage:"newest or oldest first"`
+}
+
+func (s *listSettings) Validate() error {
+	if s.Sort != "newest" && s.Sort != "oldest" {
+		return fmt.Errorf("sort: want newest or oldest, not %q", s.Sort)
+	}
+	if s.Limit < 0 {
+		return errors.New("limit: must not be negative")
+	}
+	return nil
+}
+
+type rmSettings struct {
+	Force bool `config:"force" usage:"do not complain about notes that do not exist"`
+}
+
+// command is one subcommand: its settings and what it does with the rest
+// of the arguments
+type command struct {
+	usage    string
+	settings func() interface{}
+	run      func(nb *notebook, settings interface{}, args []string) (changed bool, err error)
+}
+
+var commands = map[string]command{
+	"add": {
+		usage:    "add [-tags a,b] text...",
+		settings: func() interface{} { return &addSettings{} },
+		run:      add,
+	},
+	"list": {
+		usage:    "list [-tag t] [-limit n] [-sort newest|oldest]",
+		settings: func() interface{} { return &listSettings{Limit: 20, Sort: "newest"} },
+		run:      list,
+	},
+	"rm": {
+		usage:    "rm [-force] id...",
+		settings: func() interface{} { return &rmSettings{} },
+		run:      rm,
+	},
+}
+
+// errUsage is returned for a command line that makes no sense; main
+// prints the usage of the command for it
+var errUsage = errors.New("usage")
+
+func add(nb *notebook, settings interface{}, args []string) (bool, error) {
+	s := settings.(*addSettings)
+	text := strings.TrimSpace(strings.Join(args, " "))
+	if text == "" {
+		return false, errUsage
+	}
+	n := nb.add(note{Text: text, Tags: s.Tags, Created: time.Now().Truncate(time.Second)})
+	fmt.Printf("added note %d\n", n.ID)
+	return true, nil
+}
+
+func list(nb *notebook, settings interface{}, args []string) (bool, error) {
+	s := settings.(*listSettings)
+	if len(args) > 0 {
+		return false, errUsage
+	}
+	var notes []note
+	for _, n := range nb.Notes {
+		if s.Tag == "" || hasTag(n, s.Tag) {
+			notes = append(notes, n)
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if s.Sort == "oldest" {
+			a, b = b, a
+		}
+		if !a.Created.Equal(b.Created) {
+			return a.Created.After(b.Created)
+		}
+		return a.ID > b.ID // Created has seconds only: in the same second, the higher ID is newer
+	})
+	more := 0
+	if s.Limit > 0 && len(notes) > s.Limit {
+		notes, more = notes[:s.Limit], len(notes)-s.Limit
+	}
+
+	if len(notes) == 0 {
+		fmt.Println("no notes")
+		return false, nil
+	}
+	// tabwriter lines up the cells, separated by tabs, into columns
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCREATED\tTAGS\tNOTE")
+	for _, n := range notes {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", n.ID, n.Created.Format("2006-01-02 15:04"), strings.Join(n.Tags, ","), shorten(n.Text, 50))
+	}
+	tw.Flush()
+	if more > 0 {
+		fmt.Printf("(%d more, see -limit)\n", more)
+	}
+	return false, nil
+}
+
+func rm(nb *notebook, settings interface{}, args []string) (bool, error) {
+	s := settings.(*rmSettings)
+	if len(args) == 0 {
+		return false, errUsage
+	}
+	var ids []int
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return false, fmt.Errorf("not the id of a note: %s", arg)
+		}
+		ids = append(ids, id)
+	}
+	changed := false
+	for _, id := range ids {
+		if nb.remove(id) {
+			fmt.Printf("removed note %d\n", id)
+			changed = true
+		} else if !s.Force {
+			return changed, fmt.Errorf("there is no note %d", id)
+		}
+	}
+	return changed, nil
+}
+
+func hasTag(n note, tag string) bool {
+	for _, t := range n.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// shorten cuts s to at most max runes, marking the cut with "..."
+func shorten(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-3]) + "..."
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: notes [-file notes.json] <command> [flags] [args]\n\ncommands:")
+	for _, name := range []string{"add", "list", "rm"} {
+		fmt.Fprintf(os.Stderr, "  notes %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nnotes <command> -h shows the flags of a command")
+	os.Exit(2)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "notes:", err)
+	os.Exit(1)
+}
+
+func main() {
+	g := global{File: "notes.json"}
+	gfs := flag.NewFlagSet("notes", flag.ExitOnError)
+	gfs.Usage = usage
+	if err := (&config.Loader{EnvPrefix: "NOTES_", FlagSet: gfs}).Load(&g); err != nil {
+		fatal(err)
+	}
+	if gfs.NArg() == 0 {
+		usage()
+	}
+	name, args := gfs.Arg(0), gfs.Args()[1:]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "notes: unknown command %q\n", name)
+		usage()
+	}
+
+	// the command's own FlagSet, and its own environment variables:
+	// NOTES_LIST_LIMIT is not NOTES_LIMIT
+	fs := flag.NewFlagSet("notes "+name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: notes %s\n", cmd.usage)
+		fs.PrintDefaults()
+	}
+	settings := cmd.settings()
+	loader := config.Loader{EnvPrefix: "NOTES_" + strings.ToUpper(name) + "_", FlagSet: fs, Args: args}
+	if err := loader.Load(settings); err != nil {
+		fatal(err)
+	}
+
+	nb, err := load(g.File)
+	if err != nil {
+		fatal(err)
+	}
+	changed, err := cmd.run(nb, settings, fs.Args())
+	if errors.Is(err, errUsage) {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if changed {
+		if err := nb.save(g.File); err != nil {
+			fatal(err)
+		}
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
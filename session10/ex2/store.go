@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+)
+
+// the notes live in one JSON file, read whole at the start of a command
+// and written back whole (and atomically) when the command changed them
+
+type note struct {
+	ID      int       `json:"id"`
+	Text    string    `json:"text"`
+	Tags    []string  `json:"tags,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+type notebook struct {
+	Next  int    `json:"next"` // ID of the next note, never reused
+	Notes []note `json:"notes"`
+}
+
+// load reads the notebook in path; a file that does not exist yet is an
+// empty notebook
+func load(path string) (*notebook, error) {
+	nb := &notebook{Next: 1}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nb, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, nb); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return nb, nil
+}
+
+func (nb *notebook) save(path string) error {
+	data, err := json.MarshalIndent(nb, "", "  ")
+	if err != nil {
+		return err
+	}
+	return safewrite.WriteFile(path, append(data, '\n'), 0644)
+}
+
+func (nb *notebook) add(n note) note {
+	n.ID = nb.Next
+	nb.Next++
+	nb.Notes = append(nb.Notes, n)
+	return n
+}
+
+// remove deletes the note with the given ID and reports whether there was one
+func (nb *notebook) remove(id int) bool {
+	for i, n := range nb.Notes {
+		if n.ID == id {
+			nb.Notes = append(nb.Notes[:i], nb.Notes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+// settings from defaults, a file, the environment and flags: which one wins
+//
+// The servers of session4 take their address from -addr, TRAINING_ADDR or
+// a default, and session4/ex2 from a -config file too. The rule is the
+// one of pkg/config: every source overrides the ones before it,
+//
+//	defaults < file < environment < flags
+//
+// so the setting closest to the command being run wins. Each line below
+// loads the same settings from a made-up environment and command line,
+// with a FlagSet and an environment of its own, and shows what it got.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+)
+
+type settings struct {
+	Addr  string        `config:"addr" usage:"address to listen on" required:"true"`
+	Grace time.Duration `config:"grace" usage:"how long a shutdown waits for requests in flight"`
+	Debug bool          `config:"debug" usage:"log more"`
+	DB    struct {
+		URL  string `config:"url" usage:"database to connect to"`
+		Pool int    `config:"pool" usage:"connections to keep open"`
+	} `config:"db"` // nested: the keys are db.url and db.pool, TRAINING_DB_URL and TRAINING_DB_POOL
+}
+
+func defaults() settings {
+	s := settings{Addr: "localhost:3000", Grace: 5 * time.Second}
+	s.DB.Pool = 1
+	return s
+}
+
+// lookup looks up variables in env instead of the environment of the process
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+// load loads the settings with file (if any), the variables of env and
+// the command line args
+func load(what, file string, env map[string]string, args ...string) {
+	s := defaults()
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	loader := config.Loader{
+		EnvPrefix: "TRAINING_",
+		File:      file,
+		FileFlag:  "config",
+		FlagSet:   fs,
+		Args:      args,
+		LookupEnv: lookup(env),
+	}
+	fmt.Printf("%-34s ", what)
+	if err := loader.Load(&s); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Printf("addr=%s grace=%v debug=%t db.url=%q db.pool=%d", s.Addr, s.Grace, s.Debug, s.DB.URL, s.DB.Pool)
+	if fs.NArg() > 0 {
+		fmt.Printf(" rest=%q", fs.Args())
+	}
+	fmt.Println()
+}
+
+func main() {
+	env := map[string]string{"TRAINING_ADDR": ":8080", "TRAINING_DB_POOL": "20"}
+	load("defaults", "", nil)
+	load("settings.yaml", "settings.yaml", nil)
+	load("settings.yaml, env", "settings.yaml", env)
+	load("settings.yaml, env, flags", "settings.yaml", env, "-addr", "localhost:9000", "-debug")
+	load("-config names the file", "", env, "-config", "settings.yaml", "-db.pool", "3")
+	// a flag given on the command line wins, even with the default value
+	load("a flag set to its default", "", env, "-addr", "localhost:3000")
+	// parsing stops at the first argument that is not a flag (see ex1)
+	load("flags after a command", "", env, "serve", "-addr", "localhost:9000")
+
+	// a variable that is set, even to "", counts: this one empties a
+	// required setting. config.Addr, for servers with -addr only,
+	// ignores an empty TRAINING_ADDR instead
+	load("empty TRAINING_ADDR", "", map[string]string{"TRAINING_ADDR": ""})
+	load("TRAINING_GRACE=soon", "", map[string]string{"TRAINING_GRACE": "soon"})
+	load("-grace 1m -grace 2m", "", nil, "-grace", "1m", "-grace", "2m") // the last one wins
+	load("-config missing.json", "", nil, "-config", "missing.json")
+
+	fmt.Println()
+	fmt.Println("the flags, with the environment variables that set them too:")
+	s := defaults()
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	loader := config.Loader{EnvPrefix: "TRAINING_", FileFlag: "config", FlagSet: fs, Args: []string{}, LookupEnv: lookup(nil)}
+	loader.Load(&s)
+	fs.VisitAll(func(f *flag.Flag) {
+		env := "TRAINING_" + strings.ToUpper(strings.ReplaceAll(f.Name, ".", "_"))
+		if f.Name == loader.FileFlag {
+			env = "(flag only)"
+		}
+		fmt.Printf("  -%-8s %-18s %-48s default %q\n", f.Name, env, f.Usage, f.DefValue)
+	})
+}
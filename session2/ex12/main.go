@@ -1,7 +1,7 @@
 package main
 import (
 	"fmt"
-	"min"
+	"github.com/hannansatopay/training-golang/session2/ex12/min"
 )
 
 func ints() {
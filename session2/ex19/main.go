@@ -1,7 +1,7 @@
 package main
 import (
 	"fmt"
-	"mystack"
+	"github.com/hannansatopay/training-golang/session2/ex19/mystack"
 )
 
 var st1 mystack.Stack
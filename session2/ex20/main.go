@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/lru"
+)
+
+// the lru package is generic: the same code caches string->int and int->[]byte,
+// with the types checked by the compiler instead of interface{} assertions
+
+func behaviour() {
+	c := lru.New[string, int](3, 0)
+	c.OnEvict = func(k string, v int) { fmt.Printf("  evicted %s=%d\n", k, v) }
+	for i, k := range []string{"a", "b", "c"} {
+		c.Add(k, i)
+	}
+	c.Get("a")    // "a" is now the most recently used
+	c.Add("d", 3) // cache is full: "b", the least recently used, goes
+	fmt.Println("keys, most recent first:", c.Keys())
+	if _, ok := c.Get("b"); !ok {
+		fmt.Println("  b is gone")
+	}
+	s := c.Stats()
+	fmt.Printf("  hits=%d misses=%d evictions=%d hit rate=%.2f\n", s.Hits, s.Misses, s.Evictions, s.HitRate())
+
+	// entries with a time to live
+	short := lru.New[int, []byte](10, 50*time.Millisecond)
+	short.Add(1, []byte("fresh"))
+	v, ok := short.Get(1)
+	fmt.Printf("ttl: right away %q %v\n", v, ok)
+	time.Sleep(80 * time.Millisecond)
+	v, ok = short.Get(1)
+	fmt.Printf("ttl: after 80ms %q %v, expired=%d\n", v, ok, short.Stats().Expired)
+}
+
+var keys = func() []string {
+	k := make([]string, 1024)
+	for i := range k {
+		k[i] = strconv.Itoa(i)
+	}
+	return k
+}()
+
+func BenchmarkAdd(b *testing.B) {
+	c := lru.New[string, int](512, 0) // half the keys fit: constant eviction
+	for i := 0; i < b.N; i++ {
+		c.Add(keys[i%len(keys)], i)
+	}
+}
+
+func BenchmarkGetHit(b *testing.B) {
+	c := lru.New[string, int](len(keys), 0)
+	for i, k := range keys {
+		c.Add(k, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Get(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkGetParallel(b *testing.B) {
+	c := lru.New[string, int](len(keys), 0)
+	for i, k := range keys {
+		c.Add(k, i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) { // one mutex: watch it get slower per op
+		i := 0
+		for pb.Next() {
+			c.Get(keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+func main() {
+	behaviour()
+	fmt.Println("add (evicting):", testing.Benchmark(BenchmarkAdd).String())
+	fmt.Println("get (hit):     ", testing.Benchmark(BenchmarkGetHit).String())
+	fmt.Println("get (parallel):", testing.Benchmark(BenchmarkGetParallel).String())
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hannansatopay/training-golang/pkg/mysort"
+)
+
+// sorting by several keys: last name, then first name, then age. One less
+// function per key, combined by mysort.By, instead of one Less that
+// compares all three by hand, and no new type for every order.
+
+type person struct {
+	first, last string
+	age         int
+}
+
+type people []person
+
+func (p people) Len() int           { return len(p) }
+func (p people) Less(i, j int) bool { return p[i].last < p[j].last }
+func (p people) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func show(title string, p people) {
+	fmt.Println(title)
+	for _, x := range p {
+		fmt.Printf("  %-8s %-8s %3d\n", x.last, x.first, x.age)
+	}
+}
+
+func main() {
+	p := people{
+		{"Ada", "Lovelace", 36}, {"Alan", "Turing", 41}, {"Grace", "Hopper", 85},
+		{"Ada", "Turing", 12}, {"Alan", "Lovelace", 60}, {"Alan", "Turing", 7},
+		{"Grace", "Lovelace", 30}, {"Ada", "Hopper", 51},
+	}
+	last := func(i, j int) bool { return p[i].last < p[j].last }
+	first := func(i, j int) bool { return p[i].first < p[j].first }
+	age := func(i, j int) bool { return p[i].age < p[j].age }
+
+	// by last name only: the order within a family is whatever Sort made of it
+	mysort.Sort(p)
+	show("last name:", p)
+
+	mysort.Sort(mysort.By(p, last, first, age))
+	show("last name, first name, age:", p)
+
+	// the keys in another order, and one of them reversed
+	older := func(i, j int) bool { return p[i].age > p[j].age }
+	mysort.Sort(mysort.By(p, first, older))
+	show("first name, oldest first:", p)
+
+	// Lexicographic makes the combined less function by itself, e.g. to
+	// check an order
+	less := mysort.Lexicographic(last, first, age)
+	sorted := true
+	for i := 1; i < len(p); i++ {
+		if less(i, i-1) {
+			sorted = false
+		}
+	}
+	fmt.Println("still sorted by last name, first name, age:", sorted)
+}
@@ -44,7 +44,6 @@ func fI(it Simpler) int { // switch cases to judge whether it's Simple or RSimpl
 	default:
 			return 99
 	}
-	return 0
 }
 
 func main() {
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	// "sort"      // this uses the Go sort package, then replace mysort. with sort. in the code below
+	"github.com/hannansatopay/training-golang/pkg/mysort" // this uses our own sort package (a subset of the Go sort package)
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// sorting of slice of integers
+func ints() {
+	data := []int{74, 59, 238, -784, 9845, 959, 905, 0, 0, 42, 7586, -5467984, 7586}
+	input := append([]int(nil), data...)
+	a := mysort.IntSlice(data) //conversion to type IntSlice
+	mysort.Sort(a)
+	// sorted, and nothing lost or doubled on the way
+	if !mysort.IsSorted(a) || !mysort.IsPermutation(input, data) {
+		panic("fail")
+	}
+	fmt.Printf("Numbers: %v\n", a)
+	// a shuffle with a fixed seed is the same every run
+	mysort.Shuffle(a, rand.New(rand.NewSource(1)))
+	fmt.Printf("Numbers shuffled: %v\n", a)
+	// no need to sort for these: one pass for each of min and max, and
+	// a quickselect for the median
+	least, most := a[mysort.MinIndex(a)], a[mysort.MaxIndex(a)]
+	fmt.Printf("Min %d, max %d, median %v\n", least, most, a.Median()) // Median reorders a
+	mysort.Sort(a)
+	mysort.Sort(mysort.Reverse(a)) // Reverse only swaps the arguments of Less
+	fmt.Printf("Numbers descending: %v\n", a)
+}
+
+// sorting of slice of strings
+func strings() {
+	data := []string{"Monday", "Friday", "Tuesday", "Wednesday", "Sunday", "Thursday", "", "Saturday"}
+	input := append([]string(nil), data...)
+	a := mysort.StringSlice(data)
+	mysort.Sort(a)
+	if !mysort.IsSorted(a) || !mysort.IsPermutation(input, data) {
+		panic("fail")
+	}
+	fmt.Printf("Alphabets: %v\n", a)
+	mysort.Sort(mysort.Reverse(a))
+	fmt.Printf("Alphabets descending: %v\n", a)
+
+	// capitals sort before all small letters, and accented letters after
+	// z: FoldStringSlice ignores case, a collator knows the language
+	mixed := []string{"monday", "Tuesday", "Monday", "émile", "Zoe", "eve", "Éric", "zed", "Ölaf", "oscar"}
+	plain := mysort.StringSlice(append([]string(nil), mixed...))
+	mysort.Sort(plain)
+	fmt.Printf("Mixed: %v\n", plain)
+	fold := mysort.FoldStringSlice(append([]string(nil), mixed...))
+	mysort.Sort(fold)
+	fmt.Printf("Mixed, any case: %v\n", fold)
+	german := mysort.CollatedStringSlice{Strings: mixed, Compare: collate.New(language.German).CompareString}
+	mysort.Sort(german)
+	fmt.Printf("Mixed, German: %v\n", german.Strings)
+}
+
+// sorting of file names: StringSlice compares byte by byte, so "10"
+// comes before "2"; NaturalStringSlice compares the numbers by value
+func files() {
+	data := []string{"img12.png", "img10.png", "IMG2.png", "img2.png", "img1.png", "img02.png", "chapter 10", "chapter 9", "chapter 9b"}
+	a := mysort.StringSlice(append([]string(nil), data...))
+	mysort.Sort(a)
+	fmt.Printf("Files: %q\n", a)
+	n := mysort.NaturalStringSlice(data)
+	mysort.Sort(n)
+	if !mysort.IsSorted(n) {
+		panic("fail")
+	}
+	fmt.Printf("Files, natural order: %q\n", n)
+}
+
+// sorting of slice of floats, with infinities and NaN (not a number)
+func floats() {
+	nan, inf := math.NaN(), math.Inf(1)
+	data := []float64{2.5, nan, -inf, 0, inf, -1.25, nan, 1e-9, -0.0}
+	a := mysort.Float64Slice(data)
+	mysort.Sort(a)
+	if !mysort.IsSorted(a) {
+		panic("fail")
+	}
+	fmt.Printf("Floats: %v\n", a)
+	fmt.Printf("Median of the numbers: %v\n", a[2:].Median())
+	// with < alone, every comparison with NaN is false: NaN stays where
+	// it is and cuts the slice into parts that are sorted on their own
+	b := []float64{2.5, nan, -inf, 0, inf, -1.25, nan, 1e-9, -0.0}
+	mysort.SortFunc(b, func(x, y float64) bool { return x < y })
+	fmt.Printf("Floats with <: %v\n", b)
+}
+
+// a type which describes a day of the week
+type day struct {
+	num       int
+	shortName string
+	longName  string
+}
+
+type dayArray struct {
+	data []*day
+}
+
+func (p *dayArray) Len() int           { return len(p.data) }
+func (p *dayArray) Less(i, j int) bool { return p.data[i].num < p.data[j].num }
+func (p *dayArray) Swap(i, j int)      { p.data[i], p.data[j] = p.data[j], p.data[i] }
+
+// sorting of custom type day
+func days() {
+	Sunday := day{6, "SUN", "Sunday"}
+	Monday := day{0, "MON", "Monday"}
+	Tuesday := day{1, "TUE", "Tuesday"}
+	Wednesday := day{2, "WED", "Wednesday"}
+	Thursday := day{3, "THU", "Thursday"}
+	Friday := day{4, "FRI", "Friday"}
+	Saturday := day{5, "SAT", "Saturday"}
+	data := []*day{&Tuesday, &Thursday, &Wednesday, &Sunday, &Monday, &Friday, &Saturday}
+	a := dayArray{data}
+	mysort.Sort(&a)
+	if !mysort.IsSorted(&a) {
+		panic("fail")
+	}
+	for _, d := range data {
+		fmt.Printf("%s ", d.longName)
+	}
+	fmt.Printf("\n")
+	mysort.Sort(mysort.Reverse(&a))
+	for _, d := range data {
+		fmt.Printf("%s ", d.shortName)
+	}
+	fmt.Printf("\n")
+	// other keys without another type: working days first, then by short name
+	mysort.Sort(mysort.By(&a,
+		func(i, j int) bool { return a.data[i].num < 5 && a.data[j].num >= 5 },
+		func(i, j int) bool { return a.data[i].shortName < a.data[j].shortName },
+	))
+	for _, d := range data {
+		fmt.Printf("%s ", d.shortName)
+	}
+	fmt.Printf("\n")
+}
+
+// a person with two keys to sort by
+type person struct {
+	name string
+	age  int
+}
+
+type byName []person
+
+func (p byName) Len() int           { return len(p) }
+func (p byName) Less(i, j int) bool { return p[i].name < p[j].name }
+func (p byName) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+type byAge []person
+
+func (p byAge) Len() int           { return len(p) }
+func (p byAge) Less(i, j int) bool { return p[i].age < p[j].age }
+func (p byAge) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// sorting by two keys: first by the second key (name), then stably by the
+// first (age). Stable keeps people of the same age in name order; Sort
+// makes no such promise.
+func people() {
+	data := []person{{"Mia", 31}, {"Ben", 25}, {"Zoe", 31}, {"Ada", 40}, {"Eli", 25}, {"Kim", 31}, {"Ann", 25}}
+	mysort.Sort(byName(data))
+	mysort.Stable(byAge(data))
+	if !mysort.IsSorted(byAge(data)) {
+		panic("fail")
+	}
+	for _, p := range data {
+		fmt.Printf("%s (%d) ", p.name, p.age)
+	}
+	fmt.Printf("\n")
+}
+
+// the same with type parameters: no IntSlice, dayArray or byAge types,
+// just the slice, and for anything but numbers and strings a less function
+func generic() {
+	numbers := []int{74, 59, 238, -784, 9845, 959, 905, 0, 0, 42, 7586, -5467984, 7586}
+	mysort.SortSlice(numbers) // SortSlice[int], the type is inferred
+	if !mysort.SliceIsSorted(numbers) {
+		panic("fail")
+	}
+	fmt.Printf("Numbers: %v\n", numbers)
+
+	names := []string{"Monday", "Friday", "Tuesday", "Wednesday", "Sunday", "Thursday", "", "Saturday"}
+	mysort.SortSlice(names)
+	fmt.Printf("Alphabets: %v\n", names)
+
+	data := []person{{"Mia", 31}, {"Ben", 25}, {"Zoe", 31}, {"Ada", 40}, {"Eli", 25}, {"Kim", 31}, {"Ann", 25}}
+	mysort.SortFunc(data, func(a, b person) bool {
+		if a.age != b.age {
+			return a.age < b.age
+		}
+		return a.name < b.name // two keys in one function
+	})
+	for _, p := range data {
+		fmt.Printf("%s (%d) ", p.name, p.age)
+	}
+	fmt.Printf("\n")
+}
+
+func main() {
+	ints()
+	strings()
+	files()
+	floats()
+	days()
+	people()
+	fmt.Println("generic:")
+	generic()
+}
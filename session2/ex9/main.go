@@ -2,7 +2,7 @@ package main
 
 import (
 	"fmt"
-	"./mysort"
+	"github.com/hannansatopay/training-golang/pkg/mysort"
 )
 
 type Person struct {
@@ -1,5 +1,8 @@
 package main
-import "fmt"
+import "github.com/hannansatopay/training-golang/pkg/logger"
+
+// run with TRAINING_LOG=workers=debug to see every request being checked
+var log = logger.New("workers")
 
 type Request struct {
   a, b int
@@ -40,10 +43,10 @@ func main() {
   // checks:
   for i := N - 1; i >= 0; i-- { // doesn't matter what order
     if <-reqs[i].replyc != N+2*i {
-      fmt.Println("fail at", i)
+      log.Error("request failed", "i", i)
     } else {
-      fmt.Println("Request ", i, " is ok!")
+      log.Debug("request ok", "i", i)
     }
   }
-  fmt.Println("done")
+  log.Info("done", "requests", N)
 }
\ No newline at end of file
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// duplicate file finder: go run ./session3/ex27 [-min 1] [-max 0] [-workers N] [-json] dir...
+// 1. walk the trees, a goroutine per directory, and group files by size
+//    (files of different sizes can't be equal)
+// 2. a pool of worker goroutines hashes only the files that share a size
+// 3. files with the same SHA-256 are reported as one duplicate set
+
+var (
+	minSize = flag.Int64("min", 1, "ignore files smaller than this many bytes")
+	maxSize = flag.Int64("max", 0, "ignore files larger than this many bytes (0: no limit)")
+	workers = flag.Int("workers", runtime.NumCPU(), "number of hashing goroutines, and of directories read at once")
+	asJSON  = flag.Bool("json", false, "print the result as JSON")
+)
+
+type DuplicateSet struct {
+	Hash  string   `json:"sha256"`
+	Size  int64    `json:"size"`
+	Files []string `json:"files"`
+}
+
+type job struct {
+	path string
+	size int64
+}
+
+type result struct {
+	job
+	hash string
+	err  error
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// bySize walks the roots and returns regular files grouped by size. Every
+// directory is read by a goroutine of its own, which starts one for each
+// subdirectory; at most n of them read at the same time.
+func bySize(roots []string, n int) map[int64][]string {
+	var (
+		mu    sync.Mutex // guards sizes
+		sizes = map[int64][]string{}
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, n)
+	)
+	add := func(path string, d fs.DirEntry) {
+		info, err := d.Info()
+		if err != nil {
+			return
+		}
+		if size := info.Size(); size >= *minSize && (*maxSize == 0 || size <= *maxSize) {
+			mu.Lock()
+			sizes[size] = append(sizes[size], path)
+			mu.Unlock()
+		}
+	}
+	var walk func(dir string)
+	walk = func(dir string) {
+		defer wg.Done()
+		// a slot is held only while reading, not while the subdirectories
+		// wait for theirs
+		sem <- struct{}{}
+		entries, err := os.ReadDir(dir)
+		<-sem
+		if err != nil { // the entries read before the error are still walked
+			fmt.Fprintln(os.Stderr, "skipping:", err)
+		}
+		for _, d := range entries {
+			path := filepath.Join(dir, d.Name())
+			switch {
+			case d.IsDir():
+				wg.Add(1)
+				go walk(path)
+			case d.Type().IsRegular():
+				add(path, d)
+			}
+		}
+	}
+	for _, root := range roots {
+		info, err := os.Lstat(root)
+		switch {
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "skipping:", err)
+		case info.IsDir():
+			wg.Add(1)
+			go walk(root)
+		case info.Mode().IsRegular():
+			add(root, fs.FileInfoToDirEntry(info))
+		}
+	}
+	wg.Wait()
+	return sizes
+}
+
+// hashAll runs the worker pool: jobs go in on one channel, results come
+// out on another, and the results channel is closed when all workers are done
+func hashAll(jobs []job, n int) <-chan result {
+	in := make(chan job)
+	out := make(chan result)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range in {
+				h, err := hashFile(j.path)
+				out <- result{j, h, err}
+			}
+		}()
+	}
+	go func() {
+		for _, j := range jobs {
+			in <- j
+		}
+		close(in)
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+func main() {
+	flag.Parse()
+	if *workers < 1 {
+		fmt.Fprintf(os.Stderr, "-workers %d: want at least 1\n", *workers)
+		os.Exit(2)
+	}
+	roots := flag.Args()
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	var jobs []job
+	for size, paths := range bySize(roots, *workers) {
+		if len(paths) > 1 {
+			for _, p := range paths {
+				jobs = append(jobs, job{p, size})
+			}
+		}
+	}
+
+	groups := map[string]*DuplicateSet{}
+	for r := range hashAll(jobs, *workers) {
+		if r.err != nil {
+			fmt.Fprintln(os.Stderr, "skipping:", r.err)
+			continue
+		}
+		set := groups[r.hash]
+		if set == nil {
+			set = &DuplicateSet{Hash: r.hash, Size: r.size}
+			groups[r.hash] = set
+		}
+		set.Files = append(set.Files, r.path)
+	}
+
+	sets := []DuplicateSet{}
+	var wasted int64
+	for _, set := range groups {
+		if len(set.Files) > 1 {
+			sort.Strings(set.Files) // workers finish in any order
+			sets = append(sets, *set)
+			wasted += set.Size * int64(len(set.Files)-1)
+		}
+	}
+	sort.Slice(sets, func(i, j int) bool { // biggest savings first
+		return sets[i].Size*int64(len(sets[i].Files)) > sets[j].Size*int64(len(sets[j].Files))
+	})
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(sets)
+		return
+	}
+	for _, set := range sets {
+		fmt.Printf("%d bytes, sha256 %s\n", set.Size, set.Hash[:16])
+		for _, f := range set.Files {
+			fmt.Println("   ", f)
+		}
+	}
+	fmt.Printf("%d duplicate sets, %d bytes could be freed (%d files hashed by %d workers)\n",
+		len(sets), wasted, len(jobs), *workers)
+}
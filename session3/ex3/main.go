@@ -24,6 +24,5 @@ func getData(ch chan string) {
   for {
     input = <-ch // receiving data sent to ch channel
     fmt.Printf("%s ", input)
-  }
-  close(ch) // closed the channel
+  } // endless loop: getData never closes ch, only the sender should close a channel
 }
\ No newline at end of file
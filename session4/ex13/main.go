@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+	"github.com/hannansatopay/training-golang/pkg/search"
+)
+
+var log = logger.New("web")
+
+// a guest book entry; entries are indexed for /search by name and message
+type entry struct {
+	Name    string
+	Message string
+}
+
+var (
+	mu        sync.Mutex // handlers run concurrently
+	guestList []entry
+	index     = search.New()
+)
+
+func main() {
+	addr := config.Addr(":3000")
+	flag.Parse()
+	http.HandleFunc("/", indexHandler)
+	http.HandleFunc("/add", addHandler)
+	http.HandleFunc("/search", searchHandler)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil)}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
+
+// indexHandler serves the main page
+func indexHandler(w http.ResponseWriter, req *http.Request) {
+	t := template.New("index.html")
+	t, err := t.Parse(indexHTML)
+	if err != nil {
+		message := fmt.Sprintf("bad template: %s", err)
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	mu.Lock()
+	guests := append([]entry(nil), guestList...)
+	mu.Unlock()
+	// the HTML output is now safe against code injection
+	render(w, t, guests)
+}
+
+// addHandler add a name to the names list
+func addHandler(w http.ResponseWriter, req *http.Request) {
+	guest := req.FormValue("name")
+	if len(guest) > 0 {
+		mu.Lock()
+		e := entry{guest, req.FormValue("message")}
+		guestList = append(guestList, e)
+		index.Add(len(guestList)-1, e.Name+" "+e.Message) // the position is the document id
+		mu.Unlock()
+	}
+	http.Redirect(w, req, "/", http.StatusFound)
+}
+
+// searchHandler shows the entries matching ?q=..., best match first.
+// Words must all match; OR separates alternatives: /search?q=go+OR+gopher
+func searchHandler(w http.ResponseWriter, req *http.Request) {
+	t, err := template.New("search.html").Parse(searchHTML)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("bad template: %s", err), http.StatusInternalServerError)
+		return
+	}
+	type hit struct {
+		entry
+		Score float64
+	}
+	q := req.FormValue("q")
+	var hits []hit
+	mu.Lock()
+	for _, r := range index.Search(q) {
+		hits = append(hits, hit{guestList[r.ID], r.Score})
+	}
+	mu.Unlock()
+	render(w, t, struct {
+		Query string
+		Hits  []hit
+	}{q, hits})
+}
+
+// render executes t into a buffer first: when it fails, nothing was sent
+// yet, and the client gets a 500 instead of half a page
+func render(w http.ResponseWriter, t *template.Template, data any) {
+	var page bytes.Buffer
+	if err := t.Execute(&page, data); err != nil {
+		log.Error("rendering", "template", t.Name(), "err", err)
+		http.Error(w, "could not render the page", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page.WriteTo(w)
+}
+
+var indexHTML = `
+<!DOCTYPE html>
+<html>
+    <head>
+		<title>Guest Book ::Web GUI</title>
+    </head>
+    <body>
+		<h1>Guest Book :: Web GUI</h1>
+		<form action="/add" method="post">
+		Name: <input name="name" /> Message: <input name="message" /><input type="submit" value="Sign Guest Book">
+		</form>
+		<form action="/search">
+		<input name="q" /><input type="submit" value="Search">
+		</form>
+		<hr />
+		<h4>Previous Guests</h4>
+		<ul>
+			{{range .}}
+			<li>{{.Name}}: {{.Message}}</li>
+			{{end}}
+		</ul>
+	</body>
+</html>
+`
+
+var searchHTML = `
+<!DOCTYPE html>
+<html>
+    <head>
+		<title>Guest Book :: Search</title>
+    </head>
+    <body>
+		<h1>Results for "{{.Query}}"</h1>
+		<ol>
+			{{range .Hits}}
+			<li>{{.Name}}: {{.Message}} <small>({{printf "%.3f" .Score}})</small></li>
+			{{else}}
+			<li>no entries found</li>
+			{{end}}
+		</ol>
+		<a href="/">back</a>
+	</body>
+</html>
+`
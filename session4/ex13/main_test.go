@@ -0,0 +1,75 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+func sign(t *testing.T, name, message string) {
+	t.Helper()
+	r := httptest.NewRequest("POST", "/add", strings.NewReader(url.Values{"name": {name}, "message": {message}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	addHandler(w, r)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/" {
+		t.Errorf("POST /add: %d to %q, want 302 to /", w.Code, w.Header().Get("Location"))
+	}
+}
+
+// a signed entry shows on the main page, escaped
+func TestSign(t *testing.T) {
+	sign(t, "<b>Gopher</b>", "hello from the burrow")
+	w := httptest.NewRecorder()
+	indexHandler(w, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(w.Body.String(), "<li>&lt;b&gt;Gopher&lt;/b&gt;: hello from the burrow</li>") {
+		t.Errorf("GET /: no escaped entry in %q", w.Body.String())
+	}
+}
+
+// an entry without a name is not added
+func TestSignWithoutName(t *testing.T) {
+	sign(t, "", "anonymous")
+	w := httptest.NewRecorder()
+	indexHandler(w, httptest.NewRequest("GET", "/", nil))
+	if strings.Contains(w.Body.String(), "anonymous") {
+		t.Error("GET /: the entry without a name is listed")
+	}
+}
+
+// search finds the entries by words of their name or message
+func TestSearch(t *testing.T) {
+	sign(t, "Ken", "searching the guest book")
+	sign(t, "Rob", "nothing to see")
+	w := httptest.NewRecorder()
+	searchHandler(w, httptest.NewRequest("GET", "/search?q=guest", nil))
+	page := w.Body.String()
+	if !strings.Contains(page, "<li>Ken: searching the guest book") || strings.Contains(page, "Rob") {
+		t.Errorf("GET /search?q=guest: want Ken's entry only, got %q", page)
+	}
+	w = httptest.NewRecorder()
+	searchHandler(w, httptest.NewRequest("GET", "/search?q=zebra", nil))
+	if !strings.Contains(w.Body.String(), "no entries found") {
+		t.Errorf("GET /search?q=zebra: want no entries found, got %q", w.Body.String())
+	}
+}
+
+// a template that fails answers 500, with none of the page
+func TestRenderFails(t *testing.T) {
+	tmpl := template.Must(template.New("broken").Parse("<p>before</p>{{.Missing}}"))
+	w := httptest.NewRecorder()
+	render(w, tmpl, struct{}{})
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "before") {
+		t.Errorf("a failing template gave %d %q, want 500 without the page", w.Code, w.Body.String())
+	}
+}
@@ -1,22 +1,32 @@
 package main
+
 import (
 	"bytes"
 	"expvar"
 	"flag"
 	"fmt"
-	"net/http"
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
 	"io"
-	"log"
+	"net/http"
 	"os"
 	"strconv"
+	"time"
 )
 
+var log = logger.New("web")
+
 // hello world, the web server
 var helloRequests = expvar.NewInt("hello-requests")
-// flags:
-var webroot = flag.String("root", "/home/user", "web root directory")
-// simple flag server
-var booleanflag = flag.Bool("boolean", true, "another flag for testing")
+
+// settings, loaded from flags, TRAINING_* environment variables or a -config file:
+type settings struct {
+	Addr    string `config:"addr" usage:"address to listen on"`
+	Root    string `config:"root" usage:"web root directory"`
+	Boolean bool   `config:"boolean" usage:"another flag for testing"` // simple flag server
+}
 
 // Simple counter server. POSTing to it will set the value.
 type Counter struct {
@@ -27,7 +37,11 @@ type Counter struct {
 type Chan chan int
 
 func main() {
-	flag.Parse()
+	s := settings{Addr: "0.0.0.0:3000", Root: "/home/user", Boolean: true}
+	loader := config.Loader{EnvPrefix: "TRAINING_", FileFlag: "config"}
+	if err := loader.Load(&s); err != nil {
+		log.Fatal("loading config", "err", err)
+	}
 	http.Handle("/", http.HandlerFunc(Logger))
 	http.Handle("/go/hello", http.HandlerFunc(HelloServer))
 	// The counter is published as a variable directly.
@@ -35,19 +49,19 @@ func main() {
 	expvar.Publish("counter", ctr)
 	http.Handle("/counter", ctr)
 	// http.Handle("/go/", http.FileServer(http.Dir("/tmp"))) // uses the OS filesystem
-	http.Handle("/go/", http.StripPrefix("/go/", http.FileServer(http.Dir(*webroot))))
+	http.Handle("/go/", http.StripPrefix("/go/", http.FileServer(http.Dir(s.Root))))
 	http.Handle("/flags", http.HandlerFunc(FlagServer))
 	http.Handle("/args", http.HandlerFunc(ArgServer))
 	http.Handle("/chan", ChanCreate())
 	http.Handle("/date", http.HandlerFunc(DateServer))
-	err := http.ListenAndServe("0.0.0.0:3000", nil)
+	err := graceful.ListenAndServe(&http.Server{Addr: s.Addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil)}, 5*time.Second)
 	if err != nil {
-		log.Panicln("ListenAndServe:", err)
+		log.Fatal("ListenAndServe", "err", err)
 	}
 }
 
 func Logger(w http.ResponseWriter, req *http.Request) {
-	log.Print(req.URL.String())
+	log.Info("not found", "url", req.URL.String())
 	w.WriteHeader(404)
 	w.Write([]byte("oops"))
 }
@@ -139,4 +153,4 @@ func DateServer(rw http.ResponseWriter, req *http.Request) {
 		fmt.Fprintf(rw, "date: %v\n", wait)
 		return
 	}
-}
\ No newline at end of file
+}
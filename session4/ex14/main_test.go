@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// HelloServer answers hello, world and counts its requests
+func TestHelloServer(t *testing.T) {
+	before := helloRequests.Value()
+	w := httptest.NewRecorder()
+	HelloServer(w, httptest.NewRequest("GET", "/go/hello", nil))
+	if w.Body.String() != "hello, world!\n" || helloRequests.Value() != before+1 {
+		t.Errorf("GET /go/hello: %q, hello-requests %d to %d", w.Body.String(), before, helloRequests.Value())
+	}
+}
+
+// Logger answers 404 oops
+func TestLogger(t *testing.T) {
+	w := httptest.NewRecorder()
+	Logger(w, httptest.NewRequest("GET", "/nowhere", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != "oops" {
+		t.Errorf("GET /nowhere: %d %q, want 404 oops", w.Code, w.Body.String())
+	}
+}
+
+// the counter counts GETs and is set by a POST
+func TestCounter(t *testing.T) {
+	ctr := new(Counter)
+	do := func(method, body string) string {
+		w := httptest.NewRecorder()
+		ctr.ServeHTTP(w, httptest.NewRequest(method, "/counter", strings.NewReader(body)))
+		return w.Body.String()
+	}
+	do("GET", "")
+	if got := do("GET", ""); got != "counter = 2\n" {
+		t.Errorf("second GET: %q, want counter = 2", got)
+	}
+	if got := do("POST", "40"); got != "counter reset\ncounter = 40\n" {
+		t.Errorf("POST 40: %q", got)
+	}
+	if got := do("POST", "forty"); !strings.HasPrefix(got, "bad POST") || !strings.HasSuffix(got, "counter = 40\n") {
+		t.Errorf("POST forty: %q, want bad POST and the counter unchanged", got)
+	}
+}
+
+// every request to the channel handler gets the next number
+func TestChan(t *testing.T) {
+	ch := ChanCreate()
+	for _, want := range []string{"channel send #0\n", "channel send #1\n"} {
+		w := httptest.NewRecorder()
+		ch.ServeHTTP(w, httptest.NewRequest("GET", "/chan", nil))
+		if w.Body.String() != want {
+			t.Errorf("GET /chan: %q, want %q", w.Body.String(), want)
+		}
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"net"
 	"net/rpc"
 	"time"
-	"rpcobjects"
+	"github.com/hannansatopay/training-golang/pkg/rpcobjects"
 )
 
 func main() {
@@ -3,7 +3,7 @@ import (
 "fmt"
 "log"
 "net/rpc"
-"./rpcobjects"
+"github.com/hannansatopay/training-golang/pkg/rpcobjects"
 )
 
 const serverAddress = "localhost"
@@ -13,7 +13,7 @@ func main() {
     log.Fatal("Error dialing:", err)
   }
   // Synchronous call
-  args := &rpcobjects.Args{7, 8}
+  args := &rpcobjects.Args{N: 7, M: 8}
   var reply int
   err = client.Call("Args.Multiply", args, &reply)
   if err != nil {
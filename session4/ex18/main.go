@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/lru"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// an HTTP response cache: the first GET of a URL runs the (slow) handler,
+// later GETs are answered from an LRU cache until the entry expires.
+// The X-Cache header shows HIT or MISS.
+
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// recorder captures what a handler writes, while still sending it to the client
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+var responses = lru.New[string, cachedResponse](100, 10*time.Second)
+
+func cache(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "GET" { // only safe requests can be cached
+			next(w, req)
+			return
+		}
+		key := req.URL.String()
+		if c, ok := responses.Get(key); ok {
+			for k, v := range c.header {
+				w.Header()[k] = v
+			}
+			w.Header().Set("X-Cache", "HIT")
+			w.WriteHeader(c.status)
+			w.Write(c.body)
+			return
+		}
+		w.Header().Set("X-Cache", "MISS")
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, req)
+		if rec.status == http.StatusOK { // don't cache errors
+			responses.Add(key, cachedResponse{rec.status, w.Header().Clone(), rec.body.Bytes()})
+		}
+	}
+}
+
+// SlowServer pretends to do an expensive computation
+func SlowServer(w http.ResponseWriter, req *http.Request) {
+	time.Sleep(time.Second)
+	fmt.Fprintf(w, "computed at %s for %s\n", time.Now().Format("15:04:05.000"), req.URL.Query().Get("q"))
+}
+
+func StatsServer(w http.ResponseWriter, req *http.Request) {
+	s := responses.Stats()
+	fmt.Fprintf(w, "entries=%d hits=%d misses=%d expired=%d hit rate=%.2f\n",
+		responses.Len(), s.Hits, s.Misses, s.Expired, s.HitRate())
+}
+
+func main() {
+	addr := config.Addr("0.0.0.0:3000")
+	flag.Parse()
+	// try: curl -i 'localhost:3000/slow?q=go' twice, then localhost:3000/stats
+	http.HandleFunc("/slow", cache(SlowServer))
+	http.HandleFunc("/stats", StatsServer)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil)}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
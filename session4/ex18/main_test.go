@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// counting returns a handler that answers how often it ran, with status
+func counting(status int) (http.HandlerFunc, *int) {
+	n := new(int)
+	return func(w http.ResponseWriter, req *http.Request) {
+		*n++
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "run %d", *n)
+	}, n
+}
+
+func get(h http.HandlerFunc, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+// the second GET of a URL is answered from the cache, headers and all
+func TestHit(t *testing.T) {
+	h, n := counting(http.StatusOK)
+	c := cache(h)
+	first, second := get(c, "GET", "/grade/hit"), get(c, "GET", "/grade/hit")
+	if first.Header().Get("X-Cache") != "MISS" || second.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("X-Cache is %q, then %q; want MISS, then HIT", first.Header().Get("X-Cache"), second.Header().Get("X-Cache"))
+	}
+	if *n != 1 || second.Body.String() != "run 1" || second.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("the handler ran %d times, the second answer is %q (%s); want once, run 1, text/plain",
+			*n, second.Body.String(), second.Header().Get("Content-Type"))
+	}
+	if get(c, "GET", "/grade/hit?q=other").Body.String() != "run 2" {
+		t.Error("another query string is answered from the cache")
+	}
+}
+
+// errors and requests other than GET are not cached
+func TestNotCached(t *testing.T) {
+	h, n := counting(http.StatusInternalServerError)
+	c := cache(h)
+	get(c, "GET", "/grade/error")
+	if w := get(c, "GET", "/grade/error"); w.Code != http.StatusInternalServerError || *n != 2 {
+		t.Errorf("a 500 was cached: the handler ran %d times for two GETs", *n)
+	}
+	h, n = counting(http.StatusOK)
+	c = cache(h)
+	get(c, "POST", "/grade/post")
+	get(c, "POST", "/grade/post")
+	if *n != 2 {
+		t.Errorf("a POST was cached: the handler ran %d times for two POSTs", *n)
+	}
+}
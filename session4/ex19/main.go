@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/lru"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// a template cache: parsing is much slower than executing, so parsed
+// templates are kept in an LRU cache keyed by name. The cache only holds
+// 2 templates here, so visiting 3 pages in turn shows evictions.
+
+var sources = map[string]string{ // stands in for template files on disk
+	"home":  `<h1>Home</h1><p>Hello, {{.}}!</p>`,
+	"about": `<h1>About</h1><p>{{.}} wrote this site.</p>`,
+	"news":  `<h1>News</h1><p>Nothing new for {{.}}.</p>`,
+}
+
+var templates = lru.New[string, *template.Template](2, 0)
+
+func init() {
+	templates.OnEvict = func(name string, _ *template.Template) {
+		log.Info("template evicted from the cache", "name", name)
+	}
+}
+
+func getTemplate(name string) (*template.Template, error) {
+	if t, ok := templates.Get(name); ok {
+		return t, nil
+	}
+	src, ok := sources[name]
+	if !ok {
+		return nil, fmt.Errorf("no template %q", name)
+	}
+	log.Info("parsing template", "name", name)
+	t, err := template.New(name).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	templates.Add(name, t)
+	return t, nil
+}
+
+// PageServer renders /page/home, /page/about, /page/news
+func PageServer(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, "/page/")
+	t, err := getTemplate(name)
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	if err := t.Execute(w, "Mary"); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func main() {
+	addr := config.Addr("0.0.0.0:3000")
+	flag.Parse()
+	http.HandleFunc("/page/", PageServer)
+	http.HandleFunc("/stats", func(w http.ResponseWriter, req *http.Request) {
+		s := templates.Stats()
+		fmt.Fprintf(w, "cached=%v hits=%d misses=%d evictions=%d\n", templates.Keys(), s.Hits, s.Misses, s.Evictions)
+	})
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil)}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func page(path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	PageServer(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+// every page renders its template with Mary
+func TestPages(t *testing.T) {
+	for path, want := range map[string]string{
+		"/page/home":  "<h1>Home</h1><p>Hello, Mary!</p>",
+		"/page/about": "<h1>About</h1><p>Mary wrote this site.</p>",
+		"/page/news":  "<h1>News</h1><p>Nothing new for Mary.</p>",
+	} {
+		if w := page(path); w.Code != http.StatusOK || w.Body.String() != want {
+			t.Errorf("GET %s: %d %q, want 200 %q", path, w.Code, w.Body.String(), want)
+		}
+	}
+	if w := page("/page/nowhere"); w.Code != http.StatusNotFound {
+		t.Errorf("GET /page/nowhere: status %d, want 404", w.Code)
+	}
+}
+
+// a template is parsed once and then served from the cache, which holds two
+func TestCached(t *testing.T) {
+	page("/page/home")
+	before := templates.Stats()
+	page("/page/home")
+	if after := templates.Stats(); after.Hits != before.Hits+1 || after.Misses != before.Misses {
+		t.Errorf("the second GET /page/home: hits %d to %d, misses %d to %d; want a hit", before.Hits, after.Hits, before.Misses, after.Misses)
+	}
+	page("/page/about")
+	page("/page/news")
+	if n := templates.Len(); n != 2 {
+		t.Errorf("after three pages the cache holds %d templates, want 2", n)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var log = logger.New("web")
+
+// settings of the server; the address can come from the -addr flag,
+// the TRAINING_ADDR environment variable or a JSON file given with -config
+type settings struct {
+	Addr  string        `config:"addr" usage:"address to listen on" required:"true"`
+	Grace time.Duration `config:"grace" usage:"how long a shutdown waits for requests in flight"`
+}
+
+func HelloServer(w http.ResponseWriter, req *http.Request) {
+	log.Debug("inside HelloServer handler", "path", req.URL.Path)
+	fmt.Fprint(w, "Hello, "+req.URL.Path[1:])
+}
+
+func Spy(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "James Bond")
+}
+
+// SlowServer takes five seconds to answer: start curl localhost:3000/slow,
+// press Ctrl+C in the server while it waits, and the answer still arrives
+func SlowServer(w http.ResponseWriter, req *http.Request) {
+	select {
+	case <-time.After(5 * time.Second):
+		fmt.Fprintln(w, "sorry for the wait")
+	case <-req.Context().Done(): // the client gave up
+	}
+}
+
+func main() {
+	s := settings{Addr: "0.0.0.0:3000", Grace: 10 * time.Second} // defaults
+	loader := config.Loader{EnvPrefix: "TRAINING_", FileFlag: "config"}
+	if err := loader.Load(&s); err != nil {
+		log.Fatal("loading config", "err", err)
+	}
+	http.HandleFunc("/", HelloServer)
+	http.HandleFunc("/spy", Spy)
+	http.HandleFunc("/slow", SlowServer)
+
+	// http.ListenAndServe only returns on failure, and Ctrl+C kills the
+	// program in the middle of answering. An http.Server can be stopped:
+	// Shutdown closes the listener, waits for the requests in flight and
+	// makes ListenAndServe return http.ErrServerClosed.
+	srv := &http.Server{Addr: s.Addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil)}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() {
+		log.Info("listening", "addr", s.Addr)
+		errc <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		log.Fatal("ListenAndServe", "err", err) // the server did not start
+	case <-ctx.Done():
+	}
+
+	log.Info("shutting down", "grace", s.Grace)
+	shutdown, cancel := context.WithTimeout(context.Background(), s.Grace)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil {
+		log.Error("requests still running, cut off", "err", err)
+		srv.Close()
+	}
+	log.Info("stopped")
+}
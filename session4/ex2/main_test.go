@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// HelloServer greets the name in the path
+func TestHelloServer(t *testing.T) {
+	w := httptest.NewRecorder()
+	HelloServer(w, httptest.NewRequest("GET", "/gopher", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "Hello, gopher" {
+		t.Errorf("GET /gopher: %d %q, want 200 %q", w.Code, w.Body.String(), "Hello, gopher")
+	}
+}
+
+// Spy answers James Bond
+func TestSpy(t *testing.T) {
+	w := httptest.NewRecorder()
+	Spy(w, httptest.NewRequest("GET", "/spy", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "James Bond" {
+		t.Errorf("GET /spy: %d %q, want 200 %q", w.Code, w.Body.String(), "James Bond")
+	}
+}
+
+// SlowServer stops waiting when the client gives up
+func TestSlowServerClientGone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(SlowServer))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	if resp, err := http.DefaultClient.Do(req); err == nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		t.Fatal("the client got an answer within 100ms, want none for five seconds")
+	}
+	// Close waits for the handler to return: it must not take the five seconds
+	done := make(chan struct{})
+	go func() {
+		srv.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("SlowServer still waits two seconds after the client gave up")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/bloomfilter"
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// a URL shortener keeps its codes in a (slow) store. Most lookups of random
+// or mistyped codes miss, and every miss still costs a store round trip.
+// A Bloom filter in front of the store answers "certainly not there" from
+// memory, so only real codes and the rare false positive reach the store.
+
+const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func randomCode(r *rand.Rand) string {
+	b := make([]byte, 7)
+	for i := range b {
+		b[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// store stands in for a database table code -> URL
+type store struct {
+	mu      sync.RWMutex
+	urls    map[string]string
+	lookups int64 // round trips, counted atomically
+	delay   time.Duration
+}
+
+func (s *store) get(code string) (string, bool) {
+	atomic.AddInt64(&s.lookups, 1)
+	time.Sleep(s.delay)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	url, ok := s.urls[code]
+	return url, ok
+}
+
+type shortener struct {
+	st     *store
+	filter *bloomfilter.Filter // written only before serving starts
+}
+
+func (sh *shortener) resolve(code string) (string, bool) {
+	if !sh.filter.TestString(code) {
+		return "", false // definitely unknown: no store round trip
+	}
+	return sh.st.get(code)
+}
+
+func (sh *shortener) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	code := strings.TrimPrefix(req.URL.Path, "/")
+	if url, ok := sh.resolve(code); ok {
+		http.Redirect(w, req, url, http.StatusFound)
+		return
+	}
+	http.NotFound(w, req)
+}
+
+func build(r *rand.Rand, n int, p float64) (*shortener, []string) {
+	st := &store{urls: make(map[string]string, n)}
+	filter := bloomfilter.New(uint(n), p)
+	codes := make([]string, n)
+	for i := range codes {
+		codes[i] = randomCode(r)
+		st.urls[codes[i]] = fmt.Sprintf("https://example.com/article/%d", i)
+		filter.AddString(codes[i])
+	}
+	return &shortener{st, filter}, codes
+}
+
+func main() {
+	addr := config.Addr("0.0.0.0:3000")
+	flag.Parse()
+	r := rand.New(rand.NewSource(1))
+	const stored, probes = 10000, 100000
+
+	// measure the real false-positive rate against the target
+	fmt.Printf("%-8s %-8s %-3s %-10s %-10s %s\n", "target", "bits", "k", "estimated", "measured", "store lookups saved")
+	for _, p := range []float64{0.1, 0.01, 0.001} {
+		sh, codes := build(r, stored, p)
+		for _, c := range codes {
+			if _, ok := sh.resolve(c); !ok {
+				log.Fatal("false negative: a Bloom filter must never lose a stored code", "code", c)
+			}
+		}
+		sh.st.lookups = 0
+		for i := 0; i < probes; i++ {
+			sh.resolve(randomCode(r)) // practically never a stored code
+		}
+		measured := float64(sh.st.lookups) / probes
+		fmt.Printf("%-8g %-8d %-3d %-10.4f %-10.4f %.1f%%\n", p, sh.filter.Bits(), sh.filter.Hashes(),
+			sh.filter.EstimatedFPRate(), measured, 100*(1-measured))
+	}
+
+	// and serve one with a slow store: try a known code and a random one
+	sh, codes := build(r, stored, 0.01)
+	sh.st.delay = 20 * time.Millisecond
+	log.Info("serving", "addr", *addr, "stored", "/"+codes[0], "unknown", "/nothere")
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), sh)}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
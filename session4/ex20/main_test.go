@@ -0,0 +1,34 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// a stored code redirects to its URL
+func TestRedirect(t *testing.T) {
+	sh, codes := build(rand.New(rand.NewSource(1)), 100, 0.01)
+	w := httptest.NewRecorder()
+	sh.ServeHTTP(w, httptest.NewRequest("GET", "/"+codes[7], nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/article/7" {
+		t.Errorf("GET /%s: %d to %q, want 302 to https://example.com/article/7", codes[7], w.Code, w.Header().Get("Location"))
+	}
+}
+
+// an unknown code is 404, mostly without a store round trip
+func TestUnknown(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	sh, _ := build(r, 100, 0.01)
+	for i := 0; i < 100; i++ {
+		w := httptest.NewRecorder()
+		sh.ServeHTTP(w, httptest.NewRequest("GET", "/"+randomCode(r), nil))
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("GET of a random code: status %d, want 404", w.Code)
+		}
+	}
+	if sh.st.lookups > 10 {
+		t.Errorf("100 unknown codes cost %d store lookups, want about one", sh.st.lookups)
+	}
+}
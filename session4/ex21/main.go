@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"os/signal"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+	"github.com/hannansatopay/training-golang/pkg/queue"
+)
+
+var log = logger.New("web")
+
+// webhook receiver: POST /webhook answers 202 Accepted as soon as the event
+// is safely in the durable queue; a worker handles events in the background.
+// Stop the server with Ctrl+C while events are pending and start it again:
+// nothing is lost, unacked events are handled after the restart.
+//
+// try: for i in 1 2 3 4 5; do curl -d "{\"id\":$i,\"type\":\"push\"}" localhost:3000/webhook; done
+
+var (
+	queueDir = flag.String("queue", "webhook-queue", "directory of the event queue")
+	addr     = config.Addr("0.0.0.0:3000")
+)
+
+type event struct {
+	ID   int    `json:"id"`
+	Type string `json:"type"`
+}
+
+func webhookHandler(q *queue.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			http.Error(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(req.Body, 1<<20))
+		if err != nil || !json.Valid(body) {
+			http.Error(w, "body must be JSON", http.StatusBadRequest)
+			return
+		}
+		if err := q.Enqueue(body); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// worker handles one event at a time and acks it only when it is done
+func worker(ctx context.Context, q *queue.Queue) {
+	for {
+		m, err := q.Dequeue(ctx)
+		if err != nil {
+			return // context cancelled or queue closed
+		}
+		var e event
+		json.Unmarshal(m.Data, &e)
+		log.Info("handling event", "id", e.ID, "type", e.Type)
+		time.Sleep(2 * time.Second) // slow downstream work
+		if err := q.Ack(m); err != nil {
+			log.Error("ack", "id", e.ID, "err", err)
+			return
+		}
+		log.Info("event done", "id", e.ID)
+	}
+}
+
+func main() {
+	flag.Parse()
+	q, err := queue.Open(*queueDir, queue.Options{Sync: true})
+	if err != nil {
+		log.Fatal("opening the queue", "dir", *queueDir, "err", err)
+	}
+	log.Info("queue opened", "pending", q.Len())
+	ctx, stop := signal.NotifyContext(context.Background(), graceful.Signals...)
+	defer stop()
+	go worker(ctx, q)
+
+	http.HandleFunc("/webhook", webhookHandler(q))
+	// the server stops with the worker, after the requests in flight are
+	// answered: their events are in the queue before it closes
+	err = graceful.Run(ctx, &http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil)}, 5*time.Second)
+	log.Info("shutting down, pending events will be handled on the next start")
+	q.Close()
+	if err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/queue"
+)
+
+func post(q *queue.Queue, method, body string) int {
+	w := httptest.NewRecorder()
+	webhookHandler(q)(w, httptest.NewRequest(method, "/webhook", strings.NewReader(body)))
+	return w.Code
+}
+
+// a JSON event is answered 202 Accepted once it is in the queue
+func TestAccepted(t *testing.T) {
+	q, err := queue.Open(t.TempDir(), queue.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if code := post(q, "POST", `{"id":1,"type":"push"}`); code != http.StatusAccepted {
+		t.Fatalf("POST /webhook: status %d, want 202", code)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	m, err := q.Dequeue(ctx)
+	if err != nil || string(m.Data) != `{"id":1,"type":"push"}` {
+		t.Errorf("the queue holds %q (%v), want the event", m.Data, err)
+	}
+}
+
+// a body that is not JSON and methods other than POST are refused
+func TestRefused(t *testing.T) {
+	q, err := queue.Open(t.TempDir(), queue.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if code := post(q, "POST", "not json"); code != http.StatusBadRequest {
+		t.Errorf("POST /webhook of not json: status %d, want 400", code)
+	}
+	if code := post(q, "GET", ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /webhook: status %d, want 405", code)
+	}
+	if q.Len() != 0 {
+		t.Errorf("the refused requests left %d bytes in the queue", q.Len())
+	}
+}
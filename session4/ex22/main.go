@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/hannansatopay/training-golang/pkg/mailer"
+)
+
+// mail merge: one text and one HTML template, filled in for every guest
+// and sent as a multipart/alternative mail through pkg/mailer (STARTTLS,
+// then AUTH PLAIN). Without -smtp the mails go to a mailer.Sink started
+// right here, which keeps them, and what it received is printed. To send
+// for real:
+//
+//	SMTP_PASSWORD=... go run ./session4/ex22 -smtp smtp.example.com:587 -user me@example.com
+
+var (
+	server = flag.String("smtp", "", "SMTP server host:port (default: a local sink)")
+	user   = flag.String("user", "trainer", "SMTP username, the password is read from $SMTP_PASSWORD")
+)
+
+type guest struct {
+	Name, Email string
+	Sessions    []string
+}
+
+var guests = []guest{
+	{"Ada", "ada@example.com", []string{"Goroutines and Channels", "Performance Advices"}},
+	{"Grace Hopper", "grace@example.com", []string{"Encoding and I-O"}},
+	{"Renée", "renee@example.com", nil},
+}
+
+var subject = template.Must(template.New("subject").Parse(`Your training, {{.Name}}`))
+
+var text = template.Must(template.New("text").Parse(`Hello {{.Name}},
+{{if .Sessions}}
+you are registered for:
+{{range .Sessions}}  - {{.}}
+{{end}}{{else}}
+you are not registered for any session yet.
+{{end}}
+See you soon!
+`))
+
+// the HTML version escapes what it fills in: a name like "<b>" stays text
+var html = htmltemplate.Must(htmltemplate.New("html").Parse(`<p>Hello {{.Name}},</p>
+{{if .Sessions}}<p>you are registered for:</p>
+<ul>{{range .Sessions}}<li>{{.}}</li>{{end}}</ul>
+{{else}}<p>you are not registered for any session yet.</p>
+{{end}}<p>See you soon!</p>
+`))
+
+func compose(g guest) (*mailer.Message, error) {
+	var subj, txt, htm bytes.Buffer
+	if err := subject.Execute(&subj, g); err != nil {
+		return nil, err
+	}
+	if err := text.Execute(&txt, g); err != nil {
+		return nil, err
+	}
+	if err := html.Execute(&htm, g); err != nil {
+		return nil, err
+	}
+	return &mailer.Message{
+		From:    mail.Address{Name: "Go Training", Address: "training@example.com"},
+		To:      []mail.Address{{Name: g.Name, Address: g.Email}},
+		Subject: subj.String(),
+		Text:    txt.String(),
+		HTML:    htm.String(),
+	}, nil
+}
+
+// show prints a received mail the way a mail program would read it
+func show(m mailer.Mail) {
+	msg, err := mail.ReadMessage(bytes.NewReader(m.Data))
+	if err != nil {
+		log.Fatal(err)
+	}
+	subj, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	fmt.Printf("--- to %s (tls %v, user %s)\n", strings.Join(m.To, ", "), m.TLS, m.User)
+	fmt.Printf("Subject: %s\n", subj)
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := parts.NextPart() // decodes quoted-printable by itself
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		body, _ := io.ReadAll(p)
+		fmt.Printf("[%s]\n%s", p.Header.Get("Content-Type"), body)
+	}
+}
+
+func main() {
+	flag.Parse()
+	cfg := mailer.Config{Addr: *server, Username: *user, Password: os.Getenv("SMTP_PASSWORD"), RequireTLS: true}
+	var sink *mailer.Sink
+	if *server == "" {
+		var err error
+		if sink, err = mailer.Listen("127.0.0.1:0"); err != nil {
+			log.Fatal(err)
+		}
+		sink.Users = map[string]string{"trainer": "secret"}
+		go sink.Serve()
+		cfg.Addr, cfg.Password, cfg.TLS = sink.Addr(), "secret", sink.ClientTLS()
+	}
+	m := mailer.New(cfg)
+	for _, g := range guests {
+		msg, err := compose(g)
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err := msg.Bytes()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := m.Send(msg.From.Address, msg.Recipients(), data); err != nil {
+			log.Fatalf("%s: %v", g.Email, err)
+		}
+	}
+	if sink == nil {
+		fmt.Printf("%d mails sent\n", len(guests))
+		return
+	}
+	sink.Close()
+	for _, received := range sink.Mails() {
+		show(received)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"html/template"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/auth"
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// login with sessions and bcrypt-hashed passwords at http://localhost:3000/
+//
+// The password is checked once, against a bcrypt hash, and the browser
+// gets a random session ID in a cookie that the server looks up on every
+// request; /dashboard is only shown with a session that has not ended.
+// Log in as alice (wonderland) or bob (builder).
+//
+// Behind HTTPS, run with -secure: the browser then sends the cookie over
+// HTTPS only, where nobody on the way can read the session ID. Plain
+// http://localhost needs it off.
+//
+// The hashes in users.json were made with cost 4; the server hashes with
+// -cost, so the first login of a user hashes the password again with the
+// higher cost. A real server would store the new hash.
+
+var (
+	addr      = config.Addr(":3000")
+	cost      = flag.Int("cost", 10, "bcrypt cost of new hashes (4 to 31)")
+	usersFile = flag.String("users", "users.json", "user names and their bcrypt hashes")
+	maxAge    = flag.Duration("maxage", time.Hour, "a session ends this long after the login")
+	secure    = flag.Bool("secure", false, "send the session cookie over HTTPS only")
+)
+
+const cookieName = "session"
+
+type session struct {
+	user    string
+	expires time.Time
+}
+
+// sessions maps session IDs to sessions
+type sessions struct {
+	mu sync.Mutex // handlers run concurrently
+	m  map[string]session
+}
+
+// start makes a new session for user and returns its ID: 32 random bytes,
+// too many to guess
+func (s *sessions) start(user string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	id := base64.RawURLEncoding.EncodeToString(b)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[id] = session{user: user, expires: time.Now().Add(*maxAge)}
+	return id, nil
+}
+
+// user returns the user of the session in the request's cookie, if it
+// has not ended
+func (s *sessions) user(req *http.Request) (string, bool) {
+	c, err := req.Cookie(cookieName)
+	if err != nil {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.m[c.Value]
+	if ok && time.Now().After(sess.expires) {
+		delete(s.m, c.Value)
+		return "", false
+	}
+	return sess.user, ok
+}
+
+// sweep removes the sessions that have ended every interval: user only
+// notices the ended sessions that are still used, the others would stay
+// in memory forever
+func (s *sessions) sweep(interval time.Duration) {
+	for now := range time.Tick(interval) {
+		s.mu.Lock()
+		for id, sess := range s.m {
+			if now.After(sess.expires) {
+				delete(s.m, id)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *sessions) end(req *http.Request) {
+	if c, err := req.Cookie(cookieName); err == nil {
+		s.mu.Lock()
+		delete(s.m, c.Value)
+		s.mu.Unlock()
+	}
+}
+
+var (
+	users  *auth.Store
+	active = &sessions{m: map[string]session{}}
+)
+
+func main() {
+	flag.Parse()
+	data, err := os.ReadFile(*usersFile)
+	if err != nil {
+		log.Fatal("reading users", "err", err)
+	}
+	var hashes map[string]string
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		log.Fatal("reading users", "file", *usersFile, "err", err)
+	}
+	users = auth.NewStore(auth.Hasher{Cost: *cost})
+	for user, hash := range hashes {
+		users.SetHash(user, hash)
+	}
+	users.OnRehash = func(user string, from, to int) {
+		log.Info("rehashed a password", "user", user, "from", from, "to", to)
+	}
+
+	go active.sweep(time.Minute)
+
+	http.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, "/dashboard", http.StatusFound)
+	})
+	http.HandleFunc("/dashboard", dashboardHandler)
+	http.HandleFunc("/login", loginHandler)
+	http.HandleFunc("/logout", logoutHandler)
+	log.Info("listening", "addr", *addr)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil)}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
+
+// renderTemplate writes the page called name, or a 500 if it fails
+func renderTemplate(w http.ResponseWriter, name string, data any) {
+	if err := pages.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// dashboardHandler greets a logged-in user and sends anyone else to /login
+func dashboardHandler(w http.ResponseWriter, req *http.Request) {
+	user, ok := active.user(req)
+	if !ok {
+		http.Redirect(w, req, "/login", http.StatusFound)
+		return
+	}
+	// the page shows who is logged in: no cache may keep it for others
+	w.Header().Set("Cache-Control", "no-store")
+	renderTemplate(w, "dashboard", user)
+}
+
+// loginHandler shows the form (GET) and checks it (POST)
+func loginHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		renderTemplate(w, "login", "")
+		return
+	}
+	user := req.PostFormValue("user")
+	err := users.Login(user, req.PostFormValue("password"))
+	if errors.Is(err, auth.ErrMismatch) {
+		// the same message for an unknown user and a wrong password
+		w.WriteHeader(http.StatusUnauthorized)
+		renderTemplate(w, "login", "Wrong user name or password.")
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// a new ID on every login: an ID planted before it is worth nothing
+	active.end(req)
+	id, err := active.start(user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name: cookieName, Value: id, Path: "/",
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,                 // not readable by JavaScript
+		SameSite: http.SameSiteLaxMode, // not sent with forms posted from other sites
+		Secure:   *secure,              // not sent over plain HTTP
+	})
+	http.Redirect(w, req, "/dashboard", http.StatusSeeOther)
+}
+
+func logoutHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "log out with POST", http.StatusMethodNotAllowed)
+		return
+	}
+	active.end(req)
+	http.SetCookie(w, &http.Cookie{Name: cookieName, Path: "/", MaxAge: -1, Secure: *secure})
+	http.Redirect(w, req, "/login", http.StatusSeeOther)
+}
+
+var pages = template.Must(template.New("").Parse(`
+{{define "login"}}<!DOCTYPE html>
+<html>
+	<head><title>Log in</title></head>
+	<body>
+		<h1>Log in</h1>
+		{{if .}}<p><b>{{.}}</b></p>{{end}}
+		<form action="/login" method="post">
+		User: <input name="user" /> Password: <input name="password" type="password" /><input type="submit" value="Log in">
+		</form>
+	</body>
+</html>
+{{end}}
+{{define "dashboard"}}<!DOCTYPE html>
+<html>
+	<head><title>Dashboard</title></head>
+	<body>
+		<h1>Hello, {{.}}</h1>
+		<form action="/logout" method="post"><input type="submit" value="Log out"></form>
+	</body>
+</html>
+{{end}}`))
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/auth"
+)
+
+// newTestServer serves the handlers of main with the users of users.json
+// and returns a client that keeps cookies, like a browser
+func newTestServer(t *testing.T) (*httptest.Server, *http.Client) {
+	t.Helper()
+	data, err := os.ReadFile("users.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashes map[string]string
+	if err := json.Unmarshal(data, &hashes); err != nil {
+		t.Fatal(err)
+	}
+	users = auth.NewStore(auth.Hasher{Cost: 4})
+	for user, hash := range hashes {
+		users.SetHash(user, hash)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/dashboard", dashboardHandler)
+	mux.HandleFunc("/login", loginHandler)
+	mux.HandleFunc("/logout", logoutHandler)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	jar, _ := cookiejar.New(nil)
+	return srv, &http.Client{Jar: jar}
+}
+
+func body(t *testing.T, resp *http.Response, err error) (int, string) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+// without a session the dashboard sends the browser to the login form
+func TestDashboardWithoutSession(t *testing.T) {
+	srv, client := newTestServer(t)
+	resp, err := client.Get(srv.URL + "/dashboard")
+	status, page := body(t, resp, err)
+	if status != http.StatusOK || !strings.Contains(page, "<h1>Log in</h1>") {
+		t.Errorf("GET /dashboard without a session: %d, want to end on the login form, got %q", status, page)
+	}
+}
+
+// a wrong password gets 401 and no session
+func TestWrongPassword(t *testing.T) {
+	srv, client := newTestServer(t)
+	resp, err := client.PostForm(srv.URL+"/login", url.Values{"user": {"alice"}, "password": {"looking-glass"}})
+	status, page := body(t, resp, err)
+	if status != http.StatusUnauthorized || !strings.Contains(page, "Wrong user name or password.") {
+		t.Errorf("POST /login with a wrong password: %d %q", status, page)
+	}
+	if u, _ := url.Parse(srv.URL); len(client.Jar.Cookies(u)) != 0 {
+		t.Error("POST /login with a wrong password set a cookie")
+	}
+}
+
+// a login sets an HttpOnly session cookie and shows the dashboard until the logout
+func TestLoginLogout(t *testing.T) {
+	srv, client := newTestServer(t)
+	resp, err := client.PostForm(srv.URL+"/login", url.Values{"user": {"alice"}, "password": {"wonderland"}})
+	status, page := body(t, resp, err)
+	if status != http.StatusOK || !strings.Contains(page, "<h1>Hello, alice</h1>") {
+		t.Fatalf("POST /login as alice: %d, want to end on her dashboard, got %q", status, page)
+	}
+	if cc := resp.Request.Response.Cookies(); len(cc) != 1 || !cc[0].HttpOnly || cc[0].Name != cookieName {
+		t.Errorf("POST /login as alice set the cookies %v, want one HttpOnly %s cookie", cc, cookieName)
+	}
+	resp, err = client.PostForm(srv.URL+"/logout", nil)
+	body(t, resp, err)
+	resp, err = client.Get(srv.URL + "/dashboard")
+	if _, page := body(t, resp, err); !strings.Contains(page, "<h1>Log in</h1>") {
+		t.Errorf("GET /dashboard after the logout: want the login form, got %q", page)
+	}
+}
@@ -0,0 +1,229 @@
+// a JSON REST API for books: list, read, add, change and delete them
+//
+// Every request and response body is JSON, and the status code says what
+// happened: 200 OK, 201 Created with a Location header, 204 No Content
+// after a delete, 400 for a body that is not a valid book, 404 for an
+// unknown id. The books are kept in memory, so they are gone after a
+// restart.
+//
+// try:
+//
+//	curl localhost:3000/books
+//	curl -d '{"title":"The Go Programming Language","author":"Donovan, Kernighan","year":2015}' localhost:3000/books
+//	curl -X PUT -d '{"title":"The Go Programming Language","author":"Alan Donovan, Brian Kernighan","year":2015}' localhost:3000/books/3
+//	curl -X DELETE localhost:3000/books/1
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+type book struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Year   int    `json:"year,omitempty"`
+}
+
+// store keeps the books by id; handlers run concurrently, so every
+// access holds the lock
+type store struct {
+	mu     sync.Mutex
+	books  map[int]book
+	nextID int
+}
+
+func newStore(books ...book) *store {
+	s := &store{books: map[int]book{}, nextID: 1}
+	for _, b := range books {
+		s.add(b)
+	}
+	return s
+}
+
+// add stores b under a new id and returns it with the id
+func (s *store) add(b book) book {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b.ID = s.nextID
+	s.nextID++
+	s.books[b.ID] = b
+	return b
+}
+
+func (s *store) get(id int) (book, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.books[id]
+	return b, ok
+}
+
+// list returns all books in the order of their ids
+func (s *store) list() []book {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]book, 0, len(s.books))
+	for _, b := range s.books {
+		list = append(list, b)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// update replaces the book with b's id, if there is one
+func (s *store) update(b book) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.books[b.ID]; !ok {
+		return false
+	}
+	s.books[b.ID] = b
+	return true
+}
+
+func (s *store) remove(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.books[id]; !ok {
+		return false
+	}
+	delete(s.books, id)
+	return true
+}
+
+// writeJSON sends v with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError sends {"error": msg}, so that clients get JSON in every case
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// readBook decodes the body of req into a book; fields the book does
+// not have are an error, as is a missing title
+func readBook(req *http.Request) (book, error) {
+	var b book
+	dec := json.NewDecoder(http.MaxBytesReader(nil, req.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		return b, fmt.Errorf("body is not a book: %v", err)
+	}
+	if b.Title == "" {
+		return b, errors.New("a book needs a title")
+	}
+	return b, nil
+}
+
+// bookID returns the {id} of the path, or writes 404 and returns false
+func bookID(w http.ResponseWriter, req *http.Request) (int, bool) {
+	id, err := strconv.Atoi(req.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "no book "+req.PathValue("id"))
+		return 0, false
+	}
+	return id, true
+}
+
+// newServer routes the requests for /books to the handlers; the method
+// and the {id} wildcard in the patterns need Go 1.22
+func newServer(s *store) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /books", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, s.list())
+	})
+
+	mux.HandleFunc("GET /books/{id}", func(w http.ResponseWriter, req *http.Request) {
+		id, ok := bookID(w, req)
+		if !ok {
+			return
+		}
+		b, ok := s.get(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("no book %d", id))
+			return
+		}
+		writeJSON(w, http.StatusOK, b)
+	})
+
+	mux.HandleFunc("POST /books", func(w http.ResponseWriter, req *http.Request) {
+		b, err := readBook(req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		b = s.add(b)
+		w.Header().Set("Location", fmt.Sprintf("/books/%d", b.ID))
+		writeJSON(w, http.StatusCreated, b)
+	})
+
+	// HINT: PUT is GET and POST together: the id comes from the path, the book from the body
+	// HINT: the id in the path wins over one in the body
+	mux.HandleFunc("PUT /books/{id}", func(w http.ResponseWriter, req *http.Request) {
+		// SOLUTION-START writeError(w, http.StatusNotImplemented, "PUT is not implemented")
+		id, ok := bookID(w, req)
+		if !ok {
+			return
+		}
+		b, err := readBook(req)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		b.ID = id
+		if !s.update(b) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("no book %d", id))
+			return
+		}
+		writeJSON(w, http.StatusOK, b)
+		// SOLUTION-END
+	})
+
+	// HINT: a successful delete has nothing to say: 204 No Content, without a body
+	mux.HandleFunc("DELETE /books/{id}", func(w http.ResponseWriter, req *http.Request) {
+		// SOLUTION-START writeError(w, http.StatusNotImplemented, "DELETE is not implemented")
+		id, ok := bookID(w, req)
+		if !ok {
+			return
+		}
+		if !s.remove(id) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("no book %d", id))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		// SOLUTION-END
+	})
+	return mux
+}
+
+func main() {
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	s := newStore(
+		book{Title: "The Go Programming Language", Author: "Alan Donovan, Brian Kernighan", Year: 2015},
+		book{Title: "Concurrency in Go", Author: "Katherine Cox-Buday", Year: 2017},
+	)
+	log.Info("listening", "url", "http://"+*addr+"/books")
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(s))}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// do sends a request to a server with two books and returns the response
+func do(t *testing.T, s *store, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	newServer(s).ServeHTTP(w, req)
+	return w
+}
+
+func twoBooks() *store {
+	return newStore(book{Title: "One", Author: "A"}, book{Title: "Two", Author: "B", Year: 2000})
+}
+
+// PUT /books/{id} replaces a book and answers 200 with it
+func TestPut(t *testing.T) {
+	s := twoBooks()
+	w := do(t, s, "PUT", "/books/2", `{"title":"Deux","author":"B","year":2001}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT /books/2: status %d, want 200", w.Code)
+	}
+	var got book
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("PUT /books/2: body %q is not a book: %v", w.Body, err)
+	}
+	want := book{ID: 2, Title: "Deux", Author: "B", Year: 2001}
+	if got != want {
+		t.Errorf("PUT /books/2 answered %+v, want %+v", got, want)
+	}
+	if b, _ := s.get(2); b != want {
+		t.Errorf("after PUT /books/2 the store has %+v, want %+v", b, want)
+	}
+}
+
+// PUT keeps the id of the path, whatever the body says
+func TestPutKeepsID(t *testing.T) {
+	s := twoBooks()
+	do(t, s, "PUT", "/books/1", `{"id":2,"title":"Uno"}`)
+	if b, _ := s.get(1); b.Title != "Uno" {
+		t.Errorf("PUT /books/1 with id 2 in the body: book 1 is %+v, want the title Uno", b)
+	}
+	if b, _ := s.get(2); b.Title != "Two" {
+		t.Errorf("PUT /books/1 with id 2 in the body changed book 2 to %+v", b)
+	}
+}
+
+// PUT answers 404 for an unknown id and 400 for a body that is not a book
+func TestPutErrors(t *testing.T) {
+	for _, c := range []struct {
+		path, body string
+		want       int
+	}{
+		{"/books/9", `{"title":"Nine"}`, http.StatusNotFound},
+		{"/books/x", `{"title":"X"}`, http.StatusNotFound},
+		{"/books/1", `{"title":""}`, http.StatusBadRequest},
+		{"/books/1", `{"title":"One","pages":300}`, http.StatusBadRequest},
+		{"/books/1", `not json`, http.StatusBadRequest},
+	} {
+		if w := do(t, twoBooks(), "PUT", c.path, c.body); w.Code != c.want {
+			t.Errorf("PUT %s %s: status %d, want %d", c.path, c.body, w.Code, c.want)
+		}
+	}
+}
+
+// DELETE /books/{id} removes a book and answers 204 without a body
+func TestDelete(t *testing.T) {
+	s := twoBooks()
+	w := do(t, s, "DELETE", "/books/1", "")
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("DELETE /books/1: status %d with %q, want 204 and no body", w.Code, w.Body)
+	}
+	if _, ok := s.get(1); ok {
+		t.Error("book 1 is still there after DELETE /books/1")
+	}
+	if w := do(t, s, "GET", "/books/1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET /books/1 after the delete: status %d, want 404", w.Code)
+	}
+}
+
+// DELETE answers 404 for a book that is not there, deleted ones included
+func TestDeleteUnknown(t *testing.T) {
+	s := twoBooks()
+	do(t, s, "DELETE", "/books/2", "")
+	for _, path := range []string{"/books/2", "/books/9", "/books/x"} {
+		if w := do(t, s, "DELETE", path, ""); w.Code != http.StatusNotFound {
+			t.Errorf("DELETE %s: status %d, want 404", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// middleware: logging, panic recovery and request IDs around any handler
+//
+// A middleware takes a handler and returns one that does something before
+// and after calling it. Chained, they wrap the HelloServer and Spy
+// handlers of session4/ex2 without changing them: every request gets an
+// ID (kept from an X-Request-ID header or made up), is logged with its
+// status, size and duration, and a handler that panics answers 500
+// instead of dropping the connection.
+//
+// try:
+//
+//	curl -i localhost:3000/world
+//	curl -i -H 'X-Request-ID: abc123' localhost:3000/spy
+//	curl -i localhost:3000/panic
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// Middleware wraps a handler in another one
+type Middleware func(http.Handler) http.Handler
+
+// chain wraps h in the middlewares, the first one outermost: it sees the
+// request first and the response last
+func chain(h http.Handler, m ...Middleware) http.Handler {
+	// HINT: wrap the handler in the last middleware first, so that the first one ends up outermost
+	// SOLUTION-START return h
+	for i := len(m) - 1; i >= 0; i-- {
+		h = m[i](h)
+	}
+	return h
+	// SOLUTION-END
+}
+
+// ctxKey is the type of the context keys of this package; a type of its
+// own cannot collide with keys of other packages
+type ctxKey int
+
+const requestIDKey ctxKey = 0
+
+// requestIDFrom returns the request ID stored in ctx, or ""
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// newID returns 8 random bytes in hex
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestID keeps the X-Request-ID of the request or makes one up, puts it
+// in the request's context and sends it back in the response
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// HINT: r.WithContext(context.WithValue(...)) is the request with a value added to its context
+		// HINT: set the response header before calling next: once it writes, headers are sent
+		// SOLUTION-START next.ServeHTTP(w, r)
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = newID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+		// SOLUTION-END
+	})
+}
+
+// statusRecorder remembers the status code and size of a response; the
+// http.ResponseWriter it embeds does the writing
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK // the first Write sends 200 if nothing else was set
+	}
+	n, err := s.ResponseWriter.Write(p)
+	s.size += n
+	return n, err
+}
+
+// logging logs every request once it is answered
+func logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		log.With("id", requestIDFrom(r.Context())).Info("request",
+			"method", r.Method, "path", r.URL.Path, "status", rec.status,
+			"bytes", rec.size, "took", time.Since(start).Round(time.Microsecond))
+	})
+}
+
+// recovery turns a panic in next into a 500 Internal Server Error, so that
+// one bad request does not cost the client its connection
+func recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// HINT: recover only works in a deferred function
+		// HINT: log the panic with the request ID, the client only needs to know that it failed
+		// SOLUTION-START
+		defer func() {
+			if v := recover(); v != nil {
+				log.With("id", requestIDFrom(r.Context())).Error("panic", "value", v, "path", r.URL.Path)
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+			}
+		}()
+		// SOLUTION-END
+		next.ServeHTTP(w, r)
+	})
+}
+
+func HelloServer(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "Hello, "+req.URL.Path[1:])
+}
+
+func Spy(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "James Bond")
+}
+
+func Panic(w http.ResponseWriter, req *http.Request) {
+	var m map[string]int
+	m["boom"]++ // assignment to entry in nil map
+}
+
+func newServer() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", HelloServer)
+	mux.HandleFunc("/spy", Spy)
+	mux.HandleFunc("/panic", Panic)
+	// requestID first, so that logging and recovery can log the ID;
+	// logging outside recovery, so that it sees the 500
+	return chain(mux, requestID, logging, recovery)
+}
+
+func main() {
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	log.Info("listening", "addr", *addr)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer())}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
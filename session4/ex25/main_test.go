@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+// tag returns a middleware that writes name to the response before and
+// after the handler it wraps
+func tag(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name+"(")
+			next.ServeHTTP(w, r)
+			io.WriteString(w, ")")
+		})
+	}
+}
+
+func serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// chain runs the middlewares in the order given, the first one outermost
+func TestChainOrder(t *testing.T) {
+	h := chain(http.HandlerFunc(Spy), tag("a"), tag("b"), tag("c"))
+	w := serve(h, httptest.NewRequest("GET", "/spy", nil))
+	if got, want := w.Body.String(), "a(b(c(James Bond)))"; got != want {
+		t.Errorf("chain(Spy, a, b, c) wrote %q, want %q", got, want)
+	}
+	if w := serve(chain(http.HandlerFunc(Spy)), httptest.NewRequest("GET", "/spy", nil)); w.Body.String() != "James Bond" {
+		t.Errorf("chain(Spy) without middlewares wrote %q, want %q", w.Body, "James Bond")
+	}
+}
+
+// requestID keeps the X-Request-ID of the request, in the context and the response
+func TestRequestIDKept(t *testing.T) {
+	var seen string
+	h := requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestIDFrom(r.Context())
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	w := serve(h, req)
+	if seen != "abc123" {
+		t.Errorf("the handler sees request ID %q, want abc123", seen)
+	}
+	if got := w.Header().Get("X-Request-ID"); got != "abc123" {
+		t.Errorf("the response has X-Request-ID %q, want abc123", got)
+	}
+}
+
+// requestID makes up a different ID for each request without one
+func TestRequestIDNew(t *testing.T) {
+	ids := map[string]bool{}
+	h := requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids[requestIDFrom(r.Context())] = true
+		io.WriteString(w, "ok")
+	}))
+	for i := 0; i < 3; i++ {
+		w := serve(h, httptest.NewRequest("GET", "/", nil))
+		if id := w.Header().Get("X-Request-ID"); id == "" || !ids[id] {
+			t.Fatalf("the response has X-Request-ID %q, not the ID the handler saw", id)
+		}
+	}
+	if len(ids) != 3 || ids[""] {
+		t.Errorf("three requests got the IDs %v, want three different ones", ids)
+	}
+}
+
+// recovery answers 500 when the handler panics and logs the panic
+func TestRecovery(t *testing.T) {
+	var logged strings.Builder
+	defer logger.SetOutput(&logged)()
+	w := serve(recovery(http.HandlerFunc(Panic)), httptest.NewRequest("GET", "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("a panicking handler answers %d, want 500", w.Code)
+	}
+	if !strings.Contains(logged.String(), "nil map") {
+		t.Errorf("the panic is not logged, the log has %q", logged.String())
+	}
+	if w := serve(recovery(http.HandlerFunc(Spy)), httptest.NewRequest("GET", "/spy", nil)); w.Code != http.StatusOK || w.Body.String() != "James Bond" {
+		t.Errorf("recovery changes a handler that does not panic: %d %q", w.Code, w.Body)
+	}
+}
+
+// the whole server: a panic is logged as a 500 with the request's ID
+func TestServer(t *testing.T) {
+	var logged strings.Builder
+	defer logger.SetOutput(&logged)()
+	req := httptest.NewRequest("GET", "/panic", nil)
+	req.Header.Set("X-Request-ID", "xyz789")
+	w := serve(newServer(), req)
+	if w.Code != http.StatusInternalServerError || w.Header().Get("X-Request-ID") != "xyz789" {
+		t.Errorf("GET /panic: status %d with X-Request-ID %q, want 500 with xyz789", w.Code, w.Header().Get("X-Request-ID"))
+	}
+	if !strings.Contains(logged.String(), "xyz789") || !strings.Contains(logged.String(), "500") {
+		t.Errorf("the log does not show the ID and the 500: %q", logged.String())
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// an HTTPS client that trusts the certificate of session4/ex26/server
+//
+// Start the server first; it writes its certificate to cert.pem in its
+// folder. The client puts that certificate in a pool of its own as the
+// only authority to trust (tls.Config.RootCAs) and then verifies the
+// server as usual: the chain, the dates and the name in the URL. With
+// -ca "" it uses the system's authorities instead and is refused.
+//
+// try: go run ./session4/ex26/client https://localhost:3443/world https://localhost:3443/spy
+
+var errNoCerts = errors.New("no PEM certificates found")
+
+// rootPool returns a pool with the certificates of a PEM file in it
+func rootPool(pemCerts []byte) (*x509.CertPool, error) {
+	// HINT: x509.NewCertPool starts empty, without the system's authorities
+	// HINT: AppendCertsFromPEM reports whether it found a certificate
+	// SOLUTION-START return nil, errNoCerts
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemCerts) {
+		return nil, errNoCerts
+	}
+	return pool, nil
+	// SOLUTION-END
+}
+
+func main() {
+	ca := flag.String("ca", "../server/cert.pem", "trust the certificates in this PEM file (\"\": the system's authorities)")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: client [-ca cert.pem] https://host:port/path...")
+		os.Exit(2)
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if *ca != "" {
+		data, err := os.ReadFile(*ca)
+		if err != nil {
+			log.Fatalf("%v (start session4/ex26/server first)", err)
+		}
+		if cfg.RootCAs, err = rootPool(data); err != nil {
+			log.Fatalf("%s: %v", *ca, err)
+		}
+	}
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: cfg},
+		Timeout:   10 * time.Second,
+	}
+	for _, url := range flag.Args() {
+		resp, err := client.Get(url)
+		if err != nil {
+			// an unknown authority or a wrong name ends up here
+			log.Printf("GET %s: %v", url, err)
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			log.Printf("GET %s: %v", url, err)
+			continue
+		}
+		fmt.Printf("%s %s over %s, certificate of %s: %s\n", url, resp.Status,
+			tls.VersionName(resp.TLS.Version), resp.TLS.PeerCertificates[0].Subject.CommonName, body)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newServer starts an HTTPS server with a new self-signed certificate
+// and returns it with that certificate in PEM. (httptest.NewTLSServer
+// would give every server the same one.)
+func newServer(t *testing.T) (*httptest.Server, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(Spy))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// Spy answers like the server's handler
+func Spy(w http.ResponseWriter, req *http.Request) { w.Write([]byte("James Bond")) }
+
+// get fetches url trusting pemCerts
+func get(t *testing.T, url string, pemCerts []byte) error {
+	t.Helper()
+	pool, err := rootPool(pemCerts)
+	if err != nil {
+		t.Fatalf("rootPool: %v", err)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get(url)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+// a pool made from the server's certificate lets the client in
+func TestTrusted(t *testing.T) {
+	srv, cert := newServer(t)
+	if err := get(t, srv.URL, cert); err != nil {
+		t.Errorf("with the server's certificate in the pool: %v", err)
+	}
+}
+
+// the pool trusts only what is in it: another server is refused
+func TestOtherServerRefused(t *testing.T) {
+	_, cert := newServer(t)
+	other, _ := newServer(t)
+	if err := get(t, other.URL, cert); err == nil {
+		t.Error("a server with another certificate was accepted")
+	}
+}
+
+// a file without certificates is an error, errNoCerts
+func TestNoCerts(t *testing.T) {
+	for _, data := range []string{"", "not a certificate", "-----BEGIN CERTIFICATE-----\nbroken\n-----END CERTIFICATE-----\n"} {
+		if _, err := rootPool([]byte(data)); !errors.Is(err, errNoCerts) {
+			t.Errorf("rootPool(%q) = %v, want errNoCerts", data, err)
+		}
+	}
+}
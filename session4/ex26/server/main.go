@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// the HelloServer and Spy handlers of session4/ex2 over HTTPS
+//
+// go run ./session4/ex26/server makes a key and a self-signed certificate
+// for localhost with crypto/x509 on its first start, writes them to -cert
+// and -key and serves with graceful.ListenAndServe, which speaks TLS when
+// the server has a TLSConfig. A browser or curl does not trust such a
+// certificate: nobody they know has signed it. Give it to them as the
+// authority to trust, as session4/ex26/client does:
+//
+//	curl --cacert session4/ex26/server/cert.pem https://localhost:3443/world
+//
+// Delete both files for a new key and certificate; clients then need the
+// new cert.pem.
+
+var (
+	addr     = config.Addr("localhost:3443")
+	certFile = flag.String("cert", "cert.pem", "certificate file, created if missing")
+	keyFile  = flag.String("key", "key.pem", "private key file, created if missing")
+)
+
+// writeCert makes a new key and a certificate for it that is signed by
+// the key itself, for the names a local client uses, valid for 90 days
+func writeCert(certFile, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost", Organization: []string{"Go Training"}},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:    time.Now().Add(-time.Hour), // a client whose clock is a little behind
+		NotAfter:     time.Now().AddDate(0, 0, 90),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		// the certificate is its own authority: clients put it in
+		// their pool of roots, and a root must be a CA
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	// template and parent are the same: self-signed
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
+
+func HelloServer(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "Hello, "+req.URL.Path[1:])
+}
+
+func Spy(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "James Bond")
+}
+
+func main() {
+	flag.Parse()
+	if _, err := os.Stat(*certFile); os.IsNotExist(err) {
+		if err := writeCert(*certFile, *keyFile); err != nil {
+			log.Fatal("writing the certificate", "err", err)
+		}
+		log.Info("new key and certificate", "key", *keyFile, "cert", *certFile)
+	}
+	http.HandleFunc("/", HelloServer)
+	http.HandleFunc("/spy", Spy)
+	cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+	if err != nil {
+		log.Fatal("loading the certificate", "err", err)
+	}
+	log.Info("listening", "url", "https://"+*addr+"/")
+	srv := &http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil), TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}}}
+	if err := graceful.ListenAndServe(srv, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServeTLS", "err", err)
+	}
+}
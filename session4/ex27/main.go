@@ -0,0 +1,243 @@
+// file uploads and downloads: a form to send files, a list to fetch them
+//
+// POST /upload takes multipart/form-data, as a form with
+// enctype="multipart/form-data" sends it, and streams each file to disk
+// in -dir without holding it in memory; a file larger than -max is
+// refused with 413 and nothing is kept. GET /files/{name} sends a file
+// back with its Content-Type and a Content-Disposition that makes the
+// browser save it under its name instead of showing it: an uploaded
+// HTML page must not run in the site's pages.
+//
+// try: open http://localhost:3000/, or
+//
+//	curl -F file=@README.md localhost:3000/upload
+//	curl -OJ localhost:3000/files/README.md
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+)
+
+var log = logger.New("web")
+
+var (
+	errBadName  = errors.New("not a file name that can be stored")
+	errTooLarge = errors.New("file too large")
+)
+
+// cleanName returns the name under which an uploaded file is stored, or
+// errBadName. Browsers send the bare name, but the field is whatever the
+// client puts there: "../main.go" must not leave the upload directory,
+// and names starting with a dot (".", "..", the temporary files of
+// safewrite) are not listed, so they are not accepted either.
+func cleanName(name string) (string, error) {
+	// HINT: old browsers on Windows send the whole path, C:\Users\me\cat.png
+	// HINT: path.Base keeps what comes after the last slash
+	// SOLUTION-START return name, nil
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "/" || strings.HasPrefix(name, ".") || len(name) > 255 {
+		return "", errBadName
+	}
+	return name, nil
+	// SOLUTION-END
+}
+
+// files serves the files of one directory
+type files struct {
+	dir string
+	max int64 // largest file accepted, in bytes
+}
+
+type listing struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// list returns the files of the directory by name
+func (f *files) list() ([]listing, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	var list []listing
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			list = append(list, listing{e.Name(), info.Size(), info.ModTime()})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+func (f *files) index(w http.ResponseWriter, req *http.Request) {
+	list, err := f.list()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := page.Execute(w, map[string]any{"Files": list, "Max": f.max}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// upload stores every file of the form field "file"
+func (f *files) upload(w http.ResponseWriter, req *http.Request) {
+	// the limit of the whole body leaves room for the form around the
+	// files; the limit of each file is checked while it is copied
+	req.Body = http.MaxBytesReader(w, req.Body, f.max+1<<20)
+	mr, err := req.MultipartReader()
+	if err != nil {
+		http.Error(w, "not a multipart/form-data upload", http.StatusBadRequest)
+		return
+	}
+	var stored []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err == nil && part.FormName() == "file" && part.FileName() != "" {
+			if err = f.store(part.FileName(), part); err == nil {
+				stored = append(stored, part.FileName())
+			}
+		}
+		var tooBig *http.MaxBytesError
+		switch {
+		case errors.Is(err, errTooLarge) || errors.As(err, &tooBig):
+			http.Error(w, "file too large, the limit is "+size(f.max), http.StatusRequestEntityTooLarge)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	log.Info("stored", "files", strings.Join(stored, ", "))
+	http.Redirect(w, req, "/", http.StatusSeeOther)
+}
+
+// store copies r to the file name in the directory: completely or not at
+// all, as safewrite keeps the old file (or none) if the copy fails
+func (f *files) store(name string, r io.Reader) error {
+	name, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	return safewrite.WriteTo(filepath.Join(f.dir, name), 0644, func(w io.Writer) error {
+		n, err := io.Copy(w, io.LimitReader(r, f.max+1))
+		if err == nil && n > f.max {
+			err = errTooLarge
+		}
+		return err
+	})
+}
+
+// download sends one file as an attachment
+func (f *files) download(w http.ResponseWriter, req *http.Request) {
+	name, err := cleanName(req.PathValue("name"))
+	if err != nil || name != req.PathValue("name") {
+		http.NotFound(w, req)
+		return
+	}
+	file, err := os.Open(filepath.Join(f.dir, name))
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		http.NotFound(w, req)
+		return
+	}
+	// the type by the extension, or else by the first 512 bytes; and the
+	// browser must not guess another one
+	ctype := mime.TypeByExtension(path.Ext(name))
+	if ctype == "" {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(file, head)
+		ctype = http.DetectContentType(head[:n])
+		file.Seek(0, io.SeekStart)
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// FormatMediaType quotes the name, or encodes it if it is not ASCII
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	// ServeContent adds Content-Length, Last-Modified and range requests
+	http.ServeContent(w, req, name, info.ModTime(), file)
+}
+
+func newServer(f *files) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", f.index)
+	mux.HandleFunc("POST /upload", f.upload)
+	mux.HandleFunc("GET /files/{name}", f.download)
+	return mux
+}
+
+// size formats n bytes for people
+func size(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+var page = template.Must(template.New("files").Funcs(template.FuncMap{"size": size}).Parse(`<!DOCTYPE html>
+<html>
+	<head><title>Files</title></head>
+	<body>
+		<h1>Files</h1>
+		<form action="/upload" method="post" enctype="multipart/form-data">
+			<input type="file" name="file" multiple>
+			<input type="submit" value="Upload"> (up to {{size .Max}} each)
+		</form>
+		<table>
+		{{range .Files}}
+			<tr><td><a href="/files/{{.Name}}">{{.Name}}</a></td><td>{{size .Size}}</td><td>{{.ModTime.Format "2006-01-02 15:04"}}</td></tr>
+		{{else}}
+			<tr><td>no files yet</td></tr>
+		{{end}}
+		</table>
+	</body>
+</html>
+`))
+
+func main() {
+	dir := flag.String("dir", "uploads", "directory to keep the files in")
+	maxSize := flag.Int64("max", 10<<20, "largest file accepted, in bytes")
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		log.Fatal("making the directory", "err", err)
+	}
+	log.Info("listening", "url", "http://"+*addr+"/", "dir", *dir)
+	srv := &http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(&files{dir: *dir, max: *maxSize}))}
+	if err := graceful.ListenAndServe(srv, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// cleanName keeps plain names and refuses ones that leave the directory or start with a dot
+func TestCleanName(t *testing.T) {
+	for name, want := range map[string]string{
+		"cat.png":                  "cat.png",
+		"my report (2).pdf":        "my report (2).pdf",
+		"../main.go":               "main.go",
+		"/etc/passwd":              "passwd",
+		`C:\Users\me\cat.png`:      "cat.png",
+		"uploads/../../secret.txt": "secret.txt",
+	} {
+		if got, err := cleanName(name); got != want || err != nil {
+			t.Errorf("cleanName(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"", ".", "..", "/", ".hidden", "dir/..", `..\..`} {
+		if got, err := cleanName(name); !errors.Is(err, errBadName) {
+			t.Errorf("cleanName(%q) = %q, %v, want errBadName", name, got, err)
+		}
+	}
+}
+
+// upload posts one file as a browser form would
+func upload(t *testing.T, h http.Handler, name string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", name)
+	fw.Write(data)
+	mw.Close()
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// an uploaded file comes back with its content, type and name
+func TestRoundTrip(t *testing.T) {
+	f := &files{dir: t.TempDir(), max: 1000}
+	h := newServer(f)
+	if w := upload(t, h, "notes.txt", []byte("hello")); w.Code != http.StatusSeeOther {
+		t.Fatalf("upload: status %d, want 303", w.Code)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/files/notes.txt", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "hello" {
+		t.Fatalf("download: status %d with %q, want 200 with hello", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type %q, want text/plain; charset=utf-8", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != "attachment; filename=notes.txt" {
+		t.Errorf("Content-Disposition %q, want attachment; filename=notes.txt", cd)
+	}
+}
+
+// a file over the limit gets 413 and leaves nothing behind
+func TestTooLarge(t *testing.T) {
+	f := &files{dir: t.TempDir(), max: 1000}
+	if w := upload(t, newServer(f), "big.bin", make([]byte, 1001)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("upload of 1001 bytes with a limit of 1000: status %d, want 413", w.Code)
+	}
+	if entries, _ := os.ReadDir(f.dir); len(entries) != 0 {
+		t.Errorf("after the refused upload the directory has %d files, want none", len(entries))
+	}
+	if w := upload(t, newServer(f), "fits.bin", make([]byte, 1000)); w.Code != http.StatusSeeOther {
+		t.Errorf("upload of exactly 1000 bytes: status %d, want 303", w.Code)
+	}
+}
@@ -0,0 +1,191 @@
+// a router of our own: a tree of path segments with {name} parameters
+//
+// The router keeps its routes in a trie, one node per path segment: the
+// children of a node are the segments that may follow it, literal ones
+// by name and at most one {parameter}, which matches any segment. A
+// request walks down the tree segment by segment, trying the literal
+// child first, so /users/new wins over /users/{id}; if that leads to a
+// dead end it backs up and tries the parameter. Each node holds a
+// handler per method: a path that matches with another method gets 405
+// Method Not Allowed and an Allow header instead of 404.
+//
+// Since Go 1.22 http.ServeMux does this too (see session4/ex24). This
+// router stores the parameters with req.SetPathValue, where ServeMux puts
+// them, so the handlers read them with req.PathValue and work with both.
+//
+// try:
+//
+//	curl localhost:3000/hello/gopher
+//	curl localhost:3000/users/42/books/7
+//	curl -i -X DELETE localhost:3000/spy
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// Router dispatches requests by method and path pattern
+type Router struct {
+	root node
+}
+
+type node struct {
+	children  map[string]*node // literal segments
+	param     *node            // the {parameter} child, if any
+	paramName string           // its name, without the braces
+	handlers  map[string]http.Handler
+}
+
+// segments splits a path into its segments: "/users/42/" is users, 42
+func segments(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// Handle registers h for requests with method and a path matching
+// pattern, e.g. "/users/{id}/books/{book}". It panics on a pattern that is
+// already registered for method, or that names a parameter differently
+// than an earlier pattern at the same place.
+func (r *Router) Handle(method, pattern string, h http.Handler) {
+	n := &r.root
+	for _, seg := range segments(pattern) {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			name := seg[1 : len(seg)-1]
+			if n.param == nil {
+				n.param = &node{paramName: name}
+			} else if n.param.paramName != name {
+				panic(fmt.Sprintf("router: %s conflicts with {%s} of an earlier pattern", seg, n.param.paramName))
+			}
+			n = n.param
+			continue
+		}
+		if n.children == nil {
+			n.children = map[string]*node{}
+		}
+		if n.children[seg] == nil {
+			n.children[seg] = &node{}
+		}
+		n = n.children[seg]
+	}
+	if n.handlers == nil {
+		n.handlers = map[string]http.Handler{}
+	}
+	if n.handlers[method] != nil {
+		panic("router: " + method + " " + pattern + " registered twice")
+	}
+	n.handlers[method] = h
+}
+
+// HandleFunc registers a handler function, see Handle
+func (r *Router) HandleFunc(method, pattern string, h func(http.ResponseWriter, *http.Request)) {
+	r.Handle(method, pattern, http.HandlerFunc(h))
+}
+
+// param is a parameter found on the way down the tree
+type param struct {
+	name, value string
+}
+
+// match returns the node for segs below n, with the parameters found on
+// the way appended to params, or nil. Only nodes with handlers match: a
+// node that merely lies on the way to longer patterns is a dead end.
+func (n *node) match(segs []string, params []param) (*node, []param) {
+	// HINT: a node matches when there are no segments left and it has handlers
+	// HINT: try n.children[segs[0]] first; only if that finds nothing, try n.param
+	// SOLUTION-START return nil, nil
+	if len(segs) == 0 {
+		if len(n.handlers) == 0 {
+			return nil, nil
+		}
+		return n, params
+	}
+	if child := n.children[segs[0]]; child != nil {
+		if found, p := child.match(segs[1:], params); found != nil {
+			return found, p
+		}
+	}
+	if n.param != nil {
+		return n.param.match(segs[1:], append(params, param{n.param.paramName, segs[0]}))
+	}
+	return nil, nil
+	// SOLUTION-END
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	n, params := r.root.match(segments(req.URL.Path), nil)
+	if n == nil {
+		http.NotFound(w, req)
+		return
+	}
+	h := n.handlers[req.Method]
+	if h == nil && req.Method == http.MethodHead {
+		h = n.handlers[http.MethodGet] // the server drops the body
+	}
+	if h == nil {
+		allow := make([]string, 0, len(n.handlers))
+		for m := range n.handlers {
+			allow = append(allow, m)
+		}
+		sort.Strings(allow)
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	for _, p := range params {
+		req.SetPathValue(p.name, p.value)
+	}
+	h.ServeHTTP(w, req)
+}
+
+// the handlers of session4/ex2, the name now a parameter instead of the
+// whole rest of the path
+func HelloServer(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "Hello, "+req.PathValue("name"))
+}
+
+func Spy(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "James Bond")
+}
+
+func newRouter() *Router {
+	r := &Router{}
+	r.HandleFunc("GET", "/hello/{name}", HelloServer)
+	r.HandleFunc("GET", "/spy", Spy)
+	r.HandleFunc("GET", "/users/new", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintln(w, "a form for a new user")
+	})
+	r.HandleFunc("GET", "/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "user %s\n", req.PathValue("id"))
+	})
+	r.HandleFunc("DELETE", "/users/{id}", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "user %s deleted\n", req.PathValue("id"))
+	})
+	r.HandleFunc("GET", "/users/{id}/books/{book}", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "book %s of user %s\n", req.PathValue("book"), req.PathValue("id"))
+	})
+	return r
+}
+
+func main() {
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	log.Info("listening", "url", "http://"+*addr+"/")
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newRouter())}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
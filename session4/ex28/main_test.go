@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func get(r http.Handler, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+// literal paths and parameters reach their handlers, parameters via PathValue
+func TestRoutes(t *testing.T) {
+	r := newRouter()
+	for path, want := range map[string]string{
+		"/spy":               "James Bond",
+		"/spy/":              "James Bond",
+		"/hello/gopher":      "Hello, gopher",
+		"/users/42":          "user 42\n",
+		"/users/42/books/7":  "book 7 of user 42\n",
+		"/users/new":         "a form for a new user\n",
+		"/users/new/books/1": "book 1 of user new\n",
+	} {
+		if w := get(r, "GET", path); w.Code != http.StatusOK || w.Body.String() != want {
+			t.Errorf("GET %s: %d %q, want 200 %q", path, w.Code, w.Body, want)
+		}
+	}
+}
+
+// a path that matches no pattern is 404, also when it only starts like one
+func TestNotFound(t *testing.T) {
+	r := newRouter()
+	for _, path := range []string{"/", "/hello", "/users", "/users/42/books", "/spy/extra", "/nothere"} {
+		if w := get(r, "GET", path); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", path, w.Code)
+		}
+	}
+}
+
+// a known path with another method is 405 with the allowed methods
+func TestMethodNotAllowed(t *testing.T) {
+	r := newRouter()
+	w := get(r, "POST", "/users/42")
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "DELETE, GET" {
+		t.Errorf("POST /users/42: status %d, Allow %q, want 405 and DELETE, GET", w.Code, w.Header().Get("Allow"))
+	}
+	if w := get(r, "DELETE", "/users/42"); w.Body.String() != "user 42 deleted\n" {
+		t.Errorf("DELETE /users/42: %q, want the DELETE handler", w.Body)
+	}
+}
+
+// a literal segment that leads nowhere falls back to the parameter
+func TestBacktracking(t *testing.T) {
+	r := &Router{}
+	r.HandleFunc("GET", "/a/b/c", func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("literal")) })
+	r.HandleFunc("GET", "/a/{x}/d", func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("param " + req.PathValue("x"))) })
+	if w := get(r, "GET", "/a/b/d"); w.Body.String() != "param b" {
+		t.Errorf("GET /a/b/d: %d %q, want param b", w.Code, w.Body)
+	}
+	if w := get(r, "GET", "/a/b/c"); w.Body.String() != "literal" {
+		t.Errorf("GET /a/b/c: %d %q, want literal", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/auth"
+)
+
+func testServer(t *testing.T) *httptest.Server {
+	users := auth.NewStore(auth.Hasher{Cost: 4})
+	for user, password := range map[string]string{"bond": "shaken", "q": "gadgets"} {
+		if err := users.Set(user, password); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tokens := map[string]string{tokenHash("bond-token"): "bond", tokenHash("q-token"): "q"}
+	srv := httptest.NewServer(newServer(users, tokens))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// get requests path from srv, with the header set by auth if it is not
+// nil, and returns the response with its body read
+func get(t *testing.T, srv *httptest.Server, path string, auth func(*http.Request)) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest("GET", srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != nil {
+		auth(req)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, strings.TrimSpace(string(body))
+}
+
+func basic(user, password string) func(*http.Request) {
+	return func(req *http.Request) { req.SetBasicAuth(user, password) }
+}
+
+func bearer(header string) func(*http.Request) {
+	return func(req *http.Request) { req.Header.Set("Authorization", header) }
+}
+
+// /spy asks for Basic Auth and only shows the spy to bond
+func TestBasicAuth(t *testing.T) {
+	srv := testServer(t)
+	for _, tc := range []struct {
+		name   string
+		auth   func(*http.Request)
+		status int
+	}{
+		{"no credentials", nil, http.StatusUnauthorized},
+		{"a wrong password", basic("bond", "stirred"), http.StatusUnauthorized},
+		{"an unknown user", basic("blofeld", "shaken"), http.StatusUnauthorized},
+		{"a bearer token", bearer("Bearer bond-token"), http.StatusUnauthorized},
+		{"q, who may not", basic("q", "gadgets"), http.StatusForbidden},
+		{"bond", basic("bond", "shaken"), http.StatusOK},
+	} {
+		resp, body := get(t, srv, "/spy", tc.auth)
+		if resp.StatusCode != tc.status {
+			t.Errorf("GET /spy with %s: status %d, want %d", tc.name, resp.StatusCode, tc.status)
+		}
+		challenge := resp.Header.Get("WWW-Authenticate")
+		if tc.status == http.StatusUnauthorized && !strings.HasPrefix(challenge, "Basic ") {
+			t.Errorf("GET /spy with %s: WWW-Authenticate %q, want a Basic challenge", tc.name, challenge)
+		}
+		if tc.status == http.StatusOK && body != "James Bond" {
+			t.Errorf("GET /spy as bond: %q, want James Bond", body)
+		}
+		if tc.status != http.StatusOK && strings.Contains(body, "James Bond") {
+			t.Errorf("GET /spy with %s shows the spy", tc.name)
+		}
+	}
+}
+
+// /api/spy takes a bearer token and only shows the spy to bond
+func TestBearerAuth(t *testing.T) {
+	srv := testServer(t)
+	for _, tc := range []struct {
+		name      string
+		auth      func(*http.Request)
+		status    int
+		challenge string
+	}{
+		{"no token", nil, http.StatusUnauthorized, `Bearer realm="spy"`},
+		{"a wrong token", bearer("Bearer bond-tokeN"), http.StatusUnauthorized, `Bearer realm="spy", error="invalid_token"`},
+		{"another scheme", basic("bond", "shaken"), http.StatusUnauthorized, `Bearer realm="spy"`},
+		{"the token of q", bearer("Bearer q-token"), http.StatusForbidden, ""},
+		{"the token of bond", bearer("Bearer bond-token"), http.StatusOK, ""},
+	} {
+		resp, body := get(t, srv, "/api/spy", tc.auth)
+		if resp.StatusCode != tc.status {
+			t.Errorf("GET /api/spy with %s: status %d, want %d", tc.name, resp.StatusCode, tc.status)
+		}
+		if got := resp.Header.Get("WWW-Authenticate"); got != tc.challenge {
+			t.Errorf("GET /api/spy with %s: WWW-Authenticate %q, want %q", tc.name, got, tc.challenge)
+		}
+		if tc.status == http.StatusOK && body != "James Bond" {
+			t.Errorf("GET /api/spy with the token of bond: %q, want James Bond", body)
+		}
+	}
+}
+
+// allow sees the user that basicAuth or bearerAuth found
+func TestAllow(t *testing.T) {
+	spy := allow("bond")(http.HandlerFunc(Spy))
+	for user, status := range map[string]int{"bond": http.StatusOK, "q": http.StatusForbidden, "": http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		spy.ServeHTTP(w, withUser(httptest.NewRequest("GET", "/spy", nil), user))
+		if w.Code != status {
+			t.Errorf("allow(bond) for user %q: status %d, want %d", user, w.Code, status)
+		}
+	}
+}
+
+// the other pages stay public
+func TestPublic(t *testing.T) {
+	srv := testServer(t)
+	if resp, body := get(t, srv, "/gopher", nil); resp.StatusCode != http.StatusOK || body != "Hello, gopher" {
+		t.Errorf("GET /gopher: %d %q, want 200 Hello, gopher", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// a load client that sends requests faster than session4/ex30/server allows
+//
+// It sends -rate requests a second for -d, paced by a time.Ticker, each
+// in a goroutine of its own so that a slow answer does not hold up the
+// next request, and prints every second how they were answered. Against
+// the server's defaults (5 a second, bursts of 10) the first second gets
+// through, then about a quarter of the requests do. With -polite the
+// client waits as long as the Retry-After of a 429 says before it sends
+// again, and hardly any request is refused.
+//
+// try: go run ./session4/ex30/client -rate 20 -d 5s [-polite]
+
+// retryAfter returns how long the Retry-After header h asks to wait: a
+// number of seconds or an HTTP date. It returns 0 if there is no header
+// or it cannot be read.
+func retryAfter(h string, now time.Time) time.Duration {
+	// HINT: strconv.Atoi for the seconds, http.ParseTime for a date
+	// HINT: a date in the past means no wait at all
+	// SOLUTION-START return 0
+	if secs, err := strconv.Atoi(h); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+	// SOLUTION-END
+}
+
+type result struct {
+	status int // 0 if the request failed
+	retry  time.Duration
+}
+
+func fire(client *http.Client, url string, results chan<- result) {
+	resp, err := client.Get(url)
+	if err != nil {
+		results <- result{}
+		return
+	}
+	io.Copy(io.Discard, resp.Body) // so that the connection can be used again
+	resp.Body.Close()
+	results <- result{resp.StatusCode, retryAfter(resp.Header.Get("Retry-After"), time.Now())}
+}
+
+// tally counts the answers by status
+type tally map[int]int
+
+func (t tally) String() string {
+	statuses := make([]int, 0, len(t))
+	for status := range t {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	var parts []string
+	for _, status := range statuses {
+		name := "failed"
+		if status != 0 {
+			name = strconv.Itoa(status)
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", t[status], name))
+	}
+	if len(parts) == 0 {
+		return "no answers"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func main() {
+	url := flag.String("url", "http://localhost:3000/spy", "the page to request")
+	rate := flag.Float64("rate", 20, "requests a second")
+	duration := flag.Duration("d", 5*time.Second, "how long to send requests")
+	polite := flag.Bool("polite", false, "after a 429, wait as long as Retry-After says")
+	flag.Parse()
+	if *rate <= 0 {
+		log.Fatal("-rate must be above 0")
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	send := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+	defer send.Stop()
+	report := time.NewTicker(time.Second)
+	defer report.Stop()
+	done := time.After(*duration)
+
+	var wg sync.WaitGroup
+	results := make(chan result)
+	second, total := tally{}, tally{}
+	var until time.Time // polite: no requests before this
+	held, elapsed := 0, 0
+	add := func(r result) {
+		second[r.status]++
+		total[r.status]++
+		if *polite && r.status == http.StatusTooManyRequests && r.retry > 0 {
+			until = time.Now().Add(r.retry)
+		}
+	}
+
+loop:
+	for {
+		select {
+		case <-send.C:
+			if time.Now().Before(until) {
+				held++
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fire(client, *url, results)
+			}()
+		case r := <-results:
+			add(r)
+		case <-report.C:
+			elapsed++
+			fmt.Printf("%3ds  %s\n", elapsed, second)
+			second = tally{}
+		case <-done:
+			break loop
+		}
+	}
+	// the answers still on their way
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	for r := range results {
+		add(r)
+	}
+	fmt.Printf("total %s", total)
+	if held > 0 {
+		fmt.Printf(", %d not sent while waiting for Retry-After", held)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,150 @@
+// rate limiting: a token bucket per client IP, 429 when it is empty
+//
+// Every client gets a bucket of -burst tokens. A request takes one, and
+// the bucket fills up again at -rate tokens a second, so a client can
+// send a burst of requests at once and then -rate a second for as long
+// as it likes. A request that finds the bucket empty is answered 429 Too
+// Many Requests, with a Retry-After header saying how many seconds until
+// the next token. session4/ex30/client sends requests faster than that
+// and shows what comes back.
+//
+// The bucket does not fill up by itself: a ticker per client would be a
+// goroutine per client. It remembers when it was last used instead, and
+// a request first adds the tokens earned since then.
+//
+// try: curl -i localhost:3000/spy, a few times in a row
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+type bucket struct {
+	tokens float64
+	last   time.Time // when tokens was last brought up to date
+}
+
+// limiter keeps a token bucket per client. It is safe for concurrent use.
+type limiter struct {
+	rate  float64 // tokens added per second
+	burst float64 // the size of a bucket
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	return &limiter{rate: rate, burst: float64(burst), now: time.Now, buckets: map[string]*bucket{}}
+}
+
+// allow takes a token from the bucket of client and reports whether there
+// was one; if not, wait is how long until there is
+func (l *limiter) allow(client string) (ok bool, wait time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// HINT: a new client starts with a full bucket
+	// HINT: first add rate tokens for every second from b.last to l.now(), but never more than burst
+	// HINT: the missing part of a token takes (1 - tokens) / rate seconds to come
+	// SOLUTION-START return true, 0
+	now := l.now()
+	b, found := l.buckets[client]
+	if !found {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	// SOLUTION-END
+}
+
+// sweep forgets the clients whose buckets have filled up again every
+// interval: they are as good as new, and would otherwise stay forever
+func (l *limiter) sweep(interval time.Duration) {
+	for range time.Tick(interval) {
+		l.mu.Lock()
+		now := l.now()
+		for client, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, client)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// clientIP returns the IP address the request came from. Behind a proxy
+// that would be the proxy's; a server there takes it from X-Forwarded-For,
+// but only a proxy it trusts may set that header.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limit answers the requests of clients that have run out of tokens 429
+func (l *limiter) limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := l.allow(clientIP(r))
+		if !ok {
+			// whole seconds, rounded up: a client that waits that long gets in
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// the handlers of session4/ex2
+func HelloServer(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "Hello, "+req.URL.Path[1:])
+}
+
+func Spy(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "James Bond")
+}
+
+func newServer(l *limiter) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", HelloServer)
+	mux.HandleFunc("/spy", Spy)
+	return l.limit(mux)
+}
+
+func main() {
+	addr := config.Addr("localhost:3000")
+	rate := flag.Float64("rate", 5, "requests a second each client may send")
+	burst := flag.Int("burst", 10, "requests a client may send at once")
+	flag.Parse()
+	if *rate <= 0 || *burst < 1 {
+		log.Fatal("-rate must be above 0 and -burst at least 1", "rate", *rate, "burst", *burst)
+	}
+	l := newLimiter(*rate, *burst)
+	go l.sweep(time.Minute)
+	log.Info("listening", "url", "http://"+*addr+"/", "rate", *rate, "burst", *burst)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(l))}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
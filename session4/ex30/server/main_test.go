@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// clock is a time that only moves when the test says so
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func testLimiter(rate float64, burst int) (*limiter, *clock) {
+	c := &clock{time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	l := newLimiter(rate, burst)
+	l.now = c.now
+	return l, c
+}
+
+// a new client may send a whole burst at once, and not one more
+func TestBurst(t *testing.T) {
+	l, _ := testLimiter(1, 3)
+	for i := 1; i <= 3; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatalf("request %d of a burst of 3 refused", i)
+		}
+	}
+	ok, wait := l.allow("a")
+	if ok {
+		t.Fatal("request 4 of a burst of 3 allowed")
+	}
+	if wait != time.Second {
+		t.Errorf("at 1 request a second, the 4th waits %v, want 1s", wait)
+	}
+}
+
+// the bucket fills up with time, but never above the burst
+func TestRefill(t *testing.T) {
+	l, c := testLimiter(2, 2)
+	l.allow("a")
+	l.allow("a")
+	c.advance(250 * time.Millisecond) // half a token
+	if ok, wait := l.allow("a"); ok || wait != 250*time.Millisecond {
+		t.Errorf("half a token after 250ms at 2/s: allowed %v, wait %v; want refused, wait 250ms", ok, wait)
+	}
+	c.advance(250 * time.Millisecond)
+	if ok, _ := l.allow("a"); !ok {
+		t.Error("a token after 500ms at 2/s, but refused")
+	}
+	c.advance(time.Hour)
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if ok, _ := l.allow("a"); ok {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("after an hour, %d requests at once allowed, want the burst of 2", allowed)
+	}
+}
+
+// every client has a bucket of its own
+func TestClients(t *testing.T) {
+	l, _ := testLimiter(1, 1)
+	if ok, _ := l.allow("a"); !ok {
+		t.Fatal("first request of a refused")
+	}
+	if ok, _ := l.allow("b"); !ok {
+		t.Error("b refused after a emptied its bucket")
+	}
+}
+
+// the server answers 429 with Retry-After in whole seconds, rounded up
+func TestServer(t *testing.T) {
+	l, c := testLimiter(0.4, 1) // a token every 2.5s
+	h := newServer(l)
+	get := func(remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/spy", nil)
+		req.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	if w := get("192.0.2.1:5000"); w.Code != http.StatusOK || w.Body.String() != "James Bond" {
+		t.Fatalf("first request: %d %q, want 200 James Bond", w.Code, w.Body)
+	}
+	// another connection of the same client: the port does not count
+	w := get("192.0.2.1:5001")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request at once: %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "3" {
+		t.Errorf("Retry-After %q for a wait of 2.5s, want 3", got)
+	}
+	if w := get("192.0.2.2:5000"); w.Code != http.StatusOK {
+		t.Errorf("another client: %d, want 200", w.Code)
+	}
+	c.advance(3 * time.Second)
+	if w := get("192.0.2.1:5002"); w.Code != http.StatusOK {
+		t.Errorf("after waiting as told: %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,43 @@
+// a backend for the reverse proxy of session4/ex31: the HelloServer, with a name
+//
+// Start two of them on different ports; each says who it is, so that the
+// proxy's load balancing shows in the answers, and what the proxy told
+// it about the client in the X-Forwarded-* headers. A backend behind a
+// proxy only ever sees the proxy's address in r.RemoteAddr.
+//
+// try: go run . -addr localhost:3001 -name one, and -addr localhost:3002 -name two
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+var name = flag.String("name", "one", "name of this backend, in every answer")
+
+func HelloServer(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Server", "backend/"+*name) // the proxy hides it
+	fmt.Fprintf(w, "Hello, %s, from backend %s\n", req.URL.Path[1:], *name)
+	fmt.Fprintf(w, "RemoteAddr %s, X-Forwarded-For %q, X-Forwarded-Host %q, X-Forwarded-Proto %q\n", req.RemoteAddr,
+		req.Header.Get("X-Forwarded-For"), req.Header.Get("X-Forwarded-Host"), req.Header.Get("X-Forwarded-Proto"))
+}
+
+func main() {
+	addr := config.Addr("localhost:3001")
+	flag.Parse()
+	log = log.With("backend", *name)
+	http.HandleFunc("/", HelloServer)
+	log.Info("listening", "url", "http://"+*addr+"/")
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil)}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// the backend greets the path and names itself, in the body and in Server
+func TestHelloServer(t *testing.T) {
+	w := httptest.NewRecorder()
+	HelloServer(w, httptest.NewRequest("GET", "/gopher", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "Hello, gopher, from backend one\n") {
+		t.Errorf("GET /gopher: %d %q, want a hello from backend one", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Server"); got != "backend/one" {
+		t.Errorf("Server: %q, want backend/one", got)
+	}
+}
+
+// behind a proxy, the backend shows the X-Forwarded headers it was given
+func TestForwarded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(HelloServer))
+	defer srv.Close()
+	req, err := http.NewRequest("GET", srv.URL+"/ada", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set("X-Forwarded-Host", "example.com")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `X-Forwarded-For "203.0.113.7", X-Forwarded-Host "example.com", X-Forwarded-Proto "https"`
+	if !strings.Contains(string(b), want) {
+		t.Errorf("GET /ada: %q, want %s", b, want)
+	}
+}
@@ -0,0 +1,128 @@
+// a reverse proxy: httputil.ReverseProxy in front of two backends, taking turns
+//
+// The client talks to the proxy only; the proxy sends every request on
+// to one of the backends and the answer back. Here they take turns,
+// round robin. On the way the proxy rewrites headers: X-Forwarded-For,
+// -Host and -Proto tell the backend who the client was, since it sees
+// only the proxy; X-Backend tells the client which backend answered; the
+// backend's Server header is removed, as it tells attackers too much.
+//
+// The Transport of the proxy is wrapped to log how long every backend
+// took (the upstream latency), which is where slowness hides. A backend
+// that is down gets the client a 502 Bad Gateway instead of a hang.
+//
+// Load balancers, TLS terminators and API gateways are all this.
+//
+// try: start two session4/ex31/backend, then go run . and curl localhost:3000/gopher a few times
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("proxy")
+
+// balancer hands out the backends in turn
+type balancer struct {
+	targets []*url.URL
+	next    atomic.Uint64
+}
+
+// pick returns the backend for the next request; it is called by many
+// requests at the same time
+func (b *balancer) pick() *url.URL {
+	// HINT: next.Add(1) gives every caller its own number, even at the same time
+	// SOLUTION-START return b.targets[0]
+	n := b.next.Add(1) - 1
+	return b.targets[n%uint64(len(b.targets))]
+	// SOLUTION-END
+}
+
+// timed is a RoundTripper that logs how long the backend took
+type timed struct {
+	http.RoundTripper
+}
+
+func (t timed) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.RoundTripper.RoundTrip(r)
+	took := time.Since(start).Round(time.Microsecond)
+	if err != nil {
+		log.Warn("upstream", "backend", r.URL.Host, "path", r.URL.Path, "took", took, "err", err)
+		return nil, err
+	}
+	log.Info("upstream", "backend", r.URL.Host, "path", r.URL.Path, "status", resp.StatusCode, "took", took)
+	return resp, nil
+}
+
+// newProxy returns a reverse proxy to the backends of b
+func newProxy(b *balancer) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		// Rewrite turns the request that came in into the one that goes out
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			// HINT: pr.SetURL(target) points pr.Out at the backend; pr.SetXForwarded() adds the X-Forwarded-* headers
+			// SOLUTION-START pr.Out.URL.Scheme, pr.Out.URL.Host = "http", b.targets[0].Host
+			pr.SetURL(b.pick())
+			pr.SetXForwarded()
+			// SOLUTION-END
+		},
+		// ModifyResponse changes the answer before it goes back
+		ModifyResponse: func(resp *http.Response) error {
+			// HINT: resp.Request is the request that went to the backend
+			// SOLUTION-START
+			resp.Header.Set("X-Backend", resp.Request.URL.Host)
+			resp.Header.Del("Server")
+			// SOLUTION-END
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, "the backend is not available", http.StatusBadGateway)
+		},
+		Transport: timed{http.DefaultTransport},
+	}
+}
+
+// parseTargets reads a comma-separated list of backend URLs. Each needs
+// a scheme and a host: url.Parse takes "localhost:3001" for the scheme
+// localhost and an opaque 3001, which no proxy can reach.
+func parseTargets(list string) ([]*url.URL, error) {
+	var targets []*url.URL
+	for _, s := range strings.Split(list, ",") {
+		u, err := url.Parse(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("backend %q: want a URL like http://localhost:3001", strings.TrimSpace(s))
+		}
+		targets = append(targets, u)
+	}
+	return targets, nil
+}
+
+func main() {
+	backends := flag.String("backends", "http://localhost:3001,http://localhost:3002", "backend URLs, comma-separated")
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	targets, err := parseTargets(*backends)
+	if err != nil {
+		log.Fatal("backends", "err", err)
+	}
+	proxy := newProxy(&balancer{targets: targets})
+	log.Info("listening", "url", "http://"+*addr+"/", "backends", *backends)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), proxy)}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
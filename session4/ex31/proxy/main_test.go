@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+// backend answers with its name and the X-Forwarded-For it got
+func backend(t *testing.T, name string) *url.URL {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "secret/1.0")
+		io.WriteString(w, name+" "+r.URL.Path+" "+r.Header.Get("X-Forwarded-For")+" "+r.Header.Get("X-Forwarded-Host"))
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return u
+}
+
+// the backends take turns and get the X-Forwarded headers; the client gets X-Backend and no Server
+func TestProxy(t *testing.T) {
+	one, two := backend(t, "one"), backend(t, "two")
+	p := newProxy(&balancer{targets: []*url.URL{one, two}})
+	for i, want := range []string{"one", "two", "one", "two"} {
+		r := httptest.NewRequest("GET", "http://front.test/hello", nil)
+		r.RemoteAddr = "192.0.2.7:4321"
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, r)
+		if body := w.Body.String(); body != want+" /hello 192.0.2.7 front.test" {
+			t.Errorf("request %d: %q, want %q", i+1, body, want+" /hello 192.0.2.7 front.test")
+		}
+		if host := map[string]string{"one": one.Host, "two": two.Host}[want]; w.Header().Get("X-Backend") != host {
+			t.Errorf("request %d: X-Backend %q, want %q", i+1, w.Header().Get("X-Backend"), host)
+		}
+		if s := w.Header().Get("Server"); s != "" {
+			t.Errorf("request %d: the backend's Server header %q got through", i+1, s)
+		}
+	}
+}
+
+// at the same time too, every backend gets its share
+func TestBalance(t *testing.T) {
+	b := &balancer{targets: []*url.URL{{Host: "a"}, {Host: "b"}, {Host: "c"}}}
+	var mu sync.Mutex
+	count := map[string]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 300; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := b.pick().Host
+			mu.Lock()
+			count[h]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if count["a"] != 100 || count["b"] != 100 || count["c"] != 100 {
+		t.Errorf("300 picks from 3 backends at the same time: %v, want 100 each", count)
+	}
+}
+
+// a backend that is down gets the client a 502
+func TestDown(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	down, _ := url.Parse(srv.URL)
+	srv.Close()
+	w := httptest.NewRecorder()
+	newProxy(&balancer{targets: []*url.URL{down}}).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("a backend that is down: %d, want 502", w.Code)
+	}
+}
+
+// backends without a scheme or a host are refused
+func TestParseTargets(t *testing.T) {
+	targets, err := parseTargets("http://localhost:3001, https://10.0.0.2:8443")
+	if err != nil || len(targets) != 2 || targets[1].Host != "10.0.0.2:8443" {
+		t.Errorf("two valid backends: %v, %v", targets, err)
+	}
+	for _, list := range []string{"localhost:9000", "http://localhost:3001,localhost:3002", "/backend", "http://", "ftp://localhost:21", ""} {
+		if _, err := parseTargets(list); err == nil {
+			t.Errorf("parseTargets(%q) accepted it", list)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+)
+
+// an HTTP client that times out, retries with backoff and can be cancelled
+//
+// http.Get uses http.DefaultClient, which has no timeout at all: a
+// server that never answers keeps it waiting forever. This client limits
+// every attempt with Client.Timeout and the steps of a connection with
+// its Transport, retries what may go better a moment later (timeouts,
+// broken connections, 5xx answers) and gives up at once on the rest
+// (4xx). Between attempts it waits longer and longer, and a random part
+// of that, the jitter, keeps many clients that failed together from
+// coming back together. Ctrl+C or -total cancel the context, which ends
+// the attempt in flight and the wait alike.
+//
+// Only GET is retried: a request that changes something may have been
+// carried out although its answer got lost.
+//
+// try: start session4/ex32/server, then
+//
+//	go run ./session4/ex32/client http://localhost:3000/spy http://localhost:3000/world
+
+// newClient returns a client that spends at most timeout on an attempt
+func newClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout, // the whole attempt, from dialing to the end of the body
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           (&net.Dialer{Timeout: 3 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+			TLSHandshakeTimeout:   3 * time.Second,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConnsPerHost:   4, // connections kept open for the next attempts
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+}
+
+// statusError is an answer with a status other than 200
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("server answered %d %s", e.code, http.StatusText(e.code))
+}
+
+// retryable reports whether another attempt may get past err: not when
+// the server refused the request itself, with a 4xx status
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= 500
+	}
+	return true // a timeout or a broken connection
+}
+
+// retrier gets pages and tries again when that fails
+type retrier struct {
+	client  *http.Client
+	retries int           // attempts after the first
+	base    time.Duration // the longest wait after the first attempt
+	max     time.Duration // the longest wait after any attempt
+}
+
+// backoff returns how long to wait after attempt (0 for the first): a
+// random duration up to base doubled attempt times, but at most max
+func (r *retrier) backoff(attempt int) time.Duration {
+	// HINT: double base attempt times, but stop at max: base << attempt overflows soon
+	// SOLUTION-START return rand.N(r.base + 1)
+	limit := r.base
+	for i := 0; i < attempt && limit < r.max; i++ {
+		limit *= 2
+	}
+	limit = min(limit, r.max)
+	return rand.N(limit + 1) // from 0 to limit
+	// SOLUTION-END
+}
+
+// fetch makes one attempt
+func (r *retrier) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body) // so that the connection can be used again
+		return nil, &statusError{resp.StatusCode}
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// get fetches url, with up to r.retries more attempts when an attempt
+// fails with a retryable error. It stops when ctx is done, also while it
+// waits, and then returns ctx's error.
+func (r *retrier) get(ctx context.Context, url string) ([]byte, error) {
+	// HINT: after a failed attempt, wait in a select on ctx.Done() and time.After(r.backoff(attempt))
+	// HINT: an attempt that failed because ctx ended also fails retryable: check ctx.Err() first
+	// SOLUTION-START return r.fetch(ctx, url)
+	for attempt := 0; ; attempt++ {
+		body, err := r.fetch(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !retryable(err) || attempt == r.retries {
+			return nil, err
+		}
+		wait := r.backoff(attempt)
+		log.Printf("%s: attempt %d: %v; again in %v", url, attempt+1, err, wait.Round(time.Millisecond))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	// SOLUTION-END
+}
+
+func main() {
+	timeout := flag.Duration("timeout", 2*time.Second, "longest attempt")
+	retries := flag.Int("retries", 4, "attempts after the first")
+	base := flag.Duration("base", 100*time.Millisecond, "longest wait after the first attempt")
+	maxWait := flag.Duration("max", 2*time.Second, "longest wait after any attempt")
+	total := flag.Duration("total", 15*time.Second, "give up on all pages after this long")
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: client [flags] url...")
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), graceful.Signals...)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *total)
+	defer cancel()
+
+	r := &retrier{client: newClient(*timeout), retries: *retries, base: *base, max: *maxWait}
+	failed := false
+	for _, url := range flag.Args() {
+		body, err := r.get(ctx, url)
+		if err != nil {
+			log.Printf("%s: %v", url, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: %s\n", url, body)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
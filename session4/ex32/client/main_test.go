@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func init() {
+	log.SetOutput(io.Discard) // the retries are logged
+}
+
+// server answers the first fails requests with status and then "ok",
+// counting the requests
+func server(t *testing.T, fails int, status int) (*httptest.Server, *atomic.Int32) {
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(n.Add(1)) <= fails {
+			http.Error(w, "no", status)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &n
+}
+
+func testRetrier(retries int) *retrier {
+	return &retrier{client: newClient(time.Second), retries: retries, base: time.Millisecond, max: 4 * time.Millisecond}
+}
+
+// backoff grows from base by doubling up to max, at random below that
+func TestBackoff(t *testing.T) {
+	r := &retrier{base: 100 * time.Millisecond, max: time.Second}
+	for attempt, limit := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		limit *= time.Millisecond
+		seen := map[time.Duration]bool{}
+		for i := 0; i < 200; i++ {
+			d := r.backoff(attempt)
+			if d < 0 || d > limit {
+				t.Fatalf("backoff(%d) = %v, want 0 to %v", attempt, d, limit)
+			}
+			seen[d] = true
+		}
+		if len(seen) < 100 {
+			t.Errorf("backoff(%d) gave only %d different waits in 200 calls, want jitter", attempt, len(seen))
+		}
+	}
+	if d := r.backoff(100); d < 0 || d > time.Second {
+		t.Errorf("backoff(100) = %v, want 0 to 1s: did base overflow?", d)
+	}
+}
+
+// 5xx answers are retried until one succeeds
+func TestRetry(t *testing.T) {
+	srv, n := server(t, 2, http.StatusServiceUnavailable)
+	body, err := testRetrier(3).get(context.Background(), srv.URL)
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("get after two 503s: %q, %v; want ok", body, err)
+	}
+	if n.Load() != 3 {
+		t.Errorf("get after two 503s made %d attempts, want 3", n.Load())
+	}
+}
+
+// after retries more attempts, get gives up with the last error
+func TestGiveUp(t *testing.T) {
+	srv, n := server(t, 100, http.StatusInternalServerError)
+	_, err := testRetrier(2).get(context.Background(), srv.URL)
+	var se *statusError
+	if !errors.As(err, &se) || se.code != http.StatusInternalServerError {
+		t.Errorf("get when every attempt fails: error %v, want the 500", err)
+	}
+	if n.Load() != 3 {
+		t.Errorf("get with 2 retries made %d attempts, want 3", n.Load())
+	}
+}
+
+// 4xx answers are not retried
+func TestNoRetry(t *testing.T) {
+	srv, n := server(t, 100, http.StatusNotFound)
+	_, err := testRetrier(3).get(context.Background(), srv.URL)
+	var se *statusError
+	if !errors.As(err, &se) || se.code != http.StatusNotFound {
+		t.Errorf("get of a missing page: error %v, want the 404", err)
+	}
+	if n.Load() != 1 {
+		t.Errorf("get of a missing page made %d attempts, want 1", n.Load())
+	}
+}
+
+// an attempt that takes too long is cut off and retried
+func TestTimeout(t *testing.T) {
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.Add(1) == 1 {
+			select {
+			case <-time.After(5 * time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+	r := testRetrier(2)
+	r.client = newClient(200 * time.Millisecond)
+	start := time.Now()
+	body, err := r.get(context.Background(), srv.URL)
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("get after a slow attempt: %q, %v; want ok", body, err)
+	}
+	if took := time.Since(start); took > 2*time.Second {
+		t.Errorf("get after a slow attempt took %v, the timeout is 200ms", took)
+	}
+}
+
+// a cancelled context ends the waiting between attempts
+func TestCancel(t *testing.T) {
+	srv, n := server(t, 100, http.StatusServiceUnavailable)
+	r := testRetrier(10)
+	r.base, r.max = 10*time.Second, 10*time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := r.get(ctx, srv.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("get with a context that ends: error %v, want context.DeadlineExceeded", err)
+	}
+	if took := time.Since(start); took > 2*time.Second {
+		t.Errorf("get went on for %v after its context ended at 300ms", took)
+	}
+	if n.Load() > 2 {
+		t.Errorf("get made %d attempts in 300ms with waits of up to 10s", n.Load())
+	}
+}
@@ -0,0 +1,73 @@
+// a flaky server to try session4/ex32/client against
+//
+// It serves the HelloServer and Spy handlers of session4/ex2, but a
+// -fail share of the requests gets 503 Service Unavailable and a -slow
+// share waits -delay before it answers, as a server under load does. A
+// client that gives up on the first error, or waits forever, will not get
+// far with it.
+//
+// try: curl -i localhost:3000/spy, a few times
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+var (
+	fail  = flag.Float64("fail", 0.5, "share of the requests answered 503")
+	slow  = flag.Float64("slow", 0.2, "share of the requests answered after -delay")
+	delay = flag.Duration("delay", 5*time.Second, "how long a slow request takes")
+)
+
+// flaky fails and delays requests before next gets them
+func flaky(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rand.Float64() < *slow {
+			log.Info("slow", "path", r.URL.Path)
+			select {
+			case <-time.After(*delay):
+			case <-r.Context().Done(): // the client gave up
+				log.Info("the client gave up", "path", r.URL.Path)
+				return
+			}
+		}
+		if rand.Float64() < *fail {
+			log.Info("failing", "path", r.URL.Path, "status", http.StatusServiceUnavailable)
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+			return
+		}
+		log.Info("ok", "path", r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func HelloServer(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "Hello, "+req.URL.Path[1:])
+}
+
+func Spy(w http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(w, "James Bond")
+}
+
+func main() {
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", HelloServer)
+	mux.HandleFunc("/spy", Spy)
+	log.Info("listening", "url", "http://"+*addr+"/", "fail", *fail, "slow", *slow)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), flaky(mux))}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// shares sets the flags for one test
+func shares(t *testing.T, failShare, slowShare float64, d time.Duration) {
+	t.Helper()
+	oldFail, oldSlow, oldDelay := *fail, *slow, *delay
+	*fail, *slow, *delay = failShare, slowShare, d
+	t.Cleanup(func() { *fail, *slow, *delay = oldFail, oldSlow, oldDelay })
+}
+
+func get(path string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", HelloServer)
+	mux.HandleFunc("/spy", Spy)
+	w := httptest.NewRecorder()
+	flaky(mux).ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+// with no failures the handlers of session4/ex2 answer
+func TestHealthy(t *testing.T) {
+	shares(t, 0, 0, 0)
+	if w := get("/spy"); w.Code != http.StatusOK || w.Body.String() != "James Bond" {
+		t.Errorf("GET /spy: %d %q, want 200 James Bond", w.Code, w.Body.String())
+	}
+}
+
+// a failing request gets 503 Service Unavailable
+func TestFail(t *testing.T) {
+	shares(t, 1, 0, 0)
+	if w := get("/spy"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /spy with -fail 1: status %d, want 503", w.Code)
+	}
+}
+
+// a slow request stops waiting when the client gives up
+func TestSlowClientGone(t *testing.T) {
+	shares(t, 0, 1, time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	start := time.Now()
+	flaky(http.HandlerFunc(Spy)).ServeHTTP(w, httptest.NewRequest("GET", "/spy", nil).WithContext(ctx))
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("a slow request whose client left after 50ms took %v", took)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("the request whose client left was answered %q", w.Body.String())
+	}
+}
@@ -0,0 +1,150 @@
+// server-sent events: a clock that pushes the time to the browser
+//
+// GET /events never finishes its response: it writes an event every
+// -interval, in the text/event-stream format, and flushes it at once;
+// without the Flush it would wait in the server's buffer until the
+// response ends. An event is a few "field: value" lines and a blank one:
+//
+//	id: 3
+//	event: tick
+//	data: 2024-01-01T12:00:03+01:00
+//
+// The page at / reads the stream with an EventSource, which also
+// reconnects when the connection breaks and sends the id of the last
+// event it got in a Last-Event-ID header, so the count goes on where it
+// stopped: stop the server and start it again while the page is open.
+// Streams are the requests a graceful shutdown would wait for forever,
+// so they end when the server is stopped.
+//
+// try: open http://localhost:3000/, or curl -N localhost:3000/events
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// writeEvent writes one event in the text/event-stream format. data may
+// have several lines: each gets a data field of its own.
+func writeEvent(w io.Writer, id int, event, data string) error {
+	// the event is written in one piece, not field by field
+	var b strings.Builder
+	// HINT: a blank line ends the event; a newline inside data would end it too soon
+	// SOLUTION-START
+	fmt.Fprintf(&b, "id: %d\nevent: %s\n", id, event)
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	// SOLUTION-END
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// clock streams the time
+type clock struct {
+	interval time.Duration
+	stop     <-chan struct{} // closed when the server shuts down
+}
+
+func (c *clock) events(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// a reconnecting browser says which event it saw last
+	id, _ := strconv.Atoi(req.Header.Get("Last-Event-ID"))
+	fmt.Fprint(w, "retry: 2000\n\n") // reconnect after 2s, not the default 3s
+	flusher.Flush()
+	log.Info("streaming", "remote", req.RemoteAddr, "from", id+1)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		// HINT: wait for the ticker, the client going away (req.Context()) or c.stop
+		// HINT: after each event, Flush sends it on its way
+		// SOLUTION-START return
+		select {
+		case now := <-ticker.C:
+			id++
+			if err := writeEvent(w, id, "tick", now.Format(time.RFC3339)); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-req.Context().Done():
+			log.Info("client gone", "remote", req.RemoteAddr, "after", id)
+			return
+		case <-c.stop:
+			return
+		}
+		// SOLUTION-END
+	}
+}
+
+func (c *clock) index(w http.ResponseWriter, req *http.Request) {
+	if err := page.Execute(w, c.interval); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func newServer(c *clock) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", c.index)
+	mux.HandleFunc("GET /events", c.events)
+	return mux
+}
+
+var page = template.Must(template.New("clock").Parse(`<!DOCTYPE html>
+<html>
+	<head><title>Clock</title></head>
+	<body>
+		<h1>The time, every {{.}}</h1>
+		<p id="status">connecting...</p>
+		<ol id="ticks"></ol>
+		<script>
+		const status = document.getElementById("status");
+		const ticks = document.getElementById("ticks");
+		const events = new EventSource("/events");
+		events.onopen = () => { status.textContent = "connected"; };
+		events.onerror = () => { status.textContent = "reconnecting..."; };
+		events.addEventListener("tick", e => {
+			const li = document.createElement("li");
+			li.value = Number(e.lastEventId);
+			li.textContent = e.data;
+			ticks.prepend(li);
+		});
+		</script>
+	</body>
+</html>
+`))
+
+func main() {
+	addr := config.Addr("localhost:3000")
+	interval := flag.Duration("interval", time.Second, "time between events")
+	flag.Parse()
+	ctx, stop := signal.NotifyContext(context.Background(), graceful.Signals...)
+	defer stop()
+	c := &clock{interval: *interval, stop: ctx.Done()}
+	log.Info("listening", "url", "http://"+*addr+"/")
+	if err := graceful.Run(ctx, &http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(c))}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+// writeEvent writes the fields and a blank line, a data field per line
+func TestWriteEvent(t *testing.T) {
+	for _, tc := range []struct {
+		data, want string
+	}{
+		{"12:00", "id: 7\nevent: tick\ndata: 12:00\n\n"},
+		{"one\ntwo", "id: 7\nevent: tick\ndata: one\ndata: two\n\n"},
+	} {
+		var b strings.Builder
+		if err := writeEvent(&b, 7, "tick", tc.data); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tc.want {
+			t.Errorf("writeEvent(7, tick, %q) wrote %q, want %q", tc.data, b.String(), tc.want)
+		}
+	}
+}
+
+// readEvent reads the lines of the next event, without the blank line
+func readEvent(t *testing.T, r *bufio.Reader) []string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading the stream after %q: %v", lines, err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+// open starts a stream from srv with a Last-Event-ID of last, if not ""
+func open(t *testing.T, srv *httptest.Server, last string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest("GET", srv.URL+"/events", nil)
+	if last != "" {
+		req.Header.Set("Last-Event-ID", last)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// /events streams ticks as they happen, not when the response ends
+func TestStream(t *testing.T) {
+	srv := httptest.NewServer(newServer(&clock{interval: 50 * time.Millisecond}))
+	t.Cleanup(srv.Close) // after the stream is closed, or Close waits for it
+	resp := open(t, srv, "")
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type %q, want text/event-stream", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+	readEvent(t, r) // retry
+	for id := 1; id <= 3; id++ {
+		done := make(chan []string, 1)
+		go func() { done <- readEvent(t, r) }()
+		select {
+		case ev := <-done:
+			if len(ev) != 3 || ev[0] != "id: "+strconv.Itoa(id) || ev[1] != "event: tick" || !strings.HasPrefix(ev[2], "data: ") {
+				t.Fatalf("event %d is %q, want id, event: tick and data", id, ev)
+			}
+			if _, err := time.Parse(time.RFC3339, strings.TrimPrefix(ev[2], "data: ")); err != nil {
+				t.Errorf("event %d: %v", id, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("event %d did not arrive: is it flushed?", id)
+		}
+	}
+}
+
+// a reconnecting client goes on after its Last-Event-ID
+func TestResume(t *testing.T) {
+	srv := httptest.NewServer(newServer(&clock{interval: 10 * time.Millisecond}))
+	t.Cleanup(srv.Close) // after the stream is closed, or Close waits for it
+	r := bufio.NewReader(open(t, srv, "41").Body)
+	readEvent(t, r)
+	if ev := readEvent(t, r); len(ev) == 0 || ev[0] != "id: 42" {
+		t.Errorf("the first event after Last-Event-ID 41 is %q, want id 42", ev)
+	}
+}
+
+// the streams end when the server stops
+func TestStop(t *testing.T) {
+	stop := make(chan struct{})
+	srv := httptest.NewServer(newServer(&clock{interval: 10 * time.Millisecond, stop: stop}))
+	t.Cleanup(srv.Close) // after the stream is closed, or Close waits for it
+	resp := open(t, srv, "")
+	close(stop)
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, resp.Body)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("the stream broke off: %v, want it to end", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the stream goes on after stop was closed")
+	}
+}
@@ -0,0 +1,225 @@
+// a registration form, checked on the server and shown again with its errors
+//
+// The browser checks the form too (required, type="email", minlength),
+// but that is a convenience for the user, not a protection: anyone can
+// post anything with curl. So the server parses the form, checks every
+// field and, if one is wrong, answers 422 with the same form, the values
+// typed in kept and a message next to each field in error; only the
+// passwords are never sent back. A valid form creates the account and
+// redirects to the list of users, so that reloading the page does not
+// post the form again.
+//
+// try: open http://localhost:3000/, or
+//
+//	curl -i -d name=gopher -d email=nope -d password=short localhost:3000/register
+package main
+
+import (
+	"errors"
+	"flag"
+	"html/template"
+	"net/http"
+	"net/mail"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/auth"
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// registration is the form as it was posted. The passwords are not
+// exported: the template cannot show them.
+type registration struct {
+	Name     string
+	Email    string
+	password string
+	confirm  string
+
+	Errors map[string]string // the message for each field in error
+}
+
+// validEmail reports whether s is a bare address like gopher@example.com.
+// mail.ParseAddress also takes a name in front, "Gopher <gopher@example.com>",
+// so the address it finds must be all of s.
+func validEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+// validate checks the fields and fills in r.Errors; it reports whether
+// there were none
+func (r *registration) validate() bool {
+	r.Errors = map[string]string{}
+	// HINT: a name of 30 characters is not len(name) == 30 with letters like é or ü
+	// HINT: bcrypt only looks at the first 72 bytes of a password
+	// SOLUTION-START
+	switch {
+	case r.Name == "":
+		r.Errors["name"] = "Please choose a user name."
+	case len([]rune(r.Name)) > 30:
+		r.Errors["name"] = "At most 30 characters, please."
+	}
+	switch {
+	case r.Email == "":
+		r.Errors["email"] = "Please enter your email address."
+	case !validEmail(r.Email):
+		r.Errors["email"] = "This is not an email address."
+	}
+	switch {
+	case len([]rune(r.password)) < 8:
+		r.Errors["password"] = "At least 8 characters, please."
+	case len(r.password) > 72:
+		r.Errors["password"] = "At most 72 bytes, please."
+	}
+	if r.confirm != r.password {
+		r.Errors["confirm"] = "The passwords do not match."
+	}
+	// SOLUTION-END
+	return len(r.Errors) == 0
+}
+
+var errTaken = errors.New("this user name is taken")
+
+type account struct {
+	email string
+	hash  string
+}
+
+// accounts are the registered users, by name
+type accounts struct {
+	hasher auth.Hasher
+
+	mu sync.Mutex
+	m  map[string]account
+}
+
+func (a *accounts) add(name, email, password string) error {
+	hash, err := a.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.m[name]; ok {
+		return errTaken
+	}
+	a.m[name] = account{email, hash}
+	return nil
+}
+
+func (a *accounts) names() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	names := make([]string, 0, len(a.m))
+	for name := range a.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// render writes the page called name, or a 500 if it fails
+func render(w http.ResponseWriter, status int, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := pages.ExecuteTemplate(w, name, data); err != nil {
+		log.Error("executing template", "name", name, "err", err) // too late for another status
+	}
+}
+
+func (a *accounts) form(w http.ResponseWriter, req *http.Request) {
+	render(w, http.StatusOK, "register", &registration{})
+}
+
+func (a *accounts) register(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r := &registration{
+		Name:     strings.TrimSpace(req.PostForm.Get("name")),
+		Email:    strings.TrimSpace(req.PostForm.Get("email")),
+		password: req.PostForm.Get("password"), // spaces may be part of it
+		confirm:  req.PostForm.Get("confirm"),
+	}
+	if !r.validate() {
+		render(w, http.StatusUnprocessableEntity, "register", r)
+		return
+	}
+	err := a.add(r.Name, r.Email, r.password)
+	if errors.Is(err, errTaken) {
+		r.Errors["name"] = "This user name is taken, please choose another one."
+		render(w, http.StatusUnprocessableEntity, "register", r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Info("registered", "name", r.Name, "email", r.Email)
+	http.Redirect(w, req, "/users", http.StatusSeeOther)
+}
+
+func (a *accounts) list(w http.ResponseWriter, req *http.Request) {
+	render(w, http.StatusOK, "users", a.names())
+}
+
+func newServer(a *accounts) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", a.form)
+	mux.HandleFunc("POST /register", a.register)
+	mux.HandleFunc("GET /users", a.list)
+	return mux
+}
+
+var pages = template.Must(template.New("").Parse(`
+{{define "register"}}<!DOCTYPE html>
+<html>
+	<head>
+		<title>Register</title>
+		<style>.error { color: #b00; }</style>
+	</head>
+	<body>
+		<h1>Register</h1>
+		{{if .Errors}}<p class="error">Please correct the fields marked below.</p>{{end}}
+		<form action="/register" method="post">
+			<p>User name: <input name="name" value="{{.Name}}" required maxlength="30">
+			{{with .Errors.name}}<span class="error">{{.}}</span>{{end}}</p>
+			<p>Email: <input name="email" type="email" value="{{.Email}}" required>
+			{{with .Errors.email}}<span class="error">{{.}}</span>{{end}}</p>
+			<p>Password: <input name="password" type="password" required minlength="8">
+			{{with .Errors.password}}<span class="error">{{.}}</span>{{end}}</p>
+			<p>Password again: <input name="confirm" type="password" required>
+			{{with .Errors.confirm}}<span class="error">{{.}}</span>{{end}}</p>
+			<p><input type="submit" value="Register"></p>
+		</form>
+	</body>
+</html>
+{{end}}
+{{define "users"}}<!DOCTYPE html>
+<html>
+	<head><title>Users</title></head>
+	<body>
+		<h1>Users</h1>
+		<ul>{{range .}}<li>{{.}}</li>{{else}}<li>nobody yet</li>{{end}}</ul>
+		<p><a href="/">Register another one</a></p>
+	</body>
+</html>
+{{end}}`))
+
+func main() {
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	a := &accounts{hasher: auth.Hasher{}, m: map[string]account{}}
+	log.Info("listening", "url", "http://"+*addr+"/")
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(a))}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
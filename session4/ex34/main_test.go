@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/auth"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+// validate finds the fields in error, and only those
+func TestValidate(t *testing.T) {
+	good := registration{Name: "gopher", Email: "gopher@example.com", password: "correct horse", confirm: "correct horse"}
+	for _, tc := range []struct {
+		name   string
+		change func(*registration)
+		errors []string
+	}{
+		{"a good form", func(r *registration) {}, nil},
+		{"no name", func(r *registration) { r.Name = "" }, []string{"name"}},
+		{"a name of 30 letters é", func(r *registration) { r.Name = strings.Repeat("é", 30) }, nil},
+		{"a name of 31 letters", func(r *registration) { r.Name = strings.Repeat("a", 31) }, []string{"name"}},
+		{"no email", func(r *registration) { r.Email = "" }, []string{"email"}},
+		{"an email without @", func(r *registration) { r.Email = "gopher.example.com" }, []string{"email"}},
+		{"an email with a name", func(r *registration) { r.Email = "Gopher <gopher@example.com>" }, []string{"email"}},
+		{"a password of 7 characters", func(r *registration) { r.password, r.confirm = "1234567", "1234567" }, []string{"password"}},
+		{"a password of 73 bytes", func(r *registration) { r.password = strings.Repeat("x", 73); r.confirm = r.password }, []string{"password"}},
+		{"passwords that differ", func(r *registration) { r.confirm = "correct horse!" }, []string{"confirm"}},
+		{"nothing at all", func(r *registration) { *r = registration{} }, []string{"name", "email", "password"}},
+	} {
+		r := good
+		tc.change(&r)
+		ok := r.validate()
+		if ok != (len(tc.errors) == 0) {
+			t.Errorf("%s: validate() = %v, errors %v", tc.name, ok, r.Errors)
+		}
+		if len(r.Errors) != len(tc.errors) {
+			t.Errorf("%s: errors %v, want errors for %v", tc.name, r.Errors, tc.errors)
+			continue
+		}
+		for _, field := range tc.errors {
+			if r.Errors[field] == "" {
+				t.Errorf("%s: no error for %s, errors %v", tc.name, field, r.Errors)
+			}
+		}
+	}
+}
+
+func post(h http.Handler, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/register", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func testServer() http.Handler {
+	return newServer(&accounts{hasher: auth.Hasher{Cost: 4}, m: map[string]account{}})
+}
+
+// a form in error comes back with 422, its values and messages, not the password
+func TestFormAgain(t *testing.T) {
+	w := post(testServer(), url.Values{"name": {"<b>gopher</b>"}, "email": {"gopher@"}, "password": {"secret7"}, "confirm": {"secret7"}})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("a form in error: status %d, want 422", w.Code)
+	}
+	page := w.Body.String()
+	if !strings.Contains(page, `value="gopher@"`) {
+		t.Error("the page does not show the email typed in again")
+	}
+	if !strings.Contains(page, "&lt;b&gt;gopher&lt;/b&gt;") || strings.Contains(page, "<b>gopher") {
+		t.Error("the name typed in is not shown again, escaped")
+	}
+	if strings.Contains(page, "secret7") {
+		t.Error("the page sends the password back")
+	}
+	if strings.Count(page, `class="error"`) < 3 {
+		t.Errorf("the page does not have the error messages:\n%s", page)
+	}
+}
+
+// a valid form creates the account and redirects; the name is then taken
+func TestRegister(t *testing.T) {
+	h := testServer()
+	form := url.Values{"name": {"gopher"}, "email": {"gopher@example.com"}, "password": {"correct horse"}, "confirm": {"correct horse"}}
+	w := post(h, form)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/users" {
+		t.Fatalf("a valid form: status %d to %q, want 303 to /users", w.Code, w.Header().Get("Location"))
+	}
+	list := httptest.NewRecorder()
+	h.ServeHTTP(list, httptest.NewRequest("GET", "/users", nil))
+	if !strings.Contains(list.Body.String(), "<li>gopher</li>") {
+		t.Errorf("/users does not list gopher:\n%s", list.Body)
+	}
+	if w := post(h, form); w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "taken") {
+		t.Errorf("the same name again: status %d, want 422 and a message that it is taken", w.Code)
+	}
+}
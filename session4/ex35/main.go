@@ -0,0 +1,121 @@
+// the templates of ex9 to ex12 again, with html/template: escaped for the web
+//
+// text/template writes the data as it is. That is right for text, but a
+// page made with it runs whatever script a user managed to put in the
+// data: the name below closes the attribute it is put in and adds a
+// script of its own. html/template has the same API and knows HTML: it
+// escapes every value for the place it goes to, text, attribute, URL or
+// JavaScript, and only leaves alone what is typed template.HTML (and
+// must therefore be HTML the program made itself).
+//
+// Each template is executed with both packages. With -serve the two are
+// served at /text and /html, taking the name from ?name=; open
+// http://localhost:3000/text and the script runs.
+package main
+
+import (
+	"flag"
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	"os"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// Person is the data, as in ex9, plus a bio that is trusted HTML
+type Person struct {
+	Name string
+	Bio  htmltemplate.HTML
+}
+
+// payload closes a quoted attribute and a tag, then adds a script
+const payload = `"><script>alert('pwned')</script>`
+
+var templates = []struct{ name, text string }{
+	{"ex9", "hello {{.Name}}!"},
+	{"ex11", "{{if .Name}}Welcome back, {{.Name}}.{{else}}Welcome, stranger.{{end}}"},
+	{"ex12", "{{with $x := `hello`}}{{printf `%s %s` $x $.Name}}{{end}}!"},
+	{"attribute", `<input name="name" value="{{.Name}}">`},
+	{"url", `<a href="/users?name={{.Name}}">profile</a>`},
+	{"script", `<script>var name = {{.Name}};</script>`},
+	{"trusted", `<p>{{.Bio}}</p>`},
+}
+
+// executor is what *texttemplate.Template and *htmltemplate.Template have
+// in common
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// parse parses all the templates with one package or the other
+func parse(html bool) []executor {
+	var ts []executor
+	for _, t := range templates {
+		if html {
+			ts = append(ts, htmltemplate.Must(htmltemplate.New(t.name).Parse(t.text)))
+		} else {
+			ts = append(ts, texttemplate.Must(texttemplate.New(t.name).Parse(t.text)))
+		}
+	}
+	return ts
+}
+
+// page writes all the templates executed with p, one per line
+func page(w io.Writer, ts []executor, p Person) {
+	for i, t := range ts {
+		io.WriteString(w, templates[i].name+": ")
+		if err := t.Execute(w, p); err != nil {
+			log.Error("executing template", "name", templates[i].name, "err", err)
+		}
+		io.WriteString(w, "\n")
+	}
+}
+
+// newMux serves the page made with text/template at /text and the one
+// made with html/template at /html
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	for path, ts := range map[string][]executor{"/text": parse(false), "/html": parse(true)} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
+			name := req.FormValue("name")
+			if name == "" {
+				name = payload
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			io.WriteString(w, "<!DOCTYPE html>\n<pre>\n")
+			page(w, ts, Person{Name: name, Bio: "<em>a gopher</em>"})
+			io.WriteString(w, "</pre>\n")
+		})
+	}
+	return mux
+}
+
+func serve(addr string) {
+	log.Info("listening", "text", "http://"+addr+"/text", "html", "http://"+addr+"/html")
+	if err := graceful.ListenAndServe(&http.Server{Addr: addr, Handler: metrics.Instrument(metrics.NewRegistry(), newMux())}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
+
+func main() {
+	serveFlag := flag.Bool("serve", false, "serve the pages instead of printing them")
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	if *serveFlag {
+		serve(*addr)
+		return
+	}
+	p := Person{Name: payload, Bio: "<em>a gopher</em>"}
+	os.Stdout.WriteString("Name: " + p.Name + "\n\n--- text/template\n")
+	page(os.Stdout, parse(false), p)
+	os.Stdout.WriteString("\n--- html/template\n")
+	page(os.Stdout, parse(true), p)
+}
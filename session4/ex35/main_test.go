@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, html bool) string {
+	t.Helper()
+	var b strings.Builder
+	page(&b, parse(html), Person{Name: payload, Bio: "<em>a gopher</em>"})
+	return b.String()
+}
+
+// text/template writes the name as it is: the script gets into the page
+func TestText(t *testing.T) {
+	if out := render(t, false); !strings.Contains(out, `value=""><script>alert('pwned')</script>">`) {
+		t.Errorf("text/template: the payload is not in the attribute as it is:\n%s", out)
+	}
+}
+
+// html/template escapes the name for every place it goes to, and leaves the trusted bio alone
+func TestHTML(t *testing.T) {
+	out := render(t, true)
+	if strings.Contains(out, "<script>alert") {
+		t.Errorf("html/template: the script of the payload is in the page:\n%s", out)
+	}
+	for _, want := range []string{
+		`value="&#34;&gt;&lt;script&gt;alert(&#39;pwned&#39;)&lt;/script&gt;"`,
+		`href="/users?name=%22%3e%3cscript%3ealert%28%27pwned%27%29%3c%2fscript%3e"`,
+		`var name = "\"\u003e\u003cscript\u003ealert('pwned')\u003c/script\u003e";`,
+		"<p><em>a gopher</em></p>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("html/template: no %s in\n%s", want, out)
+		}
+	}
+}
+
+// /html escapes the name from the query, /text writes the payload as it is
+func TestServe(t *testing.T) {
+	w := httptest.NewRecorder()
+	newMux().ServeHTTP(w, httptest.NewRequest("GET", "/html?name=%3Cb%3EAda%3C%2Fb%3E", nil))
+	if body := w.Body.String(); w.Code != http.StatusOK || !strings.Contains(body, "hello &lt;b&gt;Ada&lt;/b&gt;!") {
+		t.Errorf("GET /html?name=<b>Ada</b>: %d %q, want the name escaped", w.Code, body)
+	}
+	w = httptest.NewRecorder()
+	newMux().ServeHTTP(w, httptest.NewRequest("GET", "/text", nil))
+	if body := w.Body.String(); !strings.Contains(body, "hello \"><script>alert('pwned')</script>!") {
+		t.Errorf("GET /text: %q, want the payload as it is", body)
+	}
+}
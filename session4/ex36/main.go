@@ -0,0 +1,165 @@
+// a site of several pages on one layout: {{block}}, {{define}} and {{template}}
+//
+// templates/layout.html is the frame of every page. It includes the
+// partials, the header and footer in templates/partials, with
+// {{template}}, and leaves two holes with {{block}}: "title" and
+// "content", each with a default. A page in templates/pages fills them
+// with {{define}}; about.html leaves the title alone and gets the
+// default.
+//
+// All pages define "content", and a name can only mean one thing in a
+// set of templates: parsed together, the last page would win. So the
+// layout and partials are parsed once with ParseGlob, and every page is
+// parsed on a Clone of them, a set of its own.
+//
+// try: open http://localhost:3000/
+package main
+
+import (
+	"flag"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+type book struct {
+	ID     int
+	Title  string
+	Author string
+	Year   int
+}
+
+var shelf = []book{
+	{3, "Learning Go", "Jon Bodner", 2024},
+	{2, "Concurrency in Go", "Katherine Cox-Buday", 2017},
+	{1, "The Go Programming Language", "Alan A. A. Donovan and Brian W. Kernighan", 2015},
+}
+
+// pageData is what every page is executed with
+type pageData struct {
+	Page  string // the page's name, for the navigation
+	Year  int    // for the footer
+	Books []book
+	Book  *book
+}
+
+// loadPages parses the layout and partials in dir once, and then each
+// page of dir/pages on a clone of them. The result maps page names
+// ("home" for home.html) to their sets.
+func loadPages(dir string) (map[string]*template.Template, error) {
+	base, err := template.ParseGlob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := base.ParseGlob(filepath.Join(dir, "partials", "*.html")); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "pages", "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	pages := map[string]*template.Template{}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".html")
+		// HINT: base.Clone() is a copy that the page can add its definitions to
+		// HINT: a set that was executed cannot be cloned any more: clone before the first page is served
+		// SOLUTION-START pages[name] = base
+		t, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := t.ParseFiles(file); err != nil {
+			return nil, err
+		}
+		pages[name] = t
+		// SOLUTION-END
+	}
+	return pages, nil
+}
+
+type site struct {
+	pages map[string]*template.Template
+}
+
+// render executes the layout of the page called name
+func (s *site) render(w http.ResponseWriter, status int, name string, data pageData) {
+	t, ok := s.pages[name]
+	if !ok {
+		http.Error(w, "no page "+name, http.StatusInternalServerError)
+		return
+	}
+	data.Page = name
+	data.Year = time.Now().Year()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := t.ExecuteTemplate(w, "layout", data); err != nil {
+		log.Error("executing template", "name", name, "err", err) // too late for another status
+	}
+}
+
+func (s *site) home(w http.ResponseWriter, req *http.Request) {
+	s.render(w, http.StatusOK, "home", pageData{Books: shelf})
+}
+
+func (s *site) books(w http.ResponseWriter, req *http.Request) {
+	s.render(w, http.StatusOK, "books", pageData{Books: shelf})
+}
+
+func (s *site) book(w http.ResponseWriter, req *http.Request) {
+	id, _ := strconv.Atoi(req.PathValue("id"))
+	for i := range shelf {
+		if shelf[i].ID == id {
+			s.render(w, http.StatusOK, "book", pageData{Book: &shelf[i]})
+			return
+		}
+	}
+	s.notFound(w, req)
+}
+
+func (s *site) about(w http.ResponseWriter, req *http.Request) {
+	s.render(w, http.StatusOK, "about", pageData{})
+}
+
+func (s *site) notFound(w http.ResponseWriter, req *http.Request) {
+	s.render(w, http.StatusNotFound, "notfound", pageData{})
+}
+
+func newServer(s *site) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.home)
+	mux.HandleFunc("GET /books", s.books)
+	mux.HandleFunc("GET /books/{id}", s.book)
+	mux.HandleFunc("GET /about", s.about)
+	mux.HandleFunc("/", s.notFound)
+	return mux
+}
+
+func main() {
+	dir := flag.String("templates", "templates", "directory of the layout, partials and pages")
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	pages, err := loadPages(*dir)
+	if err != nil {
+		log.Fatal("loading pages", "err", err)
+	}
+	names := make([]string, 0, len(pages))
+	for name := range pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	log.Info("listening", "url", "http://"+*addr+"/", "pages", strings.Join(names, ", "))
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(&site{pages}))}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
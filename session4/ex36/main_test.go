@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, path string) (int, string) {
+	t.Helper()
+	pages, err := loadPages("templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	newServer(&site{pages}).ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w.Code, w.Body.String()
+}
+
+// every page has its own set, with the layout, the partials and the page
+func TestLoadPages(t *testing.T) {
+	pages, err := loadPages("templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"home", "books", "book", "about", "notfound"} {
+		p := pages[name]
+		if p == nil {
+			t.Errorf("no page %s", name)
+			continue
+		}
+		for _, def := range []string{"layout", "header", "footer", "book", "content"} {
+			if p.Lookup(def) == nil {
+				t.Errorf("the set of page %s has no %q", name, def)
+			}
+		}
+	}
+	if pages["home"] == pages["about"] {
+		t.Error("home and about share a set: the content of one overwrites the other")
+	}
+}
+
+// each page shows its own content in the layout, with header and footer
+func TestPages(t *testing.T) {
+	for _, tc := range []struct {
+		path, title, content string
+		status               int
+	}{
+		{"/", "<title>Gopher Books: home</title>", "<h2>Welcome</h2>", http.StatusOK},
+		{"/books", "<title>Gopher Books: all books</title>", "<h2>All books</h2>", http.StatusOK},
+		{"/books/2", "<title>Gopher Books: Concurrency in Go</title>", "by Katherine Cox-Buday, 2017", http.StatusOK},
+		{"/about", "<title>Gopher Books</title>", "<h2>About</h2>", http.StatusOK},
+		{"/books/99", "<title>Gopher Books: not found</title>", "<h2>Not found</h2>", http.StatusNotFound},
+		{"/nowhere", "<title>Gopher Books: not found</title>", "<h2>Not found</h2>", http.StatusNotFound},
+	} {
+		status, page := get(t, tc.path)
+		if status != tc.status {
+			t.Errorf("GET %s: status %d, want %d", tc.path, status, tc.status)
+		}
+		for _, want := range []string{tc.title, tc.content, "<nav>", "<footer>"} {
+			if !strings.Contains(page, want) {
+				t.Errorf("GET %s: the page has no %q", tc.path, want)
+			}
+		}
+		if strings.Contains(page, "Nothing here yet") {
+			t.Errorf("GET %s: the page has the default content", tc.path)
+		}
+	}
+}
+
+// the books page uses the book partial for every book
+func TestPartial(t *testing.T) {
+	_, page := get(t, "/books")
+	if n := strings.Count(page, `<li><a href="/books/`); n != len(shelf) {
+		t.Errorf("/books lists %d books with the book partial, want %d", n, len(shelf))
+	}
+	if !strings.Contains(page, `<a href="/books" class="active">`) {
+		t.Error("/books does not mark Books active in the navigation")
+	}
+}
@@ -0,0 +1,119 @@
+// template functions of our own: Funcs(FuncMap) and pipelines
+//
+// A template can call the functions of a FuncMap by name, as it calls
+// the built-in len or printf. The map has to be added with Funcs before
+// Parse: the parser needs to know that upper is a function and not a
+// typo. In a pipeline the value before the | is passed as the last
+// argument, so a function meant for pipelines takes the piped value
+// last: {{.Placed | formatDate "2 Jan 2006"}} calls
+// formatDate("2 Jan 2006", .Placed).
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type item struct {
+	Name  string
+	Qty   int
+	Price int64 // in cents: money is not a float
+}
+
+// Total is a method: templates call those by name too, {{.Total}}
+func (i item) Total() int64 {
+	return int64(i.Qty) * i.Price
+}
+
+type order struct {
+	Customer string
+	Placed   time.Time
+	Items    []item
+}
+
+func (o order) Total() int64 {
+	var sum int64
+	for _, i := range o.Items {
+		sum += i.Total()
+	}
+	return sum
+}
+
+// formatDate formats t with layout; t comes last, for pipelines
+func formatDate(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+// currency formats cents as dollars with thousands separators:
+// 123456 is $1,234.56 and -5 is -$0.05
+func currency(cents int64) string {
+	// HINT: format the dollars without separators first, then insert a comma every three digits from the right
+	// SOLUTION-START return fmt.Sprint(cents)
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	dollars := fmt.Sprint(cents / 100)
+	var b strings.Builder
+	for i, d := range dollars {
+		if i > 0 && (len(dollars)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return fmt.Sprintf("%s$%s.%02d", sign, b.String(), cents%100)
+	// SOLUTION-END
+}
+
+// pluralize returns n with the singular or the plural: "1 item", "0 items"
+func pluralize(singular, plural string, n int) string {
+	// SOLUTION-START return fmt.Sprint(n, " ", plural)
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+	// SOLUTION-END
+}
+
+var funcs = template.FuncMap{
+	"formatDate": formatDate,
+	"upper":      strings.ToUpper, // any function will do, not only our own
+	"currency":   currency,
+	"pluralize":  pluralize,
+}
+
+const receipt = `ORDER FOR {{.Customer | upper}}
+placed {{.Placed | formatDate "Monday, 2 January 2006 at 15:04"}}
+{{len .Items | pluralize "item" "items"}}:
+{{range .Items}}  {{printf "%-16s" .Name}} {{.Qty | pluralize "piece" "pieces" | printf "%-10s"}} {{currency .Price | printf "%9s"}} {{.Total | currency | printf "%10s"}}
+{{end}}total {{.Total | currency}}
+`
+
+func main() {
+	// without the functions the template does not parse
+	if _, err := template.New("receipt").Parse(receipt); err != nil {
+		fmt.Println("without Funcs:", err)
+	}
+	t := template.Must(template.New("receipt").Funcs(funcs).Parse(receipt))
+
+	o := order{
+		Customer: "Gopher & Sons",
+		Placed:   time.Date(2024, time.March, 14, 9, 30, 0, 0, time.UTC),
+		Items: []item{
+			{"gopher plush", 1, 1999},
+			{"sticker", 12, 150},
+			{"mechanical keys", 3, 45000},
+		},
+	}
+	fmt.Println()
+	if err := t.Execute(os.Stdout, o); err != nil {
+		fmt.Println("error:", err)
+	}
+	fmt.Println()
+	for _, cents := range []int64{0, 5, 100000, -123456789} {
+		fmt.Printf("currency(%d) = %s\n", cents, currency(cents))
+	}
+}
@@ -0,0 +1,78 @@
+// range, with, $variables and index: the days of session2/ex7 as an HTML table
+//
+// The day of session2/ex7 keeps its fields unexported, and a template
+// only sees exported fields (see ex9); Day is the same with exported
+// ones, sorted with pkg/mysort as there. The template ranges over the
+// days with $i and $d, reaches the top-level data from inside the range
+// with $, looks up each day's sessions with index, and uses with/else
+// for the days without any.
+package main
+
+import (
+	"html/template"
+	"os"
+
+	"github.com/hannansatopay/training-golang/pkg/mysort"
+)
+
+// Day is the day of session2/ex7, exported for templates
+type Day struct {
+	Num   int // 0 for Monday
+	Short string
+	Long  string
+}
+
+// Weekend is a method without arguments: templates call it as {{$d.Weekend}}
+func (d Day) Weekend() bool {
+	return d.Num >= 5
+}
+
+type byNum []Day
+
+func (p byNum) Len() int           { return len(p) }
+func (p byNum) Less(i, j int) bool { return p[i].Num < p[j].Num }
+func (p byNum) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// week is the data of the template
+type week struct {
+	Title    string
+	Days     []Day
+	Sessions map[string][]string // the sessions of the course per day, by short name
+}
+
+var table = template.Must(template.New("week").Parse(`<h1>{{.Title}}</h1>
+{{with $first := index .Days 0}}<p>The week starts on {{$first.Long}}.</p>{{end}}
+<table>
+	<tr><th>#</th><th>Day</th><th>Sessions</th></tr>
+{{- range $i, $d := .Days}}
+	<tr{{if $d.Weekend}} class="weekend"{{end}}>
+		<td>{{$i}}</td>
+		<td><abbr title="{{$d.Long}}">{{$d.Short}}</abbr></td>
+		<td>{{with index $.Sessions $d.Short}}{{range $j, $s := .}}{{if $j}}; {{end}}{{$s}}{{end}}{{else}}free{{end}}</td>
+	</tr>
+{{- end}}
+</table>
+<p>{{len .Days}} days, {{len .Sessions}} of them with sessions; Tuesday's second one is {{index .Sessions "TUE" 1}}.</p>
+`))
+
+func main() {
+	days := []Day{
+		{1, "TUE", "Tuesday"}, {3, "THU", "Thursday"}, {2, "WED", "Wednesday"}, {6, "SUN", "Sunday"},
+		{0, "MON", "Monday"}, {4, "FRI", "Friday"}, {5, "SAT", "Saturday"},
+	}
+	mysort.Sort(byNum(days))
+	w := week{
+		Title: "The course, week by week",
+		Days:  days,
+		Sessions: map[string][]string{
+			"MON": {"Structs and Methods", "Interfaces and Reflection"},
+			"TUE": {"Goroutines and Channels", "Networking, Templating and Web-Applications"},
+			"WED": {"Common Go Pitfalls and Patterns", "Performance Advices"},
+			"THU": {"Encoding and I-O", "TCP Servers and Protocols"},
+			"FRI": {"gRPC Services", "Command-Line Tools"},
+		},
+	}
+	if err := table.Execute(os.Stdout, w); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,161 @@
+// templates from disk: parsed once for production, on every request with -dev
+//
+// Parsing a template is much slower than executing it (see ex19), so a
+// server parses its templates when it starts and keeps them, in a map
+// keyed by page name. A template with an error then stops the server
+// before it serves anything, which is what production wants. While the
+// pages are being written that is a nuisance: every change needs a
+// restart. With -dev the server parses the page's files again for every
+// request instead, so a reload shows the change, and a template with an
+// error shows the error in the browser and the server goes on.
+//
+// try: go run . -dev, open http://localhost:3000/ and edit
+// templates/pages/home.html; then the same without -dev
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// templateCache hands out the parsed templates of the pages in dir/pages,
+// each on top of the shared templates in dir
+type templateCache struct {
+	dir   string
+	dev   bool
+	pages map[string]*template.Template // by page name; only used without dev
+}
+
+// newTemplateCache parses all pages at once, unless dev is set
+func newTemplateCache(dir string, dev bool) (*templateCache, error) {
+	c := &templateCache{dir: dir, dev: dev, pages: map[string]*template.Template{}}
+	if dev {
+		return c, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "pages", "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".html")
+		if c.pages[name], err = c.parse(name); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+var errNoPage = errors.New("no such page")
+
+// pageName is what a page may be called: the name comes from the URL,
+// and "../main" must not lead out of the directory
+var pageName = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// parse reads the shared templates and the page called name from disk
+func (c *templateCache) parse(name string) (*template.Template, error) {
+	file := filepath.Join(c.dir, "pages", name+".html")
+	if _, err := os.Stat(file); !pageName.MatchString(name) || os.IsNotExist(err) {
+		return nil, errNoPage
+	}
+	start := time.Now()
+	t, err := template.ParseGlob(filepath.Join(c.dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.ParseFiles(file); err != nil {
+		return nil, err
+	}
+	log.Info("parsed", "name", name, "took", time.Since(start).Round(time.Microsecond))
+	return t, nil
+}
+
+// get returns the template of the page called name: parsed now with dev,
+// else from the pages parsed at the start
+func (c *templateCache) get(name string) (*template.Template, error) {
+	// HINT: without dev, an unknown name is an error too; a nil template would panic in Execute
+	// SOLUTION-START return c.parse(name)
+	if c.dev {
+		return c.parse(name)
+	}
+	t, ok := c.pages[name]
+	if !ok {
+		return nil, errNoPage
+	}
+	return t, nil
+	// SOLUTION-END
+}
+
+type pageData struct {
+	Mode string
+	Now  time.Time
+}
+
+// render executes the page called name into a buffer first: an error
+// halfway through then still gets a clean error page
+func (c *templateCache) render(w http.ResponseWriter, req *http.Request, name string) {
+	t, err := c.get(name)
+	if errors.Is(err, errNoPage) {
+		http.NotFound(w, req)
+		return
+	}
+	var page bytes.Buffer
+	if err == nil {
+		mode := "once, at the start"
+		if c.dev {
+			mode = "for every request (-dev)"
+		}
+		err = t.ExecuteTemplate(&page, "base", pageData{mode, time.Now()})
+	}
+	if err != nil {
+		log.Error("executing template", "name", name, "err", err)
+		msg := "internal server error"
+		if c.dev {
+			msg = err.Error() // the developer is the one looking
+		}
+		http.Error(w, msg, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page.WriteTo(w)
+}
+
+func newServer(c *templateCache) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, req *http.Request) {
+		c.render(w, req, "home")
+	})
+	mux.HandleFunc("GET /{page}", func(w http.ResponseWriter, req *http.Request) {
+		c.render(w, req, req.PathValue("page"))
+	})
+	return mux
+}
+
+func main() {
+	dir := flag.String("templates", "templates", "directory of the templates")
+	dev := flag.Bool("dev", false, "parse the templates for every request")
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	c, err := newTemplateCache(*dir, *dev)
+	if err != nil {
+		log.Fatal("loading templates", "err", err)
+	}
+	log.Info("listening", "url", "http://"+*addr+"/", "dev", *dev)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(c))}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+// copyTemplates copies the templates to a directory of the test's own,
+// where they can be changed
+func copyTemplates(t *testing.T) string {
+	dir := t.TempDir()
+	for _, name := range []string{"base.html", "pages/home.html", "pages/clock.html"} {
+		data, err := os.ReadFile(filepath.Join("templates", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func edit(t *testing.T, dir, name, old, new string) {
+	file := filepath.Join(dir, name)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(strings.Replace(string(data), old, new, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(h http.Handler, path string) (int, string) {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w.Code, w.Body.String()
+}
+
+// without -dev, the pages are parsed once: changes to the files do not show
+func TestProduction(t *testing.T) {
+	dir := copyTemplates(t)
+	c, err := newTemplateCache(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newServer(c)
+	edit(t, dir, "pages/home.html", "Hello, gopher", "Hello, changed")
+	if code, page := get(h, "/"); code != http.StatusOK || !strings.Contains(page, "Hello, gopher") {
+		t.Errorf("GET / after a change to home.html: %d, want 200 and the page as it was parsed:\n%s", code, page)
+	}
+	if code, _ := get(h, "/clock"); code != http.StatusOK {
+		t.Errorf("GET /clock: %d, want 200", code)
+	}
+	if code, _ := get(h, "/nope"); code != http.StatusNotFound {
+		t.Errorf("GET /nope: %d, want 404", code)
+	}
+}
+
+// without -dev, a broken template stops the server from starting
+func TestProductionBroken(t *testing.T) {
+	dir := copyTemplates(t)
+	edit(t, dir, "pages/clock.html", "{{end}}", "")
+	if _, err := newTemplateCache(dir, false); err == nil {
+		t.Error("newTemplateCache with a broken clock.html: no error")
+	}
+}
+
+// with -dev, every request parses again: changes show, errors are pages
+func TestDev(t *testing.T) {
+	dir := copyTemplates(t)
+	c, err := newTemplateCache(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newServer(c)
+	if _, page := get(h, "/"); !strings.Contains(page, "Hello, gopher") {
+		t.Fatalf("GET /: %s", page)
+	}
+	edit(t, dir, "pages/home.html", "Hello, gopher", "Hello, changed")
+	if _, page := get(h, "/"); !strings.Contains(page, "Hello, changed") {
+		t.Errorf("GET / after a change to home.html does not show it:\n%s", page)
+	}
+	edit(t, dir, "pages/home.html", "{{end}}", "")
+	if code, page := get(h, "/"); code != http.StatusInternalServerError || !strings.Contains(page, "home.html") {
+		t.Errorf("GET / with a broken home.html: %d %q, want 500 and the error", code, page)
+	}
+	if code, _ := get(h, "/clock"); code != http.StatusOK {
+		t.Errorf("GET /clock while home.html is broken: %d, want 200", code)
+	}
+	for _, path := range []string{"/nope", "/..%2Fbase"} {
+		if code, _ := get(h, path); code != http.StatusNotFound {
+			t.Errorf("GET %s: %d, want 404", path, code)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// CORS: a JSON API on one port that a page from another port may call
+//
+// A browser lets a page read the answer of a request to another origin
+// (scheme, host and port) only if the answer says so, in
+// Access-Control-Allow-Origin. For requests a plain HTML form could not
+// send, a POST of JSON say, it first asks with a preflight: an OPTIONS
+// request naming the method and headers to come, which the server
+// allows with Access-Control-Allow-Methods and -Headers. The cors
+// middleware answers both from a config. CORS protects nothing on the
+// server: it tells the browser what the pages of other sites may read.
+//
+// The program serves the API on -api and a page on -page, a different
+// origin. The page calls /api/time (a simple GET), /api/echo (JSON, with
+// a preflight) and /nocors/time, which has no CORS headers and fails.
+//
+// try: open http://localhost:3001/ and the browser console, or
+//
+//	curl -i -X OPTIONS -H 'Origin: http://localhost:3001' -H 'Access-Control-Request-Method: POST' -H 'Access-Control-Request-Headers: content-type' localhost:3000/api/echo
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// Middleware wraps a handler in another one, as in session4/ex25
+type Middleware func(http.Handler) http.Handler
+
+// corsConfig says who may call what
+type corsConfig struct {
+	Origins []string      // allowed origins, like http://localhost:3001; "*" allows all
+	Methods []string      // allowed methods besides GET, HEAD and POST, which are always allowed
+	Headers []string      // allowed request headers, in any case
+	MaxAge  time.Duration // how long a browser may remember a preflight
+}
+
+func (c corsConfig) allowOrigin(origin string) bool {
+	return slices.Contains(c.Origins, "*") || slices.Contains(c.Origins, origin)
+}
+
+func (c corsConfig) allowMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodPost ||
+		slices.Contains(c.Methods, method)
+}
+
+// allowHeaders reports whether all headers of a comma-separated list
+// (from Access-Control-Request-Headers) are allowed
+func (c corsConfig) allowHeaders(list string) bool {
+	for _, h := range strings.Split(list, ",") {
+		h = strings.TrimSpace(h)
+		if h != "" && !slices.ContainsFunc(c.Headers, func(a string) bool { return strings.EqualFold(a, h) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// maxAge is MaxAge as Access-Control-Max-Age wants it, in whole seconds
+func (c corsConfig) maxAge() string {
+	return strconv.Itoa(int(c.MaxAge.Seconds()))
+}
+
+// cors answers preflights and marks the answers that the pages of the
+// allowed origins may read
+func cors(c corsConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r) // not from a page of another origin
+				return
+			}
+			// the answer depends on the Origin: caches must keep one per origin
+			w.Header().Add("Vary", "Origin")
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				// a preflight: answered here, 204 if all is allowed and 403 if not
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				// HINT: Access-Control-Allow-Origin repeats the origin of the request: it holds only one
+				// SOLUTION-START w.WriteHeader(http.StatusNoContent)
+				method := r.Header.Get("Access-Control-Request-Method")
+				headers := r.Header.Get("Access-Control-Request-Headers")
+				if !c.allowOrigin(origin) || !c.allowMethod(method) || !c.allowHeaders(headers) {
+					http.Error(w, "CORS request not allowed", http.StatusForbidden)
+					return
+				}
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", method)
+				if headers != "" {
+					w.Header().Set("Access-Control-Allow-Headers", headers)
+				}
+				if c.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", c.maxAge())
+				}
+				w.WriteHeader(http.StatusNoContent)
+				// SOLUTION-END
+				return
+			}
+			// SOLUTION-START
+			if c.allowOrigin(origin) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			// SOLUTION-END
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func timeHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"time": time.Now().Format(time.RFC3339)})
+}
+
+func echoHandler(w http.ResponseWriter, r *http.Request) {
+	var v any
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&v); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"echo": v})
+}
+
+// newAPI serves the API with CORS for c, and /nocors/time without
+func newAPI(c corsConfig) http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc("GET /api/time", timeHandler)
+	api.HandleFunc("POST /api/echo", echoHandler)
+	mux := http.NewServeMux()
+	mux.Handle("/api/", cors(c)(api))
+	mux.HandleFunc("GET /nocors/time", timeHandler)
+	return mux
+}
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+	<head><title>CORS</title></head>
+	<body>
+		<h1>A page on {{.Page}} calling {{.API}}</h1>
+		<button id="time">GET /api/time</button>
+		<button id="echo">POST /api/echo (preflight)</button>
+		<button id="nocors">GET /nocors/time</button>
+		<pre id="out"></pre>
+		<script>
+		const api = {{.API}};
+		const out = document.getElementById("out");
+		async function call(path, init) {
+			try {
+				const resp = await fetch(api + path, init);
+				out.textContent += path + ": " + resp.status + " " + await resp.text();
+			} catch (e) {
+				out.textContent += path + ": " + e + " (see the console)\n";
+			}
+		}
+		document.getElementById("time").onclick = () => call("/api/time");
+		document.getElementById("echo").onclick = () => call("/api/echo", {
+			method: "POST",
+			headers: {"Content-Type": "application/json"},
+			body: JSON.stringify({hello: "gopher"}),
+		});
+		document.getElementById("nocors").onclick = () => call("/nocors/time");
+		</script>
+	</body>
+</html>
+`))
+
+func main() {
+	apiAddr := flag.String("api", "localhost:3000", "address of the API")
+	pageAddr := flag.String("page", "localhost:3001", "address of the page that calls it")
+	flag.Parse()
+	apiURL, pageURL := "http://"+*apiAddr, "http://"+*pageAddr
+	c := corsConfig{
+		Origins: []string{pageURL},
+		Methods: []string{http.MethodPut, http.MethodDelete},
+		Headers: []string{"Content-Type"},
+		MaxAge:  10 * time.Minute,
+	}
+	pageHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := page.Execute(w, map[string]string{"Page": pageURL, "API": apiURL}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
+	log.Info("listening", "api", apiURL, "origins", c.Origins, "page", pageURL+"/")
+	var wg sync.WaitGroup
+	for _, srv := range []*http.Server{{Addr: *apiAddr, Handler: metrics.Instrument(metrics.NewRegistry(), newAPI(c))}, {Addr: *pageAddr, Handler: metrics.Instrument(metrics.NewRegistry(), pageHandler)}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := graceful.ListenAndServe(srv, 5*time.Second); err != nil {
+				log.Fatal("ListenAndServe", "err", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
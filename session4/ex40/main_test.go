@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const pageOrigin = "http://localhost:3001"
+
+var config = corsConfig{
+	Origins: []string{pageOrigin},
+	Methods: []string{http.MethodPut},
+	Headers: []string{"Content-Type", "X-Request-Id"},
+	MaxAge:  time.Minute,
+}
+
+func do(c corsConfig, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(`{"hello":"gopher"}`))
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	newAPI(c).ServeHTTP(w, r)
+	return w
+}
+
+// an allowed preflight gets 204 with the origin, method, headers and max age
+func TestPreflight(t *testing.T) {
+	w := do(config, http.MethodOptions, "/api/echo", map[string]string{
+		"Origin":                         pageOrigin,
+		"Access-Control-Request-Method":  "PUT",
+		"Access-Control-Request-Headers": "content-type, x-request-id",
+	})
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("preflight: status %d, want %d", w.Code, http.StatusNoContent)
+	}
+	for k, want := range map[string]string{
+		"Access-Control-Allow-Origin":  pageOrigin,
+		"Access-Control-Allow-Methods": "PUT",
+		"Access-Control-Max-Age":       "60",
+	} {
+		if got := w.Header().Get(k); got != want {
+			t.Errorf("preflight: %s is %q, want %q", k, got, want)
+		}
+	}
+	if got := strings.ToLower(w.Header().Get("Access-Control-Allow-Headers")); !strings.Contains(got, "content-type") || !strings.Contains(got, "x-request-id") {
+		t.Errorf("preflight: Access-Control-Allow-Headers is %q, want content-type and x-request-id", got)
+	}
+	if !slices.Contains(w.Header().Values("Vary"), "Origin") {
+		t.Errorf("preflight: Vary is %q, want Origin among them", w.Header().Values("Vary"))
+	}
+}
+
+// a preflight from another origin, or for another method or header, gets 403 without CORS headers
+func TestPreflightRefused(t *testing.T) {
+	for name, header := range map[string]map[string]string{
+		"origin": {"Origin": "http://evil.example", "Access-Control-Request-Method": "POST"},
+		"method": {"Origin": pageOrigin, "Access-Control-Request-Method": "DELETE"},
+		"header": {"Origin": pageOrigin, "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "content-type, authorization"},
+	} {
+		w := do(config, http.MethodOptions, "/api/echo", header)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("preflight with another %s: status %d, want %d", name, w.Code, http.StatusForbidden)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("preflight with another %s: Access-Control-Allow-Origin is %q, want none", name, got)
+		}
+	}
+}
+
+// the answers to an allowed origin say so; those to others and without Origin do not
+func TestActualRequest(t *testing.T) {
+	for _, tc := range []struct {
+		origin, want string
+	}{{pageOrigin, pageOrigin}, {"http://evil.example", ""}, {"", ""}} {
+		w := do(config, http.MethodGet, "/api/time", map[string]string{"Origin": tc.origin})
+		if w.Code != http.StatusOK {
+			t.Errorf("GET /api/time from %q: status %d, want %d", tc.origin, w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tc.want {
+			t.Errorf("GET /api/time from %q: Access-Control-Allow-Origin is %q, want %q", tc.origin, got, tc.want)
+		}
+	}
+	w := do(config, http.MethodPost, "/api/echo", map[string]string{"Origin": pageOrigin, "Content-Type": "application/json"})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"hello":"gopher"`) {
+		t.Errorf("POST /api/echo: status %d, body %q; want the JSON echoed", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != pageOrigin {
+		t.Errorf("POST /api/echo: Access-Control-Allow-Origin is %q, want %q", got, pageOrigin)
+	}
+}
+
+// with "*" any origin is allowed, and the answer still names the one asking
+func TestWildcard(t *testing.T) {
+	c := config
+	c.Origins = []string{"*"}
+	w := do(c, http.MethodOptions, "/api/echo", map[string]string{
+		"Origin":                        "http://anywhere.example",
+		"Access-Control-Request-Method": "POST",
+	})
+	if w.Code != http.StatusNoContent {
+		t.Errorf("preflight with *: status %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "http://anywhere.example" {
+		t.Errorf("preflight with *: Access-Control-Allow-Origin is %q, want the origin", got)
+	}
+}
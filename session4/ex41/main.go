@@ -0,0 +1,201 @@
+// request contexts: values and deadlines from middleware down to a slow database
+//
+// Every request carries a context, req.Context(). The server cancels it
+// when the client goes away, middleware can add values to it, like a
+// request ID, and a deadline, like a timeout for the whole request. All
+// that reaches the code below only if the handler passes the context on
+// to what it calls: here a "database" whose queries take a while. A
+// query watches ctx.Done() and stops as soon as nobody waits for its
+// answer any more; its log line has the ID of the request it serves.
+//
+// try: go run . and
+//
+//	curl -i localhost:3000/users/bond
+//	curl -i localhost:3000/report                             # longer than -timeout: 504
+//	curl -i --max-time 0.5 'localhost:3000/users/bond?slow'   # gone before the answer
+//	curl localhost:3000/stats
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// Middleware wraps a handler in another one, as in session4/ex25
+type Middleware func(http.Handler) http.Handler
+
+func chain(h http.Handler, m ...Middleware) http.Handler {
+	for i := len(m) - 1; i >= 0; i-- {
+		h = m[i](h)
+	}
+	return h
+}
+
+type ctxKey int
+
+const requestIDKey ctxKey = 0
+
+// requestIDFrom returns the request ID stored in ctx, or ""
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// requestID gives every request an ID in its context, as in session4/ex25
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// timeout gives the context of every request a deadline d from now
+func timeout(d time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// HINT: context.WithTimeout returns a cancel function: call it when next returns, or the timer lives on
+			// SOLUTION-START next.ServeHTTP(w, r)
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+			// SOLUTION-END
+		})
+	}
+}
+
+// db is a database that takes delay to answer any query
+type db struct {
+	delay     time.Duration
+	done      atomic.Int64 // queries answered
+	cancelled atomic.Int64 // queries given up because ctx was done
+}
+
+// query answers q after the delay, times slow, or returns ctx.Err() as
+// soon as ctx is done
+func (d *db) query(ctx context.Context, q string, slow int) (string, error) {
+	l := log.With("id", requestIDFrom(ctx))
+	l.Debug("query", "q", q)
+	start := time.Now()
+	// HINT: select on a timer and ctx.Done(); stop the timer when ctx wins
+	// SOLUTION-START time.Sleep(d.delay * time.Duration(slow))
+	t := time.NewTimer(d.delay * time.Duration(slow))
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		d.cancelled.Add(1)
+		l.Warn("query given up", "q", q, "after", time.Since(start).Round(time.Millisecond), "err", ctx.Err())
+		return "", ctx.Err()
+	}
+	// SOLUTION-END
+	d.done.Add(1)
+	l.Info("query", "q", q, "took", time.Since(start).Round(time.Millisecond))
+	return "result of " + q, nil
+}
+
+type server struct {
+	db *db
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// fail answers the error of a query: 504 if the request ran out of time;
+// nothing if the client is gone, as nobody would read it
+func fail(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		http.Error(w, "the request took too long", http.StatusGatewayTimeout)
+	case errors.Is(err, context.Canceled):
+		log.With("id", requestIDFrom(r.Context())).Info("client gone", "path", r.URL.Path)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// user runs two queries in a row; ?slow makes each ten times slower
+func (s *server) user(w http.ResponseWriter, r *http.Request) {
+	slow := 1
+	if r.URL.Query().Has("slow") {
+		slow = 10
+	}
+	// HINT: the context to pass on is r.Context(), with everything the middleware added
+	// SOLUTION-START ctx := context.Background()
+	ctx := r.Context()
+	// SOLUTION-END
+	name := r.PathValue("name")
+	user, err := s.db.query(ctx, "user "+name, slow)
+	if err != nil {
+		fail(w, r, err)
+		return
+	}
+	orders, err := s.db.query(ctx, "orders of "+name, slow)
+	if err != nil {
+		fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"request": requestIDFrom(ctx), "user": user, "orders": orders,
+	})
+}
+
+// report runs a query much slower than the others
+func (s *server) report(w http.ResponseWriter, r *http.Request) {
+	// SOLUTION-START ctx := context.Background()
+	ctx := r.Context()
+	// SOLUTION-END
+	report, err := s.db.query(ctx, "report", 50)
+	if err != nil {
+		fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"request": requestIDFrom(ctx), "report": report})
+}
+
+func (s *server) stats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]int64{"done": s.db.done.Load(), "cancelled": s.db.cancelled.Load()})
+}
+
+func newServer(d *db, limit time.Duration) http.Handler {
+	s := &server{d}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{name}", s.user)
+	mux.HandleFunc("GET /report", s.report)
+	mux.HandleFunc("GET /stats", s.stats)
+	return chain(mux, requestID, timeout(limit))
+}
+
+func main() {
+	addr := config.Addr("localhost:3000")
+	delay := flag.Duration("delay", 100*time.Millisecond, "time the database takes for a query")
+	limit := flag.Duration("timeout", 2*time.Second, "time a request may take")
+	flag.Parse()
+	log.Info("listening", "addr", *addr, "delay", *delay, "timeout", *limit)
+	srv := &http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(&db{delay: *delay}, *limit))}
+	if err := graceful.ListenAndServe(srv, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
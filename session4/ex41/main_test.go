@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// a query gives up with ctx.Err() as soon as its context is cancelled
+func TestQueryCancelled(t *testing.T) {
+	d := &db{delay: time.Second}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := d.query(ctx, "slow", 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("query with a cancelled context: error %v, want context.Canceled", err)
+	}
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("query with a context cancelled after 20ms took %v", took)
+	}
+	if d.cancelled.Load() != 1 || d.done.Load() != 0 {
+		t.Errorf("after a cancelled query: done %d, cancelled %d; want 0 and 1", d.done.Load(), d.cancelled.Load())
+	}
+}
+
+// the timeout middleware gives the handler's context a deadline
+func TestTimeoutMiddleware(t *testing.T) {
+	var deadline time.Time
+	var ok bool
+	h := timeout(time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok = r.Context().Deadline()
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !ok {
+		t.Fatal("the handler's context has no deadline")
+	}
+	if left := time.Until(deadline); left < 50*time.Second || left > time.Minute {
+		t.Errorf("the deadline is %v away, want about a minute", left)
+	}
+}
+
+// a request that runs out of time gets 504 Gateway Timeout, in time
+func TestDeadline(t *testing.T) {
+	d := &db{delay: 20 * time.Millisecond} // the report takes 50 times that, a second
+	w := httptest.NewRecorder()
+	start := time.Now()
+	newServer(d, 100*time.Millisecond).ServeHTTP(w, httptest.NewRequest("GET", "/report", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("GET /report with a timeout of 100ms: status %d, want %d", w.Code, http.StatusGatewayTimeout)
+	}
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("GET /report with a timeout of 100ms took %v", took)
+	}
+}
+
+// the request ID of the middleware reaches the handler through the context
+func TestRequestValue(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/users/bond", nil)
+	r.Header.Set("X-Request-ID", "abc123")
+	newServer(&db{delay: time.Millisecond}, time.Second).ServeHTTP(w, r)
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GET /users/bond: status %d, body %q", w.Code, w.Body.String())
+	}
+	if body["request"] != "abc123" {
+		t.Errorf("GET /users/bond with X-Request-ID abc123: request is %q", body["request"])
+	}
+}
+
+// when the client disconnects, the query of its request is given up
+func TestClientGone(t *testing.T) {
+	d := &db{delay: 100 * time.Millisecond} // a second with ?slow
+	srv := httptest.NewServer(newServer(d, time.Minute))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	r, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/users/bond?slow", nil)
+	if resp, err := http.DefaultClient.Do(r); err == nil {
+		resp.Body.Close()
+		t.Fatal("the client got an answer before its timeout")
+	}
+	for end := time.Now().Add(500 * time.Millisecond); d.cancelled.Load() == 0 && time.Now().Before(end); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if d.cancelled.Load() != 1 || d.done.Load() != 0 {
+		t.Errorf("after the client left: done %d, cancelled %d; want 0 and 1", d.done.Load(), d.cancelled.Load())
+	}
+}
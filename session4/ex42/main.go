@@ -0,0 +1,196 @@
+// health checks and metrics: /healthz for load balancers, /metrics for Prometheus
+//
+// A load balancer asks /healthz every few seconds and sends no traffic
+// to a server that answers anything but 200; here that is the case while
+// the database it needs is down. Prometheus reads /metrics every few
+// seconds: counters and histograms in a text format, kept by pkg/metrics.
+// A middleware counts every request by method, route and status, times
+// it in a histogram and counts the requests in flight.
+//
+// The route is the pattern that matched, "GET /users/{id}", not the path:
+// a label per user ID would make a series per user.
+//
+// try:
+//
+//	for i in $(seq 20); do curl -s localhost:3000/users/$i > /dev/null; done
+//	curl localhost:3000/metrics
+//	curl -i localhost:3000/healthz; curl -X POST localhost:3000/db/down; curl -i localhost:3000/healthz
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// httpMetrics are the metrics of the requests
+type httpMetrics struct {
+	requests *metrics.Counter   // by method, route and status
+	duration *metrics.Histogram // seconds, by method and route
+	inFlight *metrics.Gauge
+}
+
+func newHTTPMetrics(reg *metrics.Registry) *httpMetrics {
+	return &httpMetrics{
+		requests: reg.Counter("http_requests_total", "HTTP requests answered.", "method", "route", "status"),
+		duration: reg.Histogram("http_request_duration_seconds", "Time taken to answer HTTP requests.", metrics.DefBuckets, "method", "route"),
+		inFlight: reg.Gauge("http_requests_in_flight", "HTTP requests being answered."),
+	}
+}
+
+// statusRecorder remembers the status code of a response, as in session4/ex25
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(p)
+}
+
+// code is the status as a label value; a handler that writes nothing answers 200
+func (s *statusRecorder) code() string {
+	if s.status == 0 {
+		return "200"
+	}
+	return strconv.Itoa(s.status)
+}
+
+// instrument counts, times and tracks the requests mux answers, labelled
+// with the pattern that matched them, or "none"
+func instrument(m *httpMetrics, mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// HINT: mux.Handler(r) returns the pattern that matches r, without serving it; "" if none does
+		// HINT: the status is only known after ServeHTTP: record the response
+		// SOLUTION-START mux.ServeHTTP(w, r)
+		start := time.Now()
+		_, route := mux.Handler(r)
+		if route == "" {
+			route = "none"
+		}
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+		rec := &statusRecorder{ResponseWriter: w}
+		mux.ServeHTTP(rec, r)
+		m.requests.Inc(r.Method, route, rec.code())
+		m.duration.Observe(time.Since(start).Seconds(), r.Method, route)
+		// SOLUTION-END
+	})
+}
+
+// check tells whether something the server needs works; nil if it does
+type check func(ctx context.Context) error
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// healthz runs the checks, each given a second at most, and answers 200
+// if all pass, 503 Service Unavailable if not; the body has the result of
+// every check, "ok" or its error
+func healthz(checks map[string]check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results := map[string]string{}
+		status := http.StatusOK
+		// SOLUTION-START
+		for name, c := range checks {
+			ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+			err := c(ctx)
+			cancel()
+			results[name] = "ok"
+			if err != nil {
+				results[name] = err.Error()
+				status = http.StatusServiceUnavailable
+			}
+		}
+		// SOLUTION-END
+		writeJSON(w, status, map[string]any{"status": http.StatusText(status), "checks": results})
+	}
+}
+
+// db stands in for a database: it answers after a few milliseconds,
+// unless it is down
+type db struct {
+	down atomic.Bool
+}
+
+var errDown = errors.New("connection refused")
+
+func (d *db) ping(ctx context.Context) error {
+	if d.down.Load() {
+		return errDown
+	}
+	return nil
+}
+
+// query takes up to max, so that the histogram has something to show
+func (d *db) query(ctx context.Context, max time.Duration) error {
+	if d.down.Load() {
+		return errDown
+	}
+	select {
+	case <-time.After(rand.N(max)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newServer(d *db, reg *metrics.Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := d.query(r.Context(), 100*time.Millisecond); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"id": r.PathValue("id")})
+	})
+	mux.HandleFunc("POST /db/{state}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("state") {
+		case "down":
+			d.down.Store(true)
+		case "up":
+			d.down.Store(false)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mux.Handle("GET /healthz", healthz(map[string]check{"db": d.ping}))
+	mux.Handle("GET /metrics", reg)
+	return instrument(newHTTPMetrics(reg), mux)
+}
+
+func main() {
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	log.Info("listening", "url", "http://"+*addr+"/", "metrics", "/metrics")
+	srv := &http.Server{Addr: *addr, Handler: newServer(&db{}, metrics.NewRegistry())}
+	if err := graceful.ListenAndServe(srv, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+func newInstrumented() (*httpMetrics, http.Handler) {
+	m := newHTTPMetrics(metrics.NewRegistry())
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "0" {
+			http.Error(w, "no user 0", http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		if m.inFlight.Value() != 1 {
+			http.Error(w, "not in flight", http.StatusTeapot)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	return m, instrument(m, mux)
+}
+
+func serve(h http.Handler, method, path string) {
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, path, nil))
+}
+
+// requests are counted by method, route pattern and status
+func TestRequestsCounted(t *testing.T) {
+	m, h := newInstrumented()
+	serve(h, "GET", "/users/1")
+	serve(h, "GET", "/users/2")
+	serve(h, "GET", "/users/0")
+	serve(h, "POST", "/users")
+	for _, tc := range []struct {
+		labels []string
+		want   float64
+	}{
+		{[]string{"GET", "GET /users/{id}", "200"}, 2},
+		{[]string{"GET", "GET /users/{id}", "404"}, 1},
+		{[]string{"POST", "POST /users", "201"}, 1},
+		{[]string{"GET", "/users/1", "200"}, 0},
+	} {
+		if got := m.requests.Value(tc.labels...); got != tc.want {
+			t.Errorf("http_requests_total%q is %v, want %v", tc.labels, got, tc.want)
+		}
+	}
+}
+
+// requests that match no route are counted with the route none
+func TestUnmatched(t *testing.T) {
+	m, h := newInstrumented()
+	serve(h, "GET", "/nowhere")
+	serve(h, "DELETE", "/users/1")
+	if got := m.requests.Value("GET", "none", "404"); got != 1 {
+		t.Errorf(`http_requests_total{GET, none, 404} is %v, want 1`, got)
+	}
+	if got := m.requests.Value("DELETE", "none", "405"); got != 1 {
+		t.Errorf(`http_requests_total{DELETE, none, 405} is %v, want 1`, got)
+	}
+}
+
+// every request is timed in the histogram and in flight while it runs
+func TestDurationInFlight(t *testing.T) {
+	m, h := newInstrumented()
+	serve(h, "GET", "/users/1")
+	serve(h, "POST", "/users") // answers 418 if the gauge is not 1 meanwhile
+	if n := m.duration.Count("GET", "GET /users/{id}"); n != 1 {
+		t.Errorf("the duration histogram of GET /users/{id} counts %d requests, want 1", n)
+	}
+	if got := m.requests.Value("POST", "POST /users", "201"); got != 1 {
+		t.Error("http_requests_in_flight is not 1 during a request")
+	}
+	if got := m.inFlight.Value(); got != 0 {
+		t.Errorf("http_requests_in_flight is %v after the requests, want 0", got)
+	}
+}
+
+// healthz answers 200 if all checks pass and 503 with the error if one fails
+func TestHealthz(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	broken := func(ctx context.Context) error { return errors.New("disk full") }
+	for _, tc := range []struct {
+		checks map[string]check
+		status int
+		body   string
+	}{
+		{map[string]check{"db": ok, "cache": ok}, http.StatusOK, `"checks":{"cache":"ok","db":"ok"}`},
+		{map[string]check{"db": ok, "disk": broken}, http.StatusServiceUnavailable, `"checks":{"db":"ok","disk":"disk full"}`},
+	} {
+		w := httptest.NewRecorder()
+		healthz(tc.checks)(w, httptest.NewRequest("GET", "/healthz", nil))
+		if w.Code != tc.status || !strings.Contains(w.Body.String(), tc.body) {
+			t.Errorf("GET /healthz: %d %q, want %d with %s", w.Code, w.Body.String(), tc.status, tc.body)
+		}
+	}
+}
+
+// a check gets at most a second
+func TestHealthzTimeout(t *testing.T) {
+	var left time.Duration
+	slow := func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return errors.New("no deadline")
+		}
+		left = time.Until(deadline)
+		return nil
+	}
+	w := httptest.NewRecorder()
+	healthz(map[string]check{"slow": slow})(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != http.StatusOK || left <= 0 || left > time.Second {
+		t.Errorf("GET /healthz: %d %q; the check had %v left, want a deadline within a second", w.Code, w.Body.String(), left)
+	}
+}
+
+// the server serves its metrics at /metrics in the Prometheus text format
+func TestMetricsEndpoint(t *testing.T) {
+	h := newServer(&db{}, metrics.NewRegistry())
+	serve(h, "GET", "/healthz")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, want := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="GET /healthz",status="200"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="GET /healthz"} 1`,
+		"# TYPE http_requests_in_flight gauge",
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("GET /metrics has no %q", want)
+		}
+	}
+}
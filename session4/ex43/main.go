@@ -0,0 +1,207 @@
+// structured logging with log/slog: levels, attributes, text or JSON, a logger per request
+//
+// A slog.Logger writes records: a level, a message and attributes, key
+// and value pairs that a program reading the logs can filter on, where
+// log.Printf leaves it a sentence to take apart. The handler decides how
+// a record looks: slog.NewTextHandler writes key=value, slog.NewJSONHandler
+// one JSON object per line, and logger.NewHandler the format of pkg/logger
+// that the other servers of session4 use.
+//
+// The middleware gives every request a logger of its own, with the ID of
+// the request and its method and path already attached, in its context:
+// whatever the handlers log about a request can be found by its ID. The
+// level is a slog.LevelVar, changed while the server runs.
+//
+// try: go run . -format json and
+//
+//	curl localhost:3000/orders/7
+//	curl -X POST localhost:3000/level/debug; curl localhost:3000/orders/7
+//	curl localhost:3000/orders/seven; curl localhost:3000/orders/0
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+// newLogger returns a logger that writes to w in format, "text" or
+// "json", the records at level and above
+func newLogger(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	// HINT: slog.HandlerOptions{Level: level} filters the records; slog.New makes a logger of a handler
+	// SOLUTION-START
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	// SOLUTION-END
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// user is logged as a group of its ID and name: never its password
+type user struct {
+	ID       int
+	Name     string
+	Password string
+}
+
+// LogValue makes user a slog.LogValuer: slog logs what it returns instead
+func (u user) LogValue() slog.Value {
+	// SOLUTION-START return slog.StringValue(fmt.Sprintf("%+v", u))
+	return slog.GroupValue(slog.Int("id", u.ID), slog.String("name", u.Name))
+	// SOLUTION-END
+}
+
+// Middleware wraps a handler in another one, as in session4/ex25
+type Middleware func(http.Handler) http.Handler
+
+type ctxKey int
+
+const loggerKey ctxKey = 0
+
+// loggerFrom returns the logger of the request of ctx, or slog.Default()
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+func newRequestID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder remembers the status code of a response, as in session4/ex25
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(p)
+}
+
+// withLogger gives every request a logger from base with "id" and a
+// group "req" of its method and path, in its context, and logs the
+// request when it is answered: its status and how long it took, at level
+// Error for a status of 500 and above
+func withLogger(base *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// HINT: base.With adds attributes to every record; slog.Group("req", ...) nests some
+			// HINT: r.WithContext(context.WithValue(...)) hands the logger on
+			// SOLUTION-START next.ServeHTTP(w, r)
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			l := base.With("id", newRequestID(), slog.Group("req", "method", r.Method, "path", r.URL.Path))
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), loggerKey, l)))
+			if rec.status == 0 {
+				rec.status = http.StatusOK // nothing written at all
+			}
+			level := slog.LevelInfo
+			if rec.status >= 500 {
+				level = slog.LevelError
+			}
+			l.Log(r.Context(), level, "request", "status", rec.status, "took", time.Since(start))
+			// SOLUTION-END
+		})
+	}
+}
+
+var orders = map[int]string{7: "a martini, shaken", 8: "an Aston Martin"}
+
+// order shows an order; everything it logs carries the request's ID
+func order(w http.ResponseWriter, r *http.Request) {
+	l := loggerFrom(r.Context())
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		l.Warn("bad order ID", "order", r.PathValue("id"), "err", err)
+		http.Error(w, "bad order ID", http.StatusBadRequest)
+		return
+	}
+	u := user{ID: 1, Name: "bond", Password: "007"}
+	l.Debug("looking up order", "order", id, "user", u)
+	if id == 0 {
+		l.Error("the order database is down", "order", id)
+		http.Error(w, "try again later", http.StatusInternalServerError)
+		return
+	}
+	o, ok := orders[id]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	fmt.Fprintln(w, o)
+}
+
+// newServer serves the orders and POST /level/{level}, which changes level
+func newServer(l *slog.Logger, level *slog.LevelVar) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", order)
+	mux.HandleFunc("POST /level/{level}", func(w http.ResponseWriter, r *http.Request) {
+		var lv slog.Level
+		if err := lv.UnmarshalText([]byte(r.PathValue("level"))); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		loggerFrom(r.Context()).Warn("level changed", "from", level.Level(), "to", lv)
+		level.Set(lv)
+	})
+	return withLogger(l)(mux)
+}
+
+func main() {
+	format := flag.String("format", "text", `"text", "json" or "training" for pkg/logger, which takes its level from TRAINING_LOG`)
+	levelName := flag.String("level", "info", "debug, info, warn or error")
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	var level slog.LevelVar
+	if err := level.UnmarshalText([]byte(*levelName)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	var l *slog.Logger
+	if *format == "training" {
+		l = logger.Slog("web")
+	} else {
+		var err error
+		if l, err = newLogger(os.Stderr, *format, &level); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+	slog.SetDefault(l) // for code without a request, and the log package
+	l.Info("listening", "url", "http://"+*addr+"/", "level", level.Level())
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(l, &level))}, 5*time.Second); err != nil {
+		l.Error("ListenAndServe", "err", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// records decodes the JSON lines in buf
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var recs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func jsonLogger(t *testing.T, buf *bytes.Buffer, level slog.Leveler) *slog.Logger {
+	l, err := newLogger(buf, "json", level)
+	if err != nil || l == nil {
+		t.Fatalf(`newLogger(w, "json", level): %v`, err)
+	}
+	return l
+}
+
+// newLogger writes key=value text or JSON lines, and refuses other formats
+func TestFormats(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := newLogger(&buf, "text", slog.LevelInfo)
+	if err != nil || l == nil {
+		t.Fatalf(`newLogger(w, "text", info): %v`, err)
+	}
+	l.Info("hello", "who", "bond")
+	if got := buf.String(); !strings.Contains(got, "level=INFO msg=hello who=bond") {
+		t.Errorf("the text logger wrote %q, want level=INFO msg=hello who=bond", got)
+	}
+	buf.Reset()
+	jsonLogger(t, &buf, slog.LevelInfo).Info("hello", "who", "bond")
+	if recs := records(t, &buf); len(recs) != 1 || recs[0]["msg"] != "hello" || recs[0]["who"] != "bond" {
+		t.Errorf("the JSON logger wrote %v, want msg hello and who bond", recs)
+	}
+	if _, err := newLogger(&buf, "xml", slog.LevelInfo); err == nil {
+		t.Error(`newLogger(w, "xml", info) returned no error`)
+	}
+}
+
+// only records at the level and above are written, the level can change
+func TestLevels(t *testing.T) {
+	var buf bytes.Buffer
+	var level slog.LevelVar
+	level.Set(slog.LevelWarn)
+	l := jsonLogger(t, &buf, &level)
+	l.Info("quiet")
+	l.Warn("loud")
+	level.Set(slog.LevelDebug)
+	l.Debug("now heard")
+	var msgs []string
+	for _, rec := range records(t, &buf) {
+		msgs = append(msgs, rec["msg"].(string))
+	}
+	if got := strings.Join(msgs, ","); got != "loud,now heard" {
+		t.Errorf("logged %q at level warn, then debug; want loud,now heard", got)
+	}
+}
+
+// a user is logged with its ID and name, never its password
+func TestLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	jsonLogger(t, &buf, slog.LevelInfo).Info("login", "user", user{ID: 7, Name: "bond", Password: "007"})
+	if strings.Contains(buf.String(), "007") {
+		t.Errorf("the password is in the log: %s", buf.String())
+	}
+	recs := records(t, &buf)
+	u, _ := recs[0]["user"].(map[string]any)
+	if u["id"] != 7.0 || u["name"] != "bond" {
+		t.Errorf(`logged user as %v, want {"id":7,"name":"bond"}`, recs[0]["user"])
+	}
+}
+
+// every record about a request carries its ID, method and path
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	h := newServer(jsonLogger(t, &buf, slog.LevelDebug), new(slog.LevelVar))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/7", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/seven", nil))
+	recs := records(t, &buf)
+	if len(recs) != 4 {
+		t.Fatalf("two requests logged %d records, want 4: the handlers' and the middleware's", len(recs))
+	}
+	ids := map[any]bool{}
+	for _, rec := range recs {
+		req, _ := rec["req"].(map[string]any)
+		if rec["id"] == nil || req["method"] != "GET" || !strings.HasPrefix(req["path"].(string), "/orders/") {
+			t.Errorf("record %v has no id, or no req group with method and path", rec)
+		}
+		ids[rec["id"]] = true
+	}
+	if len(ids) != 2 || recs[0]["id"] != recs[1]["id"] {
+		t.Errorf("records %v: want one ID per request", recs)
+	}
+}
+
+// the middleware logs the status of each request, at level error for 5xx
+func TestRequestRecord(t *testing.T) {
+	var buf bytes.Buffer
+	h := newServer(jsonLogger(t, &buf, slog.LevelInfo), new(slog.LevelVar))
+	for _, path := range []string{"/orders/8", "/orders/0", "/orders/9"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	want := map[float64]string{http.StatusOK: "INFO", http.StatusInternalServerError: "ERROR", http.StatusNotFound: "INFO"}
+	n := 0
+	for _, rec := range records(t, &buf) {
+		if rec["msg"] != "request" {
+			continue
+		}
+		n++
+		status, _ := rec["status"].(float64)
+		if lv, ok := want[status]; !ok || rec["level"] != lv || rec["took"] == nil {
+			t.Errorf("request record %v: want a status, its level and took", rec)
+		}
+	}
+	if n != 3 {
+		t.Errorf("three requests made %d request records, want 3", n)
+	}
+}
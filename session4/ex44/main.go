@@ -0,0 +1,258 @@
+// error pages: a templated 404 and 500, or JSON errors for clients that ask for JSON
+//
+// Left alone, a ServeMux answers a path it has no pattern for with a
+// plain "404 page not found", a method it has no pattern for with "405
+// method not allowed", and a handler that fails leaves the client with
+// whatever it wrote. Here every error goes through one helper,
+// errorHandler: a page in the layout of the site for a browser, a JSON
+// object for a client that sent Accept: application/json.
+//
+// The handlers return their errors instead of answering them. An
+// httpError carries the status and the message for the client; any
+// other error is a 500 whose details are logged and not shown.
+//
+// try: open http://localhost:3000/ and
+//
+//	curl -i localhost:3000/books/99
+//	curl -i -H 'Accept: application/json' localhost:3000/books/99
+//	curl -i -X DELETE localhost:3000/books/1
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+type book struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Year   int    `json:"year"`
+}
+
+var shelf = []book{
+	{1, "The Go Programming Language", "Alan A. A. Donovan and Brian W. Kernighan", 2015},
+	{2, "Concurrency in Go", "Katherine Cox-Buday", 2017},
+	{3, "Learning Go", "Jon Bodner", 2024},
+}
+
+// httpError is an error to answer with status; its message is for the client
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string { return e.msg }
+
+func errorf(status int, format string, args ...any) error {
+	return &httpError{status, fmt.Sprintf(format, args...)}
+}
+
+// handlerFunc is a handler that returns its error instead of answering it
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+type server struct {
+	t *template.Template
+}
+
+// wantsJSON tells whether the client asked for JSON with its Accept header
+func wantsJSON(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mt, _, err := mime.ParseMediaType(part); err == nil && mt == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// render executes the template called name into a buffer first: if it
+// fails, nothing was sent yet and the client still gets an error page
+func (s *server) render(w http.ResponseWriter, status int, name string, data any) error {
+	var page bytes.Buffer
+	if err := s.t.ExecuteTemplate(&page, name, data); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	page.WriteTo(w)
+	return nil
+}
+
+// errorData is what the error page is executed with
+type errorData struct {
+	Status  int
+	Title   string // "Not Found" for 404
+	Message string
+}
+
+// errorHandler answers with status and msg: {"status": 404, "error": msg}
+// to a client that wants JSON, the error page to anyone else, and a plain
+// text error if even the error page fails
+func (s *server) errorHandler(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	// HINT: render writes nothing if the template fails; http.Error is the last resort
+	// SOLUTION-START http.Error(w, msg, status)
+	if wantsJSON(r) {
+		writeJSON(w, status, map[string]any{"status": status, "error": msg})
+		return
+	}
+	if err := s.render(w, status, "error", errorData{status, http.StatusText(status), msg}); err != nil {
+		log.Error("executing template", "name", "error", "err", err)
+		http.Error(w, msg, status)
+	}
+	// SOLUTION-END
+}
+
+// handle adapts h to an http.Handler: an httpError is answered with its
+// status and message, any other error is logged and answered with a 500
+// that tells nothing about it
+func (s *server) handle(h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := h(w, r)
+		if err == nil {
+			return
+		}
+		// HINT: errors.As finds an *httpError even if it was wrapped with %w
+		// SOLUTION-START
+		var he *httpError
+		if errors.As(err, &he) {
+			s.errorHandler(w, r, he.status, he.msg)
+			return
+		}
+		log.Error("handler failed", "method", r.Method, "path", r.URL.Path, "err", err)
+		s.errorHandler(w, r, http.StatusInternalServerError, "Something went wrong on our side. Please try again later.")
+		// SOLUTION-END
+	}
+}
+
+// notFound is the 404 of the site
+func (s *server) notFound(w http.ResponseWriter, r *http.Request) {
+	s.errorHandler(w, r, http.StatusNotFound, "There is no page "+r.URL.Path+".")
+}
+
+// headerRecorder keeps the status and header of a response and drops its body
+type headerRecorder struct {
+	header http.Header
+	status int
+}
+
+func (h *headerRecorder) Header() http.Header         { return h.header }
+func (h *headerRecorder) Write(p []byte) (int, error) { return len(p), nil }
+func (h *headerRecorder) WriteHeader(status int)      { h.status = status }
+
+// errorPages serves mux, but answers what mux has no pattern for, 404 Not
+// Found and 405 Method Not Allowed, with the error pages of the site
+func (s *server) errorPages(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// HINT: mux.Handler(r) returns the pattern that matches r, and "" with the mux's own handler if none does
+		// HINT: that handler may also redirect to a cleaned path; a headerRecorder tells which it does
+		// SOLUTION-START mux.ServeHTTP(w, r)
+		h, pattern := mux.Handler(r)
+		if pattern == "" {
+			rec := &headerRecorder{header: http.Header{}}
+			h.ServeHTTP(rec, r)
+			switch rec.status {
+			case http.StatusNotFound:
+				s.notFound(w, r)
+				return
+			case http.StatusMethodNotAllowed:
+				w.Header().Set("Allow", rec.header.Get("Allow"))
+				s.errorHandler(w, r, rec.status, r.Method+" is not allowed on "+r.URL.Path+", only "+rec.header.Get("Allow")+".")
+				return
+			}
+		}
+		mux.ServeHTTP(w, r) // not h: the mux sets the path values
+		// SOLUTION-END
+	})
+}
+
+// recovery answers a panic with the 500 page, as in session4/ex25
+func (s *server) recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if v := recover(); v != nil {
+				log.Error("panic", "value", v, "method", r.Method, "path", r.URL.Path)
+				s.errorHandler(w, r, http.StatusInternalServerError, "Something went wrong on our side. Please try again later.")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) home(w http.ResponseWriter, r *http.Request) error {
+	if wantsJSON(r) {
+		writeJSON(w, http.StatusOK, shelf)
+		return nil
+	}
+	return s.render(w, http.StatusOK, "home", shelf)
+}
+
+func (s *server) book(w http.ResponseWriter, r *http.Request) error {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return errorf(http.StatusBadRequest, "%q is not a book ID.", r.PathValue("id"))
+	}
+	for _, b := range shelf {
+		if b.ID == id {
+			if wantsJSON(r) {
+				writeJSON(w, http.StatusOK, b)
+				return nil
+			}
+			return s.render(w, http.StatusOK, "book", b)
+		}
+	}
+	return errorf(http.StatusNotFound, "There is no book %d on the shelf.", id)
+}
+
+// export fails as a disk would: the client must not learn about the disk
+func (s *server) export(w http.ResponseWriter, r *http.Request) error {
+	return fmt.Errorf("export shelf: %w", errors.New("write /var/backups/shelf.csv: no space left on device"))
+}
+
+func newServer(t *template.Template) http.Handler {
+	s := &server{t}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handle(s.home))
+	mux.HandleFunc("GET /books/{id}", s.handle(s.book))
+	mux.HandleFunc("GET /shelf/export", s.handle(s.export))
+	mux.HandleFunc("GET /shelf/tip", func(w http.ResponseWriter, r *http.Request) {
+		panic("the shelf fell over")
+	})
+	return s.recovery(s.errorPages(mux))
+}
+
+func main() {
+	dir := flag.String("templates", "templates", "directory of the templates")
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	t, err := template.ParseGlob(filepath.Join(*dir, "*.html"))
+	if err != nil {
+		log.Fatal("parsing templates", "err", err)
+	}
+	log.Info("listening", "url", "http://"+*addr+"/")
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(t))}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+func request(t *testing.T, method, path, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	tmpl, err := template.ParseGlob("templates/*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(method, path, nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	newServer(tmpl).ServeHTTP(w, r)
+	return w
+}
+
+// a book that is not there, or cannot be, gets the error page with its status
+func TestErrorPage(t *testing.T) {
+	for _, tc := range []struct {
+		path   string
+		status int
+		msg    string
+	}{
+		{"/books/99", http.StatusNotFound, "There is no book 99 on the shelf."},
+		{"/books/one", http.StatusBadRequest, "&#34;one&#34; is not a book ID."},
+	} {
+		w := request(t, "GET", tc.path, "text/html")
+		body := w.Body.String()
+		if w.Code != tc.status || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+			t.Errorf("GET %s: %d %s, want %d and an HTML page", tc.path, w.Code, w.Header().Get("Content-Type"), tc.status)
+		}
+		if !strings.Contains(body, tc.msg) || !strings.Contains(body, "Gopher Books") {
+			t.Errorf("GET %s: the page has no %q in the layout of the site:\n%s", tc.path, tc.msg, body)
+		}
+	}
+}
+
+// paths and methods the mux has no pattern for get the error page too
+func TestNotFoundMethodNotAllowed(t *testing.T) {
+	w := request(t, "GET", "/nothing/here", "")
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "404 Not Found") {
+		t.Errorf("GET /nothing/here: %d %q, want the 404 page", w.Code, w.Body.String())
+	}
+	w = request(t, "DELETE", "/books/1", "")
+	if w.Code != http.StatusMethodNotAllowed || !strings.Contains(w.Body.String(), "405 Method Not Allowed") {
+		t.Errorf("DELETE /books/1: %d %q, want the 405 page", w.Code, w.Body.String())
+	}
+	if allow := w.Header().Get("Allow"); !strings.Contains(allow, "GET") {
+		t.Errorf("DELETE /books/1: Allow is %q, want the methods that are allowed", allow)
+	}
+	if w := request(t, "GET", "/books/1", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "The Go Programming Language") {
+		t.Errorf("GET /books/1: %d, want the page of the book", w.Code)
+	}
+}
+
+// a client that asks for JSON gets its errors as JSON
+func TestJSONErrors(t *testing.T) {
+	for _, tc := range []struct {
+		method, path string
+		status       int
+	}{
+		{"GET", "/books/99", http.StatusNotFound},
+		{"GET", "/books/one", http.StatusBadRequest},
+		{"GET", "/nothing/here", http.StatusNotFound},
+		{"POST", "/books/1", http.StatusMethodNotAllowed},
+	} {
+		w := request(t, tc.method, tc.path, "application/json, text/plain;q=0.5")
+		var got struct {
+			Status int    `json:"status"`
+			Error  string `json:"error"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &got)
+		if err != nil || w.Code != tc.status || got.Status != tc.status || got.Error == "" ||
+			w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s with Accept: application/json: %d %q, want %d and {\"status\": %d, \"error\": ...}",
+				tc.method, tc.path, w.Code, w.Body.String(), tc.status, tc.status)
+		}
+	}
+}
+
+// an unexpected error or a panic is a 500 that tells nothing about the cause
+func TestInternalError(t *testing.T) {
+	for _, accept := range []string{"", "application/json"} {
+		for _, path := range []string{"/shelf/export", "/shelf/tip"} {
+			w := request(t, "GET", path, accept)
+			if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "Something went wrong") {
+				t.Errorf("GET %s (Accept %q): %d %q, want a 500 error page", path, accept, w.Code, w.Body.String())
+			}
+			if body := w.Body.String(); strings.Contains(body, "shelf.csv") || strings.Contains(body, "fell over") {
+				t.Errorf("GET %s shows the client what went wrong inside: %q", path, body)
+			}
+		}
+	}
+}
+
+// the error page still has its status when its own template fails
+func TestErrorPageFails(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`{{.Missing}}`))
+	w := httptest.NewRecorder()
+	(&server{tmpl}).errorHandler(w, httptest.NewRequest("GET", "/books/99", nil), http.StatusNotFound, "no book 99")
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "no book 99") {
+		t.Errorf("with a broken error template: %d %q, want 404 and the message as plain text", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,253 @@
+// a URL shortener: JSON in, redirects out, a map behind a mutex saved to a file
+//
+// The capstone of the web exercises. POST /shorten takes {"url": ...} and
+// answers with a code; GET /{code} redirects to the URL; GET /{code}/stats
+// tells how often it did. Every request runs in a goroutine of its own,
+// so the map of codes is shared: a sync.RWMutex lets many redirects read
+// it at once and one shortening change it. With -data the links are kept
+// in a JSON file, written atomically with pkg/safewrite after every new
+// link and at shutdown, and read back at the start.
+//
+// try: go run . and
+//
+//	curl -i -d '{"url": "https://go.dev/doc/effective_go"}' localhost:3000/shorten
+//	curl -i localhost:3000/<code>
+//	curl localhost:3000/<code>/stats
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+)
+
+var log = logger.New("web")
+
+// 64 symbols, so that every random byte picks one with the same chance
+const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"
+
+// randomCode returns 6 symbols, 36 random bits: codes cannot be guessed
+// from the ones before, unlike a counter
+func randomCode() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[b[i]%64]
+	}
+	return string(b)
+}
+
+// newCode makes the codes; tests replace it to force a collision
+var newCode = randomCode
+
+type link struct {
+	URL     string    `json:"url"`
+	Created time.Time `json:"created"`
+	Hits    int       `json:"hits"`
+}
+
+// store keeps the links by code, and in file if it is not ""
+type store struct {
+	mu    sync.RWMutex
+	links map[string]*link
+	file  string
+}
+
+// openStore reads the links saved in file, if there is one
+func openStore(file string) (*store, error) {
+	s := &store{links: map[string]*link{}, file: file}
+	if file == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil // the first start
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.links); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return s, nil
+}
+
+// saveLocked writes all links to the file. s.mu must be held: saves
+// happen in the order of the changes, a later one never overwritten by
+// an earlier.
+func (s *store) saveLocked() error {
+	if s.file == "" {
+		return nil
+	}
+	return safewrite.WriteTo(s.file, 0644, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s.links)
+	})
+}
+
+// save writes the links, for the shutdown: the hits are not saved on
+// every redirect
+func (s *store) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveLocked()
+}
+
+// shorten stores u under a new code and returns it
+func (s *store) shorten(u string) (string, error) {
+	// HINT: a new code may already be taken: try another one
+	// HINT: only the write lock keeps two shortenings from taking the same code
+	// SOLUTION-START return "", errors.New("not implemented")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code := newCode()
+	for s.links[code] != nil {
+		code = newCode()
+	}
+	s.links[code] = &link{URL: u, Created: time.Now().UTC()}
+	if err := s.saveLocked(); err != nil {
+		delete(s.links, code) // not stored unless saved
+		return "", err
+	}
+	return code, nil
+	// SOLUTION-END
+}
+
+// resolve returns the URL of code and counts the hit; false if there is
+// no such code
+func (s *store) resolve(code string) (string, bool) {
+	// HINT: counting the hit changes the link: RLock is not enough
+	// SOLUTION-START return "", false
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.links[code]
+	if l == nil {
+		return "", false
+	}
+	l.Hits++
+	return l.URL, true
+	// SOLUTION-END
+}
+
+// stats returns a copy of the link of code
+func (s *store) stats(code string) (link, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l := s.links[code]
+	if l == nil {
+		return link{}, false
+	}
+	return *l, true
+}
+
+// validURL checks that u is an absolute http or https URL
+func validURL(u string) error {
+	p, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	if p.Scheme != "http" && p.Scheme != "https" || p.Host == "" {
+		return fmt.Errorf("%q is not an http or https URL", u)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// shortenHandler answers {"url": u} with 201 Created and {"code": c,
+// "short": the URL that redirects}; 400 for anything but a valid URL
+func shortenHandler(s *store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			URL string `json:"url"`
+		}
+		// HINT: http.MaxBytesReader keeps a client from sending gigabytes
+		// SOLUTION-START
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "want {\"url\": ...}: "+err.Error())
+			return
+		}
+		if err := validURL(req.URL); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// SOLUTION-END
+		code, err := s.shorten(req.URL)
+		if err != nil {
+			log.Error("shorten", "url", req.URL, "err", err)
+			writeError(w, http.StatusInternalServerError, "could not store the link")
+			return
+		}
+		log.Info("shortened", "code", code, "url", req.URL)
+		writeJSON(w, http.StatusCreated, map[string]string{"code": code, "short": "http://" + r.Host + "/" + code})
+	}
+}
+
+func newServer(s *store) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `POST /shorten {"url": ...}, then GET /{code} or /{code}/stats`)
+	})
+	mux.HandleFunc("POST /shorten", shortenHandler(s))
+	mux.HandleFunc("GET /{code}", func(w http.ResponseWriter, r *http.Request) {
+		u, ok := s.resolve(r.PathValue("code"))
+		if !ok {
+			writeError(w, http.StatusNotFound, "no link "+r.PathValue("code"))
+			return
+		}
+		http.Redirect(w, r, u, http.StatusFound) // not 301: browsers would cache it and stop counting
+	})
+	mux.HandleFunc("GET /{code}/stats", func(w http.ResponseWriter, r *http.Request) {
+		l, ok := s.stats(r.PathValue("code"))
+		if !ok {
+			writeError(w, http.StatusNotFound, "no link "+r.PathValue("code"))
+			return
+		}
+		writeJSON(w, http.StatusOK, l)
+	})
+	return mux
+}
+
+func main() {
+	data := flag.String("data", "links.json", `file the links are kept in, "" for memory only`)
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	s, err := openStore(*data)
+	if err != nil {
+		log.Fatal("opening the links", "err", err)
+	}
+	log.Info("listening", "url", "http://"+*addr+"/", "links", len(s.links), "data", *data)
+	err = graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(s))}, 5*time.Second)
+	if serr := s.save(); serr != nil {
+		log.Error("saving the links", "err", serr)
+	}
+	if err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+func do(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+// shortenURL posts u and returns the code it got
+func shortenURL(t *testing.T, h http.Handler, u string) string {
+	t.Helper()
+	w := do(h, "POST", "/shorten", `{"url": "`+u+`"}`)
+	var got struct{ Code, Short string }
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || w.Code != http.StatusCreated || got.Code == "" {
+		t.Fatalf("POST /shorten %s: %d %q, want 201 and a code", u, w.Code, w.Body.String())
+	}
+	if !strings.HasSuffix(got.Short, "/"+got.Code) {
+		t.Errorf("POST /shorten: short is %q, want a URL that ends in /%s", got.Short, got.Code)
+	}
+	return got.Code
+}
+
+// a shortened URL redirects, and its stats count the redirects
+func TestShortenRedirect(t *testing.T) {
+	s, _ := openStore("")
+	h := newServer(s)
+	code := shortenURL(t, h, "https://go.dev/doc/")
+	for i := 0; i < 2; i++ {
+		if w := do(h, "GET", "/"+code, ""); w.Code != http.StatusFound || w.Header().Get("Location") != "https://go.dev/doc/" {
+			t.Fatalf("GET /%s: %d Location %q, want 302 to https://go.dev/doc/", code, w.Code, w.Header().Get("Location"))
+		}
+	}
+	var l link
+	w := do(h, "GET", "/"+code+"/stats", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &l); err != nil || l.Hits != 2 || l.URL != "https://go.dev/doc/" {
+		t.Errorf("GET /%s/stats: %q, want the URL and 2 hits", code, w.Body.String())
+	}
+	if other := shortenURL(t, h, "https://pkg.go.dev/"); other == code {
+		t.Errorf("two URLs got the same code %s", code)
+	}
+}
+
+// anything but a valid http URL is a 400, an unknown code a 404
+func TestBadRequests(t *testing.T) {
+	s, _ := openStore("")
+	h := newServer(s)
+	for _, body := range []string{`{"url": "ftp://example.com/"}`, `{"url": "/relative"}`, `{"url": ""}`, `not json`, `{"link": "https://go.dev/"}`} {
+		if w := do(h, "POST", "/shorten", body); w.Code != http.StatusBadRequest {
+			t.Errorf("POST /shorten %s: %d, want 400", body, w.Code)
+		}
+	}
+	if w := do(h, "POST", "/shorten", `{"url": "https://go.dev/`+strings.Repeat("x", 8<<10)+`"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("POST /shorten with 8KB: %d, want 400", w.Code)
+	}
+	if len(s.links) != 0 {
+		t.Errorf("bad requests stored %d links", len(s.links))
+	}
+	if w := do(h, "GET", "/nothere", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET /nothere: %d, want 404", w.Code)
+	}
+}
+
+// a code that is taken is not given out again
+func TestCollision(t *testing.T) {
+	codes := []string{"aaaaaa", "aaaaaa", "bbbbbb"}
+	newCode = func() string {
+		c := codes[0]
+		codes = codes[1:]
+		return c
+	}
+	defer func() { newCode = randomCode }()
+	s, _ := openStore("")
+	a, _ := s.shorten("https://go.dev/")
+	b, _ := s.shorten("https://pkg.go.dev/")
+	if a != "aaaaaa" || b != "bbbbbb" {
+		t.Errorf("with the codes aaaaaa, aaaaaa, bbbbbb: got %q and %q, want aaaaaa and bbbbbb", a, b)
+	}
+}
+
+// many clients shortening and following links at once lose nothing
+func TestConcurrent(t *testing.T) {
+	s, _ := openStore("")
+	h := newServer(s)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	codes := map[string]string{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			u := fmt.Sprintf("https://example.com/%d", i)
+			w := do(h, "POST", "/shorten", `{"url": "`+u+`"}`)
+			var got struct{ Code string }
+			json.Unmarshal(w.Body.Bytes(), &got)
+			for j := 0; j < 10; j++ {
+				do(h, "GET", "/"+got.Code, "")
+			}
+			mu.Lock()
+			codes[got.Code] = u
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(codes) != 50 {
+		t.Fatalf("50 URLs got %d different codes", len(codes))
+	}
+	for code, u := range codes {
+		if l, ok := s.stats(code); !ok || l.URL != u || l.Hits != 10 {
+			t.Errorf("%s: %+v, want %s with 10 hits", code, l, u)
+		}
+	}
+}
+
+// with a file the links are still there after a restart
+func TestPersistence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "links.json")
+	s, err := openStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := shortenURL(t, newServer(s), "https://go.dev/blog/")
+	s, err = openStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := do(newServer(s), "GET", "/"+code, ""); w.Header().Get("Location") != "https://go.dev/blog/" {
+		t.Errorf("after a restart GET /%s: %d, want a redirect to https://go.dev/blog/", code, w.Code)
+	}
+}
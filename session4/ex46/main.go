@@ -0,0 +1,240 @@
+// a guestbook: a templated page, a form that posts, entries kept in a gob file
+//
+// GET / shows the entries, newest first, and a form that posts to /sign.
+// A valid entry is stored and the browser redirected back with 303 See
+// Other, Post/Redirect/Get: reloading the page then does not post the
+// entry again. The thanks survive the redirect in a flash cookie, shown
+// once and deleted. An invalid entry gets the page again with the errors
+// and what was typed, and a 400.
+//
+// Requests come in concurrently: the entries are behind a sync.RWMutex,
+// and saved with encoding/gob through pkg/safewrite after every entry.
+// html/template escapes what visitors write: a <script> in a message is
+// shown, not run.
+//
+// try: go run . and open http://localhost:3000/
+package main
+
+import (
+	"encoding/gob"
+	"errors"
+	"flag"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+)
+
+var log = logger.New("web")
+
+const (
+	maxName    = 40
+	maxMessage = 500
+	flashName  = "flash"
+)
+
+type entry struct {
+	Name    string
+	Message string
+	Time    time.Time
+}
+
+// book keeps the entries in the order they were written, and in file if
+// it is not ""
+type book struct {
+	mu      sync.RWMutex
+	entries []entry
+	file    string
+}
+
+// openBook reads the entries saved in file, if there is one
+func openBook(file string) (*book, error) {
+	b := &book{file: file}
+	if file == "" {
+		return b, nil
+	}
+	f, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := gob.NewDecoder(f).Decode(&b.entries); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// saveLocked writes all entries to the file. b.mu must be held: saves
+// happen in the order of the entries, a later one never overwritten by
+// an earlier.
+func (b *book) saveLocked() error {
+	if b.file == "" {
+		return nil
+	}
+	return safewrite.WriteTo(b.file, 0644, func(w io.Writer) error {
+		return gob.NewEncoder(w).Encode(b.entries)
+	})
+}
+
+// add stores e and saves all entries; e is not stored if they cannot be saved
+func (b *book) add(e entry) error {
+	// HINT: requests add at the same time: lock, append, save
+	// SOLUTION-START return errors.New("not implemented")
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, e)
+	err := b.saveLocked()
+	if err != nil {
+		b.entries = b.entries[:len(b.entries)-1]
+	}
+	return err
+	// SOLUTION-END
+}
+
+// newest returns a copy of the entries, the newest first
+func (b *book) newest() []entry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	es := slices.Clone(b.entries)
+	slices.Reverse(es)
+	return es
+}
+
+// pageData is what the page is executed with
+type pageData struct {
+	Entries []entry
+	Flash   string
+	Errors  []string
+	Name    string // what was typed, for another try
+	Message string
+
+	MaxName, MaxMessage int
+}
+
+type server struct {
+	t    *template.Template
+	book *book
+}
+
+func (s *server) render(w http.ResponseWriter, status int, data pageData) {
+	data.Entries = s.book.newest()
+	data.MaxName, data.MaxMessage = maxName, maxMessage
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := s.t.ExecuteTemplate(w, "guestbook", data); err != nil {
+		log.Error("executing template", "name", "guestbook", "err", err) // too late for another status
+	}
+}
+
+// flash returns the message of the flash cookie and deletes it, so that
+// it is shown once
+func flash(w http.ResponseWriter, r *http.Request) string {
+	// SOLUTION-START return ""
+	c, err := r.Cookie(flashName)
+	if err != nil {
+		return ""
+	}
+	http.SetCookie(w, &http.Cookie{Name: flashName, Path: "/", MaxAge: -1})
+	msg, _ := url.QueryUnescape(c.Value)
+	return msg
+	// SOLUTION-END
+}
+
+func setFlash(w http.ResponseWriter, msg string) {
+	// cookie values cannot hold spaces or commas as they are
+	http.SetCookie(w, &http.Cookie{Name: flashName, Value: url.QueryEscape(msg), Path: "/", HttpOnly: true})
+}
+
+func (s *server) home(w http.ResponseWriter, r *http.Request) {
+	s.render(w, http.StatusOK, pageData{Flash: flash(w, r)})
+}
+
+// validate returns what is wrong with name and message, nothing if they
+// can be stored
+func validate(name, message string) []string {
+	var errs []string
+	switch n := utf8.RuneCountInString(name); {
+	case n == 0:
+		errs = append(errs, "Please tell us your name.")
+	case n > maxName:
+		errs = append(errs, "Your name is too long.")
+	}
+	switch n := utf8.RuneCountInString(message); {
+	case n == 0:
+		errs = append(errs, "Please write a message.")
+	case n > maxMessage:
+		errs = append(errs, "Your message is too long.")
+	}
+	return errs
+}
+
+// sign stores the entry of the form and redirects to the page, or shows
+// the page again with the errors and a 400
+func (s *server) sign(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 16<<10)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(r.PostForm.Get("name"))
+	message := strings.TrimSpace(r.PostForm.Get("message"))
+	// HINT: 303 See Other makes the browser GET the page: a reload does not post twice
+	// SOLUTION-START log.Info("signed", "name", name, "message", message)
+	if errs := validate(name, message); errs != nil {
+		s.render(w, http.StatusBadRequest, pageData{Errors: errs, Name: name, Message: message})
+		return
+	}
+	if err := s.book.add(entry{Name: name, Message: message, Time: time.Now()}); err != nil {
+		log.Error("adding an entry", "err", err)
+		s.render(w, http.StatusInternalServerError, pageData{Errors: []string{"Sorry, your entry could not be stored. Please try again."}, Name: name, Message: message})
+		return
+	}
+	log.Info("signed", "name", name)
+	setFlash(w, "Thanks for signing, "+name+"!")
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+	// SOLUTION-END
+}
+
+func newServer(t *template.Template, b *book) http.Handler {
+	s := &server{t, b}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.home)
+	mux.HandleFunc("POST /sign", s.sign)
+	return mux
+}
+
+func main() {
+	dir := flag.String("templates", "templates", "directory of the templates")
+	data := flag.String("data", "guestbook.gob", `file the entries are kept in, "" for memory only`)
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	t, err := template.ParseGlob(filepath.Join(*dir, "*.html"))
+	if err != nil {
+		log.Fatal("parsing templates", "err", err)
+	}
+	b, err := openBook(*data)
+	if err != nil {
+		log.Fatal("opening the guestbook", "file", *data, "err", err)
+	}
+	log.Info("listening", "url", "http://"+*addr+"/", "entries", len(b.entries), "data", *data)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), newServer(t, b))}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+func newTestServer(t *testing.T, b *book) http.Handler {
+	t.Helper()
+	tmpl, err := template.ParseGlob("templates/*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newServer(tmpl, b)
+}
+
+func post(h http.Handler, name, message string) *httptest.ResponseRecorder {
+	form := url.Values{"name": {name}, "message": {message}}
+	r := httptest.NewRequest("POST", "/sign", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func get(h http.Handler, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", "/", nil)
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// a signed entry redirects with 303 and is on the page, newest first, escaped
+func TestSign(t *testing.T) {
+	b, _ := openBook("")
+	h := newTestServer(t, b)
+	for _, e := range [][2]string{{"Ada", "first!"}, {"Bob", "<script>alert(1)</script>"}} {
+		if w := post(h, e[0], e[1]); w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/" {
+			t.Fatalf("POST /sign %v: %d Location %q, want 303 to /", e, w.Code, w.Header().Get("Location"))
+		}
+	}
+	page := get(h).Body.String()
+	if strings.Contains(page, "<script>alert") || !strings.Contains(page, "&lt;script&gt;alert(1)") {
+		t.Errorf("the page shows the message <script>alert(1)</script> unescaped, or not at all")
+	}
+	if bob, ada := strings.Index(page, "Bob"), strings.Index(page, "Ada"); bob < 0 || ada < 0 || bob > ada {
+		t.Errorf("the page does not show Bob, the newest, before Ada")
+	}
+}
+
+// an invalid entry gets the page again with its errors, what was typed and a 400
+func TestInvalid(t *testing.T) {
+	b, _ := openBook("")
+	h := newTestServer(t, b)
+	for _, tc := range []struct{ name, message, err string }{
+		{"", "hello", "Please tell us your name."},
+		{"Ada", "   ", "Please write a message."},
+		{strings.Repeat("a", maxName+1), "hello", "Your name is too long."},
+		{"Ada", strings.Repeat("é", maxMessage+1), "Your message is too long."},
+	} {
+		w := post(h, tc.name, tc.message)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tc.err) {
+			t.Errorf("POST /sign name %.10q message %.10q: %d, want 400 and %q", tc.name, tc.message, w.Code, tc.err)
+		}
+	}
+	if w := post(h, "", "keep me"); !strings.Contains(w.Body.String(), "keep me") {
+		t.Error("POST /sign without a name: the page lost the message that was typed")
+	}
+	if n := len(b.newest()); n != 0 {
+		t.Errorf("invalid entries stored %d entries", n)
+	}
+	if w := post(h, "Ada", strings.Repeat("é", maxMessage)); w.Code != http.StatusSeeOther {
+		t.Errorf("POST /sign with %d é: %d, want 303: count characters, not bytes", maxMessage, w.Code)
+	}
+}
+
+// the thanks are shown once after the redirect, then the cookie is gone
+func TestFlash(t *testing.T) {
+	b, _ := openBook("")
+	h := newTestServer(t, b)
+	w := post(h, "Ada Lovelace", "hello")
+	var flash *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == flashName {
+			flash = c
+		}
+	}
+	if flash == nil {
+		t.Fatal("POST /sign sets no flash cookie")
+	}
+	w = get(h, flash)
+	if !strings.Contains(w.Body.String(), "Thanks for signing, Ada Lovelace!") {
+		t.Error("GET / with the flash cookie does not thank Ada Lovelace")
+	}
+	deleted := false
+	for _, c := range w.Result().Cookies() {
+		deleted = deleted || c.Name == flashName && c.MaxAge < 0
+	}
+	if !deleted {
+		t.Error("GET / with the flash cookie does not delete it")
+	}
+	if strings.Contains(get(h).Body.String(), "Thanks") {
+		t.Error("GET / without the cookie thanks someone")
+	}
+}
+
+// visitors signing at once all get in, and the entries are in the file
+func TestConcurrentPersistent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "guestbook.gob")
+	b, err := openBook(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newTestServer(t, b)
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			post(h, fmt.Sprintf("visitor %d", i), "hello")
+		}()
+	}
+	wg.Wait()
+	b, err = openBook(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(b.newest()); n != 30 {
+		t.Errorf("after 30 visitors signed at once, the file has %d entries", n)
+	}
+}
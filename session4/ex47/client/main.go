@@ -0,0 +1,123 @@
+// a UDP client: a deadline for every answer, and another try when it never comes
+//
+// net.DialUDP does not connect anything: it fixes the address the socket
+// sends to and the only one it takes answers from. A request that is
+// lost on the way, or whose answer is, looks the same to the client: no
+// answer. So every read has a deadline, and a request that ran out of
+// time is sent again, with an ID of its own: an answer to the earlier one
+// that shows up late is recognized and ignored.
+//
+// Over TCP (session8/ex1) the kernel does all of that, and a client
+// that sends nothing costs nothing. UDP is for what is small, frequent
+// and worthless when late: time, DNS, games, metrics.
+//
+// try: go run ../server -drop 0.5 in another terminal, then go run .
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// answer is the server's time, and when and how often it was asked
+type answer struct {
+	time  time.Time
+	sent  time.Time     // of the request that was answered
+	rtt   time.Duration // round trip time
+	tries int
+}
+
+// offset is how far the server's clock is ahead of ours, if the answer
+// took as long to come back as the request took to get there
+func (a answer) offset() time.Duration {
+	return a.time.Sub(a.sent.Add(a.rtt / 2))
+}
+
+// parseAnswer splits an answer into its ID and time; an ERR answer is an error
+func parseAnswer(b []byte) (string, time.Time, error) {
+	id, rest, _ := strings.Cut(strings.TrimSpace(string(b)), " ")
+	if id == "ERR" {
+		return "", time.Time{}, fmt.Errorf("server: %s", rest)
+	}
+	t, err := time.Parse(time.RFC3339Nano, rest)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("bad answer %q", b)
+	}
+	return id, t, nil
+}
+
+// query asks the server that conn sends to for the time, at most tries
+// times, waiting timeout for each answer. The request of try n has the
+// ID "<id>.<n>"; answers with another ID are ignored.
+func query(conn *net.UDPConn, id string, timeout time.Duration, tries int) (answer, error) {
+	for try := 1; try <= tries; try++ {
+		want := fmt.Sprintf("%s.%d", id, try)
+		sent := time.Now()
+		if _, err := fmt.Fprintf(conn, "TIME %s\n", want); err != nil {
+			return answer{}, err
+		}
+		// HINT: one deadline for the whole try: reading a stale answer must not extend it
+		// HINT: a read past the deadline fails with a net.Error whose Timeout() is true
+		// SOLUTION-START return answer{sent: sent}, errors.New("not implemented")
+		conn.SetReadDeadline(sent.Add(timeout))
+		buf := make([]byte, 512)
+		for {
+			n, err := conn.Read(buf)
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				break // lost, the request or its answer: try again
+			}
+			if err != nil {
+				return answer{}, err // connection refused: nothing listens on the port
+			}
+			got, t, err := parseAnswer(buf[:n])
+			if err != nil {
+				return answer{}, err
+			}
+			if got != want {
+				continue // a late answer to an earlier try
+			}
+			return answer{t, sent, time.Since(sent), try}, nil
+		}
+		// SOLUTION-END
+	}
+	return answer{}, fmt.Errorf("no answer after %d tries", tries)
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:3002", "address of the time server")
+	n := flag.Int("n", 5, "number of queries")
+	timeout := flag.Duration("timeout", 500*time.Millisecond, "how long to wait for each answer")
+	tries := flag.Int("tries", 3, "how often to send a query before giving up")
+	flag.Parse()
+	ua, err := net.ResolveUDPAddr("udp", *addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	conn, err := net.DialUDP("udp", nil, ua) // no packet is sent yet
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+	failed := 0
+	for i := 1; i <= *n; i++ {
+		a, err := query(conn, fmt.Sprint(i), *timeout, *tries)
+		if err != nil {
+			fmt.Printf("query %d: %v\n", i, err)
+			failed++
+			continue
+		}
+		fmt.Printf("query %d: %s, offset %v, round trip %v, tries %d\n", i,
+			a.time.Local().Format("15:04:05.000"), a.offset().Round(time.Microsecond), a.rtt.Round(time.Microsecond), a.tries)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer answers the requests it gets with answer, which returns what
+// to send back, nothing for a lost packet
+func fakeServer(t *testing.T, answer func(n int, id string) []string) *net.UDPConn {
+	t.Helper()
+	srv, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for n := 1; ; n++ {
+			m, from, err := srv.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			id := strings.TrimSpace(strings.TrimPrefix(string(buf[:m]), "TIME "))
+			for _, a := range answer(n, id) {
+				srv.WriteToUDP([]byte(a), from)
+			}
+		}
+	}()
+	c, err := net.DialUDP("udp", nil, srv.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+const stamp = "2024-02-29T11:30:00.5Z"
+
+// an answer gives the time; a lost request is sent again after the timeout
+func TestRetry(t *testing.T) {
+	conn := fakeServer(t, func(n int, id string) []string {
+		if n == 1 {
+			return nil // lost
+		}
+		return []string{id + " " + stamp + "\n"}
+	})
+	began := time.Now()
+	a, err := query(conn, "7", 100*time.Millisecond, 3)
+	if err != nil {
+		t.Fatalf("query with the first request lost: %v", err)
+	}
+	if want, _ := time.Parse(time.RFC3339Nano, stamp); !a.time.Equal(want) || a.tries != 2 {
+		t.Errorf("query: time %v after %d tries, want %v after 2", a.time, a.tries, want)
+	}
+	if took := time.Since(began); took < 100*time.Millisecond || took > time.Second {
+		t.Errorf("query took %v, want a little over the timeout of 100ms", took)
+	}
+}
+
+// a late answer to an earlier try is not taken for the answer to this one
+func TestStale(t *testing.T) {
+	conn := fakeServer(t, func(n int, id string) []string {
+		if n == 1 {
+			return nil
+		}
+		return []string{"7.1 2000-01-01T00:00:00Z\n", id + " " + stamp + "\n"}
+	})
+	a, err := query(conn, "7", 100*time.Millisecond, 3)
+	if err != nil || a.time.Year() != 2024 {
+		t.Errorf("query got %v, %v: it took the late answer to try 1 for the one to try 2", a.time, err)
+	}
+}
+
+// a server that never answers, or answers ERR, is an error in due time
+func TestGiveUp(t *testing.T) {
+	conn := fakeServer(t, func(int, string) []string { return nil })
+	began := time.Now()
+	if _, err := query(conn, "1", 50*time.Millisecond, 3); err == nil {
+		t.Error("query returned no error without any answer")
+	}
+	if took := time.Since(began); took < 150*time.Millisecond || took > time.Second {
+		t.Errorf("3 tries of 50ms took %v, want about 150ms", took)
+	}
+	conn = fakeServer(t, func(int, string) []string { return []string{"ERR no\n"} })
+	if _, err := query(conn, "1", 50*time.Millisecond, 3); err == nil || !strings.Contains(fmt.Sprint(err), "no") {
+		t.Errorf("query with an ERR answer returned %v, want the server's error", err)
+	}
+}
@@ -0,0 +1,110 @@
+// a UDP time server: net.ListenUDP, one socket for every client, packets that may be lost
+//
+// UDP has no connections: no Accept, no goroutine per client. One socket
+// reads datagrams from anyone, ReadFromUDP tells who sent each, and the
+// answer goes back with WriteToUDP. Every datagram arrives whole or not
+// at all, and nothing is sent again when it is lost: that is up to the
+// client (see ../client). Compare the TCP echo server of session8/ex1,
+// where the kernel numbers, acknowledges and resends every byte.
+//
+// The protocol is one line each way: "TIME <id>" is answered with
+// "<id> <time in RFC 3339, with nanoseconds>", anything else with
+// "ERR <why>". The ID is the client's, any word: it tells the client
+// which of its requests an answer is for. -drop ignores a share of the requests, as a bad network
+// would.
+//
+// try: go run . -drop 0.3 and go run ../client, or
+//
+//	echo 'TIME 1' | nc -u -w1 localhost 3002
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+var log = logger.New("udp")
+
+// maxPacket is the largest request read; a longer datagram is cut short
+// by the read, not split into several
+const maxPacket = 512
+
+// maxID is the longest ID a request may have
+const maxID = 32
+
+// reply returns the answer to the request req
+func reply(req []byte, now time.Time) []byte {
+	req = bytes.TrimSpace(req) // nc sends a newline, other clients may not
+	// HINT: bytes.CutPrefix splits off "TIME "; the ID is what is left
+	// SOLUTION-START return fmt.Appendf(nil, "ERR not implemented\n")
+	id, ok := bytes.CutPrefix(req, []byte("TIME "))
+	if !ok {
+		return []byte("ERR want TIME <id>\n")
+	}
+	if len(id) == 0 || len(id) > maxID || bytes.ContainsAny(id, " \t") {
+		return fmt.Appendf(nil, "ERR the id must be 1 to %d bytes without spaces\n", maxID)
+	}
+	return fmt.Appendf(nil, "%s %s\n", id, now.UTC().Format(time.RFC3339Nano))
+	// SOLUTION-END
+}
+
+// serve answers the requests that come to conn, all but a share drop of
+// them, until conn is closed
+func serve(conn *net.UDPConn, drop float64) error {
+	buf := make([]byte, maxPacket)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rand.Float64() < drop {
+			log.Info("dropped", "from", from.String())
+			continue
+		}
+		// HINT: WriteToUDP answers the address ReadFromUDP returned
+		// SOLUTION-START log.Info("request", "from", from.String(), "bytes", n)
+		if _, err := conn.WriteToUDP(reply(buf[:n], time.Now()), from); err != nil {
+			log.Warn("reply", "to", from.String(), "err", err) // the client's problem, not the server's
+		}
+		// SOLUTION-END
+	}
+}
+
+func main() {
+	drop := flag.Float64("drop", 0, "share of the requests to ignore, from 0 to 1")
+	addr := config.Addr("localhost:3002")
+	flag.Parse()
+	ua, err := net.ResolveUDPAddr("udp", *addr)
+	if err != nil {
+		log.Fatal("resolve", "addr", *addr, "err", err)
+	}
+	conn, err := net.ListenUDP("udp", ua)
+	if err != nil {
+		log.Fatal("listen", "err", err)
+	}
+	log.Info("listening", "addr", conn.LocalAddr().String(), "drop", *drop)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, graceful.Signals...)
+	go func() {
+		<-stop
+		conn.Close() // nothing to wait for: no connections, no requests in flight
+	}()
+	if err := serve(conn, *drop); err != nil {
+		log.Fatal("read", "err", err)
+	}
+	log.Info("stopped")
+}
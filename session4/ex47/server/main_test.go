@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+// TIME <id> is answered with the ID and the time, anything else with ERR
+func TestReply(t *testing.T) {
+	now := time.Date(2024, 2, 29, 12, 30, 0, 123456789, time.FixedZone("CET", 3600))
+	for _, tc := range []struct{ req, want string }{
+		{"TIME 1", "1 2024-02-29T11:30:00.123456789Z\n"},
+		{"TIME abc.2\n", "abc.2 2024-02-29T11:30:00.123456789Z\n"},
+		{"TIME", "ERR"},
+		{"TIME a b", "ERR"},
+		{"TIME " + strings.Repeat("x", 33), "ERR"},
+		{"DATE 1", "ERR"},
+	} {
+		if got := string(reply([]byte(tc.req), now)); got != tc.want && !(tc.want == "ERR" && strings.HasPrefix(got, "ERR ")) {
+			t.Errorf("reply(%q) = %q, want %q", tc.req, got, tc.want)
+		}
+	}
+}
+
+// serve answers every client on the one socket
+func TestServe(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- serve(conn, 0) }()
+	for _, id := range []string{"a", "b", "c"} {
+		c, err := net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		c.SetDeadline(time.Now().Add(2 * time.Second))
+		c.Write([]byte("TIME " + id))
+		buf := make([]byte, 512)
+		n, err := c.Read(buf)
+		if err != nil || !strings.HasPrefix(string(buf[:n]), id+" ") {
+			t.Errorf("a client sent TIME %s and got %q, %v", id, buf[:n], err)
+		}
+	}
+	conn.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serve returned %v after the socket was closed, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("serve did not return after the socket was closed")
+	}
+}
@@ -0,0 +1,173 @@
+// a REST client: JSON decoded into tagged structs, page after page, and a report
+//
+// The client asks the API of session4/ex48/server for its packages. Every
+// answer is decoded into Go structs: the json tags map the API's names
+// (updated_at) to Go's (Updated), a pointer tells a null from an empty
+// string, and time.Time reads RFC 3339 by itself. Fields the structs do
+// not have are skipped, so the API may grow without breaking the client.
+//
+// The answer holds one page and the URL of the next; the client follows
+// those links until there is none, but never more than -pages times: a
+// server that links a page to itself must not keep it busy forever.
+// Then it prints a table, most downloaded first.
+//
+// The same client works against any API that pages this way; only the
+// structs would change.
+//
+// try: start session4/ex48/server, then go run ./session4/ex48/client
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// maxBody is the largest answer read, so that a broken server cannot
+// fill the memory
+const maxBody = 1 << 20
+
+// pkg is a package, as far as the report needs it
+type pkg struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	Downloads int64     `json:"downloads"`
+	License   *string   `json:"license"` // nil for null
+	Updated   time.Time `json:"updated_at"`
+}
+
+// page is one answer of the API
+type page struct {
+	Count   int    `json:"count"`
+	Next    string `json:"next"` // "" for null: the last page
+	Results []pkg  `json:"results"`
+}
+
+// getPage fetches the page at rawURL
+func getPage(ctx context.Context, c *http.Client, rawURL string) (page, error) {
+	var p page
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return p, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.Do(req)
+	if err != nil {
+		return p, err
+	}
+	defer resp.Body.Close()
+	body := io.LimitReader(resp.Body, maxBody)
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(body).Decode(&e) // an error has a body too; if it is not JSON, the status will do
+		return p, fmt.Errorf("%s: %s %s", rawURL, resp.Status, e.Error)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		return p, fmt.Errorf("%s: got %q, want JSON", rawURL, ct)
+	}
+	// HINT: a json.Decoder reads straight from the body; Decode fills the fields by their tags
+	// SOLUTION-START return p, fmt.Errorf("%s: not implemented", rawURL)
+	if err := json.NewDecoder(body).Decode(&p); err != nil {
+		return p, fmt.Errorf("%s: %w", rawURL, err)
+	}
+	return p, nil
+	// SOLUTION-END
+}
+
+// fetchAll fetches the page at rawURL and every page it leads to, at
+// most maxPages, and returns all their packages
+func fetchAll(ctx context.Context, c *http.Client, rawURL string, maxPages int) ([]pkg, error) {
+	var all []pkg
+	// HINT: "next" may be relative, like "/api/packages?page=2": URL.ResolveReference makes it whole
+	// SOLUTION-START
+	for n := 1; rawURL != ""; n++ {
+		if n > maxPages {
+			return all, fmt.Errorf("more than %d pages", maxPages)
+		}
+		p, err := getPage(ctx, c, rawURL)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, p.Results...)
+		if p.Next == "" {
+			break
+		}
+		base, _ := url.Parse(rawURL) // getPage would have failed
+		next, err := url.Parse(p.Next)
+		if err != nil {
+			return all, fmt.Errorf("next page: %w", err)
+		}
+		rawURL = base.ResolveReference(next).String()
+	}
+	// SOLUTION-END
+	return all, nil
+}
+
+// thousands writes n with a comma every three digits
+func thousands(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// report writes pkgs as a table, the most downloaded first, and a total
+func report(w io.Writer, pkgs []pkg) error {
+	pkgs = slices.Clone(pkgs) // sorted here, not for the caller
+	slices.SortStableFunc(pkgs, func(a, b pkg) int {
+		return cmp.Compare(b.Downloads, a.Downloads)
+	})
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tVERSION\tDOWNLOADS\tLICENSE\tUPDATED")
+	var total int64
+	for _, p := range pkgs {
+		license := "-"
+		if p.License != nil {
+			license = *p.License
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", p.Name, p.Version, thousands(p.Downloads), license, p.Updated.Format(time.DateOnly))
+		total += p.Downloads
+	}
+	fmt.Fprintf(tw, "%d packages\t\t%s\n", len(pkgs), thousands(total))
+	return tw.Flush()
+}
+
+func main() {
+	api := flag.String("url", "http://localhost:3000/api/packages", "first page of the API")
+	maxPages := flag.Int("pages", 20, "most pages to follow")
+	timeout := flag.Duration("timeout", 10*time.Second, "time for all the pages")
+	flag.Parse()
+	if u, err := url.Parse(*api); err != nil || u.Host == "" {
+		fmt.Fprintf(os.Stderr, "-url %q: want an absolute URL\n", *api)
+		os.Exit(2)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	c := &http.Client{Timeout: 5 * time.Second} // never http.DefaultClient: it waits forever
+	pkgs, err := fetchAll(ctx, c, *api, *maxPages)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if len(pkgs) == 0 {
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "the report has the first %d packages only\n", len(pkgs))
+	}
+	if err := report(os.Stdout, pkgs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
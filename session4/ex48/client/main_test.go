@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// api serves pages of 2 packages; next returns the link to the page after n
+func api(t *testing.T, next func(n int) string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		n := 1
+		fmt.Sscan(r.URL.Query().Get("page"), &n)
+		if n > 3 {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": "no such page"}`)
+			return
+		}
+		link := "null"
+		if s := next(n); s != "" {
+			link = `"` + s + `"`
+		}
+		fmt.Fprintf(w, `{"count": 6, "next": %s, "results": [
+			{"name": "p%da", "version": "1.0", "downloads": %d, "license": "MIT", "updated_at": "2024-0%d-01T10:00:00Z", "stars": 5},
+			{"name": "p%db", "version": "2.0", "downloads": %d, "license": null, "updated_at": "2024-0%d-02T10:00:00Z"}]}`,
+			link, n, n*1000, n, n, n*10, n)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func fetch(url string, maxPages int) ([]pkg, error) {
+	return fetchAll(context.Background(), &http.Client{Timeout: time.Second}, url, maxPages)
+}
+
+// the pages are followed to the last, absolute or relative, and decoded by their tags
+func TestFetchAll(t *testing.T) {
+	for name, next := range map[string]func(int) string{
+		"absolute": nil,
+		"relative": func(n int) string { return fmt.Sprintf("/api?page=%d", n+1) },
+	} {
+		var srv *httptest.Server
+		srv = api(t, func(n int) string {
+			switch {
+			case n == 3:
+				return ""
+			case next != nil:
+				return next(n)
+			}
+			return fmt.Sprintf("%s/api?page=%d", srv.URL, n+1)
+		})
+		pkgs, err := fetch(srv.URL+"/api", 10)
+		if err != nil || len(pkgs) != 6 {
+			t.Fatalf("%s links: %d packages, %v; want 6", name, len(pkgs), err)
+		}
+		p := pkgs[4]
+		if p.Name != "p3a" || p.Version != "1.0" || p.Downloads != 3000 || p.License == nil || *p.License != "MIT" ||
+			!p.Updated.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("%s links: the 5th package is %+v, want p3a 1.0, 3000 downloads, MIT, 2024-03-01 10:00 UTC", name, p)
+		}
+		if pkgs[5].License != nil {
+			t.Errorf("%s links: a null license decoded to %q, want nil", name, *pkgs[5].License)
+		}
+	}
+}
+
+// an error answer, a loop of links and an answer that is no JSON are errors
+func TestErrors(t *testing.T) {
+	var srv *httptest.Server
+	srv = api(t, func(n int) string { return fmt.Sprintf("%s/api?page=%d", srv.URL, n+1) })
+	if _, err := fetch(srv.URL+"/api", 10); err == nil || !strings.Contains(err.Error(), "no such page") {
+		t.Errorf("a 404 with {\"error\": \"no such page\"}: %v, want an error with its message", err)
+	}
+	loop := api(t, func(n int) string { return "/api?page=1" })
+	if pkgs, err := fetch(loop.URL+"/api", 5); err == nil || len(pkgs) > 10 {
+		t.Errorf("a page that links to itself: %d packages, %v; want an error after 5 pages", len(pkgs), err)
+	}
+	html := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "<html>")
+	}))
+	defer html.Close()
+	if _, err := fetch(html.URL, 5); err == nil {
+		t.Error("an answer that is not JSON: no error")
+	}
+}
+
+// the report is sorted by downloads, with thousands separated and a total
+func TestReport(t *testing.T) {
+	mit := "MIT"
+	var b bytes.Buffer
+	report(&b, []pkg{
+		{Name: "small", Version: "1", Downloads: 999},
+		{Name: "big", Version: "2", Downloads: 1234567, License: &mit},
+	})
+	out := b.String()
+	if strings.Index(out, "big") > strings.Index(out, "small") || !strings.Contains(out, "1,234,567") ||
+		!strings.Contains(out, "1,235,566") || !strings.Contains(out, "2 packages") {
+		t.Errorf("report:\n%s\nwant big first, 1,234,567 downloads and a total of 1,235,566 for 2 packages", out)
+	}
+}
@@ -0,0 +1,156 @@
+// a JSON API to try session4/ex48/client against: packages, one page at a time
+//
+// Public APIs rarely send all they have at once. This one answers
+// GET /api/packages?page=2&per_page=10 with one page and links to the
+// pages around it, the way many public APIs do:
+//
+//	{"count": 23, "next": "http://localhost:3000/api/packages?page=3&per_page=10",
+//	 "previous": "http://localhost:3000/api/packages?page=1&per_page=10", "results": [...]}
+//
+// "next" is null on the last page. A client follows the links rather
+// than counting pages itself: the server may change how it pages.
+//
+// try: curl 'localhost:3000/api/packages?per_page=3'
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// maxPerPage is the largest page a client may ask for
+const maxPerPage = 50
+
+// pkg is a package as the API shows it
+type pkg struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	Downloads int64     `json:"downloads"`
+	License   *string   `json:"license"` // null when nobody said
+	Updated   time.Time `json:"updated_at"`
+	Tags      []string  `json:"tags,omitempty"`
+}
+
+// pageOf is the answer for one page
+type pageOf struct {
+	Count    int     `json:"count"`    // of all packages
+	Next     *string `json:"next"`     // null on the last page
+	Previous *string `json:"previous"` // null on the first
+	Results  []pkg   `json:"results"`
+}
+
+func license(s string) *string { return &s }
+
+func day(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+var packages = []pkg{
+	{"gopher-log", "1.4.2", 1_284_112, license("MIT"), day("2024-05-02"), []string{"logging"}},
+	{"tinyrouter", "0.9.0", 88_410, license("BSD-3-Clause"), day("2023-11-19"), []string{"web", "http"}},
+	{"csvkit", "2.1.0", 402_377, license("Apache-2.0"), day("2024-03-14"), []string{"encoding"}},
+	{"retryable", "1.0.3", 951_004, license("MIT"), day("2024-06-01"), []string{"http", "resilience"}},
+	{"bolt-cache", "3.2.1", 2_004_519, license("MIT"), day("2024-04-22"), []string{"cache"}},
+	{"tomlish", "0.3.7", 12_880, nil, day("2022-08-30"), []string{"encoding", "config"}},
+	{"uuidgen", "1.8.0", 3_310_552, license("BSD-3-Clause"), day("2024-01-09"), nil},
+	{"sqlmini", "0.12.4", 240_019, license("Apache-2.0"), day("2024-05-28"), []string{"database"}},
+	{"flagset", "2.0.0", 67_201, license("MIT"), day("2023-07-16"), []string{"cli", "config"}},
+	{"mailmerge", "1.1.1", 9_412, nil, day("2021-12-03"), []string{"mail"}},
+	{"ratelimit", "1.6.0", 730_245, license("Apache-2.0"), day("2024-02-17"), []string{"http", "resilience"}},
+	{"tablewriter", "0.0.9", 1_120_860, license("MIT"), day("2023-09-05"), []string{"cli"}},
+	{"semverx", "3.0.1", 512_300, license("MIT"), day("2024-06-11"), nil},
+	{"grpc-health", "1.2.0", 45_778, license("Apache-2.0"), day("2023-10-27"), []string{"grpc"}},
+	{"humanize", "1.0.4", 2_870_114, license("MIT"), day("2022-11-20"), []string{"text"}},
+	{"envconfig", "1.3.2", 640_982, license("MIT"), day("2023-04-08"), []string{"config"}},
+	{"pdfgen", "0.5.0", 33_016, license("LGPL-3.0"), day("2023-02-13"), []string{"documents"}},
+	{"jwtlite", "2.4.0", 820_449, license("MIT"), day("2024-05-19"), []string{"security", "http"}},
+	{"walkdir", "1.0.0", 150_233, nil, day("2020-06-25"), []string{"files"}},
+	{"colorterm", "1.9.3", 1_905_337, license("MIT"), day("2024-03-30"), []string{"cli"}},
+	{"promtext", "0.2.2", 21_509, license("Apache-2.0"), day("2024-01-26"), []string{"metrics"}},
+	{"backoff", "4.3.0", 4_102_876, license("MIT"), day("2024-04-03"), []string{"resilience"}},
+	{"xmlstream", "0.7.1", 18_644, license("BSD-2-Clause"), day("2022-05-17"), []string{"encoding"}},
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// number returns the query parameter name of r as a number from 1 to
+// max, or def when it is missing
+func number(r *http.Request, name string, def, max int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > max {
+		return 0, fmt.Errorf("%s must be a number from 1 to %d", name, max)
+	}
+	return n, nil
+}
+
+// pageLink is the absolute URL of another page of the list r asked for
+func pageLink(r *http.Request, page, perPage int) *string {
+	s := fmt.Sprintf("http://%s%s?page=%d&per_page=%d", r.Host, r.URL.Path, page, perPage)
+	return &s
+}
+
+// listHandler answers GET /api/packages with page "page" of all packages
+func listHandler(all []pkg) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		perPage, err := number(r, "per_page", 10, maxPerPage)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		pages := max(1, (len(all)+perPage-1)/perPage) // an empty list still has a page
+		page, err := number(r, "page", 1, pages)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		// HINT: page 1 holds all[0:perPage]; the last page may be shorter
+		// SOLUTION-START writeJSON(w, http.StatusOK, pageOf{Count: len(all), Results: all})
+		lo := (page - 1) * perPage
+		hi := min(lo+perPage, len(all))
+		resp := pageOf{Count: len(all), Results: all[lo:hi]}
+		if page < pages {
+			resp.Next = pageLink(r, page+1, perPage)
+		}
+		if page > 1 {
+			resp.Previous = pageLink(r, page-1, perPage)
+		}
+		writeJSON(w, http.StatusOK, resp)
+		// SOLUTION-END
+		log.Info("page", "page", page, "per_page", perPage)
+	}
+}
+
+func main() {
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/packages", listHandler(packages))
+	log.Info("listening", "url", "http://"+*addr+"/api/packages")
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), mux)}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+func getPage(t *testing.T, all []pkg, query string) (int, pageOf) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	listHandler(all).ServeHTTP(w, httptest.NewRequest("GET", "http://api.test/api/packages"+query, nil))
+	var p pageOf
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatalf("GET %s: %v", query, err)
+		}
+	}
+	return w.Code, p
+}
+
+func str(p *string) string {
+	if p == nil {
+		return "null"
+	}
+	return *p
+}
+
+// every page has its share of the packages and links to the pages around it
+func TestPages(t *testing.T) {
+	seen := 0
+	for page, want := range []int{10, 10, 3} {
+		query := fmt.Sprintf("?page=%d", page+1)
+		code, p := getPage(t, packages, query)
+		if code != http.StatusOK || len(p.Results) != want || p.Count != len(packages) {
+			t.Fatalf("GET %s: %d with %d of %d packages, want 200 with %d of %d", query, code, len(p.Results), p.Count, want, len(packages))
+		}
+		if p.Results[0].Name != packages[seen].Name {
+			t.Errorf("GET %s starts with %s, want %s", query, p.Results[0].Name, packages[seen].Name)
+		}
+		seen += want
+		next, prev := "null", "null"
+		if page < 2 {
+			next = fmt.Sprintf("http://api.test/api/packages?page=%d&per_page=10", page+2)
+		}
+		if page > 0 {
+			prev = fmt.Sprintf("http://api.test/api/packages?page=%d&per_page=10", page)
+		}
+		if str(p.Next) != next || str(p.Previous) != prev {
+			t.Errorf("GET %s: next %s, previous %s; want %s and %s", query, str(p.Next), str(p.Previous), next, prev)
+		}
+	}
+}
+
+// per_page sets the size of a page; pages that do not exist are errors
+func TestPerPage(t *testing.T) {
+	if _, p := getPage(t, packages, "?per_page=50"); len(p.Results) != len(packages) || p.Next != nil {
+		t.Errorf("?per_page=50: %d packages and next %s, want all %d and null", len(p.Results), str(p.Next), len(packages))
+	}
+	if code, p := getPage(t, nil, ""); code != http.StatusOK || p.Next != nil {
+		t.Errorf("an empty list: %d, next %s; want 200 and null", code, str(p.Next))
+	}
+	for query, want := range map[string]int{"?page=4": 404, "?page=0": 404, "?page=x": 404, "?per_page=51": 400, "?per_page=0": 400} {
+		if code, _ := getPage(t, packages, query); code != want {
+			t.Errorf("GET %s: %d, want %d", query, code, want)
+		}
+	}
+}
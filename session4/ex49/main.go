@@ -0,0 +1,241 @@
+// sending mail with net/smtp by hand: MIME headers, a text/template body, and SMTP errors told apart
+//
+// session4/ex22 leaves the work to pkg/mailer; here it is done in the
+// open. A mail is text: headers, an empty line, the body. The headers
+// are written out one by one, every line ends in CRLF, a subject that is
+// not ASCII is Q-encoded (which also keeps a newline in it from starting
+// a header of its own) and the body is quoted-printable, so that it
+// survives servers that only pass 7-bit ASCII. The subject and the body
+// come from one text/template with a {{define}} for each, the templates
+// of session4/ex9 to ex12 outside the web.
+//
+// One SMTP session can carry many mails, and a mail many recipients: the
+// server answers every recipient on its own. A 5xx answer is final (no
+// such mailbox), a 4xx one asks to try again later (mailbox busy); both
+// come back from net/smtp as a *textproto.Error with the code. A refused
+// recipient does not stop the others.
+//
+// The mails go to a mailer.Sink started right here, which refuses
+// nobody@example.com for good and busy@example.com for now.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/mailer"
+)
+
+type attendee struct {
+	Name, Email string
+	Course      string
+	Start       time.Time
+	Seats       int // left in the course
+}
+
+var reminder = template.Must(template.New("reminder").Parse(`
+{{- define "subject"}}{{.Course}} starts on {{.Start.Format "Monday"}}{{end}}
+{{- define "body"}}Hello {{.Name}},
+
+{{.Course}} starts on {{.Start.Format "Monday 2 January at 15:04"}}.
+{{- if eq .Seats 1}} Yours is the last seat.
+{{- else if .Seats}} {{.Seats}} seats are still free: bring a friend.
+{{- end}}
+
+See you there!
+{{end}}`))
+
+// render fills in the subject and body templates of t for a
+func render(t *template.Template, a attendee) (subject, body string, err error) {
+	var b strings.Builder
+	if err := t.ExecuteTemplate(&b, "subject", a); err != nil {
+		return "", "", err
+	}
+	subject = b.String()
+	b.Reset()
+	if err := t.ExecuteTemplate(&b, "body", a); err != nil {
+		return "", "", err
+	}
+	return subject, b.String(), nil
+}
+
+// compose returns the mail from from to to, a plain text in UTF-8
+func compose(from, to mail.Address, subject, body string, date time.Time) []byte {
+	var b bytes.Buffer
+	// HINT: mail.Address.String() quotes and encodes the name; mime.QEncoding.Encode does the subject
+	// HINT: MIME-Version, Content-Type with the charset and Content-Transfer-Encoding tell how to read the body
+	// SOLUTION-START
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	// SOLUTION-END
+	b.WriteString("\r\n")               // the end of the headers
+	qw := quotedprintable.NewWriter(&b) // ends the lines with CRLF too
+	qw.Write([]byte(body))              // a bytes.Buffer does not fail
+	qw.Close()
+	return b.Bytes()
+}
+
+// temporary reports whether err is an SMTP answer that asks to try again
+// later, with a 4xx code
+func temporary(err error) bool {
+	var te *textproto.Error
+	return errors.As(err, &te) && te.Code >= 400 && te.Code < 500
+}
+
+// send sends msg from from to the recipients to in the session of c. The
+// recipients the server refuses are returned with its answers; the mail
+// still goes to the others. With none left, or when the session fails,
+// err is set.
+func send(c *smtp.Client, from string, to []string, msg []byte) (refused map[string]error, err error) {
+	// HINT: c.Rcpt fails with a *textproto.Error for a refused recipient: keep it and go on
+	// HINT: with no recipient left, c.Reset() ends the transaction so that the session can go on
+	// SOLUTION-START return nil, errors.New("not implemented")
+	if err := c.Mail(from); err != nil {
+		return nil, err
+	}
+	refused = map[string]error{}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			var te *textproto.Error
+			if !errors.As(err, &te) {
+				return refused, err // the connection, not the recipient
+			}
+			refused[rcpt] = err
+		}
+	}
+	if len(refused) == len(to) {
+		return refused, errors.Join(errors.New("every recipient refused"), c.Reset())
+	}
+	w, err := c.Data()
+	if err != nil {
+		return refused, err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return refused, err
+	}
+	return refused, w.Close() // the server's answer to the whole mail
+	// SOLUTION-END
+}
+
+// dial opens an SMTP session on the sink s, with STARTTLS and AUTH PLAIN
+func dial(s *mailer.Sink, user, password string) (*smtp.Client, error) {
+	c, err := smtp.Dial(s.Addr())
+	if err != nil {
+		return nil, err
+	}
+	cfg := s.ClientTLS()
+	cfg.ServerName = "127.0.0.1" // the name the certificate must have: smtp.SendMail takes it from the address
+	if err := c.StartTLS(cfg); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if err := c.Auth(smtp.PlainAuth("", user, password, "127.0.0.1")); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// show prints a received mail the way a mail program would read it
+func show(w io.Writer, m mailer.Mail) error {
+	msg, err := mail.ReadMessage(bytes.NewReader(m.Data))
+	if err != nil {
+		return err
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "--- to %s\nSubject: %s\n%s", strings.Join(m.To, ", "), subject, body)
+	return nil
+}
+
+func main() {
+	raw := flag.Bool("raw", false, "print the mails as they were sent, headers and all")
+	flag.Parse()
+	sink, err := mailer.Listen("127.0.0.1:0")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	sink.Users = map[string]string{"trainer": "secret"}
+	sink.Refuse = func(rcpt string) error {
+		switch rcpt {
+		case "nobody@example.com":
+			return &textproto.Error{Code: 550, Msg: "5.1.1 no such mailbox"}
+		case "busy@example.com":
+			return &textproto.Error{Code: 451, Msg: "4.2.1 mailbox busy, try again later"}
+		}
+		return nil
+	}
+	go sink.Serve()
+
+	c, err := dial(sink, "trainer", "secret")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	start := time.Date(2025, time.March, 3, 9, 30, 0, 0, time.UTC)
+	from := mail.Address{Name: "Go Training", Address: "training@example.com"}
+	later := 0
+	for _, a := range []attendee{
+		{"Ada", "ada@example.com", "Goroutines and Channels", start, 3},
+		{"Renée Müller", "renee@example.com", "Encoding and I/O – Part 2", start.AddDate(0, 0, 1), 1},
+		{"Nobody", "nobody@example.com", "Goroutines and Channels", start, 0},
+		{"Busy Bee", "busy@example.com", "Goroutines and Channels", start, 0},
+	} {
+		subject, body, err := render(reminder, a)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		to := mail.Address{Name: a.Name, Address: a.Email}
+		refused, err := send(c, from.Address, []string{a.Email}, compose(from, to, subject, body, time.Now()))
+		for rcpt, err := range refused {
+			if temporary(err) {
+				fmt.Printf("%s: refused for now, try again later: %v\n", rcpt, err)
+				later++
+			} else {
+				fmt.Printf("%s: refused: %v\n", rcpt, err)
+			}
+		}
+		if err != nil && len(refused) == 0 {
+			fmt.Fprintln(os.Stderr, err) // the session is broken
+			os.Exit(1)
+		}
+	}
+	c.Quit()
+	sink.Close()
+	fmt.Printf("%d sent, %d to try again later\n", len(sink.Mails()), later)
+	for _, m := range sink.Mails() {
+		if *raw {
+			fmt.Printf("--- to %s\n%s", strings.Join(m.To, ", "), m.Data)
+			continue
+		}
+		if err := show(os.Stdout, m); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
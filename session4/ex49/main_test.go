@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/mailer"
+)
+
+// the templates give the subject and a body that depends on the seats left
+func TestRender(t *testing.T) {
+	start := time.Date(2025, time.March, 3, 9, 30, 0, 0, time.UTC)
+	for seats, want := range map[int]string{0: "09:30.\n\nSee", 1: "Yours is the last seat.", 4: "4 seats are still free"} {
+		subject, body, err := render(reminder, attendee{"Ada", "ada@example.com", "Go", start, seats})
+		if err != nil || subject != "Go starts on Monday" || !strings.Contains(body, want) {
+			t.Errorf("render with %d seats: %q, %q, %v; want the subject \"Go starts on Monday\" and %q", seats, subject, body, err, want)
+		}
+	}
+}
+
+// the headers are CRLF lines, encoded where they are not ASCII, and the body is quoted-printable
+func TestCompose(t *testing.T) {
+	from := mail.Address{Name: "Go Training", Address: "training@example.com"}
+	to := mail.Address{Name: "Renée Müller", Address: "renee@example.com"}
+	subject := "Größe – I/O\r\nBcc: eve@example.com"
+	body := "Grüße,\n" + strings.Repeat("long line ", 20) + "\n"
+	date := time.Date(2025, time.March, 3, 9, 30, 0, 0, time.UTC)
+	data := compose(from, to, subject, body, date)
+	if bytes.Contains(bytes.ReplaceAll(data, []byte("\r\n"), nil), []byte("\n")) {
+		t.Error("the mail has lines that end in \\n alone, want \\r\\n")
+	}
+	if bytes.IndexFunc(data, func(r rune) bool { return r > 127 }) >= 0 {
+		t.Error("the mail is not 7-bit ASCII")
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("the mail cannot be read: %v\n%s", err, data)
+	}
+	h := msg.Header
+	if h.Get("Bcc") != "" {
+		t.Error("a CRLF in the subject made a header of its own")
+	}
+	if got, err := mail.ParseAddress(h.Get("To")); err != nil || *got != to {
+		t.Errorf("To: %q, want %v", h.Get("To"), to.String())
+	}
+	if got, _ := new(mime.WordDecoder).DecodeHeader(h.Get("Subject")); got != subject {
+		t.Errorf("Subject: %q decodes to %q, want %q", h.Get("Subject"), got, subject)
+	}
+	if got, _ := h.Date(); !got.Equal(date) {
+		t.Errorf("Date: %q, want %v", h.Get("Date"), date)
+	}
+	ctype, params, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	if h.Get("MIME-Version") != "1.0" || ctype != "text/plain" || !strings.EqualFold(params["charset"], "utf-8") ||
+		h.Get("Content-Transfer-Encoding") != "quoted-printable" {
+		t.Errorf("MIME-Version %q, Content-Type %q, Content-Transfer-Encoding %q; want 1.0, text/plain; charset=utf-8 and quoted-printable",
+			h.Get("MIME-Version"), h.Get("Content-Type"), h.Get("Content-Transfer-Encoding"))
+	}
+	got, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if strings.ReplaceAll(string(got), "\r\n", "\n") != body {
+		t.Errorf("the body decodes to %q, want %q", got, body)
+	}
+}
+
+func testSink(t *testing.T) *mailer.Sink {
+	t.Helper()
+	sink, err := mailer.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Users = map[string]string{"u": "p"}
+	sink.Refuse = func(rcpt string) error {
+		if strings.HasPrefix(rcpt, "busy") {
+			return &textproto.Error{Code: 452, Msg: "4.2.2 busy"}
+		}
+		if strings.HasPrefix(rcpt, "gone") {
+			return &textproto.Error{Code: 550, Msg: "5.1.1 gone"}
+		}
+		return nil
+	}
+	go sink.Serve()
+	t.Cleanup(func() { sink.Close() })
+	return sink
+}
+
+// refused recipients are returned, the others get the mail, and the session goes on
+func TestSend(t *testing.T) {
+	sink := testSink(t)
+	c, err := dial(sink, "u", "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() }) // before the sink's: Close waits for the session
+	msg := []byte("Subject: hi\r\n\r\nhello\r\n")
+	refused, err := send(c, "me@example.com", []string{"a@example.com", "gone@example.com", "busy@example.com", "b@example.com"}, msg)
+	if err != nil {
+		t.Fatalf("send to 4 with 2 refused: %v, want no error", err)
+	}
+	if len(refused) != 2 || !temporary(refused["busy@example.com"]) || refused["gone@example.com"] == nil || temporary(refused["gone@example.com"]) {
+		t.Errorf("refused %v, want gone@ for good and busy@ for now", refused)
+	}
+	if _, err := send(c, "me@example.com", []string{"gone@example.com"}, msg); err == nil {
+		t.Error("send with every recipient refused: no error")
+	}
+	if _, err := send(c, "me@example.com", []string{"c@example.com"}, msg); err != nil {
+		t.Errorf("send after a mail that went to nobody: %v, want the session to go on", err)
+	}
+	c.Quit()
+	sink.Close()
+	mails := sink.Mails()
+	if len(mails) != 2 || strings.Join(mails[0].To, " ") != "a@example.com b@example.com" || !bytes.Contains(mails[0].Data, []byte("hello")) {
+		t.Errorf("the sink got %v, want 2 mails, the first to a@ and b@", mails)
+	}
+}
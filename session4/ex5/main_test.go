@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// SimpleServer answers hello, world in a heading
+func TestSimpleServer(t *testing.T) {
+	w := httptest.NewRecorder()
+	SimpleServer(w, httptest.NewRequest("GET", "/test1", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "<h1>hello, world</h1>" {
+		t.Errorf("GET /test1: %d %q", w.Code, w.Body.String())
+	}
+}
+
+// FormServer shows the form on GET, as HTML
+func TestFormServerGet(t *testing.T) {
+	w := httptest.NewRecorder()
+	FormServer(w, httptest.NewRequest("GET", "/test2", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "text/html" {
+		t.Errorf("GET /test2: Content-Type %q, want text/html", ct)
+	}
+	if !strings.Contains(w.Body.String(), `<input type="text" name="in"/>`) {
+		t.Errorf("GET /test2: no input named in in %q", w.Body.String())
+	}
+}
+
+// FormServer answers a POST with the value of the field in
+func TestFormServerPost(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/test2", strings.NewReader(url.Values{"in": {"gopher"}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	FormServer(w, r)
+	if w.Body.String() != "gopher" {
+		t.Errorf("POST /test2 with in=gopher: body %q, want %q", w.Body.String(), "gopher")
+	}
+}
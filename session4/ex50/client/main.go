@@ -0,0 +1,172 @@
+// a dig of our own: net.Resolver lookups of addresses, MX and TXT records, with timeouts
+//
+// The net package answers DNS questions with the functions of a
+// net.Resolver: LookupHost for the addresses of a name (A and AAAA),
+// LookupMX for where its mail goes, LookupTXT for the texts that SPF,
+// domain checks and the like keep there. net.DefaultResolver asks the
+// servers of /etc/resolv.conf; a Resolver of our own, with PreferGo and a
+// Dial function, asks whichever server we like, here the one of
+// session4/ex50/server.
+//
+// A lookup can take long: a server that does not answer costs several
+// tries. The context of every lookup limits it, and the three run at the
+// same time, so that the report takes as long as the slowest of them.
+// Failures are *net.DNSError values that say whether the name does not
+// exist (IsNotFound) or the server did not answer in time (IsTimeout).
+//
+// session8/ex3 takes this further: many domains at once under a deadline
+// for all of them, against the real DNS, with a table for the report.
+//
+// try: start session4/ex50/server, then
+//
+//	go run . example.test mail.example.test nope.example.test slow.example.test
+//	go run . -server '' go.dev
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// section is the outcome of one lookup
+type section struct {
+	kind    string // A/AAAA, MX or TXT
+	records []string
+	err     error
+	took    time.Duration
+}
+
+// newResolver returns a resolver that asks the DNS server at addr, or
+// the system's servers for addr "", and waits at most timeout for one
+// connection to it
+func newResolver(addr string, timeout time.Duration) *net.Resolver {
+	if addr == "" {
+		return net.DefaultResolver
+	}
+	// HINT: PreferGo uses Go's own resolver, the one that calls Dial
+	// HINT: Dial gets the address of the system's server: dial addr instead, with a net.Dialer
+	// SOLUTION-START return net.DefaultResolver
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: timeout}
+			return d.DialContext(ctx, network, addr) // "udp", or "tcp" for long answers
+		},
+	}
+	// SOLUTION-END
+}
+
+// lookups are what the report shows, in order
+var lookups = []struct {
+	kind string
+	find func(ctx context.Context, r *net.Resolver, name string) ([]string, error)
+}{
+	{"A/AAAA", func(ctx context.Context, r *net.Resolver, name string) ([]string, error) {
+		return r.LookupHost(ctx, name)
+	}},
+	{"MX", func(ctx context.Context, r *net.Resolver, name string) ([]string, error) {
+		mxs, err := r.LookupMX(ctx, name) // sorted by preference
+		var list []string
+		for _, mx := range mxs {
+			list = append(list, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+		return list, err
+	}},
+	{"TXT", func(ctx context.Context, r *net.Resolver, name string) ([]string, error) {
+		texts, err := r.LookupTXT(ctx, name)
+		for i, t := range texts {
+			texts[i] = fmt.Sprintf("%q", t)
+		}
+		return texts, err
+	}},
+}
+
+// lookup looks name up with r, every kind of record at the same time,
+// each for at most timeout
+func lookup(ctx context.Context, r *net.Resolver, name string, timeout time.Duration) []section {
+	sections := make([]section, len(lookups))
+	run := func(i int) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		start := time.Now()
+		records, err := lookups[i].find(ctx, r, name)
+		sections[i] = section{lookups[i].kind, records, err, time.Since(start)}
+	}
+	var wg sync.WaitGroup
+	// HINT: every goroutine writes its own element of sections: no mutex is needed, wg is
+	for i := range lookups {
+		// SOLUTION-START run(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run(i)
+		}()
+		// SOLUTION-END
+	}
+	wg.Wait()
+	return sections
+}
+
+// describe says what went wrong with a lookup, in the words of dig
+func describe(err error) string {
+	var de *net.DNSError
+	switch {
+	case errors.As(err, &de) && de.IsNotFound:
+		return "NXDOMAIN or no records of this type"
+	case errors.As(err, &de) && de.IsTimeout:
+		return "timed out"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed out"
+	}
+	return err.Error()
+}
+
+// report writes the sections of name the way dig does
+func report(w io.Writer, name string, sections []section) {
+	fmt.Fprintf(w, ";; %s\n", name)
+	for _, s := range sections {
+		fmt.Fprintf(w, ";; %s, %v\n", s.kind, s.took.Round(time.Millisecond))
+		if s.err != nil {
+			fmt.Fprintf(w, ";   %s\n", describe(s.err))
+		}
+		for _, rec := range s.records {
+			typ := s.kind
+			if typ == "A/AAAA" {
+				typ = "A"
+				if strings.Contains(rec, ":") {
+					typ = "AAAA"
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", strings.TrimSuffix(name, ".")+".", typ, rec)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+func main() {
+	server := flag.String("server", "localhost:3053", "DNS server to ask, '' for the system's")
+	timeout := flag.Duration("timeout", 2*time.Second, "longest lookup")
+	flag.Parse()
+	names := flag.Args()
+	if len(names) == 0 {
+		names = []string{"example.test"}
+	}
+	r := newResolver(*server, *timeout)
+	failed := false
+	for _, name := range names {
+		sections := lookup(context.Background(), r, name, *timeout)
+		report(os.Stdout, name, sections)
+		failed = failed || sections[0].err != nil
+	}
+	if failed {
+		os.Exit(1) // a name without an address
+	}
+}
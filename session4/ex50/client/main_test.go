@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeDNS answers for t.test., never for slow.test., and NXDOMAIN for the rest
+func fakeDNS(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var req dnsmessage.Message
+			if req.Unpack(buf[:n]) != nil || len(req.Questions) == 0 {
+				continue
+			}
+			q := req.Questions[0]
+			resp := dnsmessage.Message{Header: dnsmessage.Header{ID: req.ID, Response: true, Authoritative: true}, Questions: req.Questions}
+			h := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+			switch {
+			case q.Name.String() == "slow.test.":
+				continue
+			case q.Name.String() != "t.test.":
+				resp.RCode = dnsmessage.RCodeNameError
+			case q.Type == dnsmessage.TypeA:
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: h, Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}}})
+			case q.Type == dnsmessage.TypeMX:
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: h, Body: &dnsmessage.MXResource{Pref: 5, MX: dnsmessage.MustNewName("mx.t.test.")}})
+			case q.Type == dnsmessage.TypeTXT:
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{Header: h, Body: &dnsmessage.TXTResource{TXT: []string{"hello"}}})
+			}
+			out, _ := resp.Pack()
+			conn.WriteToUDP(out, from)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// the resolver asks the server it was given
+func TestResolver(t *testing.T) {
+	r := newResolver(fakeDNS(t), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if addrs, err := r.LookupHost(ctx, "t.test."); err != nil || len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+		t.Errorf("LookupHost(t.test.): %v, %v; want [192.0.2.1] from the server given", addrs, err)
+	}
+}
+
+// the three lookups run at the same time, and the report shows them all
+func TestLookup(t *testing.T) {
+	r := newResolver(fakeDNS(t), time.Second)
+	sections := lookup(context.Background(), r, "t.test.", time.Second)
+	var b bytes.Buffer
+	report(&b, "t.test.", sections)
+	for _, want := range []string{"t.test.\tA\t192.0.2.1", "t.test.\tMX\t5 mx.t.test.", "t.test.\tTXT\t\"hello\""} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("the report of t.test. has no line %q:\n%s", want, &b)
+		}
+	}
+	start := time.Now()
+	sections = lookup(context.Background(), r, "slow.test.", 300*time.Millisecond)
+	if took := time.Since(start); took > 700*time.Millisecond {
+		t.Errorf("3 lookups of 300ms each took %v: they do not run at the same time", took)
+	}
+	for _, s := range sections {
+		if s.err == nil || describe(s.err) != "timed out" {
+			t.Errorf("%s of slow.test.: %v, want a timeout", s.kind, s.err)
+		}
+	}
+	sections = lookup(context.Background(), r, "nope.test.", time.Second)
+	if err := sections[0].err; err == nil || !strings.Contains(describe(err), "NXDOMAIN") {
+		t.Errorf("nope.test.: %v, want NXDOMAIN", err)
+	}
+}
@@ -0,0 +1,168 @@
+// a DNS server of our own: the zone example.test over UDP, for session4/ex50/client to look up
+//
+// DNS is a protocol on UDP (session4/ex47): the question goes out in one
+// datagram, the answer comes back in one. golang.org/x/net/dns/dnsmessage
+// reads and writes the binary messages. The server knows the names of
+// the zone below and answers for them with authority:
+//
+//   - records of the type asked for, when the name has some
+//   - no records but no error (NOERROR, "NODATA") when the name exists
+//     with records of other types only
+//   - NXDOMAIN when there is no such name at all
+//
+// The difference matters to resolvers, which remember both. A question
+// about slow.example.test is answered after -delay, to try the
+// client's timeouts on.
+//
+// try: go run . and go run ../client, or dig @localhost -p 3053 example.test MX
+package main
+
+import (
+	"errors"
+	"flag"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+var log = logger.New("dns")
+
+// ttl is how long, in seconds, a resolver may keep an answer
+const ttl = 300
+
+func header(name string, typ dnsmessage.Type) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: typ, Class: dnsmessage.ClassINET, TTL: ttl}
+}
+
+func a(name, ip string) dnsmessage.Resource {
+	return dnsmessage.Resource{Header: header(name, dnsmessage.TypeA), Body: &dnsmessage.AResource{A: [4]byte(net.ParseIP(ip).To4())}}
+}
+
+func aaaa(name, ip string) dnsmessage.Resource {
+	return dnsmessage.Resource{Header: header(name, dnsmessage.TypeAAAA), Body: &dnsmessage.AAAAResource{AAAA: [16]byte(net.ParseIP(ip))}}
+}
+
+func mx(name string, pref uint16, host string) dnsmessage.Resource {
+	return dnsmessage.Resource{Header: header(name, dnsmessage.TypeMX), Body: &dnsmessage.MXResource{Pref: pref, MX: dnsmessage.MustNewName(host)}}
+}
+
+func txt(name string, texts ...string) dnsmessage.Resource {
+	return dnsmessage.Resource{Header: header(name, dnsmessage.TypeTXT), Body: &dnsmessage.TXTResource{TXT: texts}}
+}
+
+// zone is every record the server knows; names end in a dot
+var zone = []dnsmessage.Resource{
+	a("example.test.", "192.0.2.10"),
+	a("example.test.", "192.0.2.11"),
+	aaaa("example.test.", "2001:db8::10"),
+	mx("example.test.", 10, "mail.example.test."),
+	mx("example.test.", 20, "backup.example.test."),
+	txt("example.test.", "v=spf1 mx -all"),
+	txt("example.test.", "training=go"),
+	a("mail.example.test.", "192.0.2.25"),
+	a("backup.example.test.", "198.51.100.25"),
+	a("slow.example.test.", "192.0.2.99"),
+}
+
+// answer returns the answer to the message req
+func answer(req []byte) (dnsmessage.Message, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(req)
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+	q, err := p.Question() // there is only ever one
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true, RecursionDesired: h.RecursionDesired},
+		Questions: []dnsmessage.Question{q},
+	}
+	// HINT: names are case-insensitive: strings.EqualFold compares q.Name.String() with a record's
+	// HINT: a name without the records asked for still exists if it has others: then no RCode is set
+	// SOLUTION-START resp.RCode = dnsmessage.RCodeNotImplemented
+	known := false
+	for _, r := range zone {
+		if !strings.EqualFold(r.Header.Name.String(), q.Name.String()) {
+			continue
+		}
+		known = true
+		if r.Header.Type == q.Type && q.Class == dnsmessage.ClassINET {
+			resp.Answers = append(resp.Answers, r)
+		}
+	}
+	if !known {
+		resp.RCode = dnsmessage.RCodeNameError // NXDOMAIN
+	}
+	// SOLUTION-END
+	return resp, nil
+}
+
+// serve answers the questions that come to conn until it is closed; the
+// ones about slow.example.test after delay
+func serve(conn *net.UDPConn, delay time.Duration) error {
+	buf := make([]byte, 512) // the most a plain DNS message over UDP may have
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp, err := answer(buf[:n])
+		if err != nil {
+			log.Info("bad question", "from", from.String(), "err", err)
+			continue // not DNS at all: no answer
+		}
+		q := resp.Questions[0]
+		log.Info("question", "from", from.String(), "name", q.Name.String(), "type", q.Type.String(), "rcode", resp.RCode.String(), "answers", len(resp.Answers))
+		out, err := resp.Pack()
+		if err != nil {
+			log.Warn("pack", "err", err)
+			continue
+		}
+		if strings.EqualFold(q.Name.String(), "slow.example.test.") {
+			go func() {
+				time.Sleep(delay)
+				conn.WriteToUDP(out, from)
+			}()
+			continue
+		}
+		conn.WriteToUDP(out, from)
+	}
+}
+
+func main() {
+	delay := flag.Duration("delay", 5*time.Second, "how long the answers about slow.example.test take")
+	addr := config.Addr("localhost:3053")
+	flag.Parse()
+	ua, err := net.ResolveUDPAddr("udp", *addr)
+	if err != nil {
+		log.Fatal("resolve", "addr", *addr, "err", err)
+	}
+	conn, err := net.ListenUDP("udp", ua)
+	if err != nil {
+		log.Fatal("listen", "err", err)
+	}
+	log.Info("listening", "addr", conn.LocalAddr().String(), "zone", "example.test")
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, graceful.Signals...)
+	go func() {
+		<-stop
+		conn.Close()
+	}()
+	if err := serve(conn, *delay); err != nil {
+		log.Fatal("read", "err", err)
+	}
+	log.Info("stopped")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+func ask(t *testing.T, name string, typ dnsmessage.Type) dnsmessage.Message {
+	t.Helper()
+	req := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: 42, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName(name), Type: typ, Class: dnsmessage.ClassINET}},
+	}
+	b, err := req.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := answer(b)
+	if err != nil {
+		t.Fatalf("%s %v: %v", name, typ, err)
+	}
+	return resp
+}
+
+// the records asked for, none for a name without them, NXDOMAIN for no such name
+func TestAnswer(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		typ     dnsmessage.Type
+		rcode   dnsmessage.RCode
+		answers int
+	}{
+		{"example.test.", dnsmessage.TypeA, dnsmessage.RCodeSuccess, 2},
+		{"Example.TEST.", dnsmessage.TypeMX, dnsmessage.RCodeSuccess, 2},
+		{"example.test.", dnsmessage.TypeAAAA, dnsmessage.RCodeSuccess, 1},
+		{"mail.example.test.", dnsmessage.TypeMX, dnsmessage.RCodeSuccess, 0},
+		{"nope.example.test.", dnsmessage.TypeA, dnsmessage.RCodeNameError, 0},
+	} {
+		resp := ask(t, tc.name, tc.typ)
+		if resp.RCode != tc.rcode || len(resp.Answers) != tc.answers || resp.ID != 42 || !resp.Response || !resp.Authoritative {
+			t.Errorf("%s %v: %v with %d answers, want an authoritative response to ID 42 with %v and %d answers",
+				tc.name, tc.typ, resp.RCode, len(resp.Answers), tc.rcode, tc.answers)
+		}
+		for _, r := range resp.Answers {
+			if r.Header.Type != tc.typ {
+				t.Errorf("%s %v: an answer of type %v", tc.name, tc.typ, r.Header.Type)
+			}
+		}
+	}
+}
+
+// Go's own resolver gets its answers from serve
+func TestServe(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go serve(conn, time.Hour)
+	r := &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "udp", conn.LocalAddr().String())
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	mxs, err := r.LookupMX(ctx, "example.test.")
+	if err != nil || len(mxs) != 2 || mxs[0].Host != "mail.example.test." {
+		t.Errorf("LookupMX(example.test.): %v, %v; want mail.example.test. first of 2", mxs, err)
+	}
+	if _, err := r.LookupHost(ctx, "nope.example.test."); err == nil || !err.(*net.DNSError).IsNotFound {
+		t.Errorf("LookupHost(nope.example.test.): %v, want not found", err)
+	}
+}
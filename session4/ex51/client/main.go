@@ -0,0 +1,309 @@
+// a parallel downloader: a file fetched in parts with Range requests, put together and checked
+//
+// HEAD tells the size of the file and whether the server takes
+// "Range: bytes=first-last" requests. If it does, the file is split into
+// -n parts, each fetched by a goroutine of its own with a GET for its
+// range, which the server answers with 206 Partial Content. Every part is
+// written straight to its place in the output file with WriteAt: there is
+// nothing to put together afterwards, and the parts may finish in any
+// order. The first part that fails cancels the others.
+//
+// A server that sends whole files only (200 instead of 206) gets one
+// plain GET. In the end the SHA-256 of the file is compared with the one
+// the server publishes next to it: a download is not done before it is
+// checked.
+//
+// try: start session4/ex51/server, then go run . -n 1 and go run . -n 8
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// chunk is a part of the file, from first to last byte, both included
+// as in a Range header
+type chunk struct {
+	index       int
+	first, last int64
+}
+
+func (c chunk) size() int64 { return c.last - c.first + 1 }
+
+// progress says that chunk index has done bytes of its size
+type progress struct {
+	index int
+	done  int64
+}
+
+// probe returns the size of the file at url, and whether the server
+// sends parts of it
+func probe(ctx context.Context, c *http.Client, url string) (size int64, ranges bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return 0, false, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, fmt.Errorf("HEAD %s: %s", url, resp.Status)
+	}
+	if resp.ContentLength < 0 {
+		return 0, false, fmt.Errorf("HEAD %s: no Content-Length", url)
+	}
+	return resp.ContentLength, resp.Header.Get("Accept-Ranges") == "bytes", nil
+}
+
+// split cuts size bytes into n chunks, or fewer when there are not
+// enough bytes; their sizes differ by one byte at most
+func split(size int64, n int) []chunk {
+	// HINT: the first size%n chunks get one byte more than size/n
+	// SOLUTION-START return []chunk{{0, 0, size - 1}}
+	n = int(min(int64(n), size))
+	chunks := make([]chunk, n)
+	first := int64(0)
+	for i := range chunks {
+		length := size / int64(n)
+		if int64(i) < size%int64(n) {
+			length++
+		}
+		chunks[i] = chunk{i, first, first + length - 1}
+		first += length
+	}
+	return chunks
+	// SOLUTION-END
+}
+
+// counter reports every write to progress, as the total so far
+type counter struct {
+	index    int
+	done     int64
+	progress chan<- progress
+}
+
+func (c *counter) Write(p []byte) (int, error) {
+	c.done += int64(len(p))
+	if c.progress != nil {
+		c.progress <- progress{c.index, c.done}
+	}
+	return len(p), nil
+}
+
+// fetch gets chunk ch of the file at url and writes it to its place in
+// f, reporting to progress as it goes
+func fetch(ctx context.Context, c *http.Client, url string, ch chunk, f io.WriterAt, progress chan<- progress) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	// HINT: the answer to a Range request is 206 with a Content-Range "bytes first-last/size"
+	// HINT: io.NewOffsetWriter(f, ch.first) writes from ch.first on; io.MultiWriter also counts
+	// SOLUTION-START return fmt.Errorf("%s %s: not implemented", req.Method, req.URL)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", ch.first, ch.last))
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("chunk %d: %s, want 206 Partial Content", ch.index, resp.Status)
+	}
+	if cr := resp.Header.Get("Content-Range"); !strings.HasPrefix(cr, fmt.Sprintf("bytes %d-%d/", ch.first, ch.last)) {
+		return fmt.Errorf("chunk %d: Content-Range %q, want bytes %d-%d", ch.index, cr, ch.first, ch.last)
+	}
+	w := io.MultiWriter(io.NewOffsetWriter(f, ch.first), &counter{index: ch.index, progress: progress})
+	n, err := io.Copy(w, io.LimitReader(resp.Body, ch.size()))
+	if err != nil {
+		return fmt.Errorf("chunk %d: %w", ch.index, err)
+	}
+	if n != ch.size() {
+		return fmt.Errorf("chunk %d: %d bytes, want %d", ch.index, n, ch.size())
+	}
+	return nil
+	// SOLUTION-END
+}
+
+// download fetches all chunks of the file at url into f at the same
+// time. The first error cancels the rest, and is returned.
+func download(ctx context.Context, c *http.Client, url string, chunks []chunk, f io.WriterAt, progress chan<- progress) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var wg sync.WaitGroup
+	// HINT: cancel(err) keeps the first cause only; context.Cause(ctx) returns it
+	for _, ch := range chunks {
+		// SOLUTION-START fetch(ctx, c, url, ch, f, progress)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fetch(ctx, c, url, ch, f, progress); err != nil {
+				cancel(err) // the others end with context.Canceled
+			}
+		}()
+		// SOLUTION-END
+	}
+	wg.Wait()
+	if err := context.Cause(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	return ctx.Err() // the caller's context, or nil
+}
+
+// whole fetches the file at url in one GET, for servers without ranges
+func whole(ctx context.Context, c *http.Client, url string, f io.Writer, progress chan<- progress) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	_, err = io.Copy(io.MultiWriter(f, &counter{progress: progress}), resp.Body)
+	return err
+}
+
+// checksum returns the SHA-256 that the server publishes at url+".sha256"
+func checksum(ctx context.Context, c *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+".sha256", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s.sha256: %s", url, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	if err != nil {
+		return "", err
+	}
+	sum, _, _ := strings.Cut(string(b), " ") // "<hex>  <name>", as sha256sum writes it
+	return strings.TrimSpace(sum), nil
+}
+
+// verify reports whether the SHA-256 of r is want
+func verify(r io.Reader, want string) (bool, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return false, err
+	}
+	return hex.EncodeToString(h.Sum(nil)) == want, nil
+}
+
+// show prints the progress of every chunk, every tick, until progress is
+// closed
+func show(progress <-chan progress, sizes []int64, tick time.Duration) {
+	done := make([]int64, len(sizes))
+	t := time.NewTicker(tick)
+	defer t.Stop()
+	line := func() {
+		var b strings.Builder
+		for i, d := range done {
+			fmt.Fprintf(&b, " %3d%%", d*100/max(sizes[i], 1))
+		}
+		fmt.Fprintf(os.Stderr, "\rchunks:%s", b.String())
+	}
+	for {
+		select {
+		case p, ok := <-progress:
+			if !ok {
+				line()
+				fmt.Fprintln(os.Stderr)
+				return
+			}
+			done[p.index] = p.done
+		case <-t.C:
+			line()
+		}
+	}
+}
+
+func main() {
+	n := flag.Int("n", 4, "number of parts fetched at the same time")
+	out := flag.String("o", "big.bin", "file to write")
+	flag.Parse()
+	url := "http://localhost:3000/big.bin"
+	if flag.NArg() > 0 {
+		url = flag.Arg(0)
+	}
+	ctx := context.Background()
+	c := &http.Client{} // no Timeout: that would limit the whole download; ctx can end it
+	size, ranges, err := probe(ctx, c, url)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	ranges = ranges && size > 0
+	chunks := []chunk{{0, 0, size - 1}}
+	if ranges {
+		chunks = split(size, *n)
+	}
+	sizes := make([]int64, len(chunks))
+	for i, ch := range chunks {
+		sizes[i] = ch.size()
+	}
+	progress := make(chan progress, 64)
+	shown := make(chan struct{})
+	go func() {
+		defer close(shown)
+		show(progress, sizes, 200*time.Millisecond)
+	}()
+	start := time.Now()
+	if ranges {
+		err = download(ctx, c, url, chunks, f, progress)
+	} else {
+		fmt.Fprintln(os.Stderr, "the server sends no parts: one GET for all")
+		err = whole(ctx, c, url, f, progress)
+	}
+	close(progress)
+	<-shown
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	took := time.Since(start)
+	fmt.Printf("%s: %d bytes in %d parts, %v, %.1f MB/s\n", *out, size, len(chunks), took.Round(time.Millisecond), float64(size)/took.Seconds()/1e6)
+
+	want, err := checksum(ctx, c, url)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "no checksum to compare with:", err)
+		return
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	ok, err := verify(f, want)
+	if err != nil || !ok {
+		fmt.Fprintln(os.Stderr, "SHA-256 does not match", err)
+		os.Exit(1)
+	}
+	fmt.Printf("SHA-256 %s OK\n", want)
+}
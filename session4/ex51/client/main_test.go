@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// split covers every byte once, in chunks that differ by one byte at most
+func TestSplit(t *testing.T) {
+	for _, tc := range []struct {
+		size int64
+		n    int
+	}{{100, 4}, {101, 4}, {7, 3}, {3, 8}, {1, 1}, {1 << 30, 16}} {
+		chunks := split(tc.size, tc.n)
+		if want := int(min(tc.size, int64(tc.n))); len(chunks) != want {
+			t.Errorf("split(%d, %d): %d chunks, want %d", tc.size, tc.n, len(chunks), want)
+			continue
+		}
+		next := int64(0)
+		for i, ch := range chunks {
+			if ch.index != i || ch.first != next || ch.size() < 1 || ch.size()-chunks[len(chunks)-1].size() > 1 {
+				t.Errorf("split(%d, %d): chunk %d is %+v after byte %d", tc.size, tc.n, i, ch, next-1)
+			}
+			next = ch.last + 1
+		}
+		if next != tc.size {
+			t.Errorf("split(%d, %d): the chunks end at byte %d", tc.size, tc.n, next-1)
+		}
+	}
+}
+
+// server serves data with http.ServeContent, after before(r) returns true
+func server(t *testing.T, data []byte, before func(r *http.Request) bool) (*httptest.Server, *atomic.Int32) {
+	var ranges atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			ranges.Add(1)
+		}
+		if before != nil && !before(r) {
+			http.Error(w, "broken", http.StatusInternalServerError)
+			return
+		}
+		http.ServeContent(w, r, "f.bin", time.Time{}, bytes.NewReader(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &ranges
+}
+
+func run(t *testing.T, url string, chunks []chunk) ([]byte, error, time.Duration) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "f.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	progress := make(chan progress)
+	go func() {
+		for range progress {
+		}
+	}()
+	start := time.Now()
+	err = download(context.Background(), http.DefaultClient, url, chunks, f, progress)
+	took := time.Since(start)
+	close(progress)
+	got, _ := os.ReadFile(f.Name())
+	return got, err, took
+}
+
+// every chunk is one Range request, written to its place in the file
+func TestDownload(t *testing.T) {
+	data := make([]byte, 100_003)
+	rand.Read(data)
+	srv, ranges := server(t, data, nil)
+	got, err, _ := run(t, srv.URL, split(int64(len(data)), 5))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("download in 5 chunks: %d bytes, %v; want the %d bytes sent", len(got), err, len(data))
+	}
+	if n := ranges.Load(); n != 5 {
+		t.Errorf("download in 5 chunks made %d Range requests", n)
+	}
+	if ok, err := verify(bytes.NewReader(got), "0"); ok || err != nil {
+		t.Errorf("verify with a wrong checksum: %v, %v", ok, err)
+	}
+}
+
+// the chunks are fetched at the same time
+func TestParallel(t *testing.T) {
+	data := make([]byte, 4000)
+	srv, _ := server(t, data, func(*http.Request) bool {
+		time.Sleep(300 * time.Millisecond)
+		return true
+	})
+	if got, err, took := run(t, srv.URL, split(4000, 4)); err != nil || len(got) != 4000 || took > 900*time.Millisecond {
+		t.Errorf("4 chunks that take 300ms each: %d bytes, %v after %v; want 4000 bytes at once", len(got), err, took.Round(time.Millisecond))
+	}
+}
+
+// a failing chunk, or a server that ignores Range, cancels the download with an error
+func TestFailure(t *testing.T) {
+	data := make([]byte, 4000)
+	srv, _ := server(t, data, func(r *http.Request) bool {
+		if strings.HasPrefix(r.Header.Get("Range"), "bytes=2000-") {
+			return false
+		}
+		<-r.Context().Done() // the others hang until they are cancelled
+		return false
+	})
+	_, err, took := run(t, srv.URL, split(4000, 4))
+	if err == nil || !strings.Contains(err.Error(), "500") || took > 2*time.Second {
+		t.Errorf("a chunk answered 500, the others hang: %v after %v; want the 500 at once", err, took.Round(time.Millisecond))
+	}
+	whole := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write(data) }))
+	defer whole.Close()
+	if _, err, _ := run(t, whole.URL, split(4000, 2)); err == nil {
+		t.Error("a server that answers a Range request with 200 and everything: no error")
+	}
+}
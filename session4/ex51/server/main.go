@@ -0,0 +1,90 @@
+// a large file to download in parts: http.ServeContent answers Range requests
+//
+// GET /big.bin sends a file of -size bytes, made up when the server
+// starts; GET /big.bin.sha256 sends its SHA-256, the way download sites
+// publish checksums. http.ServeContent does what a file server needs: it
+// answers HEAD with the length, says "Accept-Ranges: bytes", and answers
+// a request with "Range: bytes=1000-1999" with 206 Partial Content and
+// those bytes only. That is what lets session4/ex51/client fetch the file
+// in parts at the same time, and resume a broken download.
+//
+// -rate limits every response to so many bytes a second, as a server
+// that limits each connection does: then parts in parallel are faster.
+//
+// try: curl -I localhost:3000/big.bin; curl -r 0-15 localhost:3000/big.bin | xxd
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+)
+
+var log = logger.New("web")
+
+// made is when the file was made, its Last-Modified
+var made = time.Now()
+
+// content returns size bytes that are the same for the same seed
+func content(size int, seed uint64) []byte {
+	r := rand.New(rand.NewPCG(seed, seed))
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = byte(r.Uint32())
+	}
+	return b
+}
+
+// slowReader reads from r at most rate bytes a second
+type slowReader struct {
+	io.ReadSeeker
+	rate int // 0: no limit
+}
+
+func (s slowReader) Read(p []byte) (int, error) {
+	n, err := s.ReadSeeker.Read(p)
+	if s.rate > 0 {
+		time.Sleep(time.Duration(n) * time.Second / time.Duration(s.rate)) // as long as n bytes take at rate
+	}
+	return n, err
+}
+
+// fileHandler serves data, whole or in ranges, at most rate bytes a
+// second per response
+func fileHandler(name string, data []byte, rate int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Info("get", "range", r.Header.Get("Range"), "method", r.Method)
+		// HINT: http.ServeContent reads the Range header and seeks the io.ReadSeeker to what was asked for
+		// SOLUTION-START bytes.NewReader(data).WriteTo(w)
+		http.ServeContent(w, r, name, made, slowReader{bytes.NewReader(data), rate})
+		// SOLUTION-END
+	}
+}
+
+func main() {
+	size := flag.Int("size", 32<<20, "size of the file in bytes")
+	rate := flag.Int("rate", 4<<20, "bytes per second per response, 0 for no limit")
+	addr := config.Addr("localhost:3000")
+	flag.Parse()
+	data := content(*size, 1)
+	sum := sha256.Sum256(data)
+	mux := http.NewServeMux()
+	mux.Handle("GET /big.bin", fileHandler("big.bin", data, *rate))
+	mux.HandleFunc("GET /big.bin.sha256", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, hex.EncodeToString(sum[:])+"  big.bin\n") // the format of sha256sum
+	})
+	log.Info("listening", "url", "http://"+*addr+"/big.bin", "size", *size, "rate", *rate)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), mux)}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/logger"
+)
+
+func init() {
+	logger.SetOutput(io.Discard)
+}
+
+// HEAD says the length and that ranges are served; a Range gets 206 and those bytes
+func TestRanges(t *testing.T) {
+	data := content(1000, 7)
+	h := fileHandler("f.bin", data, 0)
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest("HEAD", "/f.bin", nil))
+	if w.Header().Get("Accept-Ranges") != "bytes" || w.Header().Get("Content-Length") != "1000" {
+		t.Errorf("HEAD: Accept-Ranges %q, Content-Length %q; want bytes and 1000", w.Header().Get("Accept-Ranges"), w.Header().Get("Content-Length"))
+	}
+	r := httptest.NewRequest("GET", "/f.bin", nil)
+	r.Header.Set("Range", "bytes=100-199")
+	w = httptest.NewRecorder()
+	h(w, r)
+	if w.Code != http.StatusPartialContent || w.Header().Get("Content-Range") != "bytes 100-199/1000" || !bytes.Equal(w.Body.Bytes(), data[100:200]) {
+		t.Errorf("GET with Range: bytes=100-199: %d, Content-Range %q, %d bytes; want 206, bytes 100-199/1000 and the 100 bytes",
+			w.Code, w.Header().Get("Content-Range"), w.Body.Len())
+	}
+	w = httptest.NewRecorder()
+	h(w, httptest.NewRequest("GET", "/f.bin", nil))
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), data) {
+		t.Errorf("GET without Range: %d with %d bytes, want 200 with all 1000", w.Code, w.Body.Len())
+	}
+}
@@ -0,0 +1,224 @@
+// a web crawler: pages fetched a few at a time, links followed to a depth, every URL once
+//
+// The crawler starts at one page, takes the links out of its HTML and
+// crawls the pages they lead to, on the same host only, until -depth
+// links away from the start. Every page is a goroutine of its own, but
+// only -workers of them fetch at a time: a semaphore, a buffered channel
+// that holds one token per fetch, keeps the crawler from flooding the
+// server. Two pages often link to the same third one; a set of the URLs
+// seen, behind a mutex, makes sure it is fetched once. A WaitGroup tells
+// when the last page is done, and Ctrl+C or -timeout cancel the context:
+// the goroutines that wait for a token give up, the fetches in flight end.
+//
+// The report lists every page with its status: a crawler finds the
+// broken links of a site.
+//
+// try: start session4/ex44 (or ex36), then go run ./session4/ex52
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+)
+
+// page is what the crawler found at a URL
+type page struct {
+	url    string
+	depth  int // links away from the start
+	status int // 0 when there was no answer
+	links  int // to pages of the same host
+	err    error
+}
+
+type crawler struct {
+	client   *http.Client
+	maxDepth int
+	host     string        // of the start page: other hosts are not crawled
+	tokens   chan struct{} // one per fetch in flight
+
+	mu    sync.Mutex
+	seen  map[string]bool
+	pages []page
+
+	wg sync.WaitGroup // one per page being crawled
+}
+
+func newCrawler(client *http.Client, workers, maxDepth int) *crawler {
+	return &crawler{client: client, maxDepth: maxDepth, tokens: make(chan struct{}, workers), seen: map[string]bool{}}
+}
+
+// links returns the absolute URLs of the <a href> links in the HTML of
+// r, without their #fragments; base is the URL of the page
+func links(base *url.URL, r io.Reader) []string {
+	var list []string
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return list // io.EOF, or HTML too broken to go on
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) != "href" {
+					continue
+				}
+				u, err := base.Parse(string(val)) // relative to the page
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+					continue // mailto:, javascript: and the like
+				}
+				u.Fragment = ""
+				list = append(list, u.String())
+			}
+		}
+	}
+}
+
+// visit records that rawURL is crawled; it returns false when it already
+// was
+func (c *crawler) visit(rawURL string) bool {
+	// HINT: several goroutines ask at the same time: checking and adding must be one step under c.mu
+	// SOLUTION-START return true
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.seen[rawURL] {
+		return false
+	}
+	c.seen[rawURL] = true
+	return true
+	// SOLUTION-END
+}
+
+// fetch gets the page at rawURL and returns it with the links it has to
+// the crawler's host
+func (c *crawler) fetch(ctx context.Context, rawURL string, depth int) (page, []string) {
+	p := page{url: rawURL, depth: depth}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		p.err = err
+		return p, nil
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		p.err = err
+		return p, nil
+	}
+	defer resp.Body.Close()
+	p.status = resp.StatusCode
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20)) // so that the connection can be used again
+		return p, nil
+	}
+	var found []string
+	for _, l := range links(resp.Request.URL, io.LimitReader(resp.Body, 4<<20)) { // the URL after redirects
+		if u, _ := url.Parse(l); u.Host == c.host {
+			found = append(found, l)
+		}
+	}
+	p.links = len(found)
+	return p, found
+}
+
+// crawl crawls rawURL, which is depth links away from the start, and the
+// pages it links to. It runs in a goroutine of its own, counted in c.wg.
+func (c *crawler) crawl(ctx context.Context, rawURL string, depth int) {
+	defer c.wg.Done()
+	// HINT: take a token from c.tokens before the fetch, in a select with ctx.Done(), and give it back after
+	// HINT: c.wg.Add(1) for a link before its goroutine starts, or Wait may return too early
+	// SOLUTION-START p, _ := c.fetch(ctx, rawURL, depth)
+	select {
+	case c.tokens <- struct{}{}:
+	case <-ctx.Done():
+		return // cancelled while waiting: the page is not in the report
+	}
+	p, found := c.fetch(ctx, rawURL, depth)
+	<-c.tokens
+	if depth < c.maxDepth {
+		for _, l := range found {
+			if c.visit(l) {
+				c.wg.Add(1)
+				go c.crawl(ctx, l, depth+1)
+			}
+		}
+	}
+	// SOLUTION-END
+	c.mu.Lock()
+	c.pages = append(c.pages, p)
+	c.mu.Unlock()
+}
+
+// run crawls from start until every page within the depth is done or ctx
+// ends, and returns the pages, nearest first
+func (c *crawler) run(ctx context.Context, start string) ([]page, error) {
+	u, err := url.Parse(start)
+	if err != nil {
+		return nil, err
+	}
+	c.host = u.Host
+	c.visit(start)
+	c.wg.Add(1)
+	go c.crawl(ctx, start, 0)
+	c.wg.Wait()
+	slices.SortFunc(c.pages, func(a, b page) int {
+		if a.depth != b.depth {
+			return a.depth - b.depth
+		}
+		return strings.Compare(a.url, b.url)
+	})
+	return c.pages, ctx.Err()
+}
+
+func main() {
+	depth := flag.Int("depth", 3, "how many links away from the start to crawl")
+	workers := flag.Int("workers", 4, "pages fetched at the same time")
+	timeout := flag.Duration("timeout", 30*time.Second, "time for the whole crawl")
+	flag.Parse()
+	start := "http://localhost:3000/"
+	if flag.NArg() > 0 {
+		start = flag.Arg(0)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), graceful.Signals...)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	c := newCrawler(&http.Client{Timeout: 10 * time.Second}, *workers, *depth)
+	began := time.Now()
+	pages, err := c.run(ctx, start)
+	broken := 0
+	for _, p := range pages {
+		switch {
+		case p.err != nil:
+			fmt.Printf("%d  ---  %s: %v\n", p.depth, p.url, p.err)
+			broken++
+		case p.status != http.StatusOK:
+			fmt.Printf("%d  %d  %s\n", p.depth, p.status, p.url)
+			broken++
+		default:
+			fmt.Printf("%d  %d  %s (%d links)\n", p.depth, p.status, p.url, p.links)
+		}
+	}
+	fmt.Printf("%d pages, %d broken, in %v\n", len(pages), broken, time.Since(began).Round(time.Millisecond))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "crawl stopped:", err)
+		os.Exit(1)
+	}
+}
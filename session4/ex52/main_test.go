@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// links resolves relative links, drops fragments and skips what is not http
+func TestLinks(t *testing.T) {
+	base, _ := url.Parse("http://site.test/a/b")
+	got := links(base, strings.NewReader(`<p><a href="c">c</a> <a class="x" href="/d#top">d</a>
+		<a href="mailto:me@site.test">mail</a> <A HREF="https://other.test/">other</A> <a name="no-href">x</a>`))
+	want := []string{"http://site.test/a/c", "http://site.test/d", "https://other.test/"}
+	if !slices.Equal(got, want) {
+		t.Errorf("links: %q, want %q", got, want)
+	}
+}
+
+// site serves a binary tree of pages: / links to /1, /n to /2n and /2n+1,
+// every page also back to / and to another host. It counts the requests
+// per path and the most at the same time.
+type site struct {
+	delay time.Duration
+	mu    sync.Mutex
+	hits  map[string]int
+	now   int
+	most  int
+}
+
+func (s *site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.hits[r.URL.Path]++
+	s.now++
+	s.most = max(s.most, s.now)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.now--
+		s.mu.Unlock()
+	}()
+	select {
+	case <-time.After(s.delay):
+	case <-r.Context().Done():
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+	if n == 0 {
+		fmt.Fprint(w, `<a href="/1">one</a> <a href="/1#again">one again</a>`)
+		return
+	}
+	fmt.Fprintf(w, `<a href="/">home</a> <a href="/%d">left</a> <a href="%d">right</a> <a href="http://other.test/%d">away</a>`, 2*n, 2*n+1, n)
+}
+
+func crawlSite(t *testing.T, s *site, ctx context.Context, workers, depth int) ([]page, error) {
+	t.Helper()
+	s.hits = map[string]int{}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return newCrawler(srv.Client(), workers, depth).run(ctx, srv.URL+"/")
+}
+
+// every page within the depth is fetched once, and nothing beyond it or on another host
+func TestCrawl(t *testing.T) {
+	s := &site{}
+	pages, err := crawlSite(t, s, context.Background(), 4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"/": 0, "/1": 1, "/2": 2, "/3": 2, "/4": 3, "/5": 3, "/6": 3, "/7": 3}
+	if len(s.hits) != len(want) {
+		t.Errorf("with depth 3 the crawler fetched %v, want each of /, /1 to /7 once", s.hits)
+	}
+	for path, n := range s.hits {
+		if _, ok := want[path]; !ok || n != 1 {
+			t.Errorf("%s was fetched %d times, want %d", path, n, map[bool]int{true: 1}[ok])
+		}
+	}
+	if len(pages) != len(want) {
+		t.Fatalf("the report has %d pages, want %d", len(pages), len(want))
+	}
+	for _, p := range pages {
+		u, _ := url.Parse(p.url)
+		if d, ok := want[u.Path]; !ok || p.depth != d || p.status != 200 {
+			t.Errorf("the report has %s at depth %d with %d, want depth %d and 200", p.url, p.depth, p.status, d)
+		}
+	}
+}
+
+// no more than -workers pages are fetched at a time, but that many are
+func TestBounded(t *testing.T) {
+	s := &site{delay: 30 * time.Millisecond}
+	if _, err := crawlSite(t, s, context.Background(), 3, 5); err != nil {
+		t.Fatal(err)
+	}
+	if s.most > 3 || s.most < 2 {
+		t.Errorf("with 3 workers, %d pages were fetched at the same time", s.most)
+	}
+}
+
+// a cancelled crawl ends soon, with the context's error
+func TestCancel(t *testing.T) {
+	s := &site{delay: 100 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := crawlSite(t, s, ctx, 2, 20)
+	if took := time.Since(start); err == nil || took > time.Second {
+		t.Errorf("a crawl of 2^20 pages with a 250ms timeout: %v after %v, want the context's error soon", err, took.Round(time.Millisecond))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0
+	for _, n := range s.hits {
+		total += n
+	}
+	if total > 20 {
+		t.Errorf("%d pages were requested in 250ms by 2 workers, want the rest to wait", total)
+	}
+}
@@ -1,13 +1,22 @@
 package main
+
 import (
+	"flag"
 	"fmt"
-	"log"
 	"net/http"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
 )
 
+var log = logger.New("web")
+
 type statistics struct {
 	numbers []float64
 	mean    float64
@@ -28,13 +37,15 @@ var pageTop = ""
 var pageBottom = ""
 
 func main() { // Define a root handler for requests to function homePage, and start the webserver combined with error-handling
+	addr := config.Addr(":3000")
+	flag.Parse()
 	http.HandleFunc("/", homePage)
- 	if err := http.ListenAndServe(":3000", nil); err != nil {
-		log.Fatal("failed to start server", err)
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil)}, 5*time.Second); err != nil {
+		log.Fatal("failed to start server", "err", err)
 	}
 }
 
-func homePage(writer http.ResponseWriter, request *http.Request) { // Write an HTML header, parse the form, write form to writer and make request for numbers 
+func homePage(writer http.ResponseWriter, request *http.Request) { // Write an HTML header, parse the form, write form to writer and make request for numbers
 	writer.Header().Set("Content-Type", "text/html")
 	err := request.ParseForm() // Must be called before writing response
 	fmt.Fprint(writer, pageTop, form)
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func stats(t *testing.T, query string) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	homePage(w, httptest.NewRequest("GET", "/?"+query, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /?%s: status %d, want 200", query, w.Code)
+	}
+	return w.Body.String()
+}
+
+// the page without numbers shows the form and no results
+func TestForm(t *testing.T) {
+	page := stats(t, "")
+	if !strings.Contains(page, `<input type="text" name="numbers"`) || strings.Contains(page, "Results") {
+		t.Errorf("GET /: want the form without results, got %q", page)
+	}
+}
+
+// the mean and median of the numbers are shown, sorted, with commas or spaces between them
+func TestStatistics(t *testing.T) {
+	page := stats(t, "numbers=3,1+2+10")
+	for _, want := range []string{
+		"<td>Numbers</td><td>[1 2 3 10]</td>",
+		"<td>Count</td><td>4</td>",
+		"<td>Mean</td><td>4.000000</td>",
+		"<td>Median</td><td>2.500000</td>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("GET /?numbers=3,1+2+10: no %q in the page", want)
+		}
+	}
+}
+
+// a number that does not parse is reported
+func TestInvalid(t *testing.T) {
+	page := stats(t, "numbers=1+two")
+	if !strings.Contains(page, `<p class="error">'two' is invalid</p>`) {
+		t.Errorf("GET /?numbers=1+two: no error in %q", page)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// logPanics lets the handlers answer as before
+func TestLogPanics(t *testing.T) {
+	w := httptest.NewRecorder()
+	logPanics(SimpleServer)(w, httptest.NewRequest("GET", "/test1", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "<h1>hello, world</h1>" {
+		t.Errorf("GET /test1: %d %q", w.Code, w.Body.String())
+	}
+}
+
+// a panic in a handler is caught and logged instead of reaching the server
+func TestPanicCaught(t *testing.T) {
+	h := logPanics(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	defer func() {
+		if v := recover(); v != nil {
+			t.Errorf("the panic %v got through logPanics", v)
+		}
+	}()
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
@@ -1,13 +1,23 @@
 package main
+
 import (
+	"flag"
 	"fmt"
 	"html/template"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"regexp"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/config"
+	"github.com/hannansatopay/training-golang/pkg/graceful"
+	"github.com/hannansatopay/training-golang/pkg/logger"
+	"github.com/hannansatopay/training-golang/pkg/metrics"
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
 )
 
+var log = logger.New("web")
+
 const lenPath = len("/view/")
 
 type Page struct {
@@ -17,7 +27,7 @@ type Page struct {
 
 func (p *Page) save() error {
 	filename := p.Title + ".txt"
-	return ioutil.WriteFile(filename, p.Body, 0600)
+	return safewrite.WriteFile(filename, p.Body, 0600) // a crash mid-save keeps the old page
 }
 
 func loadPage(title string) (*Page, error) {
@@ -72,7 +82,7 @@ var validPath = regexp.MustCompile("^/(edit|save|view)/([a-zA-Z0-9]+)$")
 func makeHandler(fn func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if !validPath.MatchString(r.URL.Path) {
-			fmt.Fprintf(w, "Wrong URL!", r.URL.Path)
+			fmt.Fprintf(w, "Wrong URL: %s", r.URL.Path)
 			return
 		}
 		title := r.URL.Path[lenPath:]
@@ -85,9 +95,13 @@ func makeHandler(fn func(http.ResponseWriter, *http.Request, string)) http.Handl
 }
 
 func main() {
+	addr := config.Addr("0.0.0.0:3000")
+	flag.Parse()
 	http.HandleFunc("/view/", makeHandler(viewHandler))
 	http.HandleFunc("/edit/", makeHandler(editHandler))
 	http.HandleFunc("/save/", makeHandler(saveHandler))
 
-	log.Fatal(http.ListenAndServe("0.0.0.0:3000", nil))
-}
\ No newline at end of file
+	if err := graceful.ListenAndServe(&http.Server{Addr: *addr, Handler: metrics.Instrument(metrics.NewRegistry(), nil)}, 5*time.Second); err != nil {
+		log.Fatal("ListenAndServe", "err", err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, method, path string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/view/", makeHandler(viewHandler))
+	mux.HandleFunc("/edit/", makeHandler(editHandler))
+	mux.HandleFunc("/save/", makeHandler(saveHandler))
+	r := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+// viewing a page that does not exist redirects to its edit form
+func TestViewMissing(t *testing.T) {
+	w := serve(t, "GET", "/view/GradeMissing", nil)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/edit/GradeMissing" {
+		t.Errorf("GET /view/GradeMissing: %d to %q, want 302 to /edit/GradeMissing", w.Code, w.Header().Get("Location"))
+	}
+	w = serve(t, "GET", "/edit/GradeMissing", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<form action="/save/GradeMissing" method="POST">`) {
+		t.Errorf("GET /edit/GradeMissing: %d, no form saving to /save/GradeMissing in %q", w.Code, w.Body.String())
+	}
+}
+
+// a saved page is written to its file and shown, escaped, by view
+func TestSaveView(t *testing.T) {
+	t.Cleanup(func() { os.Remove("GradeSaved.txt") })
+	w := serve(t, "POST", "/save/GradeSaved", url.Values{"body": {"Gophers <3 Go"}})
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/view/GradeSaved" {
+		t.Fatalf("POST /save/GradeSaved: %d to %q, want 302 to /view/GradeSaved", w.Code, w.Header().Get("Location"))
+	}
+	if b, err := os.ReadFile("GradeSaved.txt"); err != nil || string(b) != "Gophers <3 Go" {
+		t.Errorf("GradeSaved.txt holds %q (%v), want the body", b, err)
+	}
+	w = serve(t, "GET", "/view/GradeSaved", nil)
+	if !strings.Contains(w.Body.String(), "<div>Gophers &lt;3 Go</div>") {
+		t.Errorf("GET /view/GradeSaved: no escaped body in %q", w.Body.String())
+	}
+}
+
+// paths that are not /view/, /edit/ or /save/ and a title of letters and digits are refused
+func TestValidPath(t *testing.T) {
+	for _, path := range []string{"/view/../main", "/edit/", "/view/a-b"} {
+		w := serve(t, "GET", path, nil)
+		if w.Code == http.StatusFound || strings.Contains(w.Body.String(), "<form") || strings.Contains(w.Body.String(), "<h1>") {
+			t.Errorf("GET %s: %d %q, want it refused", path, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/mysort"
+)
+
+// sorting a million ints on one goroutine and on all cores: mysort.Stable
+// against mysort.ParallelSort, which sorts the halves at the same time and
+// merges them. The payoff depends on the cores there are (GOMAXPROCS);
+// with one, ParallelSort is Stable plus a little overhead. Run with
+// trainer bench session6/ex1 to compare runs, or try GOMAXPROCS=2.
+
+const size = 1 << 20
+
+// input is the same random data for every benchmark
+var input = func() []int {
+	r := rand.New(rand.NewSource(1))
+	s := make([]int, size)
+	for i := range s {
+		s[i] = r.Int()
+	}
+	return s
+}()
+
+// benchmarkSort sorts a fresh copy of input b.N times; copying is not timed
+func benchmarkSort(b *testing.B, sort func(mysort.Interface)) {
+	data := make([]int, size)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		copy(data, input)
+		b.StartTimer()
+		sort(mysort.IntSlice(data))
+	}
+	if !mysort.IntsAreSorted(data) {
+		b.Fatal("not sorted")
+	}
+}
+
+func BenchmarkStable1M(b *testing.B) { benchmarkSort(b, mysort.Stable) }
+
+func BenchmarkParallelSort1M(b *testing.B) { benchmarkSort(b, mysort.ParallelSort) }
+
+func main() {
+	fmt.Printf("%d ints, GOMAXPROCS=%d\n", size, runtime.GOMAXPROCS(0))
+	stable := testing.Benchmark(BenchmarkStable1M)
+	parallel := testing.Benchmark(BenchmarkParallelSort1M)
+	fmt.Println("stable:  ", stable.String())
+	fmt.Println("parallel:", parallel.String())
+	fmt.Printf("speedup: %.2fx\n", float64(stable.NsPerOp())/float64(parallel.NsPerOp()))
+}
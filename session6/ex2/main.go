@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/mysort"
+)
+
+// why mysort.Sort is an introsort: quicksort with a bad pivot on every
+// split needs n*n/2 comparisons, and for every way of picking the pivot
+// there is an input that does that. quicksort below is mysort's own
+// quicksort without the depth limit; mysort.Sort switches to heapsort when
+// the splits get too deep. Both get a random input, a sorted one (easy for
+// a median-of-three pivot, the worst case for a first-element pivot) and
+// a "killer" input made against them by McIlroy's adversary.
+
+const size = 1 << 13
+
+// quicksort is mysort.Sort without the switch to heapsort
+func quicksort(data mysort.Interface, a, b int) {
+	for b-a > 12 {
+		p := partition(data, a, b)
+		if p-a < b-p {
+			quicksort(data, a, p)
+			a = p + 1
+		} else {
+			quicksort(data, p+1, b)
+			b = p
+		}
+	}
+	for i := a + 1; i < b; i++ {
+		for j := i; j > a && data.Less(j, j-1); j-- {
+			data.Swap(j, j-1)
+		}
+	}
+}
+
+// partition is the median-of-three partition of mysort
+func partition(data mysort.Interface, a, b int) int {
+	m := int(uint(a+b) >> 1)
+	if data.Less(m, a) {
+		data.Swap(m, a)
+	}
+	if data.Less(b-1, m) {
+		data.Swap(b-1, m)
+		if data.Less(m, a) {
+			data.Swap(m, a)
+		}
+	}
+	data.Swap(a, m)
+	i, j := a+1, b-1
+	for {
+		for i <= j && data.Less(i, a) {
+			i++
+		}
+		for i <= j && data.Less(a, j) {
+			j--
+		}
+		if i >= j {
+			break
+		}
+		data.Swap(i, j)
+		i++
+		j--
+	}
+	data.Swap(a, j)
+	return j
+}
+
+// adversary is McIlroy's "killer adversary for quicksort" (1999). All
+// values start as "gas", larger than anything; a comparison of two gas
+// values freezes one of them to the next smallest value, picking the one
+// that looks like the pivot. The sort never learns anything useful about
+// the pivot, and the values frozen in the end are an input on which it
+// does as badly as it can.
+type adversary struct {
+	pos       []int // pos[i]: which element is at index i
+	val       []int // val[e]: value of element e
+	gas       int
+	solid     int // the next value to freeze to
+	candidate int // the element that looks like the pivot
+}
+
+func newAdversary(n int) *adversary {
+	a := &adversary{pos: make([]int, n), val: make([]int, n), gas: n}
+	for i := range a.pos {
+		a.pos[i], a.val[i] = i, n
+	}
+	return a
+}
+
+func (a *adversary) Len() int      { return len(a.pos) }
+func (a *adversary) Swap(i, j int) { a.pos[i], a.pos[j] = a.pos[j], a.pos[i] }
+
+func (a *adversary) Less(i, j int) bool {
+	x, y := a.pos[i], a.pos[j]
+	if a.val[x] == a.gas && a.val[y] == a.gas {
+		if x == a.candidate {
+			a.val[x] = a.solid
+		} else {
+			a.val[y] = a.solid
+		}
+		a.solid++
+	}
+	if a.val[x] == a.gas {
+		a.candidate = x
+	} else if a.val[y] == a.gas {
+		a.candidate = y
+	}
+	return a.val[x] < a.val[y]
+}
+
+// killer returns an input of n ints on which sort does as badly as it can
+func killer(n int, sort func(mysort.Interface)) []int {
+	a := newAdversary(n)
+	sort(a)
+	return a.val
+}
+
+// counter counts the comparisons of a sort
+type counter struct {
+	mysort.IntSlice
+	less int
+}
+
+func (c *counter) Less(i, j int) bool {
+	c.less++
+	return c.IntSlice.Less(i, j)
+}
+
+func withoutLimit(data mysort.Interface) { quicksort(data, 0, data.Len()) }
+
+var inputs = func() map[string][]int {
+	random := rand.New(rand.NewSource(1)).Perm(size)
+	sorted := make([]int, size)
+	for i := range sorted {
+		sorted[i] = i
+	}
+	return map[string][]int{
+		"random": random,
+		"sorted": sorted,
+		"killer": killer(size, withoutLimit),
+	}
+}()
+
+func benchmarkSort(b *testing.B, input []int, sort func(mysort.Interface)) {
+	data := make([]int, len(input))
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		copy(data, input)
+		b.StartTimer()
+		sort(mysort.IntSlice(data))
+	}
+}
+
+func BenchmarkQuicksortRandom(b *testing.B) { benchmarkSort(b, inputs["random"], withoutLimit) }
+func BenchmarkQuicksortKiller(b *testing.B) { benchmarkSort(b, inputs["killer"], withoutLimit) }
+func BenchmarkIntrosortRandom(b *testing.B) { benchmarkSort(b, inputs["random"], mysort.Sort) }
+func BenchmarkIntrosortKiller(b *testing.B) { benchmarkSort(b, inputs["killer"], mysort.Sort) }
+
+func main() {
+	// comparisons do not depend on the machine: n*log2(n) is about 106000
+	fmt.Printf("comparisons to sort %d ints:\n", size)
+	fmt.Printf("%-8s %12s %12s\n", "input", "quicksort", "mysort.Sort")
+	for _, name := range []string{"random", "sorted", "killer"} {
+		q := &counter{IntSlice: append(mysort.IntSlice(nil), inputs[name]...)}
+		withoutLimit(q)
+		s := &counter{IntSlice: append(mysort.IntSlice(nil), inputs[name]...)}
+		mysort.Sort(s)
+		fmt.Printf("%-8s %12d %12d\n", name, q.less, s.less)
+	}
+	// mysort.Sort's own worst case is still n*log(n)
+	own := &counter{IntSlice: killer(size, mysort.Sort)}
+	mysort.Sort(own)
+	fmt.Printf("mysort.Sort on a killer input made against itself: %d\n", own.less)
+
+	fmt.Println("quicksort random:", testing.Benchmark(BenchmarkQuicksortRandom).String())
+	fmt.Println("quicksort killer:", testing.Benchmark(BenchmarkQuicksortKiller).String())
+	fmt.Println("introsort random:", testing.Benchmark(BenchmarkIntrosortRandom).String())
+	fmt.Println("introsort killer:", testing.Benchmark(BenchmarkIntrosortKiller).String())
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/mysort"
+)
+
+// a leaderboard: the top 10 of 100000 players. Only the first ten places
+// are shown, so only they need to be in order: mysort.PartialSort finds
+// them without sorting the other 99990.
+
+const players = 100000
+
+type player struct {
+	name  string
+	score int
+}
+
+// byScore puts the highest score first, and of equal scores the name
+// that comes first
+type byScore []player
+
+func (p byScore) Len() int { return len(p) }
+func (p byScore) Less(i, j int) bool {
+	if p[i].score != p[j].score {
+		return p[i].score > p[j].score
+	}
+	return p[i].name < p[j].name
+}
+func (p byScore) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+var board = func() []player {
+	r := rand.New(rand.NewSource(7))
+	b := make([]player, players)
+	for i := range b {
+		b[i] = player{fmt.Sprintf("player%05d", i), r.Intn(1000000)}
+	}
+	return b
+}()
+
+// counter counts the comparisons of a sort
+type counter struct {
+	byScore
+	less int
+}
+
+func (c *counter) Less(i, j int) bool {
+	c.less++
+	return c.byScore.Less(i, j)
+}
+
+func benchmarkTop(b *testing.B, sort func(mysort.Interface)) {
+	data := make(byScore, len(board))
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		copy(data, board)
+		b.StartTimer()
+		sort(data)
+	}
+}
+
+func BenchmarkTop10Partial(b *testing.B) {
+	benchmarkTop(b, func(data mysort.Interface) { mysort.PartialSort(data, 10) })
+}
+
+func BenchmarkTop10Sort(b *testing.B) { benchmarkTop(b, mysort.Sort) }
+
+func main() {
+	top := &counter{byScore: append(byScore(nil), board...)}
+	mysort.PartialSort(top, 10)
+	for i, p := range top.byScore[:10] {
+		fmt.Printf("%2d. %s %7d\n", i+1, p.name, p.score)
+	}
+	all := &counter{byScore: append(byScore(nil), board...)}
+	mysort.Sort(all)
+	fmt.Printf("comparisons: %d for the top 10, %d for all %d\n", top.less, all.less, players)
+
+	fmt.Println("top 10: ", testing.Benchmark(BenchmarkTop10Partial).String())
+	fmt.Println("sort all:", testing.Benchmark(BenchmarkTop10Sort).String())
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/mysort"
+)
+
+// radix sort against comparison sort: which is faster depends on n.
+// mysort.SortInts compares, O(n*log(n)); mysort.RadixSortInts distributes
+// by bytes, O(n) but with eight passes over the data and a second slice.
+// Small numbers need fewer passes: ints below 256 take only one.
+
+var sizes = []int{16, 256, 4096, 65536, 1 << 18}
+
+// input returns n random ints below max
+func input(n, max int) []int {
+	r := rand.New(rand.NewSource(int64(n)))
+	s := make([]int, n)
+	for i := range s {
+		s[i] = r.Intn(max)
+	}
+	return s
+}
+
+// benchmarkInts sorts a copy of in b.N times. The copy is timed too, the
+// same for both sorts: stopping the timer around it costs more than it
+// on the small inputs.
+func benchmarkInts(b *testing.B, in []int, sort func([]int)) {
+	data := make([]int, len(in))
+	for i := 0; i < b.N; i++ {
+		copy(data, in)
+		sort(data)
+	}
+}
+
+func BenchmarkSortInts(b *testing.B) {
+	for _, n := range sizes {
+		in := input(n, 1<<62)
+		b.Run(fmt.Sprint(n), func(b *testing.B) { benchmarkInts(b, in, mysort.SortInts) })
+	}
+}
+
+func BenchmarkRadixSortInts(b *testing.B) {
+	for _, n := range sizes {
+		in := input(n, 1<<62)
+		b.Run(fmt.Sprint(n), func(b *testing.B) { benchmarkInts(b, in, mysort.RadixSortInts) })
+	}
+}
+
+// compare prints how long both sorts take on in
+func compare(name string, in []int) {
+	sorted := testing.Benchmark(func(b *testing.B) { benchmarkInts(b, in, mysort.SortInts) })
+	radix := testing.Benchmark(func(b *testing.B) { benchmarkInts(b, in, mysort.RadixSortInts) })
+	winner := "radix"
+	if sorted.NsPerOp() < radix.NsPerOp() {
+		winner = "compare"
+	}
+	fmt.Printf("%-22s %14d %14d   %s\n", name, sorted.NsPerOp(), radix.NsPerOp(), winner)
+}
+
+func main() {
+	fmt.Printf("%-22s %14s %14s   %s\n", "ints", "SortInts ns", "Radix ns", "faster")
+	for _, n := range sizes {
+		compare(fmt.Sprintf("%d large", n), input(n, 1<<62))
+	}
+	compare(fmt.Sprintf("%d below 256", 1<<18), input(1<<18, 256))
+}
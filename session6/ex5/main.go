@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/mysort"
+)
+
+// our own sort package against the standard library's: mysort.Sort,
+// sort.Sort (pattern-defeating quicksort, through the same Interface) and
+// sort.Slice (the same algorithm, with a less function and reflection to
+// swap) on random, sorted, reversed and duplicate-heavy ints of several
+// sizes. BenchmarkSorts in main_test.go runs the same as benchmarks: go
+// test -bench . or trainer bench session6/ex5.
+//
+// On random input they are about even. The standard library notices
+// input that is already in order, or nearly, and runs of equal values,
+// and spends linear time on them where mysort still partitions.
+
+var benchtime = flag.Duration("benchtime", 200*time.Millisecond, "run each benchmark this long")
+
+var sizes = []int{100, 10000, 1000000}
+
+// shapes make the inputs, the same for every sort
+var shapes = []struct {
+	name string
+	make func(n int) []int
+}{
+	{"random", func(n int) []int { return rand.New(rand.NewSource(1)).Perm(n) }},
+	{"sorted", func(n int) []int {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = i
+		}
+		return s
+	}},
+	{"reversed", func(n int) []int {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = n - i
+		}
+		return s
+	}},
+	{"few values", func(n int) []int {
+		r := rand.New(rand.NewSource(1))
+		s := make([]int, n)
+		for i := range s {
+			s[i] = r.Intn(8)
+		}
+		return s
+	}},
+}
+
+var sorts = []struct {
+	name string
+	sort func([]int)
+}{
+	{"mysort.Sort", func(s []int) { mysort.Sort(mysort.IntSlice(s)) }},
+	{"sort.Sort", func(s []int) { sort.Sort(sort.IntSlice(s)) }},
+	{"sort.Slice", func(s []int) { sort.Slice(s, func(i, j int) bool { return s[i] < s[j] }) }},
+}
+
+// benchmark sorts a copy of in b.N times; the copy is timed too, the same
+// for every sort
+func benchmark(in []int, sort func([]int)) func(b *testing.B) {
+	return func(b *testing.B) {
+		data := make([]int, len(in))
+		for i := 0; i < b.N; i++ {
+			copy(data, in)
+			sort(data)
+		}
+	}
+}
+
+func main() {
+	testing.Init() // testing.Benchmark reads its -test.* flags
+	flag.Parse()
+	flag.Set("test.benchtime", benchtime.String())
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "n\tinput\t")
+	for _, s := range sorts {
+		fmt.Fprintf(tw, "%s ns\t", s.name)
+	}
+	fmt.Fprintln(tw, "mysort/sort.Sort\t")
+	for _, n := range sizes {
+		for _, shape := range shapes {
+			in := shape.make(n)
+			fmt.Fprintf(tw, "%d\t%s\t", n, shape.name)
+			var ns []int64
+			for _, s := range sorts {
+				r := testing.Benchmark(benchmark(in, s.sort))
+				ns = append(ns, r.NsPerOp())
+				fmt.Fprintf(tw, "%d\t", r.NsPerOp())
+			}
+			fmt.Fprintf(tw, "%.2fx\t\n", float64(ns[0])/float64(ns[1]))
+		}
+	}
+	tw.Flush()
+}
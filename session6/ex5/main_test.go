@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkSorts runs every sort on every shape and size of main's table
+func BenchmarkSorts(b *testing.B) {
+	for _, n := range sizes {
+		for _, shape := range shapes {
+			in := shape.make(n)
+			for _, s := range sorts {
+				b.Run(fmt.Sprintf("%s/%d/%s", s.name, n, shape.name), benchmark(in, s.sort))
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/mysort"
+)
+
+// counting sort against comparison sort on dice rolls, from 2 to 12.
+//
+// mysort.SortInts compares, O(n*log(n)), so the time per roll grows with
+// n; mysort.CountingSortInts counts the 11 possible values, O(n), so the
+// time per roll stays the same however many there are.
+
+var sizes = []int{16, 256, 4096, 65536, 1 << 20}
+
+// rolls returns n throws of two dice
+func rolls(n int) []int {
+	r := rand.New(rand.NewSource(int64(n)))
+	s := make([]int, n)
+	for i := range s {
+		s[i] = r.Intn(6) + 1 + r.Intn(6) + 1
+	}
+	return s
+}
+
+// countingSort sorts two-dice rolls
+func countingSort(a []int) { mysort.CountingSortInts(a, 2, 12) }
+
+// benchmarkInts sorts a copy of in b.N times, the copy timed too as in
+// session6/ex4
+func benchmarkInts(b *testing.B, in []int, sort func([]int)) {
+	data := make([]int, len(in))
+	for i := 0; i < b.N; i++ {
+		copy(data, in)
+		sort(data)
+	}
+}
+
+func BenchmarkSortInts(b *testing.B) {
+	for _, n := range sizes {
+		in := rolls(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) { benchmarkInts(b, in, mysort.SortInts) })
+	}
+}
+
+func BenchmarkCountingSortInts(b *testing.B) {
+	for _, n := range sizes {
+		in := rolls(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) { benchmarkInts(b, in, countingSort) })
+	}
+}
+
+// perRoll returns the nanoseconds a sort of n rolls took per roll
+func perRoll(r testing.BenchmarkResult, n int) float64 {
+	return float64(r.T.Nanoseconds()) / float64(r.N) / float64(n)
+}
+
+func main() {
+	// both sorts must agree before their times mean anything
+	in := rolls(1000)
+	a, b := append([]int(nil), in...), append([]int(nil), in...)
+	mysort.SortInts(a)
+	countingSort(b)
+	if fmt.Sprint(a) != fmt.Sprint(b) {
+		panic("the sorts disagree")
+	}
+
+	fmt.Printf("%9s %16s %16s\n", "rolls", "SortInts ns/roll", "Counting ns/roll")
+	for _, n := range sizes {
+		in := rolls(n)
+		sorted := testing.Benchmark(func(b *testing.B) { benchmarkInts(b, in, mysort.SortInts) })
+		counted := testing.Benchmark(func(b *testing.B) { benchmarkInts(b, in, countingSort) })
+		fmt.Printf("%9d %16.2f %16.2f\n", n, perRoll(sorted, n), perRoll(counted, n))
+	}
+}
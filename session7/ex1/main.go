@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+func main() {
+	data := []byte("hello, gophers?>") // '?' and '>' produce '/' and '+' in standard base64
+
+	// standard encoding: alphabet A-Z a-z 0-9 + / and '=' padding
+	std := base64.StdEncoding.EncodeToString(data)
+	// URL-safe encoding: '-' and '_' instead of '+' and '/', safe in URLs and file names
+	url := base64.URLEncoding.EncodeToString(data)
+	fmt.Println("std:", std)
+	fmt.Println("url:", url)
+
+	// padding: every 3 input bytes become 4 output characters,
+	// a short last group is filled up with '='
+	for _, s := range []string{"a", "ab", "abc"} {
+		padded := base64.StdEncoding.EncodeToString([]byte(s))
+		raw := base64.RawStdEncoding.EncodeToString([]byte(s)) // Raw = without padding
+		fmt.Printf("%-3s -> padded %-4s raw %s\n", s, padded, raw)
+	}
+
+	// decoding has to use the same alphabet and padding rules as encoding
+	if decoded, err := base64.URLEncoding.DecodeString(url); err == nil {
+		fmt.Printf("decoded: %s\n", decoded)
+	}
+	if _, err := base64.StdEncoding.DecodeString(url); err != nil {
+		fmt.Println("decoding URL-safe text as std fails:", err)
+	}
+	if _, err := base64.StdEncoding.DecodeString("YQ"); err != nil {
+		fmt.Println("decoding unpadded text with padding rules fails:", err)
+	}
+
+	// Encode into a caller-provided buffer, sized with EncodedLen
+	buf := make([]byte, base64.StdEncoding.EncodedLen(len(data)))
+	base64.StdEncoding.Encode(buf, data)
+	fmt.Printf("%d bytes encode to %d characters: %s\n", len(data), len(buf), buf)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/mysort/external"
+)
+
+// sorting a file bigger than memory: go run ./session7/ex10 -size 1GB
+//
+// The program writes a file of random lines of -size bytes, sorts it
+// with pkg/mysort/external in chunks of -chunk bytes, and checks the
+// result line by line. The heap in use stays near one chunk, however
+// large the file; watch it with a -size above the machine's memory.
+
+var (
+	size  = flag.String("size", "1GB", "size of the test file: 1GB, 250MB, 64KB ...")
+	chunk = flag.String("chunk", "32MB", "bytes sorted in memory at once")
+	file  = flag.String("o", "", "keep the test file and its sorted copy here instead of a temporary directory")
+)
+
+// parseSize reads "1GB", "250MB", "64KB" or a number of bytes
+func parseSize(s string) (int64, error) {
+	num, shift := s, 0
+	for unit, sh := range map[string]int{"KB": 10, "MB": 20, "GB": 30} {
+		if rest, ok := strings.CutSuffix(s, unit); ok {
+			num, shift = rest, sh
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("bad size %q: a number, then KB, MB or GB", s)
+	}
+	return n << shift, nil
+}
+
+// generate writes random lines of 10 to 80 letters until size bytes
+func generate(path string, size int64) (lines int64, err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriterSize(f, 1<<20)
+	r := rand.New(rand.NewSource(1))
+	line := make([]byte, 81)
+	for written := int64(0); written < size; lines++ {
+		n := 10 + r.Intn(71)
+		for i := 0; i < n; i++ {
+			line[i] = 'a' + byte(r.Intn(26))
+		}
+		line[n] = '\n'
+		w.Write(line[:n+1])
+		written += int64(n + 1)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return lines, err
+	}
+	return lines, f.Close()
+}
+
+// verify checks that the lines of path are in order and counts them
+func verify(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	var lines int64
+	prev := ""
+	for sc.Scan() {
+		if sc.Text() < prev {
+			return lines, fmt.Errorf("line %d %q comes after %q", lines+1, sc.Text(), prev)
+		}
+		prev = sc.Text()
+		lines++
+	}
+	return lines, sc.Err()
+}
+
+func main() {
+	flag.Parse()
+	n, err := parseSize(*size)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c, err := parseSize(*chunk)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dir := *file
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "external"); err != nil {
+			log.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+	} else if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatal(err)
+	}
+	in, out := dir+"/lines.txt", dir+"/sorted.txt"
+
+	start := time.Now()
+	lines, err := generate(in, n)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote %d lines (%s) in %v\n", lines, *size, time.Since(start).Round(time.Millisecond))
+
+	start = time.Now()
+	st, err := external.SortFile(out, in, external.Options{ChunkSize: c, TempDir: dir})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	fmt.Printf("sorted %d lines through %d chunks in %v, heap at most %d MB\n",
+		st.Lines, st.Chunks, time.Since(start).Round(time.Millisecond), m.HeapSys>>20)
+
+	got, err := verify(out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if got != lines {
+		log.Fatalf("%d lines in, %d out", lines, got)
+	}
+	fmt.Println("sorted.txt is in order, no line lost")
+}
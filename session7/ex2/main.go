@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+func main() {
+	data := []byte("Go is fun!\x00\x01\xff")
+
+	// every byte becomes two hex digits
+	s := hex.EncodeToString(data)
+	fmt.Println("hex:", s)
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		fmt.Println("decode error:", err)
+		return
+	}
+	fmt.Printf("decoded: %q\n", b)
+
+	// invalid input: odd length and a non-hex character
+	for _, bad := range []string{"abc", "zz"} {
+		if _, err := hex.DecodeString(bad); err != nil {
+			fmt.Printf("%q: %v\n", bad, err)
+		}
+	}
+
+	// Dump gives the classic hexdump -C layout: offset, 16 bytes, ASCII column
+	fmt.Print(hex.Dump([]byte("The quick brown fox jumps over the lazy dog")))
+
+	// a Dumper is an io.WriteCloser, so large data can be streamed through it
+	dumper := hex.Dumper(os.Stdout)
+	defer dumper.Close() // Close writes the last, partial line
+	for i := 0; i < 3; i++ {
+		dumper.Write([]byte{byte(i), byte(i * 16), 'A' + byte(i)})
+	}
+}
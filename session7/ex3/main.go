@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+func main() {
+	s := "a b&c=d/é?"
+
+	// QueryEscape is for query parameters: space becomes '+', '/' is escaped
+	fmt.Println("QueryEscape:", url.QueryEscape(s))
+	// PathEscape is for one path segment: space becomes %20
+	fmt.Println("PathEscape: ", url.PathEscape(s))
+
+	if back, err := url.QueryUnescape("a+b%26c%3Dd"); err == nil {
+		fmt.Println("QueryUnescape:", back)
+	}
+	if _, err := url.QueryUnescape("100%"); err != nil {
+		fmt.Println("bad escape:", err)
+	}
+
+	// url.Values builds (and sorts) a query string, escaping every value
+	v := url.Values{}
+	v.Set("q", "golang templates & channels")
+	v.Add("tag", "web")
+	v.Add("tag", "go/net")
+	fmt.Println("Encode:", v.Encode())
+
+	// let url.URL build a full URL, so each part gets the right escaping
+	u := url.URL{Scheme: "http", Host: "localhost:3000", Path: "/view/my page", RawQuery: v.Encode()}
+	fmt.Println("URL:", u.String())
+
+	parsed, err := url.Parse(u.String())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("path:", parsed.Path, "tags:", parsed.Query()["tag"])
+}
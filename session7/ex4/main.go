@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// "inspect this token": go run ./session7/ex4 <token>  (or pipe tokens on stdin, one per line)
+// guesses how the token is encoded and shows what is inside
+
+var (
+	hexRe     = regexp.MustCompile(`^([0-9a-fA-F]{2})+$`)
+	percentRe = regexp.MustCompile(`%[0-9a-fA-F]{2}`)
+)
+
+// a decoder reports whether token is in its encoding, and what it decodes to
+type decoder struct {
+	name   string
+	decode func(token string) ([]byte, bool)
+}
+
+// the order matters: "cafe" is valid hex and valid base64, hex is the likelier guess
+var decoders = []decoder{
+	{"JWT (base64url header.payload.signature)", decodeJWT},
+	{"hex", func(t string) ([]byte, bool) {
+		if !hexRe.MatchString(t) {
+			return nil, false
+		}
+		b, err := hex.DecodeString(t)
+		return b, err == nil
+	}},
+	{"percent-encoding", func(t string) ([]byte, bool) {
+		if !percentRe.MatchString(t) {
+			return nil, false
+		}
+		s, err := url.QueryUnescape(t)
+		return []byte(s), err == nil
+	}},
+	{"base64 (standard)", base64Decoder(base64.StdEncoding)},
+	{"base64 (URL-safe)", base64Decoder(base64.URLEncoding)},
+	{"base64 (standard, no padding)", base64Decoder(base64.RawStdEncoding)},
+	{"base64 (URL-safe, no padding)", base64Decoder(base64.RawURLEncoding)},
+}
+
+func base64Decoder(enc *base64.Encoding) func(string) ([]byte, bool) {
+	return func(t string) ([]byte, bool) {
+		b, err := enc.DecodeString(t)
+		return b, err == nil && len(b) > 0
+	}
+}
+
+// decodeJWT accepts three base64url parts whose first part is a JSON object
+func decodeJWT(t string) ([]byte, bool) {
+	parts := strings.Split(t, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || !json.Valid(header) {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	return []byte(string(header) + "\n" + string(payload)), true
+}
+
+func printable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if r < ' ' && r != '\n' && r != '\t' && r != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+func inspect(token string) {
+	fmt.Printf("token: %s\n", token)
+	for _, d := range decoders {
+		if b, ok := d.decode(token); ok {
+			fmt.Printf("looks like: %s (%d bytes)\n", d.name, len(b))
+			if printable(b) {
+				fmt.Printf("%s\n", b)
+			} else {
+				fmt.Print(hex.Dump(b))
+			}
+			return
+		}
+	}
+	fmt.Println("looks like: plain text (no known encoding)")
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		for _, token := range os.Args[1:] {
+			inspect(token)
+		}
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if token := strings.TrimSpace(scanner.Text()); token != "" {
+			inspect(token)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(os.Stderr, "reading stdin:", err)
+		os.Exit(1)
+	}
+}
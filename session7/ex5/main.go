@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hannansatopay/training-golang/session7/ex5/records"
+)
+
+// writes a few records to a file, reads them back through io.ReaderAt,
+// then damages copies of the file to show how each kind of corruption is caught
+
+func main() {
+	path := filepath.Join(os.TempDir(), "cities.grec")
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	w, err := records.NewWriter(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, city := range []string{"Washington", "Tripoli", "London", "Beijing", "Tokyo"} {
+		if err := w.Write([]byte(city)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	// *os.File implements io.ReaderAt
+	f, err = os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		log.Fatal(err)
+	}
+	rd, err := records.Open(f, info.Size())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: version %d, %d records, %d bytes\n", path, rd.Version, rd.Len(), info.Size())
+	for i := rd.Len() - 1; i >= 0; i-- { // random access: read backwards
+		payload, err := rd.Record(i)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("  record %d: %s\n", i, payload)
+	}
+
+	good, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	corruptions := []struct {
+		name   string
+		damage func(b []byte) []byte
+	}{
+		{"bad magic", func(b []byte) []byte { b[0] = 'X'; return b }},
+		{"future version", func(b []byte) []byte { b[5] = 9; return b }},
+		{"flipped payload bit", func(b []byte) []byte { b[8+4+2] ^= 0x01; return b }},
+		{"huge length field", func(b []byte) []byte { b[8] = 0xff; return b }},
+		{"truncated file", func(b []byte) []byte { return b[:len(b)-3] }},
+	}
+	for _, c := range corruptions {
+		b := c.damage(append([]byte(nil), good...))
+		err := check(b)
+		kind := "undetected!"
+		for _, known := range []error{records.ErrBadMagic, records.ErrVersion, records.ErrChecksum, records.ErrTooLarge, records.ErrTruncated} {
+			if errors.Is(err, known) {
+				kind = "detected"
+			}
+		}
+		fmt.Printf("%-20s %-11s %v\n", c.name+":", kind, err)
+	}
+}
+
+// check opens a file image and reads every record
+func check(b []byte) error {
+	rd, err := records.Open(bytes.NewReader(b), int64(len(b))) // *bytes.Reader is an io.ReaderAt too
+	if err != nil {
+		return err
+	}
+	for i := 0; i < rd.Len(); i++ {
+		if _, err := rd.Record(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
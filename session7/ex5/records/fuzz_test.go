@@ -0,0 +1,64 @@
+package records
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// FuzzOpen feeds arbitrary files to the reader: it may reject them, but
+// must not panic, and every record it indexed must be readable or fail
+// its checksum.
+//
+//trainer:fuzztime 30s
+func FuzzOpen(f *testing.F) {
+	var good bytes.Buffer
+	w, _ := NewWriter(&good)
+	w.Write([]byte("hello"))
+	w.Write(nil)
+	f.Add(good.Bytes())
+	f.Add([]byte(Magic))
+	f.Add([]byte("GREC\x00\x01\x00\x00\xff\xff\xff\xff"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		rd, err := Open(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+		for i := 0; i < rd.Len(); i++ {
+			if _, err := rd.Record(i); err != nil && !errors.Is(err, ErrChecksum) {
+				t.Fatalf("record %d: %v", i, err)
+			}
+		}
+	})
+}
+
+// FuzzRoundTrip checks that records come back as they were written.
+func FuzzRoundTrip(f *testing.F) {
+	f.Add([]byte("a"), []byte(""))
+	f.Add([]byte("GREC"), []byte{0, 0, 0, 1})
+	f.Fuzz(func(t *testing.T, a, b []byte) {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range [][]byte{a, b} {
+			if err := w.Write(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		rd, err := Open(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rd.Len() != 2 {
+			t.Fatalf("Len() = %d, want 2", rd.Len())
+		}
+		for i, want := range [][]byte{a, b} {
+			got, err := rd.Record(i)
+			if err != nil || !bytes.Equal(got, want) {
+				t.Fatalf("Record(%d) = %q, %v, want %q", i, got, err, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,139 @@
+// Package records reads and writes a small binary file format:
+//
+//	header:  magic "GREC" | version uint16 | reserved uint16
+//	record:  length uint32 | payload [length]byte | crc32 uint32
+//
+// All integers are big endian. The checksum is CRC-32 (IEEE) of the payload.
+package records
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	Magic   = "GREC"
+	Version = 1
+
+	headerSize = 8
+	// MaxRecord guards against allocating gigabytes because of a corrupt length field.
+	MaxRecord = 1 << 20
+)
+
+var (
+	ErrBadMagic  = errors.New("records: not a record file (bad magic)")
+	ErrVersion   = errors.New("records: unsupported version")
+	ErrChecksum  = errors.New("records: checksum mismatch")
+	ErrTruncated = errors.New("records: file is truncated")
+	ErrTooLarge  = errors.New("records: record length too large")
+)
+
+type header struct {
+	Magic    [4]byte
+	Version  uint16
+	Reserved uint16
+}
+
+// Writer appends records to an io.Writer.
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+// NewWriter writes the file header and returns a Writer for the records.
+func NewWriter(w io.Writer) (*Writer, error) {
+	h := header{Version: Version}
+	copy(h.Magic[:], Magic)
+	if err := binary.Write(w, binary.BigEndian, h); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w}, nil
+}
+
+// Write appends one record. After the first error every call fails.
+func (w *Writer) Write(payload []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(payload) > MaxRecord {
+		return ErrTooLarge
+	}
+	buf := make([]byte, 4+len(payload)+4)
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)))
+	copy(buf[4:], payload)
+	binary.BigEndian.PutUint32(buf[4+len(payload):], crc32.ChecksumIEEE(payload))
+	_, w.err = w.w.Write(buf)
+	return w.err
+}
+
+// Reader gives random access to the records of a file through io.ReaderAt,
+// so several goroutines can read one *os.File without sharing an offset.
+type Reader struct {
+	r       io.ReaderAt
+	size    int64
+	Version uint16
+	offsets []int64 // start of every record, filled by Open
+}
+
+// Open checks the header and indexes every record. A corrupt length field
+// or a short file is reported here; checksums are checked by Record.
+func Open(r io.ReaderAt, size int64) (*Reader, error) {
+	var h header
+	if err := binary.Read(io.NewSectionReader(r, 0, size), binary.BigEndian, &h); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrTruncated
+		}
+		return nil, err
+	}
+	if string(h.Magic[:]) != Magic {
+		return nil, ErrBadMagic
+	}
+	if h.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, h.Version)
+	}
+	rd := &Reader{r: r, size: size, Version: h.Version}
+	var lenBuf [4]byte
+	for off := int64(headerSize); off < size; {
+		if _, err := r.ReadAt(lenBuf[:], off); err != nil {
+			return nil, fmt.Errorf("%w: record %d length at offset %d", ErrTruncated, len(rd.offsets), off)
+		}
+		n := int64(binary.BigEndian.Uint32(lenBuf[:]))
+		if n > MaxRecord {
+			return nil, fmt.Errorf("%w: record %d claims %d bytes", ErrTooLarge, len(rd.offsets), n)
+		}
+		if off+4+n+4 > size {
+			return nil, fmt.Errorf("%w: record %d needs %d bytes at offset %d", ErrTruncated, len(rd.offsets), 4+n+4, off)
+		}
+		rd.offsets = append(rd.offsets, off)
+		off += 4 + n + 4
+	}
+	return rd, nil
+}
+
+// Len returns the number of records.
+func (rd *Reader) Len() int { return len(rd.offsets) }
+
+// Record reads record i and verifies its checksum.
+func (rd *Reader) Record(i int) ([]byte, error) {
+	if i < 0 || i >= len(rd.offsets) {
+		return nil, fmt.Errorf("records: index %d out of range [0,%d)", i, len(rd.offsets))
+	}
+	off := rd.offsets[i]
+	var lenBuf [4]byte
+	if _, err := rd.r.ReadAt(lenBuf[:], off); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(lenBuf[:])
+	buf := make([]byte, n+4)
+	if _, err := rd.r.ReadAt(buf, off+4); err != nil {
+		return nil, err
+	}
+	payload, sum := buf[:n], binary.BigEndian.Uint32(buf[n:])
+	if crc32.ChecksumIEEE(payload) != sum {
+		return nil, fmt.Errorf("%w: record %d at offset %d", ErrChecksum, i, off)
+	}
+	return payload, nil
+}
@@ -0,0 +1,143 @@
+package records
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+)
+
+var payloads = []string{"first record", "", "the third and last record"}
+
+// writeFile writes payloads to a record file in a test directory
+func writeFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.rec")
+	err := safewrite.WriteTo(path, 0644, func(f io.Writer) error {
+		w, err := NewWriter(f)
+		if err != nil {
+			return err
+		}
+		for _, p := range payloads {
+			if err := w.Write([]byte(p)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readFile opens the record file at path and reads all its records
+func readFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	rd, err := Open(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for i := 0; i < rd.Len(); i++ {
+		p, err := rd.Record(i)
+		if err != nil {
+			return list, err
+		}
+		list = append(list, string(p))
+	}
+	return list, nil
+}
+
+// TestReadBack checks that a file written through safewrite reads back.
+func TestReadBack(t *testing.T) {
+	got, err := readFile(writeFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(payloads) {
+		t.Fatalf("%d records, want %d", len(got), len(payloads))
+	}
+	for i := range got {
+		if got[i] != payloads[i] {
+			t.Errorf("record %d = %q, want %q", i, got[i], payloads[i])
+		}
+	}
+}
+
+// TestTruncated cuts the file short at several places: Open must notice.
+func TestTruncated(t *testing.T) {
+	size := int64(headerSize)
+	for _, p := range payloads {
+		size += 4 + int64(len(p)) + 4
+	}
+	for _, tc := range []struct {
+		name string
+		size int64
+		want error
+	}{
+		{"empty", 0, ErrTruncated},
+		{"half a header", headerSize / 2, ErrTruncated},
+		{"in a length", headerSize + 2, ErrTruncated},
+		{"in a payload", headerSize + 4 + 5, ErrTruncated},
+		{"in the last checksum", size - 1, ErrTruncated},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeFile(t)
+			if err := os.Truncate(path, tc.size); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readFile(path); !errors.Is(err, tc.want) {
+				t.Errorf("truncated to %d bytes: err = %v, want %v", tc.size, err, tc.want)
+			}
+		})
+	}
+}
+
+// TestFlipped flips one byte of the header, a length, a payload or a
+// checksum: every one of them must make reading fail.
+func TestFlipped(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		off  int64
+		want error
+	}{
+		{"magic", 0, ErrBadMagic},
+		{"version", 5, ErrVersion},
+		{"first byte of a length", headerSize, ErrTooLarge},
+		{"last byte of a length", headerSize + 3, ErrTruncated},
+		{"payload", headerSize + 4, ErrChecksum},
+		{"checksum", headerSize + 4 + int64(len(payloads[0])), ErrChecksum},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeFile(t)
+			f, err := os.OpenFile(path, os.O_RDWR, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := make([]byte, 1)
+			if _, err := f.ReadAt(b, tc.off); err != nil {
+				t.Fatal(err)
+			}
+			b[0] ^= 0xff
+			if _, err := f.WriteAt(b, tc.off); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+			if _, err := readFile(path); !errors.Is(err, tc.want) {
+				t.Errorf("byte %d flipped: err = %v, want %v", tc.off, err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// an upload is read exactly once, but written to disk and hashed at the same time:
+// - io.MultiWriter duplicates every write to several writers
+// - io.TeeReader copies everything that is read into a writer
+
+// MultiWriter version: one io.Copy feeds the file and the hash
+func uploadMulti(w http.ResponseWriter, req *http.Request) {
+	f, err := os.Create(filepath.Join(os.TempDir(), "upload-multi.bin"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(w, "multi: stored %d bytes in %s, sha256 %x\n", n, f.Name(), h.Sum(nil))
+}
+
+// TeeReader version: whoever reads from tee (here io.Copy to the file)
+// also feeds the hash
+func uploadTee(w http.ResponseWriter, req *http.Request) {
+	f, err := os.Create(filepath.Join(os.TempDir(), "upload-tee.bin"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	h := sha256.New()
+	tee := io.TeeReader(req.Body, h)
+	n, err := io.Copy(f, tee)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(w, "tee:   stored %d bytes in %s, sha256 %x\n", n, f.Name(), h.Sum(nil))
+}
+
+// gophers is an endless "gopher gopher gopher ...", made as it is read:
+// however much is read, it holds nothing but its position
+type gophers struct{ pos int }
+
+func (g *gophers) Read(p []byte) (int, error) {
+	const word = "gopher "
+	for i := range p {
+		p[i] = word[g.pos%len(word)]
+		g.pos++
+	}
+	return len(p), nil
+}
+
+func main() {
+	http.HandleFunc("/upload/multi", uploadMulti)
+	http.HandleFunc("/upload/tee", uploadTee)
+	listener, err := net.Listen("tcp", "127.0.0.1:0") // any free port
+	if err != nil {
+		log.Fatal(err)
+	}
+	go http.Serve(listener, nil)
+
+	// 1 MB of data that is never held in memory at once on the client side:
+	// http.Post reads it from the gophers a buffer at a time
+	const size = 1 << 20
+	sum := sha256.New()
+	for _, path := range []string{"/upload/multi", "/upload/tee"} {
+		sum.Reset()
+		body := io.TeeReader(io.LimitReader(&gophers{}, size), sum)
+		resp, err := http.Post("http://"+listener.Addr().String()+path, "application/octet-stream", body)
+		if err != nil {
+			log.Fatal(err)
+		}
+		io.Copy(os.Stdout, resp.Body)
+		resp.Body.Close()
+		fmt.Println("client sha256", hex.EncodeToString(sum.Sum(nil)))
+	}
+}
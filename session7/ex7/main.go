@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// io.Pipe connects code that wants an io.Writer to code that wants an io.Reader
+// without a buffer in between: every Write blocks until a Read takes the data.
+// That makes it easy to deadlock, so each rule below is shown in practice.
+
+type event struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// producer encodes events into the pipe. Rule 1: always Close the writer,
+// otherwise the reader waits for more data forever. Rule 2: report failures
+// with CloseWithError, so the reader gets the error instead of a clean EOF.
+func producer(pw *io.PipeWriter, n int, failAt int) {
+	enc := json.NewEncoder(pw)
+	for i := 1; i <= n; i++ {
+		if i == failAt {
+			pw.CloseWithError(fmt.Errorf("producer: event %d could not be built", i))
+			return
+		}
+		if err := enc.Encode(event{i, fmt.Sprintf("event-%d", i)}); err != nil {
+			return // the reader closed its end: stop producing
+		}
+	}
+	pw.Close()
+}
+
+// consumer reads until EOF or until the producer's error arrives
+func consumer(r io.Reader) (int, error) {
+	count := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var e event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return count, err
+		}
+		fmt.Printf("  consumed %d %s\n", e.ID, e.Name)
+		count++
+	}
+	return count, scanner.Err()
+}
+
+// withTimeout runs f and reports a deadlock if it doesn't return in time
+func withTimeout(name string, f func()) {
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+	select {
+	case <-done:
+		fmt.Printf("%s: finished\n", name)
+	case <-time.After(500 * time.Millisecond):
+		fmt.Printf("%s: DEADLOCK (still blocked after 500ms)\n", name)
+	}
+}
+
+func main() {
+	// 1. the right way: writer and reader in different goroutines
+	withTimeout("producer in its own goroutine", func() {
+		pr, pw := io.Pipe()
+		go producer(pw, 3, 0)
+		n, err := consumer(pr)
+		fmt.Printf("  %d events, err=%v\n", n, err)
+	})
+
+	// 2. the producer fails halfway: the consumer sees the error, not EOF
+	withTimeout("producer fails", func() {
+		pr, pw := io.Pipe()
+		go producer(pw, 5, 3)
+		n, err := consumer(pr)
+		fmt.Printf("  %d events, err=%v\n", n, err)
+	})
+
+	// 3. the consumer gives up early: closing the reader unblocks the producer
+	withTimeout("consumer stops early", func() {
+		pr, pw := io.Pipe()
+		stopped := make(chan struct{})
+		go func() {
+			producer(pw, 1000, 0)
+			close(stopped)
+		}()
+		first, _ := bufio.NewReader(pr).ReadString('\n')
+		fmt.Printf("  only wanted the first: %s", first)
+		pr.Close() // Write now returns io.ErrClosedPipe
+		<-stopped
+	})
+
+	// 4. the classic mistake: writing and reading in the same goroutine.
+	// Write blocks until someone reads, and the reader is us.
+	withTimeout("same goroutine", func() {
+		pr, pw := io.Pipe()
+		pw.Write([]byte("never read\n")) // blocks forever
+		consumer(pr)
+	})
+
+	// 5. forgetting Close: the consumer never sees EOF
+	withTimeout("writer never closed", func() {
+		pr, pw := io.Pipe()
+		go func() {
+			json.NewEncoder(pw).Encode(event{1, "lonely"})
+			// pw.Close() missing
+		}()
+		consumer(pr)
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"testing"
+	"time"
+)
+
+// pipe runs f and fails if it is still blocked after a second
+func pipe(t *testing.T, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deadlock: still blocked after 1s")
+	}
+}
+
+// the producer writes in its own goroutine and closes the pipe: the consumer gets every event and EOF
+func TestProducerCloses(t *testing.T) {
+	pipe(t, func() {
+		pr, pw := io.Pipe()
+		go producer(pw, 3, 0)
+		if n, err := consumer(pr); n != 3 || err != nil {
+			t.Errorf("consumer = %d, %v, want 3 events and no error", n, err)
+		}
+	})
+}
+
+// a producer that fails closes the pipe with its error: the consumer gets the error, not EOF
+func TestProducerFails(t *testing.T) {
+	pipe(t, func() {
+		pr, pw := io.Pipe()
+		go producer(pw, 5, 3)
+		if n, err := consumer(pr); n != 2 || err == nil {
+			t.Errorf("consumer = %d, %v, want 2 events and the producer's error", n, err)
+		}
+	})
+}
+
+// a consumer that stops early closes its end: the producer's Write fails and it stops too
+func TestConsumerStops(t *testing.T) {
+	pipe(t, func() {
+		pr, pw := io.Pipe()
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			producer(pw, 1000, 0)
+		}()
+		if _, err := bufio.NewReader(pr).ReadString('\n'); err != nil {
+			t.Error(err)
+		}
+		pr.Close()
+		<-stopped
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hannansatopay/training-golang/pkg/safewrite"
+)
+
+// Why os.WriteFile can lose data: it opens the file with O_TRUNC, so the old
+// content is gone *before* the new content is written. If the program dies
+// in between (crash, kill -9, full disk, power cut), the file is left empty
+// or half-written. safewrite writes a temporary file, fsyncs it and renames
+// it over the original, so the old content survives until the new one is
+// complete.
+//
+// A crash is simulated here by a writer that fails after a few bytes.
+
+var errCrash = errors.New("simulated crash")
+
+// crashAfter passes at most n bytes through to w, then fails
+type crashAfter struct {
+	w io.Writer
+	n int
+}
+
+func (c *crashAfter) Write(p []byte) (int, error) {
+	if len(p) > c.n {
+		written, _ := c.w.Write(p[:c.n])
+		c.n = 0
+		return written, errCrash
+	}
+	c.n -= len(p)
+	return c.w.Write(p)
+}
+
+const (
+	oldContent = `{"addr": "0.0.0.0:3000", "root": "/home/user"}`
+	newContent = `{"addr": "0.0.0.0:8080", "root": "/srv/www", "boolean": false}`
+)
+
+// naiveSave is what os.WriteFile does internally: truncate, then write
+func naiveSave(path string, data string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.WriteString(&crashAfter{f, 20}, data)
+	return err
+}
+
+// safeSave streams through safewrite; the crash happens before Commit
+func safeSave(path string, data string) error {
+	return safewrite.WriteTo(path, 0644, func(w io.Writer) error {
+		_, err := io.WriteString(&crashAfter{w, 20}, data)
+		return err
+	})
+}
+
+func main() {
+	dir, err := os.MkdirTemp("", "safewrite-demo")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, v := range []struct {
+		name string
+		save func(string, string) error
+	}{{"naive os.WriteFile", naiveSave}, {"safewrite", safeSave}} {
+		path := filepath.Join(dir, strings.ReplaceAll(v.name, " ", "_")+".json")
+		if err := os.WriteFile(path, []byte(oldContent), 0644); err != nil {
+			log.Fatal(err)
+		}
+		err := v.save(path, newContent)
+		after, _ := os.ReadFile(path)
+		fmt.Printf("%s: save failed with %q\n", v.name, err)
+		fmt.Printf("  file now contains: %s\n", after)
+		switch string(after) {
+		case oldContent:
+			fmt.Println("  -> old content intact, nothing lost")
+		case newContent:
+			fmt.Println("  -> new content complete")
+		default:
+			fmt.Println("  -> CORRUPT: neither old nor new content")
+		}
+	}
+
+	// leftover temporary files are cleaned up after a failed write
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		fmt.Println("left in directory:", e.Name())
+	}
+
+	// and the happy path
+	path := filepath.Join(dir, "settings.json")
+	if err := safewrite.WriteFile(path, []byte(newContent), 0644); err != nil {
+		log.Fatal(err)
+	}
+	b, _ := os.ReadFile(path)
+	fmt.Printf("safewrite.WriteFile without crash: %s\n", b)
+}
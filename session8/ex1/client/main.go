@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"net"
+	"os"
+	"time"
+)
+
+// echo client: sends what is typed (or piped) to the echo server and prints
+// what comes back. Sending and receiving run at the same time, because the
+// server may also speak on its own (its goodbye when we idle too long).
+// At the end of the input the client only closes its sending half
+// (CloseWrite): the server sees EOF, but its last answers still arrive.
+//
+// try: printf 'hello\nworld\n' | go run ./session8/ex1/client
+
+var (
+	addr    = flag.String("addr", "localhost:3002", "address of the echo server")
+	timeout = flag.Duration("timeout", 5*time.Second, "give up connecting after this long")
+)
+
+func main() {
+	flag.Parse()
+	conn, err := net.DialTimeout("tcp", *addr, *timeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	go func() {
+		if err := send(conn.(*net.TCPConn), os.Stdin); err != nil {
+			log.Print(err)
+		}
+	}()
+	if _, err := io.Copy(os.Stdout, conn); err != nil {
+		log.Print(err)
+	}
+}
+
+// send copies r to conn, then closes only the sending half of conn
+func send(conn *net.TCPConn, r io.Reader) error {
+	if _, err := io.Copy(conn, r); err != nil {
+		return err
+	}
+	return conn.CloseWrite()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSend sends every line, then EOF, and the connection still reads
+// the reply.
+func TestSend(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	got := make(chan string, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			got <- err.Error()
+			return
+		}
+		defer c.Close()
+		c.SetDeadline(time.Now().Add(5 * time.Second))
+		all, _ := io.ReadAll(c) // until the client's EOF
+		got <- string(all)
+		io.WriteString(c, "after EOF\n")
+	}()
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := send(c.(*net.TCPConn), strings.NewReader("hello\nworld\n")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if all := <-got; all != "hello\nworld\n" {
+		t.Errorf("the server read %q and EOF, want \"hello\\nworld\\n\" and EOF", all)
+	}
+	if reply, err := bufio.NewReader(c).ReadString('\n'); reply != "after EOF\n" {
+		t.Errorf("after send the client read %q, %v: closed instead of half-closed?", reply, err)
+	}
+}
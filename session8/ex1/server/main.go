@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// echo server: every connection gets its own goroutine, which sends back
+// each line it reads. A read deadline, renewed before every read, drops
+// clients that stay silent longer than -idle, and a write deadline keeps a
+// client that does not read from blocking its goroutine forever.
+// Ctrl+C stops accepting, gives open connections -grace to finish and then
+// closes them: a graceful close instead of cutting everyone off mid-line.
+//
+// try: go run ./session8/ex1/client (or nc localhost 3002)
+
+var (
+	addr  = flag.String("addr", "localhost:3002", "address to listen on")
+	idle  = flag.Duration("idle", 30*time.Second, "disconnect clients that are silent this long")
+	grace = flag.Duration("grace", 5*time.Second, "time open connections get to finish on shutdown")
+)
+
+// server keeps track of its connections, so that shutdown can reach them
+type server struct {
+	idle time.Duration // how long a client may stay silent
+
+	mu       sync.Mutex
+	conns    map[net.Conn]bool
+	closing  bool
+	handlers sync.WaitGroup
+}
+
+func (s *server) serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil // the listener was closed by shutdown
+		}
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.handlers.Add(1)
+		go func() {
+			defer s.handlers.Done()
+			s.handle(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// handle echoes lines until the client closes, goes idle or the server stops
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	who := conn.RemoteAddr()
+	log.Printf("%v connected", who)
+	r := bufio.NewReader(conn)
+	for {
+		s.mu.Lock()
+		if !s.closing { // during shutdown the deadline set by shutdown stays
+			conn.SetReadDeadline(time.Now().Add(s.idle))
+		}
+		s.mu.Unlock()
+		line, err := r.ReadString('\n')
+		var ne net.Error
+		switch {
+		case err == io.EOF:
+			log.Printf("%v closed the connection", who)
+			return
+		case errors.As(err, &ne) && ne.Timeout():
+			conn.SetWriteDeadline(time.Now().Add(time.Second))
+			if s.isClosing() {
+				io.WriteString(conn, "server shutting down, bye\n")
+			} else {
+				io.WriteString(conn, "idle too long, bye\n")
+			}
+			log.Printf("%v timed out", who)
+			return
+		case err != nil:
+			log.Printf("%v: %v", who, err)
+			return
+		}
+		conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if _, err := io.WriteString(conn, line); err != nil {
+			log.Printf("%v: %v", who, err)
+			return
+		}
+	}
+}
+
+func (s *server) isClosing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closing
+}
+
+// shutdown lets every open connection read for at most grace more, then
+// waits for the handlers to return
+func (s *server) shutdown(grace time.Duration) {
+	s.mu.Lock()
+	s.closing = true
+	for conn := range s.conns {
+		conn.SetReadDeadline(time.Now().Add(grace))
+	}
+	n := len(s.conns)
+	s.mu.Unlock()
+	log.Printf("shutting down, %d connections get %v to finish", n, grace)
+	s.handlers.Wait()
+}
+
+func main() {
+	flag.Parse()
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("echo server on %v", l.Addr())
+	s := &server{idle: *idle, conns: map[net.Conn]bool{}}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	go func() {
+		<-stop
+		l.Close() // Accept returns net.ErrClosed
+	}()
+	if err := s.serve(l); err != nil {
+		log.Fatal(err)
+	}
+	s.shutdown(*grace)
+	log.Print("bye")
+}
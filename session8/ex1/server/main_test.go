@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func init() {
+	log.SetOutput(io.Discard)
+}
+
+// start serves on a free port and returns its address; the server is shut
+// down at the end of the test
+func start(t *testing.T, idle time.Duration) (*server, net.Listener) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{idle: idle, conns: map[net.Conn]bool{}}
+	go s.serve(l)
+	t.Cleanup(func() {
+		l.Close()
+		s.shutdown(0)
+	})
+	return s, l
+}
+
+type client struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func dial(t *testing.T, addr net.Addr) *client {
+	t.Helper()
+	c, err := net.DialTimeout("tcp", addr.String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	c.SetDeadline(time.Now().Add(5 * time.Second)) // a broken server fails the test, not hangs it
+	return &client{c, bufio.NewReader(c)}
+}
+
+func (c *client) line() string {
+	s, _ := c.r.ReadString('\n')
+	return strings.TrimSuffix(s, "\n")
+}
+
+// TestEcho sends lines and reads them back; the server closes when the
+// client does.
+func TestEcho(t *testing.T) {
+	_, l := start(t, time.Minute)
+	c := dial(t, l.Addr())
+	for _, line := range []string{"hello", "", "gophers of the world"} {
+		fmt.Fprintln(c, line)
+		if got := c.line(); got != line {
+			t.Errorf("sent %q, got back %q", line, got)
+		}
+	}
+	c.Conn.(*net.TCPConn).CloseWrite()
+	if _, err := c.r.ReadByte(); err != io.EOF {
+		t.Errorf("after the client's EOF the connection is still open: %v", err)
+	}
+}
+
+// TestConcurrent serves many clients at once; a silent one holds up
+// nobody.
+func TestConcurrent(t *testing.T) {
+	_, l := start(t, time.Minute)
+	dial(t, l.Addr()) // connected, silent
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := dial(t, l.Addr())
+			for j := 0; j < 5; j++ {
+				line := fmt.Sprintf("client %d line %d", i, j)
+				fmt.Fprintln(c, line)
+				if got := c.line(); got != line {
+					t.Errorf("sent %q, got back %q", line, got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestIdle renews the deadline with every line, and drops a client that
+// stays silent for longer than idle.
+func TestIdle(t *testing.T) {
+	_, l := start(t, 200*time.Millisecond)
+	c := dial(t, l.Addr())
+	for i := 0; i < 3; i++ {
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprintln(c, "still here")
+		if got := c.line(); got != "still here" {
+			t.Fatalf("a line every 100ms with -idle 200ms got back %q", got)
+		}
+	}
+	began := time.Now()
+	if got := c.line(); got != "idle too long, bye" {
+		t.Errorf("after 200ms of silence got %q, want idle too long, bye", got)
+	}
+	if took := time.Since(began); took > time.Second {
+		t.Errorf("the idle client was dropped after %v, want 200ms", took.Round(time.Millisecond))
+	}
+}
+
+// TestShutdown gives open connections grace to finish, tells them
+// goodbye, and waits for them.
+func TestShutdown(t *testing.T) {
+	s, l := start(t, time.Minute)
+	a, b := dial(t, l.Addr()), dial(t, l.Addr())
+	fmt.Fprintln(a, "ping")
+	a.line()
+	fmt.Fprintln(b, "ping")
+	b.line()
+	l.Close()
+	began := time.Now()
+	s.shutdown(200 * time.Millisecond)
+	if took := time.Since(began); took > time.Second {
+		t.Errorf("shutdown took %v: it waited for the idle deadline instead of grace", took.Round(time.Millisecond))
+	}
+	for _, c := range []*client{a, b} {
+		if got := c.line(); got != "server shutting down, bye" {
+			t.Errorf("a client got %q, want server shutting down, bye", got)
+		}
+	}
+	if c, err := net.DialTimeout("tcp", l.Addr().String(), time.Second); err == nil {
+		c.Close()
+		t.Error("the server still accepts connections after shutdown")
+	}
+}
@@ -0,0 +1,52 @@
+// Package frame reads and writes length-prefixed messages on a stream: a
+// 4-byte big-endian length, then that many bytes of message. The reader
+// always knows how much belongs to the current message, however TCP has
+// split or joined the bytes on the way.
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// MaxSize is the largest message Read accepts, so that a corrupt or
+// hostile length cannot make it allocate gigabytes.
+const MaxSize = 1 << 20
+
+// ErrTooLarge is returned for a message longer than MaxSize.
+var ErrTooLarge = errors.New("frame: message too large")
+
+// Write writes msg as one frame. Header and message go out in a single
+// Write, so that a frame is never interleaved with another writer's.
+func Write(w io.Writer, msg []byte) error {
+	if len(msg) > MaxSize {
+		return ErrTooLarge
+	}
+	buf := make([]byte, 4+len(msg))
+	binary.BigEndian.PutUint32(buf, uint32(len(msg)))
+	copy(buf[4:], msg)
+	_, err := w.Write(buf)
+	return err
+}
+
+// Read reads the next frame. It returns io.EOF if the stream ends between
+// frames, and io.ErrUnexpectedEOF if it ends in the middle of one.
+func Read(r io.Reader) ([]byte, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err // io.EOF only if not a single byte was read
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	if n > MaxSize {
+		return nil, ErrTooLarge
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return msg, nil
+}
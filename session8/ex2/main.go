@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+
+	"github.com/hannansatopay/training-golang/session8/ex2/frame"
+)
+
+// TCP carries a stream of bytes, not messages: three Writes can arrive as
+// one Read, and one large Write as several. Where one message ends is up to
+// the protocol. Here the same three messages are sent twice over a real
+// connection, first as they are and then framed with a length prefix.
+
+var messages = []string{"hello", "how are you?", "bye"}
+
+// exchange connects a client to a fresh listener, runs send on the
+// client side and receive on the server side
+func exchange(send func(net.Conn), receive func(net.Conn)) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer l.Close()
+	sent := make(chan bool)
+	go func() {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			log.Fatal(err)
+		}
+		send(conn)
+		sent <- true
+		conn.Close()
+	}()
+	conn, err := l.Accept()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	<-sent // a busy server: by the time it reads, everything has arrived
+	receive(conn)
+}
+
+func main() {
+	fmt.Println("without framing:")
+	exchange(func(c net.Conn) {
+		for _, m := range messages {
+			c.Write([]byte(m))
+		}
+	}, func(c net.Conn) {
+		buf := make([]byte, 512)
+		n, _ := c.Read(buf)
+		fmt.Printf("  %d Writes, but one Read: %q\n", len(messages), buf[:n])
+	})
+
+	fmt.Println("with a length prefix:")
+	exchange(func(c net.Conn) {
+		for _, m := range messages {
+			if err := frame.Write(c, []byte(m)); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}, func(c net.Conn) {
+		for {
+			msg, err := frame.Read(c)
+			if err == io.EOF {
+				fmt.Println("  end of stream between messages: done")
+				return
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("  message: %q\n", msg)
+		}
+	})
+
+	fmt.Println("a sender that dies mid-message:")
+	exchange(func(c net.Conn) {
+		var hdr [4]byte
+		binary.BigEndian.PutUint32(hdr[:], 10) // promises 10 bytes ...
+		c.Write(hdr[:])
+		c.Write([]byte("half")) // ... and sends 4
+	}, func(c net.Conn) {
+		_, err := frame.Read(c)
+		fmt.Printf("  %v: the reader knows the message is incomplete\n", err)
+	})
+}
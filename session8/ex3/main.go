@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// DNS lookups for many domains at once: go run ./session8/ex3 golang.org example.com
+// (or one domain per line on stdin). Every domain gets its A, AAAA, MX and
+// TXT records looked up concurrently through a net.Resolver, each lookup
+// with its own -timeout and all of them under one -deadline: when the
+// deadline passes, the context cancels the lookups still running, and they
+// show up as cancelled instead of holding up the table.
+//
+// session4/ex50 looks up one name at a time, and has a DNS server of its
+// own to do it offline: go run ./session8/ex3 -server localhost:3053
+// example.test slow.example.test works against it too.
+
+var (
+	timeout  = flag.Duration("timeout", 2*time.Second, "limit of one lookup")
+	deadline = flag.Duration("deadline", 5*time.Second, "limit of all lookups together")
+	server   = flag.String("server", "", "ask this DNS server (host:port) instead of the system's")
+)
+
+var kinds = []string{"A", "AAAA", "MX", "TXT"}
+
+// errCancelled replaces the error of a lookup stopped by -deadline
+var errCancelled = errors.New("cancelled")
+
+// answer is the outcome of one lookup
+type answer struct {
+	domain, kind string
+	records      []string
+	err          error
+	took         time.Duration
+}
+
+func newResolver() *net.Resolver {
+	if *server == "" {
+		return net.DefaultResolver
+	}
+	// the pure Go resolver, talking to our server whatever address it was given
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, *server)
+		},
+	}
+}
+
+func lookup(ctx context.Context, r *net.Resolver, domain, kind string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	var records []string
+	switch kind {
+	case "A", "AAAA":
+		network := "ip4"
+		if kind == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := r.LookupIP(ctx, network, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "MX":
+		mxs, err := r.LookupMX(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, txt := range txts {
+			if len(txt) > 60 {
+				txt = txt[:57] + "..."
+			}
+			records = append(records, fmt.Sprintf("%q", txt))
+		}
+	}
+	return records, nil
+}
+
+// describe turns a lookup error into a short table cell
+func describe(err error) string {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.Is(err, errCancelled):
+		return "cancelled (-deadline)"
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return "-"
+	case errors.As(err, &dnsErr) && dnsErr.IsTimeout, errors.Is(err, context.DeadlineExceeded):
+		return "timed out (-timeout)"
+	}
+	return "error: " + err.Error()
+}
+
+func main() {
+	flag.Parse()
+	domains := flag.Args()
+	if len(domains) == 0 {
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			if d := strings.TrimSpace(sc.Text()); d != "" {
+				domains = append(domains, d)
+			}
+		}
+	}
+	if len(domains) == 0 {
+		log.Fatal("no domains: name them as arguments or on stdin")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *deadline)
+	defer cancel()
+	r := newResolver()
+	answers := make(chan answer)
+	var wg sync.WaitGroup
+	for _, d := range domains {
+		for _, k := range kinds {
+			wg.Add(1)
+			go func(domain, kind string) {
+				defer wg.Done()
+				start := time.Now()
+				records, err := lookup(ctx, r, domain, kind)
+				if err != nil && ctx.Err() != nil {
+					err = errCancelled // not this lookup's fault: time is up for all
+				}
+				answers <- answer{domain, kind, records, err, time.Since(start)}
+			}(d, k)
+		}
+	}
+	go func() {
+		wg.Wait()
+		close(answers)
+	}()
+
+	var all []answer
+	for a := range answers {
+		all = append(all, a)
+	}
+	order := map[string]int{}
+	for i, d := range domains {
+		order[d] = i
+	}
+	kindOrder := map[string]int{"A": 0, "AAAA": 1, "MX": 2, "TXT": 3}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].domain != all[j].domain {
+			return order[all[i].domain] < order[all[j].domain]
+		}
+		return kindOrder[all[i].kind] < kindOrder[all[j].kind]
+	})
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DOMAIN\tTYPE\tTOOK\tRECORDS")
+	for _, a := range all {
+		cell := strings.Join(a.records, ", ")
+		if a.err != nil {
+			cell = describe(a.err)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%s\n", a.domain, a.kind, a.took.Round(time.Millisecond), cell)
+	}
+	tw.Flush()
+}
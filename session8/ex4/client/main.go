@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// TLS client with certificate pinning: go run ./session8/ex4/client -pin sha256/... https://localhost:3443/
+//
+// A self-signed certificate cannot be checked against the system's
+// certificate authorities. Instead of turning verification off, the client
+// pins the server's key: it only talks to a server whose certificate
+// carries a public key with the expected SHA-256 hash (an SPKI pin, as in
+// HPKP and `curl --pinnedpubkey`). A renewed certificate with the same key
+// keeps its pin; a server with another key, however valid its
+// certificate, is refused.
+
+var errPinMismatch = errors.New("no certificate of the server matches a pin")
+
+// spkiPin returns the pin of a certificate: "sha256/" and the base64 of the
+// hash of its SubjectPublicKeyInfo
+func spkiPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// pinnedConfig returns a TLS configuration that accepts a server only if
+// one of its certificates has one of the pins, failing the handshake with
+// errPinMismatch otherwise
+func pinnedConfig(pins []string) *tls.Config {
+	// HINT: the certificates of the server are in tls.ConnectionState.PeerCertificates
+	// HINT: InsecureSkipVerify turns off the usual checks; VerifyConnection still runs
+	return &tls.Config{
+		// SOLUTION-START
+		InsecureSkipVerify: true, // the pin replaces the check against CAs
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			for _, cert := range cs.PeerCertificates {
+				for _, pin := range pins {
+					if spkiPin(cert) == pin {
+						return nil
+					}
+				}
+			}
+			return errPinMismatch
+		},
+		// SOLUTION-END
+	}
+}
+
+// printChain shows the certificates the server sent
+func printChain(cs *tls.ConnectionState) {
+	fmt.Printf("%s, %s\n", tls.VersionName(cs.Version), tls.CipherSuiteName(cs.CipherSuite))
+	for i, cert := range cs.PeerCertificates {
+		fmt.Printf("certificate %d:\n", i)
+		fmt.Printf("  subject  %s\n", cert.Subject)
+		fmt.Printf("  issuer   %s\n", cert.Issuer)
+		fmt.Printf("  valid    %s to %s\n", cert.NotBefore.Format(time.DateOnly), cert.NotAfter.Format(time.DateOnly))
+		if len(cert.DNSNames)+len(cert.IPAddresses) > 0 {
+			names := append([]string(nil), cert.DNSNames...)
+			for _, ip := range cert.IPAddresses {
+				names = append(names, ip.String())
+			}
+			fmt.Printf("  names    %s\n", strings.Join(names, ", "))
+		}
+		fmt.Printf("  pin      %s\n", spkiPin(cert))
+	}
+}
+
+func main() {
+	var pins []string
+	flag.Func("pin", "accept a server key with this pin (sha256/...), may be repeated", func(s string) error {
+		pins = append(pins, s)
+		return nil
+	})
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: client [-pin sha256/...] https://host:port/path")
+		os.Exit(2)
+	}
+	cfg := &tls.Config{} // without pins: the usual verification
+	if len(pins) > 0 {
+		cfg = pinnedConfig(pins)
+	}
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: cfg},
+		Timeout:   10 * time.Second,
+	}
+	resp, err := client.Get(flag.Arg(0))
+	if errors.Is(err, errPinMismatch) {
+		log.Fatalf("refused: %v (is it the server you expect?)", err)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	printChain(resp.TLS)
+	fmt.Println(resp.Status)
+	io.Copy(os.Stdout, resp.Body)
+}
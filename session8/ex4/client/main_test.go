@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// newCert makes a self-signed certificate for key
+func newCert(t *testing.T, key *ecdsa.PrivateKey, serial int64) tls.Certificate {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// handshake connects with cfg to a TLS server presenting cert
+func handshake(t *testing.T, cert tls.Certificate, cfg *tls.Config) error {
+	t.Helper()
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	conn, err := tls.Dial("tcp", l.Addr().String(), cfg)
+	if err == nil {
+		conn.Close()
+	}
+	return err
+}
+
+// a server whose key matches a pin is accepted, although its certificate is self-signed
+func TestPinMatch(t *testing.T) {
+	cert := newCert(t, newKey(t), 1)
+	other := newCert(t, newKey(t), 1)
+	if err := handshake(t, cert, pinnedConfig([]string{spkiPin(other.Leaf), spkiPin(cert.Leaf)})); err != nil {
+		t.Errorf("handshake with a pinned key failed: %v", err)
+	}
+}
+
+// a server with another key is refused with errPinMismatch
+func TestPinMismatch(t *testing.T) {
+	cert := newCert(t, newKey(t), 1)
+	other := newCert(t, newKey(t), 1)
+	err := handshake(t, cert, pinnedConfig([]string{spkiPin(other.Leaf)}))
+	if !errors.Is(err, errPinMismatch) {
+		t.Errorf("handshake with an unpinned key: err = %v, want errPinMismatch", err)
+	}
+	if err := handshake(t, cert, pinnedConfig(nil)); !errors.Is(err, errPinMismatch) {
+		t.Errorf("handshake without pins: err = %v, want errPinMismatch", err)
+	}
+}
+
+// a new certificate for the same key is still accepted: the pin is on the key
+func TestPinSurvivesRenewal(t *testing.T) {
+	key := newKey(t)
+	old, renewed := newCert(t, key, 1), newCert(t, key, 2)
+	if err := handshake(t, renewed, pinnedConfig([]string{spkiPin(old.Leaf)})); err != nil {
+		t.Errorf("handshake with a renewed certificate of the pinned key failed: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// HTTPS server with a self-signed certificate: go run ./session8/ex4/server
+//
+// The key and certificate are made on the first start and kept in -cert
+// and -key, so the key, and with it the pin that session8/ex4/client
+// checks, stays the same across restarts; delete both files to get a new
+// key.
+//
+// try: go run ./session8/ex4/client -pin <the pin printed at start> https://localhost:3443/
+
+var (
+	addr     = flag.String("addr", "localhost:3443", "address to listen on")
+	certFile = flag.String("cert", "cert.pem", "certificate file, created if missing")
+	keyFile  = flag.String("key", "key.pem", "private key file, created if missing")
+)
+
+// makeCert writes a new key and a certificate for localhost, valid for a year
+func makeCert(certFile, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		return err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "localhost", Organization: []string{"Go Training"}},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
+
+func main() {
+	flag.Parse()
+	if _, err := os.Stat(*certFile); os.IsNotExist(err) {
+		if err := makeCert(*certFile, *keyFile); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("new key and certificate in %s and %s", *keyFile, *certFile)
+	}
+	cert, err := tls.LoadX509KeyPair(*certFile, *keyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	sum := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+	fmt.Printf("pin: sha256/%s\n", base64.StdEncoding.EncodeToString(sum[:]))
+
+	http.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "hello over %s\n", tls.VersionName(req.TLS.Version))
+	})
+	srv := &http.Server{
+		Addr:      *addr,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+	}
+	log.Printf("listening on https://%s/", *addr)
+	log.Fatal(srv.ListenAndServeTLS("", ""))
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// port scanner: go run ./session8/ex5 -ports 1-1024 localhost
+//
+// Every port of the range is tried with its own goroutine, but a
+// semaphore (a buffered channel with one slot per connection) lets only
+// -workers of them be connecting at the same time: a thousand ports do not
+// mean a thousand open sockets. Each connection has two deadlines: the
+// dial gives up after -timeout, and reading the banner that many servers
+// send first (SSH, SMTP, FTP) after -banner. Scan only hosts you are
+// allowed to.
+
+var (
+	ports   = flag.String("ports", "1-1024", "ports to try: 22,80,8000-8100")
+	workers = flag.Int("workers", 100, "connections at the same time")
+	timeout = flag.Duration("timeout", 500*time.Millisecond, "give up connecting to a port after this long")
+	banner  = flag.Duration("banner", time.Second, "wait this long for a server to speak first")
+	total   = flag.Duration("deadline", time.Minute, "stop the whole scan after this long")
+)
+
+// result is an open port and what the server said first, if anything
+type result struct {
+	port   int
+	banner string
+}
+
+// parsePorts turns "22,80,8000-8100" into the list of ports
+func parsePorts(s string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("bad port %q", part)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(hi); err != nil {
+				return nil, fmt.Errorf("bad port range %q", part)
+			}
+		}
+		if first < 1 || last > 65535 || first > last {
+			return nil, fmt.Errorf("bad port range %q", part)
+		}
+		for p := first; p <= last; p++ {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// probe connects to one port and reads the first line the server sends.
+// It returns an error if the port is closed or filtered.
+func probe(ctx context.Context, host string, port int) (result, error) {
+	d := net.Dialer{Timeout: *timeout}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return result{}, err
+	}
+	defer conn.Close()
+	r := result{port: port}
+	// a server that waits for the client (HTTP) sends nothing: the read
+	// deadline ends the wait, and the port is open without a banner. The
+	// error does not matter, only what was read before it.
+	conn.SetReadDeadline(time.Now().Add(*banner))
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	r.banner = strings.TrimSpace(strings.ToValidUTF8(line, "?"))
+	return r, nil
+}
+
+func main() {
+	flag.Parse()
+	host := "localhost"
+	if flag.NArg() > 0 {
+		host = flag.Arg(0)
+	}
+	list, err := parsePorts(*ports)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *workers < 1 {
+		log.Fatal("-workers must be at least 1")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), *total)
+	defer cancel()
+
+	start := time.Now()
+	sem := make(chan struct{}, *workers)
+	var (
+		mu   sync.Mutex
+		open []result
+		wg   sync.WaitGroup
+	)
+	for _, port := range list {
+		select {
+		case sem <- struct{}{}: // take a slot, waiting for one to free up
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break // the deadline passed: no new connections
+		}
+		wg.Add(1)
+		go func(port int) {
+			defer wg.Done()
+			defer func() { <-sem }() // give the slot back
+			r, err := probe(ctx, host, port)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			open = append(open, r)
+			mu.Unlock()
+		}(port)
+	}
+	wg.Wait()
+
+	sort.Slice(open, func(i, j int) bool { return open[i].port < open[j].port })
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PORT\tSERVICE\tBANNER")
+	for _, r := range open {
+		service := "?"
+		if name, ok := services[r.port]; ok {
+			service = name
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", r.port, service, r.banner)
+	}
+	tw.Flush()
+	status := ""
+	if ctx.Err() != nil {
+		status = ", stopped by -deadline"
+	}
+	fmt.Printf("%d of %d ports open on %s, %v%s\n", len(open), len(list), host, time.Since(start).Round(time.Millisecond), status)
+}
+
+// services are the usual servers of some well-known ports
+var services = map[int]string{
+	21: "ftp", 22: "ssh", 23: "telnet", 25: "smtp", 53: "dns", 80: "http",
+	110: "pop3", 143: "imap", 443: "https", 465: "smtps", 587: "submission",
+	3000: "dev http", 3306: "mysql", 5432: "postgres", 6379: "redis",
+	8080: "http-alt", 27017: "mongodb",
+}
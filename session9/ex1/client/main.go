@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hannansatopay/training-golang/session9/ex1/taskspb"
+)
+
+// gRPC client of session9/ex1/server:
+//
+//	client hello <name>
+//	client add <title>
+//	client list [-all]
+//	client done <id>
+//
+// The generated stubs make a remote call look like a method call; the
+// token travels with every call as metadata (per-RPC credentials), the
+// way a browser sends an Authorization header.
+
+var (
+	addr  = flag.String("addr", "localhost:3003", "address of the server")
+	token = flag.String("token", "secret", "token for the Tasks service")
+)
+
+// bearer adds the token to the metadata of every call
+type bearer string
+
+func (b bearer) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(b)}, nil
+}
+
+// RequireTransportSecurity allows the token over a plain connection;
+// outside of a training it must go over TLS
+func (bearer) RequireTransportSecurity() bool { return false }
+
+func main() {
+	flag.Parse()
+	if flag.NArg() < 1 {
+		fmt.Fprintln(os.Stderr, "usage: client [-addr a] [-token t] hello|add|list|done [args]")
+		os.Exit(2)
+	}
+	conn, err := grpc.NewClient(*addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithPerRPCCredentials(bearer(*token)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tasks := pb.NewTasksClient(conn)
+	switch flag.Arg(0) {
+	case "hello":
+		reply, err := pb.NewGreeterClient(conn).SayHello(ctx, &pb.HelloRequest{Name: flag.Arg(1)})
+		check(err)
+		fmt.Println(reply.Message)
+	case "add":
+		t, err := tasks.Add(ctx, &pb.AddRequest{Title: flag.Arg(1)})
+		check(err)
+		fmt.Printf("added task %d\n", t.Id)
+	case "list":
+		fs := flag.NewFlagSet("list", flag.ExitOnError)
+		all := fs.Bool("all", false, "include the tasks that are done")
+		fs.Parse(flag.Args()[1:])
+		stream, err := tasks.List(ctx, &pb.ListRequest{IncludeDone: *all})
+		check(err)
+		for {
+			t, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			check(err)
+			mark := " "
+			if t.Done {
+				mark = "x"
+			}
+			fmt.Printf("[%s] %3d  %s  (%s)\n", mark, t.Id, t.Title, time.Unix(t.CreatedUnix, 0).Format(time.Kitchen))
+		}
+	case "done":
+		id, err := strconv.ParseInt(flag.Arg(1), 10, 64)
+		if err != nil {
+			log.Fatal(err)
+		}
+		t, err := tasks.Done(ctx, &pb.DoneRequest{Id: id})
+		check(err)
+		fmt.Printf("task %d done: %s\n", t.Id, t.Title)
+	default:
+		log.Fatalf("unknown command %q", flag.Arg(0))
+	}
+}
+
+// check stops on an error, showing its gRPC status code
+func check(err error) {
+	if err != nil {
+		s := status.Convert(err)
+		log.Fatalf("%v: %s", s.Code(), s.Message())
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/hannansatopay/training-golang/session9/ex1/taskspb"
+)
+
+// gRPC server of the Greeter and Tasks services of tasks.proto. The service
+// code only implements the generated interfaces; logging and the token
+// check are interceptors around every call, the gRPC version of wrapping
+// an http.HandlerFunc (like the cache of session4/ex18).
+//
+// Compare SayHello with the HelloServer of session4/ex14: there the URL
+// names the call and the body is whatever the handler writes; here the
+// contract is tasks.proto, the request and reply are typed messages, and
+// a failure is a status code of gRPC's own, not an HTTP one. List is the
+// streaming call: one response, many messages.
+//
+// try: go run ./session9/ex1/client hello gopher
+
+var (
+	addr  = flag.String("addr", "localhost:3003", "address to listen on")
+	token = flag.String("token", "secret", "token the Tasks service requires")
+)
+
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (greeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is empty")
+	}
+	return &pb.HelloReply{Message: "hello, " + req.Name}, nil
+}
+
+// taskList is the Tasks service, with the tasks in memory
+type taskList struct {
+	pb.UnimplementedTasksServer
+	mu    sync.Mutex
+	tasks []*pb.Task
+}
+
+func (l *taskList) Add(ctx context.Context, req *pb.AddRequest) (*pb.Task, error) {
+	if strings.TrimSpace(req.Title) == "" {
+		return nil, status.Error(codes.InvalidArgument, "title is empty")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := &pb.Task{Id: int64(len(l.tasks) + 1), Title: req.Title, CreatedUnix: time.Now().Unix()}
+	l.tasks = append(l.tasks, t)
+	return t, nil
+}
+
+// List sends one message per task: a client can show the first ones
+// before the last ones are sent
+func (l *taskList) List(req *pb.ListRequest, stream pb.Tasks_ListServer) error {
+	l.mu.Lock()
+	tasks := append([]*pb.Task(nil), l.tasks...) // do not hold the lock while sending
+	l.mu.Unlock()
+	for _, t := range tasks {
+		if t.Done && !req.IncludeDone {
+			continue
+		}
+		if err := stream.Send(t); err != nil {
+			return err // the client went away
+		}
+	}
+	return nil
+}
+
+func (l *taskList) Done(ctx context.Context, req *pb.DoneRequest) (*pb.Task, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if req.Id < 1 || req.Id > int64(len(l.tasks)) {
+		return nil, status.Errorf(codes.NotFound, "no task %d", req.Id)
+	}
+	t := l.tasks[req.Id-1]
+	t.Done = true
+	return t, nil
+}
+
+// logUnary logs every call with its outcome and duration
+func logUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	log.Printf("%s %v in %v", info.FullMethod, status.Code(err), time.Since(start).Round(time.Microsecond))
+	return resp, err
+}
+
+func logStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	log.Printf("%s %v in %v (stream)", info.FullMethod, status.Code(err), time.Since(start).Round(time.Microsecond))
+	return err
+}
+
+// public are the methods that need no token
+var public = map[string]bool{"/training.tasks.Greeter/SayHello": true}
+
+// authorize checks the "authorization: Bearer <token>" metadata of a call
+func authorize(ctx context.Context, method string) error {
+	if public[method] {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if v == "Bearer "+*token {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or wrong token")
+}
+
+func authUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func authStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func main() {
+	flag.Parse()
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// interceptors run in the order given: a rejected call is still logged
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(logUnary, authUnary),
+		grpc.ChainStreamInterceptor(logStream, authStream),
+	)
+	pb.RegisterGreeterServer(s, greeter{})
+	pb.RegisterTasksServer(s, &taskList{})
+	fmt.Printf("gRPC server on %v\n", l.Addr())
+	if err := s.Serve(l); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,5 @@
+// Package taskspb holds the Go code generated from tasks.proto: the message
+// types (tasks.pb.go) and the client and server stubs (tasks_grpc.pb.go).
+package taskspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative tasks.proto
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"time"
+
+	"github.com/hannansatopay/training-golang/pkg/rpcobjects"
+)
+
+// net/rpc client of session9/ex2/server: synchronous and asynchronous calls
+//
+// Call waits for its reply; Go sends the call and returns at once,
+// and the finished *rpc.Call arrives on its Done channel later, so many
+// calls can be on their way over the one connection at the same time.
+//
+// Compared with gRPC (session9/ex1): no .proto and no generated code, the
+// method name is a string that is only checked when it is called, there
+// are no streams, deadlines or metadata, and an error comes back as the
+// text of rpc.ServerError, without a code.
+
+var (
+	addr    = flag.String("addr", "", "server address (default: localhost:3004, or :3005 with -json)")
+	useJSON = flag.Bool("json", false, "speak JSON-RPC instead of gob")
+)
+
+func main() {
+	flag.Parse()
+	var client *rpc.Client
+	var err error
+	if *useJSON {
+		if *addr == "" {
+			*addr = "localhost:3005"
+		}
+		client, err = jsonrpc.Dial("tcp", *addr)
+	} else {
+		if *addr == "" {
+			*addr = "localhost:3004"
+		}
+		client, err = rpc.Dial("tcp", *addr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	// synchronous: one call after the other
+	var task rpcobjects.Task
+	if err := client.Call("Tasks.Add", &rpcobjects.AddArgs{Title: "learn net/rpc"}, &task); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("added %d: %s\n", task.ID, task.Title)
+	first := task.ID
+	err = client.Call("Tasks.Add", &rpcobjects.AddArgs{Title: " "}, &task)
+	if _, ok := err.(rpc.ServerError); ok {
+		fmt.Println("server said:", err) // the method's error, as text
+	}
+	err = client.Call("Tasks.Remove", &rpcobjects.DoneArgs{ID: first}, &task)
+	fmt.Println("no such method:", err)
+
+	// asynchronous: all calls are sent first, the replies are collected
+	// as they come, in any order; a shared channel needs room for all
+	titles := []string{"write the server", "write the client", "compare with gRPC"}
+	done := make(chan *rpc.Call, len(titles))
+	start := time.Now()
+	for _, title := range titles {
+		client.Go("Tasks.Add", &rpcobjects.AddArgs{Title: title}, new(rpcobjects.Task), done)
+	}
+	for range titles {
+		call := <-done
+		if call.Error != nil {
+			log.Fatal(call.Error)
+		}
+		t := call.Reply.(*rpcobjects.Task)
+		fmt.Printf("added %d: %s (async)\n", t.ID, t.Title)
+	}
+	fmt.Printf("%d calls in %v\n", len(titles), time.Since(start).Round(time.Microsecond))
+
+	// with its own channel (nil: Go makes one), a call is waited for alone
+	call := client.Go("Tasks.Done", &rpcobjects.DoneArgs{ID: first}, new(rpcobjects.Task), nil)
+	<-call.Done
+	if call.Error != nil {
+		log.Fatal(call.Error)
+	}
+	fmt.Printf("done %d\n", first)
+
+	var tasks []rpcobjects.Task
+	if err := client.Call("Tasks.List", &rpcobjects.ListArgs{IncludeDone: true}, &tasks); err != nil {
+		log.Fatal(err)
+	}
+	for _, t := range tasks {
+		mark := " "
+		if t.Done {
+			mark = "x"
+		}
+		fmt.Printf("[%s] %3d %s\n", mark, t.ID, t.Title)
+	}
+}
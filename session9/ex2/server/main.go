@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+
+	"github.com/hannansatopay/training-golang/pkg/rpcobjects"
+)
+
+// net/rpc server of the task list over plain TCP, in gob and JSON-RPC
+//
+// Two codecs: gob on -addr, for Go clients only, and JSON-RPC 1.0 on
+// -jsonaddr, which any language can speak. Compare with session4/ex16, the same package over
+// HTTP, and with the gRPC server of session9/ex1.
+//
+// try: go run ./session9/ex2/client, or by hand:
+//
+//	echo '{"method":"Tasks.Add","params":[{"Title":"by hand"}],"id":1}' | nc localhost 3005
+
+var (
+	addr     = flag.String("addr", "localhost:3004", "address for gob clients")
+	jsonAddr = flag.String("jsonaddr", "localhost:3005", "address for JSON-RPC clients")
+)
+
+// serve accepts connections on l and serves each with its own goroutine;
+// serveConn is rpc.ServeConn or jsonrpc.ServeConn
+func serve(l net.Listener, serveConn func(conn net.Conn)) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s: client %v", l.Addr(), conn.RemoteAddr())
+		go serveConn(conn)
+	}
+}
+
+func main() {
+	flag.Parse()
+	// one Tasks value serves both codecs: the tasks are the same
+	if err := rpc.Register(new(rpcobjects.Tasks)); err != nil {
+		log.Fatal(err)
+	}
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	jl, err := net.Listen("tcp", *jsonAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("net/rpc on %v (gob) and %v (JSON-RPC)\n", l.Addr(), jl.Addr())
+	go serve(jl, func(conn net.Conn) { jsonrpc.ServeConn(conn) })
+	serve(l, func(conn net.Conn) { rpc.ServeConn(conn) })
+}